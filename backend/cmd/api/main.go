@@ -13,6 +13,9 @@ import (
 	"github.com/webrtc-meeting/backend/internal/auth"
 	"github.com/webrtc-meeting/backend/internal/config"
 	"github.com/webrtc-meeting/backend/internal/database"
+	"github.com/webrtc-meeting/backend/internal/recording"
+	"github.com/webrtc-meeting/backend/internal/storage"
+	"github.com/webrtc-meeting/backend/internal/streaming"
 	"github.com/webrtc-meeting/backend/pkg/logger"
 )
 
@@ -39,9 +42,28 @@ func main() {
 		log.WithError(err).Fatal("Failed to initialize database")
 	}
 
+	// File storage (local atau S3)
+	blob, err := storage.NewBlob(cfg.Storage)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize storage")
+	}
+	storageService := storage.NewService(db.DB, log, blob, cfg.Storage)
+
+	// Live stream HLS webinar (ffmpeg dijalankan oleh API server)
+	streamingService := streaming.NewService(db.DB, log, cfg.Streaming)
+
+	// Recording post-processing worker
+	recordingProcessor := recording.NewProcessor(db.DB, log, cfg.Recording, storageService)
+	processorCtx, stopProcessor := context.WithCancel(context.Background())
+	processorDone := make(chan struct{})
+	go func() {
+		recordingProcessor.Run(processorCtx)
+		close(processorDone)
+	}()
+
 	// Initialize services and router
 	authService := auth.NewService(db.DB, cfg, log)
-	router, err := api.InitializeRouter(db.DB, log, authService)
+	router, err := api.InitializeRouter(db.DB, log, cfg, authService, storageService, streamingService, recordingProcessor)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to initialize router")
 	}
@@ -80,6 +102,13 @@ func main() {
 		log.Info("Server shutdown completed")
 	}
 
+	// Hentikan ffmpeg live stream dan RTP forward-nya
+	streamingService.Shutdown()
+
+	// Recording yang sedang diproses dikembalikan ke antrean
+	stopProcessor()
+	<-processorDone
+
 	// Close database connection
 	if err := db.Close(); err != nil {
 		log.WithError(err).Error("Failed to close database connection")
@@ -2,20 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
-
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/auth"
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/internal/database"
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/room"
+	"github.com/webrtc-meeting/backend/internal/sfu"
 	"github.com/webrtc-meeting/backend/internal/webrtc"
 	"github.com/webrtc-meeting/backend/internal/websocket"
+	"github.com/webrtc-meeting/backend/models"
 	"github.com/webrtc-meeting/backend/pkg/logger"
 )
 
@@ -53,36 +67,76 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Create Janus client
-	janusBaseURL := os.Getenv("JANUS_BASE_URL")
-	if janusBaseURL == "" {
-		janusBaseURL = "http://localhost:8088/janus"
-	}
-
-	janusAdminURL := os.Getenv("JANUS_ADMIN_URL")
-	if janusAdminURL == "" {
-		janusAdminURL = "http://localhost:8088/admin"
-	}
-
-	janusAPISecret := os.Getenv("JANUS_API_SECRET")
-	janusAdminSecret := os.Getenv("JANUS_ADMIN_SECRET")
-
-	janusClient := webrtc.NewJanusClient(janusBaseURL, janusAdminURL, janusAPISecret, janusAdminSecret)
+	// Koneksi database opsional, dipakai untuk membaca pengaturan user
+	db, cfg := connectDatabase()
 
 	// Create WebSocket hub
 	hub := websocket.NewHub()
 
-	// Create signaling handler
-	signalingHandler := webrtc.NewSignalingHandler(janusClient, hub)
+	// Lama session client disimpan untuk resume setelah koneksi terputus
+	if resumeWindow := os.Getenv("WS_RESUME_WINDOW"); resumeWindow != "" {
+		window, err := time.ParseDuration(resumeWindow)
+		if err != nil {
+			logrus.Fatalf("Invalid WS_RESUME_WINDOW: %v", err)
+		}
+		hub.ResumeWindow = window
+	}
+
+	// Backplane menyebarkan pesan hub ke replika websocket server lain
+	if err := setupBackplane(hub, cfg); err != nil {
+		logrus.Fatalf("Failed to set up backplane: %v", err)
+	}
+
+	// Pilih media backend: Janus (default) atau SFU embedded berbasis pion
+	var mediaBackend webrtc.MediaBackend
+	switch backend := os.Getenv("MEDIA_BACKEND"); backend {
+	case "sfu":
+		mediaBackend = newEmbeddedSFU(hub, db)
+	case "", "janus":
+		mediaBackend = newJanusBackend(hub, db)
+	default:
+		logrus.Fatalf("Unknown media backend: %s", backend)
+	}
 
-	// Set signaling handler to hub
-	hub.SignalingHandler = signalingHandler
+	// Set media backend to hub
+	hub.SignalingHandler = mediaBackend
+
+	// Join room signaling diotorisasi dengan keanggotaan room di database. Tanpa
+	// database semua join ditolak, kecuali mode development WS_ALLOW_USER_ID.
+	if db != nil {
+		hub.AuthorizeJoin = roomJoinAuthorizer(db)
+	} else if os.Getenv("WS_ALLOW_USER_ID") == "true" {
+		logrus.Warn("Database not available, join room authorization disabled (WS_ALLOW_USER_ID)")
+		hub.AuthorizeJoin = func(roomID, userID string) (int, error) { return 0, nil }
+	} else {
+		logrus.Error("Database not available, all join room requests will be rejected")
+	}
 
 	// Start hub in goroutine
 	go hub.Run()
 
 	// Create WebSocket handler
 	wsHandler := websocket.NewHandler(hub)
+	wsHandler.InternalSecret = os.Getenv("INTERNAL_API_SECRET")
+	wsHandler.AllowUserID = os.Getenv("WS_ALLOW_USER_ID") == "true"
+
+	// Origin frontend yang boleh membuka WebSocket, sama dengan CORS API server
+	if value := os.Getenv("CORS_ALLOWED_ORIGINS"); value != "" {
+		for _, origin := range strings.Split(value, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				wsHandler.AllowedOrigins = append(wsHandler.AllowedOrigins, origin)
+			}
+		}
+	} else {
+		logrus.Warn("CORS_ALLOWED_ORIGINS is not set, WebSocket connections authenticated by cookie are limited to the same origin")
+	}
+
+	// Access token divalidasi dengan aturan yang sama dengan API server
+	if db != nil {
+		wsHandler.Auth = auth.NewService(db, cfg, logger.GetDefaultLogger())
+	} else {
+		logrus.Warn("Database not available, WebSocket token authentication disabled")
+	}
 
 	// Setup Gin router
 	router := gin.New()
@@ -156,5 +210,386 @@ func main() {
 		logrus.Errorf("Server forced to shutdown: %v", err)
 	}
 
+	// Keluarkan node ini dari registry room cluster
+	hub.LeaveCluster()
+
+	// Tutup media backend
+	if err := mediaBackend.Close(); err != nil {
+		logrus.Errorf("Failed to close media backend: %v", err)
+	}
+
 	logrus.Info("WebSocket server stopped")
 }
+
+// connectDatabase membuka koneksi database API server. Websocket server tetap
+// berjalan tanpa database, hanya fitur yang membutuhkan pengaturan user yang nonaktif.
+func connectDatabase() (*gorm.DB, *config.Config) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logrus.Warnf("Failed to load config, running without database: %v", err)
+		return nil, nil
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		logrus.Warnf("Failed to connect to database, running without database: %v", err)
+		return nil, cfg
+	}
+
+	return db.DB, cfg
+}
+
+// setupBackplane memilih backplane hub dari WS_BACKPLANE: "memory" (default, satu
+// node) atau "redis" untuk beberapa replika yang memakai RedisConfig yang sama
+func setupBackplane(hub *websocket.Hub, cfg *config.Config) error {
+	nodeID := os.Getenv("WS_NODE_ID")
+	if nodeID == "" {
+		nodeID = uuid.NewString()
+	}
+
+	var backplane websocket.Backplane
+	switch kind := os.Getenv("WS_BACKPLANE"); kind {
+	case "", "memory":
+		backplane = websocket.NewMemoryBackplane()
+	case "redis":
+		if cfg == nil {
+			return fmt.Errorf("redis backplane requires configuration")
+		}
+		client := redis.NewClient(&redis.Options{
+			Addr:     net.JoinHostPort(cfg.Redis.Host, cfg.Redis.Port),
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		backplane = websocket.NewRedisBackplane(client, os.Getenv("WS_BACKPLANE_PREFIX"))
+	default:
+		return fmt.Errorf("unknown backplane: %s", kind)
+	}
+
+	return hub.SetBackplane(backplane, nodeID)
+}
+
+// userVideoQualityLookup membaca UserSetting.VideoQuality user dari database
+func userVideoQualityLookup(db *gorm.DB) func(userID string) string {
+	return func(userID string) string {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return ""
+		}
+
+		var setting models.UserSetting
+		if err := db.Select("video_quality").Where("user_id = ?", userUUID).First(&setting).Error; err != nil {
+			return ""
+		}
+		return setting.VideoQuality
+	}
+}
+
+// roomMediaPolicyLookup membaca tipe room dan RoomSetting dari database lalu
+// menurunkan kebijakan medianya
+func roomMediaPolicyLookup(db *gorm.DB) func(roomID string) (mediaplane.MediaPolicy, bool) {
+	return func(roomID string) (mediaplane.MediaPolicy, bool) {
+		roomUUID, err := uuid.Parse(roomID)
+		if err != nil {
+			return mediaplane.MediaPolicy{}, false
+		}
+
+		var roomModel models.Room
+		if err := db.Preload("Settings").Select("id", "type").Where("id = ?", roomUUID).First(&roomModel).Error; err != nil {
+			return mediaplane.MediaPolicy{}, false
+		}
+		return room.MediaPolicyFor(roomModel.Type, roomModel.Settings), true
+	}
+}
+
+// roomJoinAuthorizer membaca room dan keanggotaan user dari database lalu memeriksa
+// apakah user boleh join room signaling
+func roomJoinAuthorizer(db *gorm.DB) func(roomID, userID string) (int, error) {
+	return func(roomID, userID string) (int, error) {
+		roomUUID, err := uuid.Parse(roomID)
+		if err != nil {
+			return 0, fmt.Errorf("%w: room not found", mediaplane.ErrRoomAccessDenied)
+		}
+
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid user", mediaplane.ErrRoomAccessDenied)
+		}
+
+		var roomModel models.Room
+		if err := db.Select("id", "host_id", "max_users", "status").Where("id = ?", roomUUID).First(&roomModel).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, fmt.Errorf("%w: room not found", mediaplane.ErrRoomAccessDenied)
+			}
+			return 0, err
+		}
+
+		var participant *models.RoomParticipant
+		var existing models.RoomParticipant
+		if err := db.Where("room_id = ? AND user_id = ?", roomUUID, userUUID).First(&existing).Error; err == nil {
+			participant = &existing
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+
+		if err := room.SignalingAccess(&roomModel, participant, userUUID); err != nil {
+			return 0, err
+		}
+
+		return roomModel.MaxUsers, nil
+	}
+}
+
+// newJanusBackend membuat signaling handler yang memakai Janus sebagai media server
+func newJanusBackend(hub *websocket.Hub, db *gorm.DB) *webrtc.SignalingHandler {
+	janusBaseURL := os.Getenv("JANUS_BASE_URL")
+	if janusBaseURL == "" {
+		janusBaseURL = "http://localhost:8088/janus"
+	}
+
+	janusAdminURL := os.Getenv("JANUS_ADMIN_URL")
+	if janusAdminURL == "" {
+		janusAdminURL = "http://localhost:8088/admin"
+	}
+
+	janusAPISecret := os.Getenv("JANUS_API_SECRET")
+	janusAdminSecret := os.Getenv("JANUS_ADMIN_SECRET")
+
+	// Gunakan pool jika JANUS_POOL diset, transport WebSocket jika JANUS_WS_URL diset,
+	// selain itu HTTP long-poll
+	var janusClient *webrtc.JanusClient
+	var janusPool *webrtc.JanusPool
+	if poolSpec := os.Getenv("JANUS_POOL"); poolSpec != "" {
+		instances, err := webrtc.ParseJanusInstances(poolSpec, janusAPISecret, janusAdminSecret)
+		if err != nil {
+			logrus.Fatalf("Failed to parse Janus pool: %v", err)
+		}
+
+		janusPool, err = webrtc.NewJanusPool(instances)
+		if err != nil {
+			logrus.Fatalf("Failed to create Janus pool: %v", err)
+		}
+		janusClient = instances[0].Client
+	} else if janusWSURL := os.Getenv("JANUS_WS_URL"); janusWSURL != "" {
+		client, err := webrtc.NewJanusClientWithTransport(webrtc.NewWebSocketTransport(janusWSURL), janusAdminURL, janusAPISecret, janusAdminSecret)
+		if err != nil {
+			logrus.Fatalf("Failed to connect to Janus WebSocket: %v", err)
+		}
+		janusClient = client
+	} else {
+		janusClient = webrtc.NewJanusClient(janusBaseURL, janusAdminURL, janusAPISecret, janusAdminSecret)
+	}
+
+	// Buat session Janus yang dipakai bersama oleh semua handle.
+	// Jika gagal, keepalive loop akan mencoba membuat session lagi.
+	// Pada pool, health check sekaligus berfungsi sebagai keepalive.
+	if janusPool != nil {
+		janusPool.Start(webrtc.DefaultHealthCheckInterval)
+	} else {
+		if _, err := janusClient.CreateSession(); err != nil {
+			logrus.Errorf("Failed to create Janus session: %v", err)
+		}
+		janusClient.StartKeepalive(webrtc.DefaultKeepaliveInterval)
+	}
+
+	// Create signaling handler
+	signalingHandler := webrtc.NewSignalingHandler(janusClient, hub)
+	if janusPool != nil {
+		signalingHandler.UsePool(janusPool)
+	}
+
+	// Aktifkan stored-token auth Janus jika diminta
+	if os.Getenv("JANUS_TOKEN_AUTH") == "true" {
+		tokenTTL := webrtc.DefaultTokenTTL
+		if value := os.Getenv("JANUS_TOKEN_TTL"); value != "" {
+			if duration, err := time.ParseDuration(value); err == nil {
+				tokenTTL = duration
+			}
+		}
+
+		tokenManager := webrtc.NewTokenManager(tokenTTL)
+		tokenManager.Start(tokenTTL / 2)
+		signalingHandler.TokenManager = tokenManager
+
+		logrus.WithField("ttl", tokenTTL).Info("Janus token authentication enabled")
+	}
+
+	// Opsi media room: codec video, simulcast dan VP9-SVC
+	if value := os.Getenv("JANUS_VIDEO_CODEC"); value != "" {
+		signalingHandler.RoomOptions.VideoCodec = value
+	}
+	if value := os.Getenv("JANUS_VP9_PROFILE"); value != "" {
+		signalingHandler.RoomOptions.VP9Profile = value
+	}
+	if os.Getenv("JANUS_SIMULCAST") == "false" {
+		signalingHandler.RoomOptions.Simulcast = false
+	}
+	if os.Getenv("JANUS_VP9_SVC") == "true" {
+		signalingHandler.RoomOptions.VideoSVC = true
+	}
+
+	// Direktori recording di host Janus
+	if value := os.Getenv("JANUS_RECORDING_DIR"); value != "" {
+		signalingHandler.RecordingDir = value
+	}
+
+	// Layer simulcast otomatis mengikuti kualitas video pilihan user, dan
+	// bitrate serta codec room mengikuti pengaturan room
+	if db != nil {
+		signalingHandler.VideoQualityLookup = userVideoQualityLookup(db)
+		signalingHandler.MediaPolicyLookup = roomMediaPolicyLookup(db)
+	}
+
+	// RTP forward (HLS webinar dan restream RTMP) hanya ke host penerima yang
+	// dikonfigurasi, biasanya sama dengan STREAMING_FORWARD_HOST API server
+	if value := os.Getenv("RTP_FORWARD_ALLOWED_HOSTS"); value != "" {
+		for _, host := range strings.Split(value, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				signalingHandler.ForwardHosts = append(signalingHandler.ForwardHosts, host)
+			}
+		}
+	}
+
+	// Data channel room lewat Janus TextRoom. Janus mem-POST pesan peserta ke
+	// TEXTROOM_POST_URL dengan URL yang ditandatangani memakai TEXTROOM_SECRET.
+	if os.Getenv("JANUS_DATACHANNELS") == "true" {
+		signalingHandler.RoomOptions.DataChannels = true
+		signalingHandler.TextRoomPostURL = os.Getenv("TEXTROOM_POST_URL")
+		signalingHandler.TextRoomSecret = os.Getenv("TEXTROOM_SECRET")
+		if signalingHandler.TextRoomPostURL != "" && signalingHandler.TextRoomSecret == "" {
+			logrus.Error("TEXTROOM_SECRET is not set, data channel chat will not be stored")
+		}
+		if db != nil {
+			signalingHandler.MessageStore = roomMessageStore(db)
+		}
+	}
+
+	// Gateway SIP untuk peserta telepon (dial-in dengan PIN room dan dial-out host)
+	if proxy := os.Getenv("SIP_PROXY"); proxy != "" {
+		signalingHandler.SIP = newSIPGateway(signalingHandler, janusClient, db, proxy)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"video_codec":   signalingHandler.RoomOptions.VideoCodec,
+		"simulcast":     signalingHandler.RoomOptions.Simulcast,
+		"data_channels": signalingHandler.RoomOptions.DataChannels,
+	}).Info("Using Janus media backend")
+
+	return signalingHandler
+}
+
+// roomMessageStore menyimpan pesan chat data channel sebagai RoomMessage teks
+func roomMessageStore(db *gorm.DB) func(roomID, userID, message string) error {
+	return func(roomID, userID, message string) error {
+		roomUUID, err := uuid.Parse(roomID)
+		if err != nil {
+			return fmt.Errorf("invalid room ID: %s", roomID)
+		}
+
+		senderUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return fmt.Errorf("invalid user ID: %s", userID)
+		}
+
+		return db.Create(&models.RoomMessage{
+			RoomID:   roomUUID,
+			SenderID: senderUUID,
+			Message:  message,
+			Type:     models.MessageTypeText,
+		}).Error
+	}
+}
+
+// roomDialInLookup mencari room aktif dari PIN dial-in
+func roomDialInLookup(db *gorm.DB) func(pin string) (string, bool) {
+	return func(pin string) (string, bool) {
+		var roomModel models.Room
+		if err := db.Select("id").Where("dial_in_pin = ? AND status = ?", pin, models.RoomStatusActive).First(&roomModel).Error; err != nil {
+			return "", false
+		}
+		return roomModel.ID.String(), true
+	}
+}
+
+// newSIPGateway mendaftarkan akun SIP di Janus. Jika gagal, panggilan telepon nonaktif.
+func newSIPGateway(signalingHandler *webrtc.SignalingHandler, janusClient *webrtc.JanusClient, db *gorm.DB, proxy string) *webrtc.SIPGateway {
+	config := webrtc.SIPConfig{
+		Proxy:       proxy,
+		Username:    os.Getenv("SIP_USERNAME"),
+		Secret:      os.Getenv("SIP_SECRET"),
+		DisplayName: os.Getenv("SIP_DISPLAY_NAME"),
+		DialDomain:  os.Getenv("SIP_DIAL_DOMAIN"),
+	}
+
+	if value := os.Getenv("SIP_MAX_CALLS"); value != "" {
+		if maxCalls, err := strconv.Atoi(value); err == nil {
+			config.MaxCalls = maxCalls
+		}
+	}
+
+	if value := os.Getenv("SIP_PIN_TIMEOUT"); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			config.PINTimeout = duration
+		}
+	}
+
+	gateway := webrtc.NewSIPGateway(signalingHandler, janusClient, config)
+
+	// Tanpa database PIN room tidak dapat dicari sehingga panggilan masuk ditolak
+	if db != nil {
+		gateway.RoomLookup = roomDialInLookup(db)
+	}
+
+	if err := gateway.Start(); err != nil {
+		logrus.Errorf("Failed to start SIP gateway, phone calls disabled: %v", err)
+		return nil
+	}
+
+	return gateway
+}
+
+// newEmbeddedSFU membuat SFU pion yang berjalan di dalam proses ini, tanpa Janus
+func newEmbeddedSFU(hub *websocket.Hub, db *gorm.DB) *sfu.SFU {
+	config := sfu.Config{}
+
+	if value := os.Getenv("SFU_ICE_SERVERS"); value != "" {
+		for _, server := range strings.Split(value, ",") {
+			if server = strings.TrimSpace(server); server != "" {
+				config.ICEServers = append(config.ICEServers, server)
+			}
+		}
+	}
+
+	if value := os.Getenv("SFU_PUBLIC_IP"); value != "" {
+		config.PublicIPs = strings.Split(value, ",")
+	}
+
+	if value := os.Getenv("SFU_UDP_PORT_MIN"); value != "" {
+		if port, err := strconv.ParseUint(value, 10, 16); err == nil {
+			config.UDPPortMin = uint16(port)
+		}
+	}
+
+	if value := os.Getenv("SFU_UDP_PORT_MAX"); value != "" {
+		if port, err := strconv.ParseUint(value, 10, 16); err == nil {
+			config.UDPPortMax = uint16(port)
+		}
+	}
+
+	embeddedSFU, err := sfu.New(hub, config)
+	if err != nil {
+		logrus.Fatalf("Failed to create embedded SFU: %v", err)
+	}
+
+	// Batas bitrate room mengikuti pengaturan room
+	if db != nil {
+		embeddedSFU.MediaPolicyLookup = roomMediaPolicyLookup(db)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"ice_servers":  config.ICEServers,
+		"udp_port_min": config.UDPPortMin,
+		"udp_port_max": config.UDPPortMax,
+	}).Info("Using embedded SFU media backend")
+
+	return embeddedSFU
+}
@@ -9,18 +9,28 @@ import (
 
 	"github.com/webrtc-meeting/backend/internal/api/middleware"
 	"github.com/webrtc-meeting/backend/internal/auth"
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/internal/recording"
 	"github.com/webrtc-meeting/backend/internal/room"
+	"github.com/webrtc-meeting/backend/internal/storage"
+	"github.com/webrtc-meeting/backend/internal/streaming"
 	"github.com/webrtc-meeting/backend/internal/user"
+	"github.com/webrtc-meeting/backend/internal/webrtc"
+	"github.com/webrtc-meeting/backend/internal/websocket"
 	"github.com/webrtc-meeting/backend/pkg/logger"
 )
 
 // Router struct untuk menyimpan semua dependencies
 type Router struct {
-	db          *gorm.DB
-	logger      *logger.Logger
-	authHandler *auth.Handler
-	userHandler *user.Handler
-	roomHandler *room.Handler
+	db                *gorm.DB
+	logger            *logger.Logger
+	authHandler       *auth.Handler
+	userHandler       *user.Handler
+	roomHandler       *room.Handler
+	recordingHandler  *recording.Handler
+	storageHandler    *storage.Handler
+	streamingHandler  *streaming.Handler
+	janusAdminHandler *webrtc.AdminHandler
 }
 
 // NewRouter membuat router baru dengan semua dependencies
@@ -30,13 +40,21 @@ func NewRouter(
 	authHandler *auth.Handler,
 	userHandler *user.Handler,
 	roomHandler *room.Handler,
+	recordingHandler *recording.Handler,
+	storageHandler *storage.Handler,
+	streamingHandler *streaming.Handler,
+	janusAdminHandler *webrtc.AdminHandler,
 ) *Router {
 	return &Router{
-		db:          db,
-		logger:      log,
-		authHandler: authHandler,
-		userHandler: userHandler,
-		roomHandler: roomHandler,
+		db:                db,
+		logger:            log,
+		authHandler:       authHandler,
+		userHandler:       userHandler,
+		roomHandler:       roomHandler,
+		recordingHandler:  recordingHandler,
+		storageHandler:    storageHandler,
+		streamingHandler:  streamingHandler,
+		janusAdminHandler: janusAdminHandler,
 	}
 }
 
@@ -153,6 +171,9 @@ func (r *Router) setupAdminRoutes(admin *gin.RouterGroup) {
 
 	// System health check (admin only)
 	admin.GET("/health", r.adminHealthCheck)
+
+	// Janus introspection (admin only)
+	r.janusAdminHandler.RegisterRoutes(admin)
 }
 
 // setupWebRTCRoutes mengatur WebRTC routes
@@ -160,19 +181,11 @@ func (r *Router) setupWebRTCRoutes(webrtc *gin.RouterGroup) {
 	// Get ICE servers
 	webrtc.GET("/ice-servers", r.getICEServers)
 
-	// Recording routes
-	recording := webrtc.Group("/recording")
-	{
-		recording.GET("", r.getRecordings)
-		recording.GET("/:recordingId", r.getRecording)
-	}
+	// Recording routes dan room recording controls
+	r.recordingHandler.RegisterRoutes(webrtc)
 
-	// Room recording controls
-	rooms := webrtc.Group("/rooms")
-	{
-		rooms.POST("/:roomId/recording/start", r.startRecording)
-		rooms.POST("/:roomId/recording/stop", r.stopRecording)
-	}
+	// Live stream HLS webinar
+	r.streamingHandler.RegisterRoutes(webrtc)
 }
 
 // setupPublicRoutes mengatur public routes
@@ -188,6 +201,12 @@ func (r *Router) setupPublicRoutes(public *gin.RouterGroup) {
 
 	// API documentation
 	public.GET("/docs", r.getAPIDocumentation)
+
+	// User avatar (redirect ke signed URL)
+	public.GET("/avatars/:userId", r.userHandler.GetAvatar)
+
+	// Signed file URL untuk local storage
+	r.storageHandler.RegisterRoutes(public)
 }
 
 // setupHealthCheck mengatur health check routes
@@ -297,30 +316,6 @@ func (r *Router) getICEServers(c *gin.Context) {
 	})
 }
 
-func (r *Router) getRecordings(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Get recordings - to be implemented",
-	})
-}
-
-func (r *Router) getRecording(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Get recording - to be implemented",
-	})
-}
-
-func (r *Router) startRecording(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Start recording - to be implemented",
-	})
-}
-
-func (r *Router) stopRecording(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Stop recording - to be implemented",
-	})
-}
-
 // Public Handlers (placeholders - to be implemented)
 
 func (r *Router) getSystemInfo(c *gin.Context) {
@@ -427,17 +422,49 @@ func (r *Router) injectAuthMiddleware() {
 func InitializeRouter(
 	db *gorm.DB,
 	log *logger.Logger,
+	cfg *config.Config,
 	authService *auth.Service,
+	storageService *storage.Service,
+	streamingService *streaming.Service,
+	recordingProcessor *recording.Processor,
 ) (*Router, error) {
 	// Create handlers
 	authHandler := auth.NewHandler(authService, log)
 	userService := user.NewService(db, log)
+	userService.SetStorage(storageService)
 	userHandler := user.NewHandler(userService, log)
+	controlClient := websocket.NewControlClient(cfg.WebSocket.InternalURL, cfg.WebSocket.InternalSecret)
+	if cfg.WebSocket.InternalSecret == "" {
+		log.Warn("INTERNAL_API_SECRET is not set, moderation, recording and streaming calls to the websocket server will fail")
+	}
+	recordingService := recording.NewService(db, log, cfg.Recording.Dir)
+	recordingService.SetMediaRecorder(controlClient)
+	recordingService.SetProcessor(recordingProcessor)
+	recordingService.SetStorage(storageService)
+	recordingHandler := recording.NewHandler(recordingService, log)
+	streamingService.SetMediaForwarder(controlClient)
+	streamingService.SetNotifier(controlClient)
+	streamingHandler := streaming.NewHandler(streamingService, log)
 	roomService := room.NewService(db, log)
+	roomService.SetMediaController(controlClient)
+	roomService.SetRecorder(recordingService)
+	roomService.SetStorage(storageService)
+	roomService.SetStreamer(streamingService)
+	roomService.SetDialer(controlClient, cfg.SIP.DialInNumber)
 	roomHandler := room.NewHandler(roomService, log)
+	// Admin API Janus mencakup semua instance pool agar token dan session di setiap instance terlihat
+	janusAdmin := webrtc.ParseJanusAdminInstances(cfg.Janus.Pool, cfg.Janus.AdminSecret)
+	if len(janusAdmin) == 0 {
+		janusAdmin = []*webrtc.JanusAdminInstance{{
+			Name:   "janus-1",
+			Client: webrtc.NewJanusAdminClient(cfg.Janus.AdminURL, cfg.Janus.AdminSecret),
+		}}
+	}
+	janusAdminHandler := webrtc.NewAdminHandler(janusAdmin, log)
+	storageHandler := storage.NewHandler(storageService.Blob(), log)
 
 	// Create router
-	router := NewRouter(db, log, authHandler, userHandler, roomHandler)
+	router := NewRouter(db, log, authHandler, userHandler, roomHandler, recordingHandler, storageHandler, streamingHandler, janusAdminHandler)
 
 	// Inject auth middleware
 	router.injectAuthMiddleware()
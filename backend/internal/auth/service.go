@@ -315,29 +315,41 @@ func (s *Service) ChangePassword(userID uuid.UUID, req *ChangePasswordRequest) e
 
 // ValidateToken validates access token and returns user info
 func (s *Service) ValidateToken(tokenString string) (*models.User, error) {
+	user, _, err := s.ValidateSession(tokenString)
+	return user, err
+}
+
+// ValidateSession memvalidasi access token seperti ValidateToken dan juga mengembalikan
+// waktu token berhenti berlaku, yaitu kedaluwarsa token atau session mana yang lebih dulu
+func (s *Service) ValidateSession(tokenString string) (*models.User, time.Time, error) {
 	claims, err := s.validateToken(tokenString)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 
 	// Find user
 	var user models.User
 	if err := s.db.First(&user, claims.UserID).Error; err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, time.Time{}, fmt.Errorf("user not found")
 	}
 
 	// Check if user is active
 	if !user.IsActive() {
-		return nil, fmt.Errorf("user account is not active")
+		return nil, time.Time{}, fmt.Errorf("user account is not active")
 	}
 
 	// Check if session exists and is valid
 	var session models.UserSession
 	if err := s.db.Where("token = ? AND expires_at > ?", tokenString, time.Now()).First(&session).Error; err != nil {
-		return nil, fmt.Errorf("invalid session")
+		return nil, time.Time{}, fmt.Errorf("invalid session")
 	}
 
-	return &user, nil
+	expiresAt := session.ExpiresAt
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	return &user, expiresAt, nil
 }
 
 // GetProfile mengambil profile user
@@ -12,13 +12,18 @@ import (
 
 // Config struct untuk menyimpan semua konfigurasi aplikasi
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Janus    JanusConfig
-	Email    EmailConfig
-	Logger   LoggerConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	JWT       JWTConfig
+	Janus     JanusConfig
+	WebSocket WebSocketConfig
+	Recording RecordingConfig
+	Storage   StorageConfig
+	Streaming StreamingConfig
+	SIP       SIPConfig
+	Email     EmailConfig
+	Logger    LoggerConfig
 }
 
 // ServerConfig konfigurasi server
@@ -60,8 +65,94 @@ type JWTConfig struct {
 type JanusConfig struct {
 	WebSocketURL string
 	HTTPURL      string
+	AdminURL     string
 	AdminSecret  string
 	APISecret    string
+
+	// Spesifikasi pool multi-instance "url|weight|adminURL,..." (kosong = satu instance AdminURL)
+	Pool string
+}
+
+// WebSocketConfig konfigurasi akses API server ke endpoint internal websocket server
+type WebSocketConfig struct {
+	InternalURL    string
+	InternalSecret string
+}
+
+// RecordingConfig konfigurasi penyimpanan recording
+type RecordingConfig struct {
+	// Direktori tempat Janus menulis file recording, dilihat dari API server
+	Dir string
+
+	// Post-processing: "ffmpeg" (janus-pp-rec + ffmpeg, video dan audio di-mix) atau
+	// "native" (demuxer MJR Go, audio saja tanpa binary eksternal)
+	Processor      string
+	Format         string // "webm" atau "mp4"
+	FFmpegPath     string
+	JanusPPRecPath string
+	Workers        int
+	PollInterval   time.Duration
+}
+
+// StorageConfig konfigurasi penyimpanan file (recording, lampiran chat, avatar)
+type StorageConfig struct {
+	// Backend "local" (filesystem) atau "s3" (S3-compatible, misalnya MinIO)
+	Backend string
+
+	// Secret HMAC signed URL backend local dan base URL API server untuk URL tersebut
+	LocalDir      string
+	PublicURL     string
+	SigningSecret string
+
+	S3Endpoint       string
+	S3PublicEndpoint string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3Bucket         string
+	S3Region         string
+	S3UseSSL         bool
+
+	// Masa berlaku signed URL download
+	URLExpiry time.Duration
+
+	// Quota storage per room dalam byte (0 = tanpa batas)
+	RoomQuota int64
+
+	// Batas ukuran upload lampiran chat dan avatar dalam byte
+	MaxAttachmentSize int64
+	MaxAvatarSize     int64
+}
+
+// StreamingConfig konfigurasi live stream HLS webinar dan restream RTMP
+// (RTP forward Janus ke ffmpeg)
+type StreamingConfig struct {
+	// Direktori segment dan playlist HLS
+	Dir        string
+	FFmpegPath string
+
+	// Host API server yang dituju RTP forward, dilihat dari Janus
+	ForwardHost string
+
+	// Rentang port UDP penerima RTP (4 port per stream: RTP dan RTCP audio dan video)
+	PortMin int
+	PortMax int
+
+	// Durasi segment dan jumlah segment di playlist live
+	SegmentDuration time.Duration
+	PlaylistSize    int
+
+	// Secret untuk mengenkripsi stream key RTMP di database
+	KeySecret string
+
+	// Batas restart ffmpeg restream berturut-turut sebelum restream dinyatakan gagal
+	RestreamMaxRestarts int
+}
+
+// SIPConfig konfigurasi dial-in telepon yang ditampilkan ke host. Akun SIP sendiri
+// dikonfigurasi di websocket server.
+type SIPConfig struct {
+	// Nomor telepon yang dihubungi peserta untuk dial-in (kosong = hanya PIN yang ditampilkan)
+	DialInNumber string
 }
 
 // EmailConfig konfigurasi email
@@ -117,8 +208,55 @@ func LoadConfig() (*Config, error) {
 		Janus: JanusConfig{
 			WebSocketURL: getEnv("JANUS_WS_URL", "ws://localhost:8188"),
 			HTTPURL:      getEnv("JANUS_HTTP_URL", "http://localhost:8088/janus"),
+			AdminURL:     getEnv("JANUS_ADMIN_URL", "http://localhost:7088/admin"),
 			AdminSecret:  getEnv("JANUS_ADMIN_SECRET", "janusrocks"),
 			APISecret:    getEnv("JANUS_API_SECRET", "janusrocks"),
+			Pool:         getEnv("JANUS_POOL", ""),
+		},
+		WebSocket: WebSocketConfig{
+			InternalURL:    getEnv("WEBSOCKET_INTERNAL_URL", "http://localhost:8081"),
+			InternalSecret: getEnv("INTERNAL_API_SECRET", ""),
+		},
+		Recording: RecordingConfig{
+			Dir:            getEnv("RECORDING_DIR", "./recordings"),
+			Processor:      getEnv("RECORDING_PROCESSOR", "ffmpeg"),
+			Format:         getEnv("RECORDING_FORMAT", "webm"),
+			FFmpegPath:     getEnv("FFMPEG_PATH", "ffmpeg"),
+			JanusPPRecPath: getEnv("JANUS_PP_REC_PATH", "janus-pp-rec"),
+			Workers:        getIntEnv("RECORDING_WORKERS", 1),
+			PollInterval:   getDurationEnv("RECORDING_POLL_INTERVAL", 30*time.Second),
+		},
+		Storage: StorageConfig{
+			Backend:           getEnv("STORAGE_BACKEND", "local"),
+			LocalDir:          getEnv("STORAGE_LOCAL_DIR", "./storage"),
+			PublicURL:         getEnv("STORAGE_PUBLIC_URL", ""),
+			SigningSecret:     getEnv("STORAGE_SIGNING_SECRET", getEnv("JWT_SECRET", "your-secret-key")),
+			S3Endpoint:        getEnv("S3_ENDPOINT", "localhost:9000"),
+			S3PublicEndpoint:  getEnv("S3_PUBLIC_ENDPOINT", ""),
+			S3AccessKey:       getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:       getEnv("S3_SECRET_KEY", ""),
+			S3Bucket:          getEnv("S3_BUCKET", "webrtc-meeting"),
+			S3Region:          getEnv("S3_REGION", "us-east-1"),
+			S3UseSSL:          getEnv("S3_USE_SSL", "false") == "true",
+			URLExpiry:         getDurationEnv("STORAGE_URL_EXPIRY", 15*time.Minute),
+			RoomQuota:         int64(getIntEnv("STORAGE_ROOM_QUOTA_MB", 5120)) << 20,
+			MaxAttachmentSize: int64(getIntEnv("STORAGE_MAX_ATTACHMENT_MB", 50)) << 20,
+			MaxAvatarSize:     int64(getIntEnv("STORAGE_MAX_AVATAR_MB", 5)) << 20,
+		},
+		Streaming: StreamingConfig{
+			Dir:             getEnv("STREAMING_DIR", "./streams"),
+			FFmpegPath:      getEnv("FFMPEG_PATH", "ffmpeg"),
+			ForwardHost:     getEnv("STREAMING_FORWARD_HOST", "127.0.0.1"),
+			PortMin:         getIntEnv("STREAMING_PORT_MIN", 30000),
+			PortMax:         getIntEnv("STREAMING_PORT_MAX", 30099),
+			SegmentDuration: getDurationEnv("STREAMING_SEGMENT_DURATION", 2*time.Second),
+			PlaylistSize:    getIntEnv("STREAMING_PLAYLIST_SIZE", 6),
+
+			KeySecret:           getEnv("STREAMING_KEY_SECRET", getEnv("JWT_SECRET", "your-secret-key")),
+			RestreamMaxRestarts: getIntEnv("STREAMING_RESTREAM_MAX_RESTARTS", 5),
+		},
+		SIP: SIPConfig{
+			DialInNumber: getEnv("SIP_DIAL_IN_NUMBER", ""),
 		},
 		Email: EmailConfig{
 			SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
@@ -17,8 +17,24 @@ type Database struct {
 	DB *gorm.DB
 }
 
-// NewDatabase membuat koneksi database baru
+// NewDatabase membuat koneksi database baru dan menjalankan auto migration
 func NewDatabase(cfg *config.Config) (*Database, error) {
+	database, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Auto migrate tables
+	if err := database.AutoMigrate(); err != nil {
+		return nil, fmt.Errorf("failed to auto migrate: %w", err)
+	}
+
+	return database, nil
+}
+
+// Open membuat koneksi database tanpa migration, untuk proses yang hanya
+// membaca tabel milik API server (misalnya websocket server)
+func Open(cfg *config.Config) (*Database, error) {
 	dsn := cfg.GetDatabaseURL()
 
 	// Konfigurasi GORM logger
@@ -62,16 +78,9 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 
 	logrus.Info("Database connection established successfully")
 
-	database := &Database{
+	return &Database{
 		DB: db,
-	}
-
-	// Auto migrate tables
-	if err := database.AutoMigrate(); err != nil {
-		return nil, fmt.Errorf("failed to auto migrate: %w", err)
-	}
-
-	return database, nil
+	}, nil
 }
 
 // AutoMigrate melakukan auto migration untuk semua model
@@ -90,6 +99,9 @@ func (d *Database) AutoMigrate() error {
 		&models.RoomSetting{},
 		&models.MeetingHistory{},
 		&models.Notification{},
+		&models.Recording{},
+		&models.StoredFile{},
+		&models.LiveStream{},
 	}
 
 	// Lakukan migration
@@ -158,6 +170,11 @@ func (d *Database) createIndexes() error {
 		return fmt.Errorf("failed to create idx_room_messages_created_at: %w", err)
 	}
 
+	// Index untuk recordings table: hanya satu recording aktif per room
+	if err := d.DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_recordings_active_room ON recordings(room_id) WHERE status = 'recording'").Error; err != nil {
+		return fmt.Errorf("failed to create idx_recordings_active_room: %w", err)
+	}
+
 	// Index untuk user_sessions table
 	if err := d.DB.Exec("CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id)").Error; err != nil {
 		return fmt.Errorf("failed to create idx_user_sessions_user_id: %w", err)
@@ -0,0 +1,79 @@
+// Package mediaplane berisi kontrak antara API server dan media plane (websocket
+// server beserta media backend-nya): kebijakan media room, panggilan telepon SIP
+// dan error yang dikembalikan media plane. Package ini tidak bergantung pada
+// package internal lain sehingga dapat dipakai kedua sisi.
+package mediaplane
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrParticipantNotFound dikembalikan media backend ketika user tidak terhubung ke room media
+var ErrParticipantNotFound = errors.New("participant not found in media room")
+
+// ErrRoomAccessDenied dikembalikan Hub.AuthorizeJoin ketika user tidak boleh join room
+var ErrRoomAccessDenied = errors.New("room access denied")
+
+// MediaPolicy adalah kebijakan media room yang diturunkan dari RoomSetting dan tipe room,
+// dikirim API server ke websocket server dan diterapkan oleh media backend
+type MediaPolicy struct {
+	// Batas bitrate video per publisher dalam bit/detik (0 = tanpa batas)
+	Bitrate uint64 `json:"bitrate"`
+
+	// Codec yang diizinkan, urut prioritas (misalnya "vp8,h264" dan "opus")
+	VideoCodec string `json:"videocodec,omitempty"`
+	AudioCodec string `json:"audiocodec,omitempty"`
+
+	// Interval permintaan keyframe berkala ke publisher dalam detik (0 = nonaktif)
+	FirFreq int `json:"fir_freq,omitempty"`
+
+	// Kirim event talking/stopped-talking untuk deteksi pembicara aktif
+	AudioLevelEvent    bool `json:"audiolevel_event"`
+	AudioActivePackets int  `json:"audio_active_packets,omitempty"`
+	AudioLevelAverage  int  `json:"audio_level_average,omitempty"`
+
+	// Room hanya audio yang di-mix server (Janus AudioBridge): setiap peserta menerima
+	// satu stream hasil mix, bukan satu stream per publisher. SFU embedded tidak
+	// me-mix audio dan tetap meneruskan stream per publisher.
+	AudioBridge bool `json:"audiobridge,omitempty"`
+
+	// Chat, reaksi dan kursor/whiteboard dikirim lewat data channel (Janus TextRoom)
+	// yang terhubung ke room. Tidak berpengaruh pada SFU embedded.
+	DataChannels bool `json:"datachannels,omitempty"`
+}
+
+// Arah panggilan telepon SIP
+const (
+	SIPCallInbound  = "inbound"
+	SIPCallOutbound = "outbound"
+)
+
+// Status panggilan telepon SIP
+const (
+	SIPCallRinging   = "ringing"
+	SIPCallPIN       = "pin"
+	SIPCallConnected = "connected"
+)
+
+// SIPDial adalah permintaan API server untuk menelepon nomor dan memasukkannya ke room
+type SIPDial struct {
+	Number    string `json:"number"`
+	Display   string `json:"display,omitempty"`
+	StartedBy string `json:"started_by,omitempty"`
+}
+
+// SIPCall adalah panggilan telepon yang dijembatani ke room. Penelepon tampil di
+// room sebagai peserta dengan UserID "sip:<id>".
+type SIPCall struct {
+	ID         string     `json:"id"`
+	RoomID     string     `json:"room_id,omitempty"`
+	UserID     string     `json:"user_id"`
+	Direction  string     `json:"direction"`
+	Number     string     `json:"number"`
+	Display    string     `json:"display,omitempty"`
+	State      string     `json:"state"`
+	StartedBy  string     `json:"started_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+}
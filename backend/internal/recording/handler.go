@@ -0,0 +1,343 @@
+package recording
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// Handler struct untuk recording handler
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler membuat recording handler baru
+func NewHandler(service *Service, log *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  log,
+	}
+}
+
+// RegisterRoutes registrasi routes untuk recording (di bawah group /webrtc yang sudah terautentikasi)
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	recordings := router.Group("/recording")
+	{
+		recordings.GET("", h.GetRecordings)
+		recordings.GET("/:recordingId", h.GetRecording)
+		recordings.GET("/:recordingId/download", h.DownloadRecording)
+		recordings.POST("/:recordingId/process", h.RetryProcessing)
+	}
+
+	rooms := router.Group("/rooms")
+	{
+		rooms.GET("/:roomId/recordings", h.GetRoomRecordings)
+		rooms.POST("/:roomId/recording/start", h.StartRecording)
+		rooms.POST("/:roomId/recording/stop", h.StopRecording)
+	}
+}
+
+// StartRecording handler untuk start recording endpoint
+func (h *Handler) StartRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	recording, err := h.service.StartRecording(roomUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to start recording")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Recording started successfully")
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recording started successfully",
+		"data":    recording,
+	})
+}
+
+// StopRecording handler untuk stop recording endpoint
+func (h *Handler) StopRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	recording, err := h.service.StopRecording(roomUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to stop recording")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Recording stopped successfully")
+	h.SuccessResponse(c, "Recording stopped successfully", recording)
+}
+
+// GetRoomRecordings handler untuk get room recordings endpoint
+func (h *Handler) GetRoomRecordings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	params := h.GetPaginationParams(c)
+
+	recordings, total, err := h.service.GetRoomRecordings(roomUUID, userUUID, params.Page, params.PerPage)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to get room recordings")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.PaginatedResponse(c, "Room recordings retrieved successfully", recordings, total, params)
+}
+
+// GetRecordings handler untuk get recordings endpoint
+func (h *Handler) GetRecordings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	params := h.GetPaginationParams(c)
+
+	recordings, total, err := h.service.GetRecordings(userUUID, params.Page, params.PerPage)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to get recordings")
+		h.ErrorResponse(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	h.PaginatedResponse(c, "Recordings retrieved successfully", recordings, total, params)
+}
+
+// GetRecording handler untuk get recording endpoint
+func (h *Handler) GetRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	recordingUUID, err := uuid.Parse(c.Param("recordingId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid recording ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid recording ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	recording, err := h.service.GetRecording(recordingUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to get recording")
+		h.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Recording retrieved successfully", recording)
+}
+
+// DownloadRecording handler untuk download hasil recording endpoint
+func (h *Handler) DownloadRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	recordingUUID, err := uuid.Parse(c.Param("recordingId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid recording ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid recording ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	url, expiresAt, err := h.service.GetDownloadURL(c.Request.Context(), recordingUUID, userUUID, c.Query("file"))
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to get recording download url")
+		h.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	// redirect=false untuk client yang membutuhkan URL-nya, misalnya untuk elemen <video>
+	if c.Query("redirect") == "false" {
+		h.SuccessResponse(c, "Recording download url created successfully", gin.H{
+			"url":        url,
+			"expires_at": expiresAt,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// RetryProcessing handler untuk retry recording processing endpoint
+func (h *Handler) RetryProcessing(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	recordingUUID, err := uuid.Parse(c.Param("recordingId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid recording ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid recording ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	recording, err := h.service.RetryProcessing(recordingUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to retry recording processing")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Recording processing requeued successfully", recording)
+}
+
+// Helper functions
+
+// PaginationParams struct for pagination
+type PaginationParams struct {
+	Page    int `form:"page" binding:"min=1"`
+	PerPage int `form:"per_page" binding:"min=1,max=100"`
+}
+
+// GetPaginationParams helper function to get pagination params
+func (h *Handler) GetPaginationParams(c *gin.Context) PaginationParams {
+	params := PaginationParams{
+		Page:    1,
+		PerPage: 20,
+	}
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			params.Page = page
+		}
+	}
+
+	if perPageStr := c.Query("per_page"); perPageStr != "" {
+		if perPage, err := strconv.Atoi(perPageStr); err == nil && perPage > 0 && perPage <= 100 {
+			params.PerPage = perPage
+		}
+	}
+
+	return params
+}
+
+// SuccessResponse helper function for success response
+func (h *Handler) SuccessResponse(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+	})
+}
+
+// ErrorResponse helper function for error response
+func (h *Handler) ErrorResponse(c *gin.Context, statusCode int, message string, details interface{}) {
+	response := gin.H{
+		"error": message,
+	}
+
+	if details != nil {
+		response["details"] = details
+	}
+
+	c.JSON(statusCode, response)
+}
+
+// PaginatedResponse helper function for paginated response
+func (h *Handler) PaginatedResponse(c *gin.Context, message string, data interface{}, total int64, params PaginationParams) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+		"pagination": gin.H{
+			"page":        params.Page,
+			"per_page":    params.PerPage,
+			"total":       total,
+			"total_pages": (total + int64(params.PerPage) - 1) / int64(params.PerPage),
+		},
+	})
+}
@@ -0,0 +1,224 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media/oggwriter"
+)
+
+// Format file .mjr (MJR00002) yang ditulis Janus:
+//
+//	"MJR00002" | uint16 panjang header | header JSON
+//	lalu berulang: "MEET" | uint32 waktu (ms sejak recording dimulai) | uint16 panjang | paket RTP
+//
+// Format lama MJR00001 menulis header JSON sebagai frame pertama dan frame
+// "MEETECHO" tanpa timestamp.
+const (
+	mjrMagicV1   = "MJR00001"
+	mjrMagicV2   = "MJR00002"
+	mjrFrameV1   = "MEETECHO"
+	mjrFrameV2   = "MEET"
+	opusRate     = 48000
+	opusChannels = 2
+)
+
+// MJRInfo adalah header JSON file .mjr
+type MJRInfo struct {
+	// Tipe track: "a" (audio), "v" (video) atau "d" (data)
+	Type  string `json:"t"`
+	Codec string `json:"c"`
+
+	// Waktu file dibuat dan paket pertama ditulis (mikrodetik sejak epoch)
+	Created int64 `json:"s"`
+	Written int64 `json:"u"`
+}
+
+// MJRFrame adalah satu paket di file .mjr
+type MJRFrame struct {
+	// Waktu paket ditulis dalam milidetik sejak recording dimulai (0 untuk MJR00001)
+	Time uint32
+	Data []byte
+}
+
+// MJRReader membaca file .mjr frame demi frame
+type MJRReader struct {
+	Info MJRInfo
+
+	file   *os.File
+	reader *bufio.Reader
+	v2     bool
+}
+
+// OpenMJR membuka file .mjr dan membaca header-nya
+func OpenMJR(name string) (*MJRReader, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &MJRReader{file: file, reader: bufio.NewReader(file)}
+	if err := r.readHeader(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("invalid mjr file %s: %w", name, err)
+	}
+
+	return r, nil
+}
+
+// Close menutup file
+func (r *MJRReader) Close() error {
+	return r.file.Close()
+}
+
+// ReadFrame membaca frame berikutnya. Mengembalikan io.EOF di akhir file; frame
+// terakhir yang terpotong (Janus berhenti saat menulis) juga dianggap akhir file.
+func (r *MJRReader) ReadFrame() (*MJRFrame, error) {
+	frame := &MJRFrame{}
+
+	if r.v2 {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(r.reader, header); err != nil {
+			return nil, io.EOF
+		}
+		if string(header[:4]) != mjrFrameV2 {
+			return nil, fmt.Errorf("invalid frame header")
+		}
+		frame.Time = binary.BigEndian.Uint32(header[4:])
+	} else {
+		header := make([]byte, len(mjrFrameV1))
+		if _, err := io.ReadFull(r.reader, header); err != nil {
+			return nil, io.EOF
+		}
+		if string(header) != mjrFrameV1 {
+			return nil, fmt.Errorf("invalid frame header")
+		}
+	}
+
+	data, err := r.readChunk()
+	if err != nil {
+		return nil, io.EOF
+	}
+	frame.Data = data
+
+	return frame, nil
+}
+
+// Duration memindai semua frame dan mengembalikan waktu frame terakhir dalam
+// milidetik (0 jika file tidak memiliki timestamp)
+func (r *MJRReader) Duration() (uint32, error) {
+	var last uint32
+	for {
+		frame, err := r.ReadFrame()
+		if err == io.EOF {
+			return last, nil
+		}
+		if err != nil {
+			return last, err
+		}
+		last = frame.Time
+	}
+}
+
+// readHeader membaca magic dan header JSON
+func (r *MJRReader) readHeader() error {
+	magic := make([]byte, len(mjrMagicV2))
+	if _, err := io.ReadFull(r.reader, magic); err != nil {
+		return err
+	}
+
+	switch string(magic) {
+	case mjrMagicV2:
+		r.v2 = true
+	case mjrMagicV1:
+		// Header JSON MJR00001 ada di frame pertama
+		frame := make([]byte, len(mjrFrameV1))
+		if _, err := io.ReadFull(r.reader, frame); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown magic %q", magic)
+	}
+
+	header, err := r.readChunk()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(header, &r.Info)
+}
+
+// readChunk membaca uint16 panjang diikuti data sepanjang itu
+func (r *MJRReader) readChunk() ([]byte, error) {
+	var length uint16
+	if err := binary.Read(r.reader, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ConvertMJRToOgg menulis track audio Opus dari file .mjr ke file Ogg Opus tanpa
+// decode ulang. Paket yang datang terlambat (sequence number lebih lama dari paket
+// terakhir yang ditulis) dibuang karena Ogg harus ditulis berurutan.
+func ConvertMJRToOgg(source, destination string) error {
+	reader, err := OpenMJR(source)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if reader.Info.Type != "a" || reader.Info.Codec != "opus" {
+		return fmt.Errorf("unsupported track %s/%s for native conversion", reader.Info.Type, reader.Info.Codec)
+	}
+
+	writer, err := oggwriter.New(destination, opusRate, opusChannels)
+	if err != nil {
+		return err
+	}
+
+	var (
+		started bool
+		prevSeq uint16
+	)
+
+	for {
+		frame, err := reader.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writer.Close()
+			return err
+		}
+
+		packet := &rtp.Packet{}
+		if err := packet.Unmarshal(frame.Data); err != nil || len(packet.Payload) == 0 {
+			continue
+		}
+
+		// Selisih 16 bit bertanda menangani wrap-around sequence number
+		if started && int16(packet.SequenceNumber-prevSeq) <= 0 {
+			continue
+		}
+		prevSeq = packet.SequenceNumber
+		started = true
+
+		if err := writer.WriteRTP(packet); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+
+	return writer.Close()
+}
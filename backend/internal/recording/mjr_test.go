@@ -0,0 +1,194 @@
+package recording
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+// mjrHeader membuat magic MJR00002 beserta header JSON
+func mjrHeader(header string) []byte {
+	data := []byte(mjrMagicV2)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(header)))
+	return append(data, header...)
+}
+
+// mjrFrame membuat satu frame MJR00002
+func mjrFrame(time uint32, packet []byte) []byte {
+	data := []byte(mjrFrameV2)
+	data = binary.BigEndian.AppendUint32(data, time)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(packet)))
+	return append(data, packet...)
+}
+
+// opusPacket membuat paket RTP Opus dengan sequence number tertentu
+func opusPacket(t *testing.T, sequence uint16) []byte {
+	t.Helper()
+
+	packet := &rtp.Packet{
+		Header:  rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: sequence, Timestamp: uint32(sequence) * 960, SSRC: 1},
+		Payload: []byte{0xfc, 0xff, 0xfe},
+	}
+	data, err := packet.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal rtp: %v", err)
+	}
+	return data
+}
+
+// writeMJR menulis isi file .mjr ke direktori sementara
+func writeMJR(t *testing.T, directory, name string, data []byte) string {
+	t.Helper()
+
+	file := filepath.Join(directory, name)
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return file
+}
+
+func TestOpenMJRRejectsInvalidHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "short magic", data: []byte("MJR0")},
+		{name: "unknown magic", data: []byte("RIFF0000\x00\x02{}")},
+		{name: "missing header length", data: []byte(mjrMagicV2)},
+		{name: "half header length", data: append([]byte(mjrMagicV2), 0x00)},
+		{name: "header longer than file", data: append([]byte(mjrMagicV2), 0xff, 0xff, '{')},
+		{name: "garbage json", data: mjrHeader("{{not json")},
+		{name: "empty json", data: mjrHeader("")},
+		{name: "v1 without frame", data: []byte(mjrMagicV1)},
+		{name: "v1 truncated header", data: append([]byte(mjrMagicV1+mjrFrameV1), 0x00, 0x20, '{')},
+	}
+
+	directory := t.TempDir()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := writeMJR(t, directory, "track.mjr", test.data)
+
+			reader, err := OpenMJR(file)
+			if err == nil {
+				reader.Close()
+				t.Fatal("invalid header was accepted")
+			}
+		})
+	}
+}
+
+func TestMJRReaderTreatsTruncatedFrameAsEnd(t *testing.T) {
+	data := mjrHeader(`{"t":"a","c":"opus","s":1000,"u":2000}`)
+	data = append(data, mjrFrame(20, opusPacket(t, 1))...)
+	data = append(data, mjrFrame(40, opusPacket(t, 2))...)
+
+	// Frame terakhir terpotong di tengah paket, seperti saat Janus berhenti menulis
+	last := mjrFrame(60, opusPacket(t, 3))
+	data = append(data, last[:len(last)-4]...)
+
+	reader, err := OpenMJR(writeMJR(t, t.TempDir(), "track.mjr", data))
+	if err != nil {
+		t.Fatalf("failed to open mjr: %v", err)
+	}
+	defer reader.Close()
+
+	if reader.Info.Type != "a" || reader.Info.Codec != "opus" || reader.Info.Written != 2000 {
+		t.Fatalf("unexpected header: %+v", reader.Info)
+	}
+
+	duration, err := reader.Duration()
+	if err != nil {
+		t.Fatalf("truncated frame returned error: %v", err)
+	}
+	if duration != 40 {
+		t.Fatalf("expected duration of the last complete frame, got %d", duration)
+	}
+}
+
+func TestMJRReaderRejectsCorruptFrameHeader(t *testing.T) {
+	data := mjrHeader(`{"t":"a","c":"opus"}`)
+	data = append(data, mjrFrame(20, opusPacket(t, 1))...)
+	data = append(data, []byte("JUNK\x00\x00\x00\x00\x00\x01x")...)
+
+	reader, err := OpenMJR(writeMJR(t, t.TempDir(), "track.mjr", data))
+	if err != nil {
+		t.Fatalf("failed to open mjr: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := reader.ReadFrame(); err != nil {
+		t.Fatalf("first frame failed: %v", err)
+	}
+	if _, err := reader.ReadFrame(); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("expected invalid frame header error, got %v", err)
+	}
+}
+
+func TestMJRReaderSurvivesRandomData(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	directory := t.TempDir()
+
+	for i := 0; i < 500; i++ {
+		// Header valid diikuti byte acak, sebagian dengan magic frame agar panjang acak ikut dibaca
+		data := mjrHeader(`{"t":"a","c":"opus"}`)
+		garbage := make([]byte, random.Intn(256))
+		random.Read(garbage)
+		if i%2 == 0 {
+			garbage = append([]byte(mjrFrameV2), garbage...)
+		}
+		data = append(data, garbage...)
+
+		file := writeMJR(t, directory, "track.mjr", data)
+		reader, err := OpenMJR(file)
+		if err != nil {
+			t.Fatalf("failed to open mjr: %v", err)
+		}
+		reader.Duration()
+		reader.Close()
+
+		// Byte acak sejak awal file
+		random.Read(garbage)
+		if reader, err := OpenMJR(writeMJR(t, directory, "garbage.mjr", garbage)); err == nil {
+			reader.Duration()
+			reader.Close()
+		}
+	}
+}
+
+func TestConvertMJRToOggDropsLatePackets(t *testing.T) {
+	directory := t.TempDir()
+
+	data := mjrHeader(`{"t":"a","c":"opus"}`)
+	for i, sequence := range []uint16{1, 2, 4, 3, 5} {
+		data = append(data, mjrFrame(uint32(i*20), opusPacket(t, sequence))...)
+	}
+	// Paket bukan RTP dilewati tanpa menggagalkan konversi
+	data = append(data, mjrFrame(120, []byte{0x01})...)
+
+	source := writeMJR(t, directory, "track.mjr", data)
+	destination := filepath.Join(directory, "track.opus")
+	if err := ConvertMJRToOgg(source, destination); err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+
+	info, err := os.Stat(destination)
+	if err != nil || info.Size() == 0 {
+		t.Fatalf("no ogg output written: %v", err)
+	}
+}
+
+func TestConvertMJRToOggRejectsVideoTrack(t *testing.T) {
+	directory := t.TempDir()
+	source := writeMJR(t, directory, "track.mjr", mjrHeader(`{"t":"v","c":"vp8"}`))
+
+	if err := ConvertMJRToOgg(source, filepath.Join(directory, "track.opus")); err == nil {
+		t.Fatal("video track was converted natively")
+	}
+}
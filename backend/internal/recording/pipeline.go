@@ -0,0 +1,361 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ukuran kanvas video hasil mix, dibagi rata menjadi grid per peserta
+const (
+	mixWidth  = 1280
+	mixHeight = 720
+	mixFPS    = 25
+)
+
+// trackNamePattern memisahkan nama file .mjr videoroom menjadi prefix peserta dan
+// jenis track, misalnya "videoroom-1234-user-5678-1700000000000000-audio-0"
+var trackNamePattern = regexp.MustCompile(`^(.*)-(audio|video|data)(-\d+)?$`)
+
+// Track adalah satu file .mjr beserta metadatanya
+type Track struct {
+	File        string
+	Participant string
+	Info        MJRInfo
+
+	// Offset terhadap track pertama recording dan durasi, dalam milidetik
+	Offset   int64
+	Duration int64
+
+	// Path file hasil konversi, kosong jika track dilewati
+	Converted string
+}
+
+// IsAudio mengembalikan true untuk track audio
+func (t *Track) IsAudio() bool {
+	return t.Info.Type == "a"
+}
+
+// IsVideo mengembalikan true untuk track video
+func (t *Track) IsVideo() bool {
+	return t.Info.Type == "v"
+}
+
+// ProgressFunc menerima progress pipeline dalam persen 0-100
+type ProgressFunc func(percent int)
+
+// Pipeline mengubah file .mjr recording menjadi file yang dapat diputar
+type Pipeline struct {
+	// Native hanya memakai demuxer MJR Go (audio Opus per peserta, tanpa mix)
+	Native bool
+
+	// Format output mix: "webm" (VP8/Opus) atau "mp4" (H.264/AAC)
+	Format string
+
+	FFmpegPath     string
+	JanusPPRecPath string // kosong = track selain audio Opus dilewati
+}
+
+// ScanTracks membaca header semua file .mjr di direktori dan menghitung offset
+// serta durasi masing-masing track
+func ScanTracks(directory string, files []string) ([]*Track, error) {
+	var tracks []*Track
+	var first int64
+
+	for _, file := range files {
+		// Janus menulis header saat paket pertama diterima, file kosong berarti track tanpa media
+		if info, err := os.Stat(filepath.Join(directory, file)); err != nil || info.Size() == 0 {
+			continue
+		}
+
+		reader, err := OpenMJR(filepath.Join(directory, file))
+		if err != nil {
+			return nil, err
+		}
+
+		duration, err := reader.Duration()
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		track := &Track{
+			File:        file,
+			Participant: strings.TrimSuffix(file, ".mjr"),
+			Info:        reader.Info,
+			Duration:    int64(duration),
+		}
+
+		// Waktu frame dihitung dari pembuatan file, bukan dari paket pertama
+		if track.Info.Written > track.Info.Created && track.Info.Created > 0 {
+			track.Duration -= (track.Info.Written - track.Info.Created) / 1000
+		}
+		if track.Duration < 0 {
+			track.Duration = 0
+		}
+		if match := trackNamePattern.FindStringSubmatch(track.Participant); match != nil {
+			track.Participant = match[1]
+		}
+
+		if track.Info.Type == "d" {
+			continue
+		}
+
+		start := track.startTime()
+		if first == 0 || start < first {
+			first = start
+		}
+		tracks = append(tracks, track)
+	}
+
+	for _, track := range tracks {
+		track.Offset = (track.startTime() - first) / 1000
+	}
+
+	sort.Slice(tracks, func(i, j int) bool {
+		if tracks[i].Offset != tracks[j].Offset {
+			return tracks[i].Offset < tracks[j].Offset
+		}
+		return tracks[i].File < tracks[j].File
+	})
+
+	return tracks, nil
+}
+
+// startTime mengembalikan waktu paket pertama track dalam mikrodetik sejak epoch
+func (t *Track) startTime() int64 {
+	if t.Info.Written > 0 {
+		return t.Info.Written
+	}
+	return t.Info.Created
+}
+
+// Run mengonversi setiap track lalu (kecuali mode native) me-mix semuanya menjadi satu
+// file. Mengembalikan nama file output relatif terhadap directory.
+func (p *Pipeline) Run(ctx context.Context, directory, name string, tracks []*Track, progress ProgressFunc) ([]string, error) {
+	// Mode native menyimpan track audio per peserta sebagai hasil akhir, mode ffmpeg
+	// menyimpan hasil konversi sementara di subdirektori yang dihapus setelah mix
+	convertDir := directory
+	convertShare := 100
+	if !p.Native {
+		convertDir = filepath.Join(directory, "tracks")
+		convertShare = 50
+		if err := os.MkdirAll(convertDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer os.RemoveAll(convertDir)
+	}
+
+	var converted []*Track
+	for i, track := range tracks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		output, err := p.convert(ctx, directory, convertDir, track)
+		if err != nil {
+			return nil, err
+		}
+		if output != "" {
+			track.Converted = output
+			converted = append(converted, track)
+		}
+
+		progress(convertShare * (i + 1) / len(tracks))
+	}
+
+	if len(converted) == 0 {
+		return nil, fmt.Errorf("no playable tracks in recording")
+	}
+
+	if p.Native {
+		outputs := make([]string, 0, len(converted))
+		for _, track := range converted {
+			outputs = append(outputs, filepath.Base(track.Converted))
+		}
+		return outputs, nil
+	}
+
+	output := name + "." + p.Format
+	if err := p.mix(ctx, converted, filepath.Join(directory, output), func(percent int) {
+		progress(convertShare + (100-convertShare)*percent/100)
+	}); err != nil {
+		os.Remove(filepath.Join(directory, output))
+		return nil, err
+	}
+
+	return []string{output}, nil
+}
+
+// convert mengubah satu track .mjr menjadi file media biasa. Audio Opus dikonversi
+// dengan demuxer Go, codec lain membutuhkan janus-pp-rec. Track yang tidak dapat
+// dikonversi dilewati (mengembalikan string kosong).
+func (p *Pipeline) convert(ctx context.Context, directory, outputDir string, track *Track) (string, error) {
+	source := filepath.Join(directory, track.File)
+	base := strings.TrimSuffix(track.File, ".mjr")
+
+	if track.IsAudio() && track.Info.Codec == "opus" {
+		output := filepath.Join(outputDir, base+".opus")
+		if err := ConvertMJRToOgg(source, output); err != nil {
+			return "", fmt.Errorf("failed to convert %s: %w", track.File, err)
+		}
+		return output, nil
+	}
+
+	// Tanpa janus-pp-rec hanya audio Opus yang dapat dikonversi
+	if p.Native || p.JanusPPRecPath == "" {
+		return "", nil
+	}
+
+	extension := trackExtension(track.Info)
+	if extension == "" {
+		return "", nil
+	}
+
+	output := filepath.Join(outputDir, base+extension)
+	cmd := exec.CommandContext(ctx, p.JanusPPRecPath, source, output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("janus-pp-rec failed for %s: %v: %s", track.File, err, lastLine(out))
+	}
+
+	return output, nil
+}
+
+// trackExtension mengembalikan ekstensi output janus-pp-rec untuk codec track
+func trackExtension(info MJRInfo) string {
+	switch info.Codec {
+	case "vp8", "vp9", "av1":
+		return ".webm"
+	case "h264", "h265":
+		return ".mp4"
+	case "opus", "multiopus":
+		return ".opus"
+	case "g711", "pcmu", "pcma", "g722":
+		return ".wav"
+	}
+	return ""
+}
+
+// mix menggabungkan track hasil konversi dengan ffmpeg: video disusun dalam grid,
+// audio di-mix, dan setiap track digeser sesuai offset-nya
+func (p *Pipeline) mix(ctx context.Context, tracks []*Track, output string, progress ProgressFunc) error {
+	args := []string{"-y", "-nostdin", "-hide_banner", "-loglevel", "error", "-nostats", "-progress", "pipe:1"}
+
+	var filters, videos, audios []string
+	var total int64
+	for i, track := range tracks {
+		args = append(args, "-i", track.Converted)
+
+		if end := track.Offset + track.Duration; end > total {
+			total = end
+		}
+
+		label := fmt.Sprintf("%d", i)
+		if track.IsVideo() {
+			videos = append(videos, label)
+		} else {
+			filters = append(filters, fmt.Sprintf("[%s:a]adelay=%d:all=1[a%s]", label, track.Offset, label))
+			audios = append(audios, "[a"+label+"]")
+		}
+	}
+
+	if len(videos) > 0 {
+		filters = append(filters, videoGrid(tracks, videos)...)
+	}
+
+	if len(audios) == 1 {
+		filters = append(filters, audios[0]+"anull[aout]")
+	} else if len(audios) > 1 {
+		filters = append(filters, fmt.Sprintf("%samix=inputs=%d:duration=longest:dropout_transition=0[aout]",
+			strings.Join(audios, ""), len(audios)))
+	}
+
+	args = append(args, "-filter_complex", strings.Join(filters, ";"))
+	if len(videos) > 0 {
+		args = append(args, "-map", "[vout]")
+	}
+	if len(audios) > 0 {
+		args = append(args, "-map", "[aout]")
+	}
+
+	switch p.Format {
+	case "mp4":
+		args = append(args, "-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p",
+			"-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart")
+	default:
+		args = append(args, "-c:v", "libvpx", "-b:v", "1M", "-deadline", "realtime", "-cpu-used", "8",
+			"-c:a", "libopus", "-b:a", "64k")
+	}
+	args = append(args, output)
+
+	cmd := exec.CommandContext(ctx, p.FFmpegPath, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	// -progress menulis pasangan key=value, out_time_us dalam mikrodetik
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found || (key != "out_time_us" && key != "out_time_ms") || total <= 0 {
+			continue
+		}
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil && us > 0 {
+			progress(int(math.Min(99, float64(us/1000)*100/float64(total))))
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %v: %s", err, lastLine([]byte(stderr.String())))
+	}
+
+	progress(100)
+	return nil
+}
+
+// videoGrid membuat filter yang menskalakan setiap video ke satu sel grid, menambahkan
+// frame hitam sebelum peserta mulai publish, lalu menyusunnya dengan xstack
+func videoGrid(tracks []*Track, videos []string) []string {
+	columns := int(math.Ceil(math.Sqrt(float64(len(videos)))))
+	rows := (len(videos) + columns - 1) / columns
+	width := mixWidth / columns &^ 1
+	height := mixHeight / rows &^ 1
+
+	var filters, inputs, layout []string
+	for i, label := range videos {
+		index, _ := strconv.Atoi(label)
+		filters = append(filters, fmt.Sprintf(
+			"[%s:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%d,tpad=start_duration=%.3f:color=black[v%s]",
+			label, width, height, width, height, mixFPS, float64(tracks[index].Offset)/1000, label))
+		inputs = append(inputs, "[v"+label+"]")
+		layout = append(layout, fmt.Sprintf("%d_%d", (i%columns)*width, (i/columns)*height))
+	}
+
+	if len(videos) == 1 {
+		return append(filters, inputs[0]+"null[vout]")
+	}
+
+	return append(filters, fmt.Sprintf("%sxstack=inputs=%d:layout=%s:fill=black[vout]",
+		strings.Join(inputs, ""), len(videos), strings.Join(layout, "|")))
+}
+
+// lastLine mengembalikan baris terakhir yang tidak kosong dari output proses
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
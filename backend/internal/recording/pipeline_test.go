@@ -0,0 +1,166 @@
+package recording
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// fakeBinary menulis script yang mencatat argumennya ke <name>.args dan membuat
+// file output pada argumen terakhir, pengganti ffmpeg dan janus-pp-rec
+func fakeBinary(t *testing.T, directory, name string) string {
+	t.Helper()
+
+	file := filepath.Join(directory, name)
+	script := "#!/bin/sh\n" +
+		"printf '%s\\n' \"$@\" > '" + file + ".args'\n" +
+		"eval last=\\${$#}\n" +
+		"echo media > \"$last\"\n"
+	if err := os.WriteFile(file, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+	return file
+}
+
+// fakeArgs membaca argumen terakhir yang diterima fake binary
+func fakeArgs(t *testing.T, binary string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(binary + ".args")
+	if err != nil {
+		t.Fatalf("%s was not run: %v", filepath.Base(binary), err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// writeTracks menulis satu track audio Opus dan satu track video VP8 peserta
+func writeTracks(t *testing.T, directory string) []*Track {
+	t.Helper()
+
+	audio := mjrHeader(`{"t":"a","c":"opus","s":1000000,"u":1000000}`)
+	video := mjrHeader(`{"t":"v","c":"vp8","s":1000000,"u":1000000}`)
+	for i := uint16(1); i <= 3; i++ {
+		audio = append(audio, mjrFrame(uint32(i)*20, opusPacket(t, i))...)
+		video = append(video, mjrFrame(uint32(i)*40, opusPacket(t, i))...)
+	}
+	writeMJR(t, directory, "videoroom-1-user-1-audio-0.mjr", audio)
+	writeMJR(t, directory, "videoroom-1-user-1-video-1.mjr", video)
+
+	tracks, err := ScanTracks(directory, []string{"videoroom-1-user-1-audio-0.mjr", "videoroom-1-user-1-video-1.mjr"})
+	if err != nil {
+		t.Fatalf("failed to scan tracks: %v", err)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("expected 2 tracks, got %d", len(tracks))
+	}
+	return tracks
+}
+
+func TestNewProcessorDropsMissingJanusPPRec(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	bin := t.TempDir()
+
+	processor := NewProcessor(nil, &logger.Logger{Logger: log}, config.RecordingConfig{
+		Processor:      "ffmpeg",
+		FFmpegPath:     fakeBinary(t, bin, "ffmpeg"),
+		JanusPPRecPath: filepath.Join(bin, "janus-pp-rec"),
+	}, nil)
+
+	if processor.pipeline.Native {
+		t.Fatal("ffmpeg mode disabled although ffmpeg exists")
+	}
+	if processor.pipeline.JanusPPRecPath != "" {
+		t.Fatal("missing janus-pp-rec is still used")
+	}
+
+	var logged bool
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.ErrorLevel && strings.Contains(entry.Message, "janus-pp-rec not found") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Fatal("missing janus-pp-rec was not logged as an error")
+	}
+}
+
+func TestNewProcessorFallsBackToNativeWithoutFFmpeg(t *testing.T) {
+	log, _ := test.NewNullLogger()
+
+	processor := NewProcessor(nil, &logger.Logger{Logger: log}, config.RecordingConfig{
+		Processor:  "ffmpeg",
+		FFmpegPath: filepath.Join(t.TempDir(), "ffmpeg"),
+	}, nil)
+
+	if !processor.pipeline.Native {
+		t.Fatal("missing ffmpeg did not fall back to native mode")
+	}
+}
+
+func TestPipelineMixesAudioOnlyWithoutJanusPPRec(t *testing.T) {
+	directory := t.TempDir()
+	tracks := writeTracks(t, directory)
+	ffmpeg := fakeBinary(t, t.TempDir(), "ffmpeg")
+
+	pipeline := Pipeline{Format: "webm", FFmpegPath: ffmpeg}
+	outputs, err := pipeline.Run(context.Background(), directory, "recording", tracks, func(int) {})
+	if err != nil {
+		t.Fatalf("pipeline failed: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0] != "recording.webm" {
+		t.Fatalf("unexpected outputs: %v", outputs)
+	}
+
+	args := strings.Join(fakeArgs(t, ffmpeg), " ")
+	if strings.Count(args, "-i ") != 1 || !strings.Contains(args, "audio-0.opus") {
+		t.Fatalf("ffmpeg should only mix the audio track: %s", args)
+	}
+	if strings.Contains(args, "[vout]") {
+		t.Fatalf("video mapped without a converted video track: %s", args)
+	}
+}
+
+func TestPipelineConvertsVideoWithJanusPPRec(t *testing.T) {
+	directory := t.TempDir()
+	tracks := writeTracks(t, directory)
+	bin := t.TempDir()
+	ffmpeg := fakeBinary(t, bin, "ffmpeg")
+	janusPPRec := fakeBinary(t, bin, "janus-pp-rec")
+
+	pipeline := Pipeline{Format: "mp4", FFmpegPath: ffmpeg, JanusPPRecPath: janusPPRec}
+	if _, err := pipeline.Run(context.Background(), directory, "recording", tracks, func(int) {}); err != nil {
+		t.Fatalf("pipeline failed: %v", err)
+	}
+
+	converted := fakeArgs(t, janusPPRec)
+	if len(converted) != 2 || !strings.HasSuffix(converted[0], "video-1.mjr") || !strings.HasSuffix(converted[1], "video-1.webm") {
+		t.Fatalf("unexpected janus-pp-rec arguments: %v", converted)
+	}
+
+	args := strings.Join(fakeArgs(t, ffmpeg), " ")
+	if strings.Count(args, "-i ") != 2 || !strings.Contains(args, "-map [vout]") || !strings.Contains(args, "libx264") {
+		t.Fatalf("ffmpeg did not mix audio and video: %s", args)
+	}
+}
+
+func TestPipelineNativeSkipsVideo(t *testing.T) {
+	directory := t.TempDir()
+	tracks := writeTracks(t, directory)
+
+	pipeline := Pipeline{Native: true}
+	outputs, err := pipeline.Run(context.Background(), directory, "recording", tracks, func(int) {})
+	if err != nil {
+		t.Fatalf("pipeline failed: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0] != "videoroom-1-user-1-audio-0.opus" {
+		t.Fatalf("unexpected outputs: %v", outputs)
+	}
+}
@@ -0,0 +1,427 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/internal/storage"
+	"github.com/webrtc-meeting/backend/models"
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+const (
+	// Recording processing tanpa heartbeat selama ini dianggap ditinggalkan worker
+	// yang mati dan dikembalikan ke antrean
+	staleProcessingAfter = 10 * time.Minute
+	processingHeartbeat  = time.Minute
+
+	// Progress ditulis ke database setiap naik minimal sebesar ini
+	progressStep = 5
+)
+
+// Processor adalah worker background yang mengubah recording berstatus stopped
+// menjadi file yang dapat diputar. Beberapa instance API dapat berjalan bersamaan;
+// recording di-claim dengan update bersyarat pada status.
+type Processor struct {
+	db           *gorm.DB
+	logger       *logger.Logger
+	pipeline     Pipeline
+	storage      *storage.Service
+	recordingDir string
+	workers      int
+	pollInterval time.Duration
+
+	// wake membangunkan worker ketika recording baru dihentikan
+	wake chan struct{}
+}
+
+// NewProcessor membuat recording processor baru. Mode ffmpeg jatuh ke mode native
+// jika ffmpeg tidak ditemukan; tanpa janus-pp-rec hanya track audio yang diproses.
+// Hasil pipeline disimpan ke storage dan dihitung ke quota room.
+func NewProcessor(db *gorm.DB, log *logger.Logger, cfg config.RecordingConfig, files *storage.Service) *Processor {
+	pipeline := Pipeline{
+		Native:         cfg.Processor == "native",
+		Format:         cfg.Format,
+		FFmpegPath:     cfg.FFmpegPath,
+		JanusPPRecPath: cfg.JanusPPRecPath,
+	}
+
+	if pipeline.Format != "mp4" {
+		pipeline.Format = "webm"
+	}
+
+	if !pipeline.Native {
+		if _, err := exec.LookPath(pipeline.FFmpegPath); err != nil {
+			log.WithField("ffmpeg", pipeline.FFmpegPath).Warn("ffmpeg not found, recordings are processed as audio-only tracks")
+			pipeline.Native = true
+		} else if _, err := exec.LookPath(pipeline.JanusPPRecPath); err != nil {
+			log.WithField("janus_pp_rec", pipeline.JanusPPRecPath).Error("janus-pp-rec not found, video tracks are skipped and recordings are audio-only")
+			pipeline.JanusPPRecPath = ""
+		}
+	}
+
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 30 * time.Second
+	}
+
+	return &Processor{
+		db:           db,
+		logger:       log,
+		pipeline:     pipeline,
+		storage:      files,
+		recordingDir: cfg.Dir,
+		workers:      workers,
+		pollInterval: pollInterval,
+		wake:         make(chan struct{}, workers),
+	}
+}
+
+// Run menjalankan worker sampai ctx dibatalkan. Recording yang sedang diproses saat
+// shutdown dikembalikan ke antrean.
+func (p *Processor) Run(ctx context.Context) {
+	p.logger.WithField("workers", p.workers).
+		WithField("native", p.pipeline.Native).
+		WithField("format", p.pipeline.Format).
+		Info("Recording processor started")
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	p.logger.Info("Recording processor stopped")
+}
+
+// Enqueue membangunkan worker untuk memproses recording yang baru dihentikan
+func (p *Processor) Enqueue() {
+	if p == nil {
+		return
+	}
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work memproses recording selama antrean tidak kosong, lalu menunggu poll berikutnya
+func (p *Processor) work(ctx context.Context) {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		p.requeueStale()
+
+		for ctx.Err() == nil {
+			recording, err := p.claim()
+			if err != nil {
+				p.logger.LogError(err, "Failed to claim recording for processing")
+				break
+			}
+			if recording == nil {
+				break
+			}
+			p.process(ctx, recording)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+	}
+}
+
+// claim mengambil recording stopped tertua dan menandainya processing
+func (p *Processor) claim() (*models.Recording, error) {
+	for {
+		var recording models.Recording
+		if err := p.db.Where("status = ?", models.RecordingStatusStopped).
+			Order("stopped_at").
+			First(&recording).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		result := p.db.Model(&models.Recording{}).
+			Where("id = ? AND status = ?", recording.ID, models.RecordingStatusStopped).
+			Updates(map[string]interface{}{
+				"status":   models.RecordingStatusProcessing,
+				"progress": 0,
+				"error":    "",
+				"attempts": gorm.Expr("attempts + 1"),
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		// Recording sudah diambil worker lain, coba recording berikutnya
+		if result.RowsAffected == 1 {
+			recording.Status = models.RecordingStatusProcessing
+			recording.Attempts++
+			return &recording, nil
+		}
+	}
+}
+
+// requeueStale mengembalikan recording processing yang heartbeat-nya berhenti ke antrean
+func (p *Processor) requeueStale() {
+	result := p.db.Model(&models.Recording{}).
+		Where("status = ? AND updated_at < ?", models.RecordingStatusProcessing, time.Now().Add(-staleProcessingAfter)).
+		Updates(map[string]interface{}{
+			"status":   models.RecordingStatusStopped,
+			"progress": 0,
+		})
+	if result.Error != nil {
+		p.logger.LogError(result.Error, "Failed to requeue stale recordings")
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		p.logger.WithField("count", result.RowsAffected).Warn("Requeued stale recordings")
+	}
+}
+
+// process menjalankan pipeline untuk satu recording dan menyimpan hasilnya
+func (p *Processor) process(ctx context.Context, recording *models.Recording) {
+	log := p.logger.WithField("recording_id", recording.ID.String()).WithField("room_id", recording.RoomID.String())
+	log.WithField("attempt", recording.Attempts).Info("Processing recording")
+
+	stopHeartbeat := p.heartbeat(recording.ID)
+	defer stopHeartbeat()
+
+	directory := filepath.Join(p.recordingDir, filepath.FromSlash(recording.Directory))
+
+	// File dibaca ulang karena Janus baru menutup file .mjr setelah recording dihentikan
+	files, size, err := mjrFiles(directory)
+	if err != nil {
+		p.fail(recording, err)
+		return
+	}
+
+	tracks, err := ScanTracks(directory, files)
+	if err != nil {
+		p.fail(recording, err)
+		return
+	}
+
+	if len(tracks) == 0 {
+		p.fail(recording, errors.New("no media was recorded"))
+		return
+	}
+
+	outputs, err := p.pipeline.Run(ctx, directory, recording.ID.String(), tracks, p.progressUpdater(recording.ID))
+	if err != nil {
+		if ctx.Err() != nil {
+			p.db.Model(&models.Recording{}).Where("id = ?", recording.ID).Updates(map[string]interface{}{
+				"status":   models.RecordingStatusStopped,
+				"progress": 0,
+			})
+			log.Info("Recording processing interrupted by shutdown, requeued")
+			return
+		}
+		p.fail(recording, err)
+		return
+	}
+
+	keys, outputSize, err := p.store(ctx, recording, directory, outputs)
+	if err != nil {
+		p.fail(recording, err)
+		return
+	}
+
+	now := time.Now()
+	if err := p.db.Model(&models.Recording{}).Where("id = ?", recording.ID).Updates(map[string]interface{}{
+		"status":       models.RecordingStatusCompleted,
+		"progress":     100,
+		"files":        pq.StringArray(files),
+		"size":         size,
+		"output_files": pq.StringArray(keys),
+		"output_size":  outputSize,
+		"processed_at": &now,
+	}).Error; err != nil {
+		log.WithError(err).Error("Failed to save processed recording")
+		return
+	}
+
+	p.updateMeetingHistory(recording, outputSize)
+
+	log.WithField("outputs", strings.Join(keys, ",")).
+		WithField("output_size", outputSize).
+		Info("Recording processed successfully")
+}
+
+// store memindahkan file hasil pipeline ke storage. File lokal dihapus setelah
+// tersimpan; file .mjr tetap ada sehingga recording dapat diproses ulang.
+func (p *Processor) store(ctx context.Context, recording *models.Recording, directory string, outputs []string) ([]string, int64, error) {
+	defer func() {
+		for _, output := range outputs {
+			os.Remove(filepath.Join(directory, output))
+		}
+	}()
+
+	var keys []string
+	var size int64
+	for _, output := range outputs {
+		file, err := p.storeFile(ctx, recording, filepath.Join(directory, output))
+		if err != nil {
+			return nil, 0, err
+		}
+		keys = append(keys, file.Key)
+		size += file.Size
+	}
+
+	return keys, size, nil
+}
+
+// storeFile menyimpan satu file hasil pipeline sebagai file recording room
+func (p *Processor) storeFile(ctx context.Context, recording *models.Recording, name string) (*models.StoredFile, error) {
+	reader, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	info, err := reader.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(name)
+	return p.storage.Store(ctx, storage.StoreRequest{
+		Kind:    models.FileKindRecording,
+		OwnerID: recording.StartedBy,
+		RoomID:  &recording.RoomID,
+		Key:     path.Join("recordings", recording.RoomID.String(), recording.ID.String(), strings.TrimSuffix(base, filepath.Ext(base))),
+		Name:    base,
+		Size:    info.Size(),
+	}, reader)
+}
+
+// fail menandai recording gagal diproses beserta pesan error-nya
+func (p *Processor) fail(recording *models.Recording, cause error) {
+	p.logger.WithField("recording_id", recording.ID.String()).
+		WithError(cause).
+		Error("Failed to process recording")
+
+	if err := p.db.Model(&models.Recording{}).Where("id = ?", recording.ID).Updates(map[string]interface{}{
+		"status": models.RecordingStatusFailed,
+		"error":  cause.Error(),
+	}).Error; err != nil {
+		p.logger.LogError(err, "Failed to mark recording as failed")
+	}
+}
+
+// heartbeat memperbarui updated_at recording secara berkala agar tidak dianggap stale
+func (p *Processor) heartbeat(recordingID uuid.UUID) func() {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(processingHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				p.db.Model(&models.Recording{}).
+					Where("id = ? AND status = ?", recordingID, models.RecordingStatusProcessing).
+					Update("updated_at", time.Now())
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// progressUpdater menyimpan progress pipeline ke database setiap naik progressStep persen
+func (p *Processor) progressUpdater(recordingID uuid.UUID) ProgressFunc {
+	last := 0
+	return func(percent int) {
+		if percent < last+progressStep && percent < 100 {
+			return
+		}
+		last = percent
+
+		if err := p.db.Model(&models.Recording{}).Where("id = ?", recordingID).
+			Update("progress", percent).Error; err != nil {
+			p.logger.LogError(err, "Failed to update recording progress")
+		}
+	}
+}
+
+// updateMeetingHistory menautkan hasil recording ke meeting history room. History
+// dibuat jika room belum memilikinya.
+func (p *Processor) updateMeetingHistory(recording *models.Recording, size int64) {
+	var room models.Room
+	if err := p.db.First(&room, recording.RoomID).Error; err != nil {
+		p.logger.LogError(err, "Failed to find room for meeting history")
+		return
+	}
+
+	var history models.MeetingHistory
+	err := p.db.Where("room_id = ? AND start_time <= ?", recording.RoomID, recording.StartedAt).
+		Order("start_time DESC").
+		First(&history).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		p.logger.LogError(err, "Failed to find meeting history")
+		return
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		history = models.MeetingHistory{
+			RoomID:      room.ID,
+			HostID:      room.HostID,
+			Title:       room.Name,
+			Description: room.Description,
+			StartTime:   recording.StartedAt,
+			EndTime:     room.EndTime,
+			Status:      models.MeetingStatusOngoing,
+		}
+		if room.StartTime != nil && room.StartTime.Before(recording.StartedAt) {
+			history.StartTime = *room.StartTime
+		}
+		if room.Status == models.RoomStatusEnded {
+			history.Status = models.MeetingStatusEnded
+		}
+	}
+
+	history.RecordingURL = DownloadPath(recording.ID)
+	history.RecordingSize = size
+
+	if err := p.db.Save(&history).Error; err != nil {
+		p.logger.LogError(err, "Failed to update meeting history recording")
+	}
+}
+
+// DownloadPath mengembalikan path API untuk mengunduh hasil recording
+func DownloadPath(recordingID uuid.UUID) string {
+	return "/api/v1/webrtc/recording/" + recordingID.String() + "/download"
+}
@@ -0,0 +1,536 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/storage"
+	"github.com/webrtc-meeting/backend/models"
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// Service struct untuk recording service
+type Service struct {
+	db     *gorm.DB
+	logger *logger.Logger
+	media  MediaRecorder
+
+	// Processor dibangunkan setiap recording dihentikan (nil = hanya polling)
+	processor *Processor
+
+	// Storage tempat file hasil recording disimpan
+	storage *storage.Service
+
+	// Direktori recording yang ditulis Janus, dilihat dari API server
+	recordingDir string
+}
+
+// MediaRecorder menjalankan recording di media plane (websocket server dan media backend-nya)
+type MediaRecorder interface {
+	StartRecording(roomID, recordingID, directory, startedBy string) error
+	StopRecording(roomID, recordingID string) error
+}
+
+// NewService membuat recording service baru
+func NewService(db *gorm.DB, log *logger.Logger, recordingDir string) *Service {
+	return &Service{
+		db:           db,
+		logger:       log,
+		recordingDir: recordingDir,
+	}
+}
+
+// SetMediaRecorder mengatur media recorder (nil = recording tidak tersedia)
+func (s *Service) SetMediaRecorder(media MediaRecorder) {
+	s.media = media
+}
+
+// SetProcessor mengatur recording processor
+func (s *Service) SetProcessor(processor *Processor) {
+	s.processor = processor
+}
+
+// SetStorage mengatur storage file hasil recording
+func (s *Service) SetStorage(files *storage.Service) {
+	s.storage = files
+}
+
+// StartRecording mulai merekam room (host atau moderator)
+func (s *Service) StartRecording(roomID, userID uuid.UUID) (*models.Recording, error) {
+	room, err := s.findActiveRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkControl(room, userID); err != nil {
+		return nil, err
+	}
+
+	return s.start(room, userID, false)
+}
+
+// AutoStartRecording mulai merekam room atas nama host jika RoomSetting.AutoRecord
+// aktif dan room belum direkam. Mengembalikan nil jika recording tidak dimulai.
+func (s *Service) AutoStartRecording(roomID uuid.UUID) (*models.Recording, error) {
+	var settings models.RoomSetting
+	if err := s.db.Where("room_id = ?", roomID).First(&settings).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		s.logger.LogError(err, "Failed to find room settings for auto record")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if !settings.AutoRecord || !settings.EnableRecording {
+		return nil, nil
+	}
+
+	room, err := s.findActiveRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.IsRecording {
+		return nil, nil
+	}
+
+	return s.start(room, room.HostID, true)
+}
+
+// StopRecording menghentikan recording room yang sedang berjalan (host atau moderator)
+func (s *Service) StopRecording(roomID, userID uuid.UUID) (*models.Recording, error) {
+	var room models.Room
+	if err := s.db.First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("room not found")
+		}
+		s.logger.LogError(err, "Failed to find room for stop recording")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if err := s.checkControl(&room, userID); err != nil {
+		return nil, err
+	}
+
+	return s.stop(&room, &userID)
+}
+
+// StopRoomRecording menghentikan recording room yang sedang berjalan tanpa
+// pengecekan akses, misalnya ketika room diakhiri
+func (s *Service) StopRoomRecording(roomID uuid.UUID) error {
+	var room models.Room
+	if err := s.db.First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		s.logger.LogError(err, "Failed to find room for stop recording")
+		return fmt.Errorf("internal server error")
+	}
+
+	if !room.IsRecording {
+		return nil
+	}
+
+	_, err := s.stop(&room, nil)
+	return err
+}
+
+// GetRoomRecordings mendapatkan daftar recording room (host dan peserta room)
+func (s *Service) GetRoomRecordings(roomID, userID uuid.UUID, page, perPage int) ([]*models.Recording, int64, error) {
+	var room models.Room
+	if err := s.db.First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, fmt.Errorf("room not found")
+		}
+		s.logger.LogError(err, "Failed to find room for recordings")
+		return nil, 0, fmt.Errorf("internal server error")
+	}
+
+	if err := s.checkAccess(&room, userID); err != nil {
+		return nil, 0, err
+	}
+
+	var recordings []*models.Recording
+	var total int64
+
+	query := s.db.Model(&models.Recording{}).Where("room_id = ?", roomID)
+
+	if err := query.Count(&total).Error; err != nil {
+		s.logger.LogError(err, "Failed to count recordings")
+		return nil, 0, fmt.Errorf("internal server error")
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Preload("Starter").
+		Order("started_at DESC").
+		Offset(offset).
+		Limit(perPage).
+		Find(&recordings).Error; err != nil {
+		s.logger.LogError(err, "Failed to get recordings")
+		return nil, 0, fmt.Errorf("internal server error")
+	}
+
+	return recordings, total, nil
+}
+
+// GetRecordings mendapatkan recording dari room yang di-host atau diikuti user
+func (s *Service) GetRecordings(userID uuid.UUID, page, perPage int) ([]*models.Recording, int64, error) {
+	var recordings []*models.Recording
+	var total int64
+
+	query := s.db.Model(&models.Recording{}).
+		Where("room_id IN (?) OR room_id IN (?)",
+			s.db.Model(&models.Room{}).Select("id").Where("host_id = ?", userID),
+			s.db.Model(&models.RoomParticipant{}).Select("room_id").Where("user_id = ?", userID),
+		)
+
+	if err := query.Count(&total).Error; err != nil {
+		s.logger.LogError(err, "Failed to count recordings")
+		return nil, 0, fmt.Errorf("internal server error")
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Preload("Room").
+		Preload("Starter").
+		Order("started_at DESC").
+		Offset(offset).
+		Limit(perPage).
+		Find(&recordings).Error; err != nil {
+		s.logger.LogError(err, "Failed to get recordings")
+		return nil, 0, fmt.Errorf("internal server error")
+	}
+
+	return recordings, total, nil
+}
+
+// GetRecording mendapatkan detail recording
+func (s *Service) GetRecording(recordingID, userID uuid.UUID) (*models.Recording, error) {
+	var recording models.Recording
+	if err := s.db.Preload("Room").Preload("Starter").First(&recording, recordingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("recording not found")
+		}
+		s.logger.LogError(err, "Failed to get recording")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if recording.Room == nil {
+		return nil, fmt.Errorf("recording not found")
+	}
+
+	if err := s.checkAccess(recording.Room, userID); err != nil {
+		return nil, err
+	}
+
+	return &recording, nil
+}
+
+// RetryProcessing mengantrekan ulang recording yang gagal diproses (host atau moderator)
+func (s *Service) RetryProcessing(recordingID, userID uuid.UUID) (*models.Recording, error) {
+	var recording models.Recording
+	if err := s.db.Preload("Room").First(&recording, recordingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("recording not found")
+		}
+		s.logger.LogError(err, "Failed to get recording")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if recording.Room == nil {
+		return nil, fmt.Errorf("recording not found")
+	}
+
+	if err := s.checkControl(recording.Room, userID); err != nil {
+		return nil, err
+	}
+
+	// Recording yang gagal dimulai di media server (belum pernah diproses) tidak memiliki file
+	if recording.Status != models.RecordingStatusFailed || recording.Attempts == 0 {
+		return nil, fmt.Errorf("only recordings that failed processing can be retried")
+	}
+
+	result := s.db.Model(&models.Recording{}).
+		Where("id = ? AND status = ?", recording.ID, models.RecordingStatusFailed).
+		Updates(map[string]interface{}{
+			"status":   models.RecordingStatusStopped,
+			"progress": 0,
+			"error":    "",
+		})
+	if result.Error != nil {
+		s.logger.LogError(result.Error, "Failed to requeue recording")
+		return nil, fmt.Errorf("failed to retry recording processing")
+	}
+
+	s.processor.Enqueue()
+
+	recording.Status = models.RecordingStatusStopped
+	recording.Progress = 0
+	recording.Error = ""
+
+	s.logger.WithUserID(userID.String()).WithField("recording_id", recording.ID.String()).Info("Recording processing requeued")
+	return &recording, nil
+}
+
+// GetDownloadURL mengembalikan signed URL file hasil recording. Nama kosong berarti
+// file output pertama; nama lain dicocokkan dengan nama file output.
+func (s *Service) GetDownloadURL(ctx context.Context, recordingID, userID uuid.UUID, name string) (string, time.Time, error) {
+	recording, err := s.GetRecording(recordingID, userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if recording.Status != models.RecordingStatusCompleted || len(recording.OutputFiles) == 0 || s.storage == nil {
+		return "", time.Time{}, fmt.Errorf("recording is not ready")
+	}
+
+	key := ""
+	for _, output := range recording.OutputFiles {
+		if name == "" || path.Base(output) == name {
+			key = output
+			break
+		}
+	}
+
+	if key == "" {
+		return "", time.Time{}, fmt.Errorf("recording file not found")
+	}
+
+	file, err := s.storage.GetFileByKey(key)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("recording file not found")
+	}
+
+	return s.storage.SignedURL(ctx, file)
+}
+
+// start membuat recording baru dan memulainya di media server
+func (s *Service) start(room *models.Room, startedBy uuid.UUID, auto bool) (*models.Recording, error) {
+	var settings models.RoomSetting
+	if err := s.db.Where("room_id = ?", room.ID).First(&settings).Error; err == nil {
+		if !settings.EnableRecording {
+			return nil, fmt.Errorf("recording is disabled for this room")
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.LogError(err, "Failed to find room settings for recording")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if s.media == nil {
+		return nil, fmt.Errorf("recording is not available")
+	}
+
+	var active int64
+	if err := s.db.Model(&models.Recording{}).
+		Where("room_id = ? AND status = ?", room.ID, models.RecordingStatusRecording).
+		Count(&active).Error; err != nil {
+		s.logger.LogError(err, "Failed to check active recording")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if active > 0 {
+		return nil, fmt.Errorf("room is already being recorded")
+	}
+
+	recordingID := uuid.New()
+	recording := &models.Recording{
+		ID:          recordingID,
+		RoomID:      room.ID,
+		StartedBy:   startedBy,
+		Status:      models.RecordingStatusRecording,
+		AutoStarted: auto,
+		Directory:   path.Join(room.ID.String(), recordingID.String()),
+		StartedAt:   time.Now(),
+	}
+
+	// Unique index idx_recordings_active_room menolak dua recording aktif yang dibuat bersamaan
+	if err := s.db.Create(recording).Error; err != nil {
+		s.logger.LogError(err, "Failed to create recording")
+		return nil, fmt.Errorf("failed to start recording")
+	}
+
+	if err := s.media.StartRecording(room.ID.String(), recording.ID.String(), recording.Directory, startedBy.String()); err != nil {
+		s.logger.LogError(err, "Failed to start recording on media server")
+
+		now := time.Now()
+		s.db.Model(recording).Updates(map[string]interface{}{
+			"status":     models.RecordingStatusFailed,
+			"stopped_at": &now,
+		})
+		return nil, fmt.Errorf("failed to start recording on media server")
+	}
+
+	if err := s.db.Model(room).Update("is_recording", true).Error; err != nil {
+		s.logger.LogError(err, "Failed to update room recording flag")
+	}
+
+	s.logger.WithUserID(startedBy.String()).
+		WithField("room_id", room.ID.String()).
+		WithField("recording_id", recording.ID.String()).
+		WithField("auto", auto).
+		Info("Recording started successfully")
+	return recording, nil
+}
+
+// stop menghentikan recording aktif room dan mencatat file yang dihasilkan
+func (s *Service) stop(room *models.Room, stoppedBy *uuid.UUID) (*models.Recording, error) {
+	var recording models.Recording
+	if err := s.db.Where("room_id = ? AND status = ?", room.ID, models.RecordingStatusRecording).
+		First(&recording).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("room is not being recorded")
+		}
+		s.logger.LogError(err, "Failed to find active recording")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if s.media == nil {
+		return nil, fmt.Errorf("recording is not available")
+	}
+
+	// Recording tetap ditandai berhenti walaupun media server gagal (misalnya session
+	// room hilang atau Janus restart), karena row yang tertinggal berstatus recording
+	// memblokir semua recording room berikutnya
+	mediaErr := s.media.StopRecording(room.ID.String(), recording.ID.String())
+	if mediaErr != nil {
+		s.logger.LogError(mediaErr, "Failed to stop recording on media server")
+	}
+
+	now := time.Now()
+	recording.Status = models.RecordingStatusStopped
+	recording.StoppedAt = &now
+	recording.StoppedBy = stoppedBy
+	recording.Files, recording.Size = s.collectFiles(recording.Directory)
+
+	// File yang sudah ditulis sebelum media server gagal tetap diproses
+	if mediaErr != nil && len(recording.Files) == 0 {
+		recording.Status = models.RecordingStatusFailed
+		recording.Error = fmt.Sprintf("media server failed to stop recording: %v", mediaErr)
+	}
+
+	if err := s.db.Save(&recording).Error; err != nil {
+		s.logger.LogError(err, "Failed to update recording")
+		return nil, fmt.Errorf("failed to stop recording")
+	}
+
+	if err := s.db.Model(room).Update("is_recording", false).Error; err != nil {
+		s.logger.LogError(err, "Failed to update room recording flag")
+	}
+
+	// File .mjr diproses menjadi file yang dapat diputar di background
+	if recording.Status == models.RecordingStatusStopped {
+		s.processor.Enqueue()
+	}
+
+	s.logger.WithField("room_id", room.ID.String()).
+		WithField("recording_id", recording.ID.String()).
+		WithField("files", len(recording.Files)).
+		WithField("status", recording.Status).
+		Info("Recording stopped")
+	return &recording, nil
+}
+
+// collectFiles mencari file .mjr recording dan total ukurannya. Direktori yang
+// tidak dapat dibaca (misalnya volume recording tidak di-mount) menghasilkan daftar kosong.
+func (s *Service) collectFiles(directory string) ([]string, int64) {
+	files, size, err := mjrFiles(filepath.Join(s.recordingDir, filepath.FromSlash(directory)))
+	if err != nil {
+		s.logger.WithField("directory", directory).Warnf("Failed to read recording directory: %v", err)
+		return nil, 0
+	}
+
+	return files, size
+}
+
+// mjrFiles mengembalikan nama file .mjr di direktori dan total ukurannya
+func mjrFiles(directory string) ([]string, int64, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var files []string
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".mjr") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, entry.Name())
+		size += info.Size()
+	}
+
+	return files, size, nil
+}
+
+// findActiveRoom mencari room yang masih aktif
+func (s *Service) findActiveRoom(roomID uuid.UUID) (*models.Room, error) {
+	var room models.Room
+	if err := s.db.First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("room not found")
+		}
+		s.logger.LogError(err, "Failed to find room for recording")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if room.Status != models.RoomStatusActive {
+		return nil, fmt.Errorf("room is not active")
+	}
+
+	return &room, nil
+}
+
+// checkControl memastikan user adalah host atau moderator yang sedang berada di room
+func (s *Service) checkControl(room *models.Room, userID uuid.UUID) error {
+	if room.HostID == userID {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ? AND role = ? AND status = ?",
+			room.ID, userID, models.ParticipantRoleModerator, models.ParticipantStatusJoined).
+		Count(&count).Error; err != nil {
+		s.logger.LogError(err, "Failed to check recording permission")
+		return fmt.Errorf("internal server error")
+	}
+
+	if count == 0 {
+		return fmt.Errorf("only host or moderator can control recording")
+	}
+
+	return nil
+}
+
+// checkAccess memastikan user adalah host atau pernah menjadi peserta room
+func (s *Service) checkAccess(room *models.Room, userID uuid.UUID) error {
+	if room.HostID == userID {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ?", room.ID, userID).
+		Count(&count).Error; err != nil {
+		s.logger.LogError(err, "Failed to check recording access")
+		return fmt.Errorf("internal server error")
+	}
+
+	if count == 0 {
+		return fmt.Errorf("access denied")
+	}
+
+	return nil
+}
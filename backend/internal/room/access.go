@@ -0,0 +1,64 @@
+package room
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// SignalingAccess memeriksa apakah user boleh join room di signaling server dengan
+// aturan yang sama dengan Service.JoinRoom: room harus aktif dan user harus host atau
+// peserta yang sedang joined. participant nil jika user belum pernah join room.
+// Penolakan dibungkus mediaplane.ErrRoomAccessDenied.
+func SignalingAccess(room *models.Room, participant *models.RoomParticipant, userID uuid.UUID) error {
+	if err := roomStatusError(room); err != nil {
+		return fmt.Errorf("%w: %v", mediaplane.ErrRoomAccessDenied, err)
+	}
+
+	if participant != nil {
+		if err := removedParticipantError(participant); err != nil {
+			return fmt.Errorf("%w: %v", mediaplane.ErrRoomAccessDenied, err)
+		}
+	}
+
+	if room.HostID == userID || (participant != nil && participant.IsHost()) {
+		return nil
+	}
+
+	if participant == nil || !participant.IsActive() {
+		return fmt.Errorf("%w: not a participant of this room", mediaplane.ErrRoomAccessDenied)
+	}
+
+	return nil
+}
+
+// roomStatusError mengembalikan alasan room tidak dapat dijoin (nil jika room aktif)
+func roomStatusError(room *models.Room) error {
+	switch room.Status {
+	case models.RoomStatusActive:
+		return nil
+	case models.RoomStatusEnded:
+		return errors.New("room has ended")
+	case models.RoomStatusBlocked:
+		return errors.New("room is blocked")
+	default:
+		return errors.New("room is not active")
+	}
+}
+
+// removedParticipantError mengembalikan alasan peserta tidak boleh kembali ke room
+// (nil jika peserta tidak di-kick atau di-ban)
+func removedParticipantError(participant *models.RoomParticipant) error {
+	switch participant.Status {
+	case models.ParticipantStatusKicked:
+		return errors.New("removed from room by host")
+	case models.ParticipantStatusBanned:
+		return errors.New("banned from room")
+	default:
+		return nil
+	}
+}
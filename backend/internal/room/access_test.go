@@ -0,0 +1,54 @@
+package room
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+func TestSignalingAccess(t *testing.T) {
+	hostID := uuid.New()
+	userID := uuid.New()
+
+	participant := func(role models.ParticipantRole, status models.ParticipantStatus) *models.RoomParticipant {
+		return &models.RoomParticipant{UserID: userID, Role: role, Status: status}
+	}
+
+	tests := []struct {
+		name        string
+		status      models.RoomStatus
+		userID      uuid.UUID
+		participant *models.RoomParticipant
+		allowed     bool
+	}{
+		{name: "host without participant record", status: models.RoomStatusActive, userID: hostID, allowed: true},
+		{name: "joined participant", status: models.RoomStatusActive, userID: userID, participant: participant(models.ParticipantRoleParticipant, models.ParticipantStatusJoined), allowed: true},
+		{name: "joined moderator", status: models.RoomStatusActive, userID: userID, participant: participant(models.ParticipantRoleModerator, models.ParticipantStatusJoined), allowed: true},
+		{name: "co-host who left", status: models.RoomStatusActive, userID: userID, participant: participant(models.ParticipantRoleHost, models.ParticipantStatusLeft), allowed: true},
+		{name: "not a participant", status: models.RoomStatusActive, userID: userID},
+		{name: "participant who left", status: models.RoomStatusActive, userID: userID, participant: participant(models.ParticipantRoleParticipant, models.ParticipantStatusLeft)},
+		{name: "kicked participant", status: models.RoomStatusActive, userID: userID, participant: participant(models.ParticipantRoleParticipant, models.ParticipantStatusKicked)},
+		{name: "banned participant", status: models.RoomStatusActive, userID: userID, participant: participant(models.ParticipantRoleParticipant, models.ParticipantStatusBanned)},
+		{name: "banned co-host", status: models.RoomStatusActive, userID: userID, participant: participant(models.ParticipantRoleHost, models.ParticipantStatusBanned)},
+		{name: "host of ended room", status: models.RoomStatusEnded, userID: hostID},
+		{name: "participant of blocked room", status: models.RoomStatusBlocked, userID: userID, participant: participant(models.ParticipantRoleParticipant, models.ParticipantStatusJoined)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			room := &models.Room{ID: uuid.New(), HostID: hostID, Status: test.status}
+
+			err := SignalingAccess(room, test.participant, test.userID)
+			if test.allowed && err != nil {
+				t.Fatalf("access denied: %v", err)
+			}
+			if !test.allowed && !errors.Is(err, mediaplane.ErrRoomAccessDenied) {
+				t.Fatalf("expected ErrRoomAccessDenied, got %v", err)
+			}
+		})
+	}
+}
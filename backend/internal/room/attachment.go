@@ -0,0 +1,170 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/storage"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// SetStorage mengatur storage untuk lampiran chat (nil = berbagi file tidak tersedia)
+func (s *Service) SetStorage(files *storage.Service) {
+	s.storage = files
+}
+
+// AttachmentPath mengembalikan path API lampiran pesan yang disimpan di RoomMessage.FileURL.
+// Path ini stabil; setiap request menghasilkan signed URL baru.
+func AttachmentPath(roomID, messageID uuid.UUID) string {
+	return "/api/v1/rooms/" + roomID.String() + "/messages/" + messageID.String() + "/file"
+}
+
+// UploadAttachment menyimpan lampiran chat ke storage dan membuat pesan file/image.
+// Ukuran lampiran dihitung ke quota storage room.
+func (s *Service) UploadAttachment(ctx context.Context, roomID, userID uuid.UUID, header *multipart.FileHeader, caption string) (*models.RoomMessage, error) {
+	if s.storage == nil {
+		return nil, fmt.Errorf("file sharing is not available")
+	}
+
+	var room models.Room
+	if err := s.db.First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("room not found")
+		}
+		s.logger.LogError(err, "Failed to find room for attachment")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	if room.Status != models.RoomStatusActive {
+		return nil, fmt.Errorf("room is not active")
+	}
+
+	// Hanya host dan peserta yang sedang berada di room yang dapat mengirim lampiran
+	if room.HostID != userID {
+		var count int64
+		if err := s.db.Model(&models.RoomParticipant{}).
+			Where("room_id = ? AND user_id = ? AND status = ?", roomID, userID, models.ParticipantStatusJoined).
+			Count(&count).Error; err != nil {
+			s.logger.LogError(err, "Failed to check attachment permission")
+			return nil, fmt.Errorf("internal server error")
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("you are not in this room")
+		}
+	}
+
+	var settings models.RoomSetting
+	if err := s.db.Where("room_id = ?", roomID).First(&settings).Error; err == nil {
+		if !settings.AllowChat || !settings.AllowFileShare {
+			return nil, fmt.Errorf("file sharing is disabled for this room")
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.LogError(err, "Failed to find room settings for attachment")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	reader, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file")
+	}
+	defer reader.Close()
+
+	name := filepath.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+	messageID := uuid.New()
+
+	file, err := s.storage.Store(ctx, storage.StoreRequest{
+		Kind:    models.FileKindAttachment,
+		OwnerID: userID,
+		RoomID:  &roomID,
+		Key:     path.Join("attachments", roomID.String(), messageID.String()),
+		Name:    name,
+		Size:    header.Size,
+	}, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	messageType := models.MessageTypeFile
+	if strings.HasPrefix(file.ContentType, "image/") {
+		messageType = models.MessageTypeImage
+	}
+
+	if caption == "" {
+		caption = name
+	}
+
+	message := &models.RoomMessage{
+		ID:       messageID,
+		RoomID:   roomID,
+		SenderID: userID,
+		Message:  caption,
+		Type:     messageType,
+		FileID:   &file.ID,
+		FileURL:  AttachmentPath(roomID, messageID),
+		FileName: name,
+		FileSize: file.Size,
+	}
+
+	if err := s.db.Create(message).Error; err != nil {
+		s.logger.LogError(err, "Failed to create attachment message")
+		if err := s.storage.Delete(ctx, file); err != nil {
+			s.logger.LogError(err, "Failed to remove attachment of failed message")
+		}
+		return nil, fmt.Errorf("failed to send attachment")
+	}
+
+	if err := s.db.Preload("Sender").First(message, messageID).Error; err != nil {
+		s.logger.LogError(err, "Failed to load attachment message")
+	}
+
+	s.logger.WithUserID(userID.String()).
+		WithField("room_id", roomID.String()).
+		WithField("size", file.Size).
+		Info("Attachment uploaded successfully")
+	return message, nil
+}
+
+// GetAttachmentURL membuat signed URL lampiran pesan untuk user yang memiliki akses ke room
+func (s *Service) GetAttachmentURL(ctx context.Context, roomID, messageID, userID uuid.UUID) (string, time.Time, error) {
+	if s.storage == nil {
+		return "", time.Time{}, fmt.Errorf("file sharing is not available")
+	}
+
+	var room models.Room
+	if err := s.db.Where("id = ?", roomID).
+		Where("is_public = ? OR host_id = ? OR id IN (SELECT room_id FROM room_participants WHERE user_id = ?)",
+			true, userID, userID).
+		First(&room).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", time.Time{}, fmt.Errorf("room not found or access denied")
+		}
+		s.logger.LogError(err, "Failed to check room access")
+		return "", time.Time{}, fmt.Errorf("internal server error")
+	}
+
+	var message models.RoomMessage
+	if err := s.db.Where("id = ? AND room_id = ? AND is_deleted = ? AND file_id IS NOT NULL", messageID, roomID, false).
+		First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", time.Time{}, fmt.Errorf("attachment not found")
+		}
+		s.logger.LogError(err, "Failed to find attachment message")
+		return "", time.Time{}, fmt.Errorf("internal server error")
+	}
+
+	file, err := s.storage.GetFile(*message.FileID)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("attachment not found")
+	}
+
+	return s.storage.SignedURL(ctx, file)
+}
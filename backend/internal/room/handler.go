@@ -1,12 +1,14 @@
 package room
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/webrtc-meeting/backend/internal/storage"
 	"github.com/webrtc-meeting/backend/pkg/logger"
 )
 
@@ -49,9 +51,22 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 		// Room participants
 		rooms.GET("/:roomId/participants", h.AuthMiddleware(), h.GetRoomParticipants)
 		rooms.POST("/:roomId/participants/:participantId/kick", h.AuthMiddleware(), h.KickParticipant)
+		rooms.POST("/:roomId/participants/:participantId/mute", h.AuthMiddleware(), h.MuteParticipant)
+		rooms.POST("/:roomId/participants/:participantId/unpublish", h.AuthMiddleware(), h.UnpublishParticipant)
+		rooms.POST("/:roomId/participants/:participantId/volume", h.AuthMiddleware(), h.SetParticipantVolume)
+
+		// Telepon: PIN dial-in dan panggilan keluar (host only)
+		rooms.GET("/:roomId/dial-in", h.AuthMiddleware(), h.GetDialIn)
+		rooms.POST("/:roomId/dial-in", h.AuthMiddleware(), h.EnableDialIn)
+		rooms.DELETE("/:roomId/dial-in", h.AuthMiddleware(), h.DisableDialIn)
+		rooms.POST("/:roomId/dial-out", h.AuthMiddleware(), h.DialOut)
+		rooms.GET("/:roomId/calls", h.AuthMiddleware(), h.GetPhoneCalls)
+		rooms.DELETE("/:roomId/calls/:callId", h.AuthMiddleware(), h.HangupPhoneCall)
 
 		// Room messages
 		rooms.GET("/:roomId/messages", h.AuthMiddleware(), h.GetRoomMessages)
+		rooms.POST("/:roomId/messages/attachments", h.AuthMiddleware(), h.UploadAttachment)
+		rooms.GET("/:roomId/messages/:messageId/file", h.AuthMiddleware(), h.GetAttachment)
 
 		// Room settings
 		rooms.GET("/:roomId/settings", h.AuthMiddleware(), h.GetRoomSettings)
@@ -393,14 +408,287 @@ func (h *Handler) KickParticipant(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.KickParticipant(roomUUID, userUUID, participantUUID); err != nil {
+	result, err := h.service.KickParticipant(roomUUID, userUUID, participantUUID)
+	if err != nil {
 		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to kick participant")
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
 	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).WithField("participant_id", participantUUID.String()).Info("Participant kicked successfully")
-	h.SuccessResponse(c, "Participant kicked successfully", nil)
+	h.SuccessResponse(c, "Participant kicked successfully", result)
+}
+
+// MuteParticipant handler untuk mute participant endpoint
+func (h *Handler) MuteParticipant(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	participantUUID, err := uuid.Parse(c.Param("participantId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid participant ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid participant ID", nil)
+		return
+	}
+
+	var req MuteParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid mute participant request")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	if err := h.service.MuteParticipant(roomUUID, userUUID, participantUUID, &req); err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to mute participant")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).WithField("participant_id", participantUUID.String()).Info("Participant moderated successfully")
+	h.SuccessResponse(c, "Participant moderated successfully", gin.H{
+		"kind": req.Kind,
+		"mute": req.Mute,
+	})
+}
+
+// UnpublishParticipant handler untuk unpublish participant endpoint
+func (h *Handler) UnpublishParticipant(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	participantUUID, err := uuid.Parse(c.Param("participantId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid participant ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid participant ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	if err := h.service.UnpublishParticipant(roomUUID, userUUID, participantUUID); err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to unpublish participant")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).WithField("participant_id", participantUUID.String()).Info("Participant unpublished successfully")
+	h.SuccessResponse(c, "Participant unpublished successfully", nil)
+}
+
+// SetParticipantVolume handler untuk participant volume endpoint
+func (h *Handler) SetParticipantVolume(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	participantUUID, err := uuid.Parse(c.Param("participantId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid participant ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid participant ID", nil)
+		return
+	}
+
+	var req ParticipantVolumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid participant volume request")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	if err := h.service.SetParticipantVolume(roomUUID, userUUID, participantUUID, &req); err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to set participant volume")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).WithField("participant_id", participantUUID.String()).Info("Participant volume changed successfully")
+	h.SuccessResponse(c, "Participant volume changed successfully", gin.H{
+		"volume": *req.Volume,
+	})
+}
+
+// hostRoomRequest membaca user dan room ID dari request host. Response error sudah
+// dikirim jika ok bernilai false.
+func (h *Handler) hostRoomRequest(c *gin.Context) (roomUUID, userUUID uuid.UUID, ok bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userUUID, err = uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return roomUUID, userUUID, true
+}
+
+// GetDialIn handler untuk melihat PIN dial-in room
+func (h *Handler) GetDialIn(c *gin.Context) {
+	roomUUID, userUUID, ok := h.hostRoomRequest(c)
+	if !ok {
+		return
+	}
+
+	info, err := h.service.GetDialIn(roomUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to get dial-in")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Dial-in retrieved successfully", info)
+}
+
+// EnableDialIn handler untuk membuat (ulang) PIN dial-in room
+func (h *Handler) EnableDialIn(c *gin.Context) {
+	roomUUID, userUUID, ok := h.hostRoomRequest(c)
+	if !ok {
+		return
+	}
+
+	info, err := h.service.EnableDialIn(roomUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to enable dial-in")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Dial-in enabled successfully", info)
+}
+
+// DisableDialIn handler untuk menghapus PIN dial-in room
+func (h *Handler) DisableDialIn(c *gin.Context) {
+	roomUUID, userUUID, ok := h.hostRoomRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DisableDialIn(roomUUID, userUUID); err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to disable dial-in")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Dial-in disabled successfully", nil)
+}
+
+// DialOut handler untuk menelepon nomor dan memasukkannya ke room
+func (h *Handler) DialOut(c *gin.Context) {
+	roomUUID, userUUID, ok := h.hostRoomRequest(c)
+	if !ok {
+		return
+	}
+
+	var req DialOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid dial-out request")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	call, err := h.service.DialOut(roomUUID, userUUID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to dial out")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Call started successfully", call)
+}
+
+// GetPhoneCalls handler untuk melihat panggilan telepon yang sedang berjalan di room
+func (h *Handler) GetPhoneCalls(c *gin.Context) {
+	roomUUID, userUUID, ok := h.hostRoomRequest(c)
+	if !ok {
+		return
+	}
+
+	calls, err := h.service.GetPhoneCalls(roomUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to get phone calls")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Phone calls retrieved successfully", calls)
+}
+
+// HangupPhoneCall handler untuk mengakhiri panggilan telepon di room
+func (h *Handler) HangupPhoneCall(c *gin.Context) {
+	roomUUID, userUUID, ok := h.hostRoomRequest(c)
+	if !ok {
+		return
+	}
+
+	callID := c.Param("callId")
+	if err := h.service.HangupPhoneCall(roomUUID, userUUID, callID); err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to hang up phone call")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Call ended successfully", gin.H{
+		"call_id": callID,
+	})
 }
 
 // GetRoomMessages handler untuk get room messages endpoint
@@ -438,6 +726,100 @@ func (h *Handler) GetRoomMessages(c *gin.Context) {
 	h.PaginatedResponse(c, "Room messages retrieved successfully", messages, total, params)
 }
 
+// UploadAttachment handler untuk upload lampiran chat endpoint (multipart: file, caption)
+func (h *Handler) UploadAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "File is required", err.Error())
+		return
+	}
+
+	message, err := h.service.UploadAttachment(c.Request.Context(), roomUUID, userUUID, header, c.PostForm("caption"))
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to upload attachment")
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			statusCode = http.StatusRequestEntityTooLarge
+		}
+		h.ErrorResponse(c, statusCode, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Attachment uploaded successfully")
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Attachment uploaded successfully",
+		"data":    message,
+	})
+}
+
+// GetAttachment handler untuk download lampiran chat endpoint. Mengarahkan ke signed
+// URL, atau mengembalikannya sebagai JSON dengan redirect=false.
+func (h *Handler) GetAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return
+	}
+
+	messageUUID, err := uuid.Parse(c.Param("messageId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid message ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid message ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	url, expiresAt, err := h.service.GetAttachmentURL(c.Request.Context(), roomUUID, messageUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to get attachment url")
+		h.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	if c.Query("redirect") == "false" {
+		h.SuccessResponse(c, "Attachment url created successfully", gin.H{
+			"url":        url,
+			"expires_at": expiresAt,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
 // GetRoomSettings handler untuk get room settings endpoint
 func (h *Handler) GetRoomSettings(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -0,0 +1,108 @@
+package room
+
+import (
+	"strings"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// Batas bitrate video per publisher untuk setiap RoomSetting.VideoQuality (bit/detik)
+const (
+	bitrateLow = 256_000
+	bitrateSD  = 512_000
+	bitrateHD  = 1_500_000
+	bitrateFHD = 2_500_000
+)
+
+// Batas bitrate conference, karena setiap peserta menerima banyak stream sekaligus
+const conferenceMaxBitrate = 768_000
+
+// MediaPolicyFor menurunkan kebijakan media Janus/SFU dari tipe room dan pengaturannya.
+// settings nil dianggap memakai nilai default RoomSetting (hd, high). Room audio dan
+// room dengan RoomSetting.AudioOnly dilayani Janus AudioBridge. Data channel hanya
+// aktif jika RoomSetting.AllowChat.
+func MediaPolicyFor(roomType models.RoomType, settings *models.RoomSetting) mediaplane.MediaPolicy {
+	videoQuality, audioQuality := "hd", "high"
+	if settings != nil {
+		if settings.VideoQuality != "" {
+			videoQuality = settings.VideoQuality
+		}
+		if settings.AudioQuality != "" {
+			audioQuality = settings.AudioQuality
+		}
+	}
+
+	policy := mediaplane.MediaPolicy{
+		FirFreq: 10,
+	}
+
+	// Kualitas video menentukan batas bitrate dan codec yang diizinkan
+	switch strings.ToLower(videoQuality) {
+	case "low", "ld", "240p", "360p":
+		policy.Bitrate = bitrateLow
+		policy.VideoCodec = "vp8,h264"
+	case "sd", "medium", "480p":
+		policy.Bitrate = bitrateSD
+		policy.VideoCodec = "vp8,h264"
+	case "fhd", "1080p":
+		policy.Bitrate = bitrateFHD
+		policy.VideoCodec = "vp9,vp8,h264"
+	default:
+		// "hd", "high", "720p"
+		policy.Bitrate = bitrateHD
+		policy.VideoCodec = "vp8,vp9,h264"
+	}
+
+	// Kualitas audio menentukan codec audio; Opus tetap diprioritaskan. PCMU/PCMA
+	// diizinkan sebagai cadangan agar penelepon SIP dapat publish tanpa transcoding.
+	switch strings.ToLower(audioQuality) {
+	case "low":
+		policy.AudioCodec = "opus,pcmu,pcma"
+	case "medium":
+		policy.AudioCodec = "opus,g722,pcmu,pcma"
+	default:
+		policy.AudioCodec = "opus,pcmu,pcma"
+	}
+
+	// Tipe room menentukan deteksi pembicara dan frekuensi keyframe
+	switch roomType {
+	case models.RoomTypeWebinar:
+		// Penonton (termasuk HLS) sering masuk di tengah sesi; event talking
+		// dipakai live stream mode active-speaker
+		policy.AudioLevelEvent = true
+		policy.FirFreq = 5
+	case models.RoomTypeConference:
+		policy.AudioLevelEvent = true
+		if policy.Bitrate > conferenceMaxBitrate {
+			policy.Bitrate = conferenceMaxBitrate
+		}
+	case models.RoomTypeAudio:
+		policy.AudioBridge = true
+		policy.AudioLevelEvent = true
+	default:
+		// Meeting dan classroom
+		policy.AudioLevelEvent = true
+	}
+
+	if settings != nil && settings.AudioOnly {
+		policy.AudioBridge = true
+	}
+
+	// Data channel dipakai chat dan fitur yang menumpang padanya (reaksi, kursor)
+	policy.DataChannels = settings == nil || settings.AllowChat
+
+	// Audio di-mix server sehingga tidak ada video yang dibatasi
+	if policy.AudioBridge {
+		policy.Bitrate = 0
+		policy.VideoCodec = ""
+		policy.FirFreq = 0
+	}
+
+	if policy.AudioLevelEvent {
+		policy.AudioActivePackets = 50
+		policy.AudioLevelAverage = 25
+	}
+
+	return policy
+}
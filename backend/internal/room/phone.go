@@ -0,0 +1,237 @@
+package room
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// Panjang PIN dial-in room
+const dialInPINLength = 6
+
+// PhoneDialer menjalankan panggilan telepon SIP room di media plane
+type PhoneDialer interface {
+	DialOut(roomID string, dial mediaplane.SIPDial) (*mediaplane.SIPCall, error)
+	HangupCall(roomID, callID string) error
+	ListCalls(roomID string) ([]mediaplane.SIPCall, error)
+}
+
+// DialInInfo adalah informasi dial-in room yang ditampilkan ke host
+type DialInInfo struct {
+	Enabled bool   `json:"enabled"`
+	Number  string `json:"number,omitempty"`
+	PIN     string `json:"pin,omitempty"`
+}
+
+// DialOutRequest struct untuk request dial-out ke nomor telepon
+type DialOutRequest struct {
+	Number  string `json:"number" binding:"required,min=3,max=21"`
+	Display string `json:"display" binding:"max=100"`
+}
+
+// SetDialer mengatur dialer telepon room dan nomor dial-in yang ditampilkan ke host
+// (nil = dial-out tidak tersedia)
+func (s *Service) SetDialer(dialer PhoneDialer, dialInNumber string) {
+	s.dialer = dialer
+	s.dialInNumber = dialInNumber
+}
+
+// GetDialIn mengembalikan PIN dial-in room (host only)
+func (s *Service) GetDialIn(roomID, hostID uuid.UUID) (*DialInInfo, error) {
+	room, err := s.findHostRoom(roomID, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.dialInInfo(room), nil
+}
+
+// EnableDialIn membuat PIN dial-in baru untuk room (host only). PIN lama tidak
+// berlaku lagi, penelepon yang sudah masuk tidak terpengaruh.
+func (s *Service) EnableDialIn(roomID, hostID uuid.UUID) (*DialInInfo, error) {
+	room, err := s.findHostRoom(roomID, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != models.RoomStatusActive {
+		return nil, fmt.Errorf("room is not active")
+	}
+
+	pin, err := s.generateDialInPIN()
+	if err != nil {
+		s.logger.LogError(err, "Failed to generate dial-in PIN")
+		return nil, fmt.Errorf("failed to generate dial-in PIN")
+	}
+
+	if err := s.db.Model(room).Update("dial_in_pin", pin).Error; err != nil {
+		s.logger.LogError(err, "Failed to save dial-in PIN")
+		return nil, fmt.Errorf("failed to enable dial-in")
+	}
+	room.DialInPIN = &pin
+
+	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).Info("Room dial-in enabled")
+	return s.dialInInfo(room), nil
+}
+
+// DisableDialIn menghapus PIN dial-in room sehingga panggilan masuk baru ditolak (host only)
+func (s *Service) DisableDialIn(roomID, hostID uuid.UUID) error {
+	room, err := s.findHostRoom(roomID, hostID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Model(room).Update("dial_in_pin", nil).Error; err != nil {
+		s.logger.LogError(err, "Failed to clear dial-in PIN")
+		return fmt.Errorf("failed to disable dial-in")
+	}
+
+	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).Info("Room dial-in disabled")
+	return nil
+}
+
+// DialOut menelepon nomor dan memasukkan penerima telepon ke room (host only)
+func (s *Service) DialOut(roomID, hostID uuid.UUID, req *DialOutRequest) (*mediaplane.SIPCall, error) {
+	room, err := s.findHostRoom(roomID, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != models.RoomStatusActive {
+		return nil, fmt.Errorf("room is not active")
+	}
+
+	if s.dialer == nil {
+		return nil, fmt.Errorf("phone dial-out is not available")
+	}
+
+	call, err := s.dialer.DialOut(roomID.String(), mediaplane.SIPDial{
+		Number:    req.Number,
+		Display:   req.Display,
+		StartedBy: hostID.String(),
+	})
+	if err != nil {
+		s.logger.LogError(err, "Failed to dial out")
+		return nil, fmt.Errorf("failed to dial out")
+	}
+
+	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).WithField("call_id", call.ID).Info("Dial-out started")
+	return call, nil
+}
+
+// GetPhoneCalls mengembalikan panggilan telepon yang sedang berjalan di room (host only)
+func (s *Service) GetPhoneCalls(roomID, hostID uuid.UUID) ([]mediaplane.SIPCall, error) {
+	if _, err := s.findHostRoom(roomID, hostID); err != nil {
+		return nil, err
+	}
+
+	if s.dialer == nil {
+		return []mediaplane.SIPCall{}, nil
+	}
+
+	calls, err := s.dialer.ListCalls(roomID.String())
+	if err != nil {
+		s.logger.LogError(err, "Failed to list phone calls")
+		return nil, fmt.Errorf("failed to list phone calls")
+	}
+
+	return calls, nil
+}
+
+// HangupPhoneCall mengakhiri panggilan telepon di room (host only)
+func (s *Service) HangupPhoneCall(roomID, hostID uuid.UUID, callID string) error {
+	if _, err := s.findHostRoom(roomID, hostID); err != nil {
+		return err
+	}
+
+	if s.dialer == nil {
+		return fmt.Errorf("phone calls are not available")
+	}
+
+	if err := s.dialer.HangupCall(roomID.String(), callID); err != nil {
+		if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			return fmt.Errorf("call not found")
+		}
+		s.logger.LogError(err, "Failed to hang up phone call")
+		return fmt.Errorf("failed to hang up call")
+	}
+
+	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).WithField("call_id", callID).Info("Phone call ended by host")
+	return nil
+}
+
+// hangupRoomCalls mengakhiri semua panggilan telepon room, misalnya saat room diakhiri
+func (s *Service) hangupRoomCalls(roomID uuid.UUID) {
+	if s.dialer == nil {
+		return
+	}
+
+	calls, err := s.dialer.ListCalls(roomID.String())
+	if err != nil {
+		s.logger.LogError(err, "Failed to list phone calls of ended room")
+		return
+	}
+
+	for _, call := range calls {
+		if err := s.dialer.HangupCall(roomID.String(), call.ID); err != nil && !errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			s.logger.LogError(err, "Failed to hang up phone call of ended room")
+		}
+	}
+}
+
+// findHostRoom mengambil room milik host
+func (s *Service) findHostRoom(roomID, hostID uuid.UUID) (*models.Room, error) {
+	var room models.Room
+	if err := s.db.Where("id = ? AND host_id = ?", roomID, hostID).First(&room).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("room not found or access denied")
+		}
+		s.logger.LogError(err, "Failed to find room")
+		return nil, fmt.Errorf("internal server error")
+	}
+	return &room, nil
+}
+
+// dialInInfo membangun informasi dial-in room
+func (s *Service) dialInInfo(room *models.Room) *DialInInfo {
+	info := &DialInInfo{
+		Number: s.dialInNumber,
+	}
+	if room.DialInPIN != nil {
+		info.Enabled = true
+		info.PIN = *room.DialInPIN
+	}
+	return info
+}
+
+// generateDialInPIN menggenerate PIN dial-in numerik yang unik
+func (s *Service) generateDialInPIN() (string, error) {
+	for i := 0; i < 10; i++ { // Try 10 times
+		pin := make([]byte, dialInPINLength)
+		for j := range pin {
+			num, err := rand.Int(rand.Reader, big.NewInt(10))
+			if err != nil {
+				return "", err
+			}
+			pin[j] = byte('0' + num.Int64())
+		}
+
+		var count int64
+		if err := s.db.Model(&models.Room{}).Where("dial_in_pin = ?", string(pin)).Count(&count).Error; err != nil {
+			return "", err
+		}
+
+		if count == 0 {
+			return string(pin), nil
+		}
+	}
+
+	return "", errors.New("failed to generate unique dial-in PIN after 10 attempts")
+}
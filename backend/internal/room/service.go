@@ -11,6 +11,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/storage"
+	"github.com/webrtc-meeting/backend/internal/streaming"
 	"github.com/webrtc-meeting/backend/models"
 	"github.com/webrtc-meeting/backend/pkg/logger"
 )
@@ -19,6 +22,44 @@ import (
 type Service struct {
 	db     *gorm.DB
 	logger *logger.Logger
+	media  MediaController
+
+	// Recorder untuk AutoRecord dan menghentikan recording saat room diakhiri (nil = nonaktif)
+	recorder RoomRecorder
+
+	// Storage untuk lampiran chat dan quota storage room (nil = nonaktif)
+	storage *storage.Service
+
+	// Live stream HLS webinar dan restream RTMP untuk status di GetRoomStats dan
+	// penghentian saat room diakhiri (nil = nonaktif)
+	streamer RoomStreamer
+
+	// Dialer panggilan telepon SIP room dan nomor dial-in-nya (nil = dial-out nonaktif)
+	dialer       PhoneDialer
+	dialInNumber string
+}
+
+// RoomRecorder menjalankan recording room atas nama room service
+type RoomRecorder interface {
+	AutoStartRecording(roomID uuid.UUID) (*models.Recording, error)
+	StopRoomRecording(roomID uuid.UUID) error
+}
+
+// RoomStreamer mengelola live stream room atas nama room service
+type RoomStreamer interface {
+	StreamStatus(roomID uuid.UUID) *streaming.Status
+	StopRoomStream(roomID uuid.UUID) error
+	StopRoomLiveStreams(roomID uuid.UUID) error
+}
+
+// MediaController menegakkan moderasi host dan kebijakan media room di media plane
+// (websocket server dan media backend-nya)
+type MediaController interface {
+	KickParticipant(roomID, userID string) error
+	MuteParticipant(roomID, userID, kind string, mute bool) error
+	UnpublishParticipant(roomID, userID string) error
+	SetParticipantVolume(roomID, userID string, volume int) error
+	ApplyMediaPolicy(roomID string, policy mediaplane.MediaPolicy) error
 }
 
 // NewService membuat room service baru
@@ -29,13 +70,28 @@ func NewService(db *gorm.DB, log *logger.Logger) *Service {
 	}
 }
 
+// SetMediaController mengatur media controller untuk moderasi (nil = hanya update database)
+func (s *Service) SetMediaController(media MediaController) {
+	s.media = media
+}
+
+// SetRecorder mengatur recorder room
+func (s *Service) SetRecorder(recorder RoomRecorder) {
+	s.recorder = recorder
+}
+
+// SetStreamer mengatur live streamer room
+func (s *Service) SetStreamer(streamer RoomStreamer) {
+	s.streamer = streamer
+}
+
 // CreateRoomRequest struct untuk request create room
 type CreateRoomRequest struct {
 	Name        string     `json:"name" binding:"required,min=1,max=100"`
 	Description string     `json:"description" binding:"max=500"`
 	Password    string     `json:"password" binding:"min=6"`
 	MaxUsers    int        `json:"max_users" binding:"min=2,max=100"`
-	Type        string     `json:"type" binding:"required,oneof=meeting webinar conference classroom"`
+	Type        string     `json:"type" binding:"required,oneof=meeting webinar conference classroom audio"`
 	IsPublic    bool       `json:"is_public"`
 	StartTime   *time.Time `json:"start_time"`
 	EndTime     *time.Time `json:"end_time"`
@@ -72,6 +128,26 @@ type UpdateRoomSettingsRequest struct {
 	EnablePolling       bool   `json:"enable_polling"`
 	EnableWhiteboard    bool   `json:"enable_whiteboard"`
 	EnableRecording     bool   `json:"enable_recording"`
+	AudioOnly           bool   `json:"audio_only"`
+}
+
+// MuteParticipantRequest struct untuk request mute participant
+type MuteParticipantRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=audio video"`
+	Mute bool   `json:"mute"`
+}
+
+// ParticipantVolumeRequest struct untuk request volume participant di room audio.
+// Volume dalam persen terhadap volume asli (100 = tidak diubah).
+type ParticipantVolumeRequest struct {
+	Volume *int `json:"volume" binding:"required,min=0,max=400"`
+}
+
+// KickResult adalah hasil kick participant. Kick tetap tercatat walaupun media
+// participant belum berhasil diputus; Warning menjelaskan kondisi tersebut ke host.
+type KickResult struct {
+	MediaEnforced bool   `json:"media_enforced"`
+	Warning       string `json:"warning,omitempty"`
 }
 
 // CreateRoom membuat room baru
@@ -352,6 +428,10 @@ func (s *Service) JoinRoom(roomID, userID uuid.UUID, req *JoinRoomRequest) (*mod
 		if existingParticipant.Status == models.ParticipantStatusJoined {
 			return nil, fmt.Errorf("already joined room")
 		}
+		// Peserta yang di-kick atau di-ban tidak dapat join kembali
+		if err := removedParticipantError(&existingParticipant); err != nil {
+			return nil, err
+		}
 		// Rejoin if left before
 		existingParticipant.Status = models.ParticipantStatusJoined
 		existingParticipant.JoinedAt = time.Now()
@@ -360,6 +440,7 @@ func (s *Service) JoinRoom(roomID, userID uuid.UUID, req *JoinRoomRequest) (*mod
 			s.logger.LogError(err, "Failed to rejoin room")
 			return nil, fmt.Errorf("failed to join room")
 		}
+		s.autoRecord(roomID)
 		return &existingParticipant, nil
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		s.logger.LogError(err, "Failed to check existing participant")
@@ -387,6 +468,8 @@ func (s *Service) JoinRoom(roomID, userID uuid.UUID, req *JoinRoomRequest) (*mod
 		return nil, fmt.Errorf("failed to load participant")
 	}
 
+	s.autoRecord(roomID)
+
 	s.logger.WithUserID(userID.String()).WithField("room_id", roomID.String()).Info("User joined room successfully")
 	return participant, nil
 }
@@ -437,6 +520,26 @@ func (s *Service) EndRoom(roomID, userID uuid.UUID) error {
 		return fmt.Errorf("failed to end room")
 	}
 
+	// Hentikan recording yang masih berjalan
+	if s.recorder != nil {
+		if err := s.recorder.StopRoomRecording(roomID); err != nil {
+			s.logger.LogError(err, "Failed to stop recording of ended room")
+		}
+	}
+
+	// Hentikan live stream yang masih berjalan
+	if s.streamer != nil {
+		if err := s.streamer.StopRoomStream(roomID); err != nil {
+			s.logger.LogError(err, "Failed to stop live stream of ended room")
+		}
+		if err := s.streamer.StopRoomLiveStreams(roomID); err != nil {
+			s.logger.LogError(err, "Failed to stop restreams of ended room")
+		}
+	}
+
+	// Putus panggilan telepon yang masih tersambung
+	s.hangupRoomCalls(roomID)
+
 	// Remove all participants from room
 	if err := s.db.Model(&models.RoomParticipant{}).
 		Where("room_id = ? AND status = ?", roomID, models.ParticipantStatusJoined).
@@ -605,22 +708,51 @@ func (s *Service) UpdateRoomSettings(roomID, userID uuid.UUID, req *UpdateRoomSe
 	settings.EnablePolling = req.EnablePolling
 	settings.EnableWhiteboard = req.EnableWhiteboard
 	settings.EnableRecording = req.EnableRecording
+	settings.AudioOnly = req.AudioOnly
 
 	if err := s.db.Save(&settings).Error; err != nil {
 		s.logger.LogError(err, "Failed to update room settings")
 		return nil, fmt.Errorf("failed to update settings")
 	}
 
+	// Terapkan bitrate dan kebijakan media baru ke room yang sedang berjalan
+	s.applyMediaPolicy(&room, &settings)
+
 	s.logger.WithUserID(userID.String()).WithField("room_id", roomID.String()).Info("Room settings updated successfully")
 	return &settings, nil
 }
 
 // Helper functions
 
+// autoRecord mulai merekam room jika RoomSetting.AutoRecord aktif. Kegagalan hanya
+// dicatat agar user tetap dapat bergabung ke room.
+func (s *Service) autoRecord(roomID uuid.UUID) {
+	if s.recorder == nil {
+		return
+	}
+
+	if _, err := s.recorder.AutoStartRecording(roomID); err != nil {
+		s.logger.LogError(err, "Failed to auto start recording")
+	}
+}
+
+// applyMediaPolicy mengirim kebijakan media room ke media server. Kegagalan hanya dicatat,
+// karena pengaturan sudah tersimpan dan akan dipakai saat room media dibuat berikutnya.
+func (s *Service) applyMediaPolicy(room *models.Room, settings *models.RoomSetting) {
+	if s.media == nil {
+		return
+	}
+
+	policy := MediaPolicyFor(room.Type, settings)
+	if err := s.media.ApplyMediaPolicy(room.ID.String(), policy); err != nil {
+		s.logger.LogError(err, "Failed to apply media policy to media server")
+	}
+}
+
 // isValidRoomType memvalidasi room type
 func isValidRoomType(roomType models.RoomType) bool {
 	switch roomType {
-	case models.RoomTypeMeeting, models.RoomTypeWebinar, models.RoomTypeConference, models.RoomTypeClassroom:
+	case models.RoomTypeMeeting, models.RoomTypeWebinar, models.RoomTypeConference, models.RoomTypeClassroom, models.RoomTypeAudio:
 		return true
 	default:
 		return false
@@ -658,21 +790,23 @@ func (s *Service) generateRoomCode() (string, error) {
 	return "", errors.New("failed to generate unique room code after 10 attempts")
 }
 
-// KickParticipant mengeluarkan peserta dari room (host only)
-func (s *Service) KickParticipant(roomID, hostID, participantID uuid.UUID) error {
+// KickParticipant mengeluarkan peserta dari room (host only). Status kicked di
+// database sudah cukup untuk menolak join ulang, sehingga kegagalan memutus media
+// participant dilaporkan sebagai warning, bukan error.
+func (s *Service) KickParticipant(roomID, hostID, participantID uuid.UUID) (*KickResult, error) {
 	// Check if user is host
 	var room models.Room
 	if err := s.db.Where("id = ? AND host_id = ?", roomID, hostID).First(&room).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("room not found or access denied")
+			return nil, fmt.Errorf("room not found or access denied")
 		}
 		s.logger.LogError(err, "Failed to find room for kick participant")
-		return fmt.Errorf("internal server error")
+		return nil, fmt.Errorf("internal server error")
 	}
 
 	// Don't allow kicking host
 	if participantID == hostID {
-		return fmt.Errorf("cannot kick host")
+		return nil, fmt.Errorf("cannot kick host")
 	}
 
 	// Update participant status
@@ -686,14 +820,131 @@ func (s *Service) KickParticipant(roomID, hostID, participantID uuid.UUID) error
 
 	if result.Error != nil {
 		s.logger.LogError(result.Error, "Failed to kick participant")
-		return fmt.Errorf("failed to kick participant")
+		return nil, fmt.Errorf("failed to kick participant")
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("participant not found")
+		return nil, fmt.Errorf("participant not found")
+	}
+
+	kick := &KickResult{MediaEnforced: true}
+
+	// Putus media participant di media server
+	if s.media != nil {
+		if err := s.media.KickParticipant(roomID.String(), participantID.String()); err != nil && !errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			s.logger.LogError(err, "Failed to kick participant from media server")
+			kick.MediaEnforced = false
+			kick.Warning = "participant kicked but is still connected to the media server"
+		}
 	}
 
 	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).WithField("participant_id", participantID.String()).Info("Participant kicked successfully")
+	return kick, nil
+}
+
+// MuteParticipant mute atau unmute audio/video peserta di media server (host only)
+func (s *Service) MuteParticipant(roomID, hostID, participantID uuid.UUID, req *MuteParticipantRequest) error {
+	if err := s.checkModeration(roomID, hostID, participantID); err != nil {
+		return err
+	}
+
+	if s.media == nil {
+		return fmt.Errorf("media moderation is not available")
+	}
+
+	if err := s.media.MuteParticipant(roomID.String(), participantID.String(), req.Kind, req.Mute); err != nil {
+		if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			return fmt.Errorf("participant is not connected")
+		}
+		s.logger.LogError(err, "Failed to mute participant on media server")
+		return fmt.Errorf("failed to mute participant")
+	}
+
+	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).WithField("participant_id", participantID.String()).WithField("kind", req.Kind).WithField("mute", req.Mute).Info("Participant moderated successfully")
+	return nil
+}
+
+// UnpublishParticipant menghentikan publish peserta tanpa mengeluarkannya dari room (host only)
+func (s *Service) UnpublishParticipant(roomID, hostID, participantID uuid.UUID) error {
+	if err := s.checkModeration(roomID, hostID, participantID); err != nil {
+		return err
+	}
+
+	if s.media == nil {
+		return fmt.Errorf("media moderation is not available")
+	}
+
+	if err := s.media.UnpublishParticipant(roomID.String(), participantID.String()); err != nil {
+		if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			return fmt.Errorf("participant is not connected")
+		}
+		s.logger.LogError(err, "Failed to unpublish participant on media server")
+		return fmt.Errorf("failed to unpublish participant")
+	}
+
+	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).WithField("participant_id", participantID.String()).Info("Participant unpublished successfully")
+	return nil
+}
+
+// SetParticipantVolume mengubah volume peserta di mix audio room audio bridge (host only)
+func (s *Service) SetParticipantVolume(roomID, hostID, participantID uuid.UUID, req *ParticipantVolumeRequest) error {
+	if err := s.checkModeration(roomID, hostID, participantID); err != nil {
+		return err
+	}
+
+	if s.media == nil {
+		return fmt.Errorf("media moderation is not available")
+	}
+
+	var room models.Room
+	if err := s.db.Preload("Settings").First(&room, roomID).Error; err != nil {
+		s.logger.LogError(err, "Failed to find room for participant volume")
+		return fmt.Errorf("internal server error")
+	}
+
+	if !MediaPolicyFor(room.Type, room.Settings).AudioBridge {
+		return fmt.Errorf("participant volume is only available in audio rooms")
+	}
+
+	if err := s.media.SetParticipantVolume(roomID.String(), participantID.String(), *req.Volume); err != nil {
+		if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			return fmt.Errorf("participant is not connected")
+		}
+		s.logger.LogError(err, "Failed to set participant volume on media server")
+		return fmt.Errorf("failed to set participant volume")
+	}
+
+	s.logger.WithUserID(hostID.String()).WithField("room_id", roomID.String()).WithField("participant_id", participantID.String()).WithField("volume", *req.Volume).Info("Participant volume changed successfully")
+	return nil
+}
+
+// checkModeration memastikan user adalah host room dan participant sedang berada di room
+func (s *Service) checkModeration(roomID, hostID, participantID uuid.UUID) error {
+	var room models.Room
+	if err := s.db.Where("id = ? AND host_id = ?", roomID, hostID).First(&room).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("room not found or access denied")
+		}
+		s.logger.LogError(err, "Failed to find room for moderation")
+		return fmt.Errorf("internal server error")
+	}
+
+	if participantID == hostID {
+		return fmt.Errorf("cannot moderate host")
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ? AND status = ?", roomID, participantID, models.ParticipantStatusJoined).
+		Count(&count).Error; err != nil {
+		s.logger.LogError(err, "Failed to find participant for moderation")
+		return fmt.Errorf("internal server error")
+	}
+
+	if count == 0 {
+		return fmt.Errorf("participant not found")
+	}
+
 	return nil
 }
 
@@ -760,5 +1011,20 @@ func (s *Service) GetRoomStats(roomID uuid.UUID, userID uuid.UUID) (map[string]i
 	stats["room_status"] = room.Status
 	stats["room_type"] = room.Type
 
+	// Penggunaan storage room (recording dan lampiran chat)
+	if s.storage != nil {
+		used, quota, err := s.storage.RoomUsage(roomID)
+		if err != nil {
+			return nil, err
+		}
+		stats["storage_used"] = used
+		stats["storage_quota"] = quota
+	}
+
+	// Status live stream HLS webinar
+	if s.streamer != nil {
+		stats["stream"] = s.streamer.StreamStatus(roomID)
+	}
+
 	return stats, nil
 }
@@ -0,0 +1,96 @@
+package sfu
+
+import (
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+)
+
+// Interval pengiriman REMB ke publisher, seperti Janus yang mengirim REMB berkala
+const rembInterval = time.Second
+
+// ApplyMediaPolicy menyimpan kebijakan media room dan langsung mengirim batas
+// bitrate baru ke semua publisher. Codec dan audiolevel_event tidak diterapkan
+// karena SFU memakai satu MediaEngine untuk semua room. Room yang belum aktif
+// tidak diubah; kebijakannya dibaca saat room dibuat.
+func (s *SFU) ApplyMediaPolicy(roomID string, policy mediaplane.MediaPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return nil
+	}
+	room.Policy = policy
+
+	for userID, participant := range room.Participants {
+		if participant.Publisher != nil && policy.Bitrate > 0 {
+			for _, track := range participant.Tracks {
+				if track.Kind == webrtc.RTPCodecTypeVideo {
+					s.sendREMB(participant.Publisher, track, policy.Bitrate)
+				}
+			}
+		}
+
+		s.sendMediaEvent(roomID, userID, "media-policy", policy)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id":  roomID,
+		"bitrate":  policy.Bitrate,
+		"fir_freq": policy.FirFreq,
+	}).Info("Applied media policy to SFU room")
+
+	return nil
+}
+
+// enforcePolicy mengirim REMB sesuai batas bitrate room dan meminta keyframe
+// setiap fir_freq detik ke track video publisher sampai done ditutup
+func (s *SFU) enforcePolicy(roomID string, pc *webrtc.PeerConnection, track *ForwardedTrack, done <-chan struct{}) {
+	ticker := time.NewTicker(rembInterval)
+	defer ticker.Stop()
+
+	lastKeyframe := time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		room, exists := s.Rooms[roomID]
+		if !exists {
+			s.mu.Unlock()
+			return
+		}
+		policy := room.Policy
+		s.mu.Unlock()
+
+		if policy.Bitrate > 0 {
+			s.sendREMB(pc, track, policy.Bitrate)
+		}
+
+		if policy.FirFreq > 0 && time.Since(lastKeyframe) >= time.Duration(policy.FirFreq)*time.Second {
+			lastKeyframe = time.Now()
+			if err := pc.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: track.SSRC}}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendREMB mengirim batas bitrate ke publisher untuk track video
+func (s *SFU) sendREMB(pc *webrtc.PeerConnection, track *ForwardedTrack, bitrate uint64) {
+	remb := &rtcp.ReceiverEstimatedMaximumBitrate{
+		Bitrate: float32(bitrate),
+		SSRCs:   []uint32{track.SSRC},
+	}
+	if err := pc.WriteRTCP([]rtcp.Packet{remb}); err != nil {
+		s.logger.Debugf("Failed to send REMB: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package sfu
+
+import (
+	"fmt"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// KickParticipant menutup koneksi publisher user dan memberitahu user lain dengan
+// event leaving. Koneksi subscriber user ditutup ketika hub memproses leave-room.
+func (s *SFU) KickParticipant(roomID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, participant, err := s.findParticipant(roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if participant.Publisher != nil {
+		s.dropPublisher(room, participant, "leaving")
+	}
+
+	s.sendMediaEvent(roomID, userID, "kicked", nil)
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+	}).Info("Participant kicked from SFU room")
+
+	return nil
+}
+
+// MuteParticipant berhenti (atau kembali) meneruskan track audio/video user ke subscriber
+func (s *SFU) MuteParticipant(roomID, userID, kind string, mute bool) error {
+	codecType := webrtc.NewRTPCodecType(kind)
+	if codecType == 0 {
+		return fmt.Errorf("unsupported media kind: %s", kind)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, participant, err := s.findParticipant(roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if participant.muted == nil {
+		participant.muted = make(map[webrtc.RTPCodecType]bool)
+	}
+	participant.muted[codecType] = mute
+
+	for _, track := range participant.Tracks {
+		if track.Kind != codecType {
+			continue
+		}
+		track.muted.Store(mute)
+
+		// Minta keyframe agar video subscriber langsung pulih setelah unmute
+		if !mute && codecType == webrtc.RTPCodecTypeVideo && participant.Publisher != nil {
+			if err := participant.Publisher.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: track.SSRC}}); err != nil {
+				s.logger.Errorf("Failed to request keyframe: %v", err)
+			}
+		}
+	}
+
+	s.sendMediaEvent(roomID, userID, "moderated", websocket.ModerationData{
+		Kind:  kind,
+		Muted: mute,
+	})
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+		"kind":    kind,
+		"mute":    mute,
+	}).Info("Participant moderated in SFU room")
+
+	return nil
+}
+
+// UnpublishParticipant menutup koneksi publisher user tanpa mengeluarkannya dari room
+func (s *SFU) UnpublishParticipant(roomID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, participant, err := s.findParticipant(roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if participant.Publisher == nil {
+		return fmt.Errorf("participant is not publishing: %s", userID)
+	}
+
+	s.dropPublisher(room, participant, "unpublished")
+
+	s.sendMediaEvent(roomID, userID, "force-unpublished", nil)
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+	}).Info("Participant unpublished from SFU room")
+
+	return nil
+}
+
+// findParticipant mencari room dan participant. Dipanggil saat s.mu dipegang.
+func (s *SFU) findParticipant(roomID, userID string) (*Room, *Participant, error) {
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: room %s", mediaplane.ErrParticipantNotFound, roomID)
+	}
+
+	participant, exists := room.Participants[userID]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: user %s", mediaplane.ErrParticipantNotFound, userID)
+	}
+
+	return room, participant, nil
+}
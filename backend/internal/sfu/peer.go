@@ -0,0 +1,400 @@
+package sfu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// newPublisherConnection membuat PeerConnection yang menerima media dari publisher
+func (s *SFU) newPublisherConnection(roomID string, participant *Participant) (*webrtc.PeerConnection, error) {
+	pc, err := s.api.NewPeerConnection(s.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create publisher connection: %w", err)
+	}
+
+	userID := participant.UserID
+
+	// Candidate SFU untuk koneksi publisher dikirim atas nama user itu sendiri
+	pc.OnICECandidate(func(candidate *webrtc.ICECandidate) {
+		if candidate != nil {
+			s.sendIceCandidate(roomID, userID, userID, candidate.ToJSON())
+		}
+	})
+
+	pc.OnTrack(func(remote *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
+		s.forwardTrack(roomID, userID, pc, remote)
+	})
+
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		s.logger.WithFields(logrus.Fields{
+			"room_id": roomID,
+			"user_id": userID,
+			"state":   state.String(),
+		}).Debug("Publisher connection state changed")
+
+		if state == webrtc.PeerConnectionStateFailed {
+			s.unpublish(roomID, userID, pc)
+		}
+	})
+
+	return pc, nil
+}
+
+// forwardTrack mendaftarkan track publisher, men-subscribe user lain di room,
+// lalu meneruskan paket RTP sampai track berakhir
+func (s *SFU) forwardTrack(roomID, userID string, pc *webrtc.PeerConnection, remote *webrtc.TrackRemote) {
+	// Stream ID memakai user ID agar browser mengelompokkan audio dan video per publisher
+	local, err := webrtc.NewTrackLocalStaticRTP(remote.Codec().RTPCodecCapability, remote.ID(), userID)
+	if err != nil {
+		s.logger.Errorf("Failed to create forwarded track: %v", err)
+		return
+	}
+
+	track := &ForwardedTrack{
+		Local: local,
+		SSRC:  uint32(remote.SSRC()),
+		Kind:  remote.Kind(),
+	}
+
+	s.mu.Lock()
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		s.mu.Unlock()
+		return
+	}
+	participant, exists := room.Participants[userID]
+	if !exists || participant.Publisher != pc {
+		s.mu.Unlock()
+		return
+	}
+
+	track.muted.Store(participant.muted[track.Kind])
+	firstTrack := len(participant.Tracks) == 0
+	participant.Tracks = append(participant.Tracks, track)
+
+	for viewerID, viewer := range room.Participants {
+		if viewerID == userID {
+			continue
+		}
+
+		if firstTrack {
+			s.sendMediaEvent(roomID, viewerID, "publishers", map[string]interface{}{
+				"publishers": []map[string]interface{}{describePublisher(participant)},
+			})
+		}
+
+		if err := s.subscribe(room, viewer, participant); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"room_id": roomID,
+				"user_id": viewerID,
+				"feed_id": participant.FeedID,
+			}).Errorf("Failed to subscribe to feed: %v", err)
+		}
+	}
+	s.mu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+		"kind":    track.Kind.String(),
+		"codec":   remote.Codec().MimeType,
+	}).Info("Forwarding publisher track")
+
+	// Batas bitrate dan keyframe berkala dari kebijakan media room
+	done := make(chan struct{})
+	defer close(done)
+	if track.Kind == webrtc.RTPCodecTypeVideo {
+		go s.enforcePolicy(roomID, pc, track, done)
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := remote.Read(buf)
+		if err != nil {
+			return
+		}
+
+		if track.muted.Load() {
+			continue
+		}
+
+		if _, err := local.Write(buf[:n]); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			return
+		}
+	}
+}
+
+// subscribe membuat (atau memperbarui) koneksi subscriber viewer untuk publisher
+// dan mengirim offer jika ada track baru. Dipanggil saat s.mu dipegang.
+func (s *SFU) subscribe(room *Room, viewer *Participant, publisher *Participant) error {
+	subscriber, exists := viewer.Subscribers[publisher.UserID]
+	if !exists {
+		pc, err := s.api.NewPeerConnection(s.config)
+		if err != nil {
+			return fmt.Errorf("failed to create subscriber connection: %w", err)
+		}
+
+		subscriber = &Subscriber{
+			UserID:          viewer.UserID,
+			PublisherUserID: publisher.UserID,
+			FeedID:          publisher.FeedID,
+			PeerConnection:  pc,
+			CreatedAt:       time.Now(),
+			senders:         make(map[*ForwardedTrack]*webrtc.RTPSender),
+		}
+
+		// Candidate SFU untuk koneksi subscriber dikirim atas nama publisher yang ditonton
+		roomID, viewerID, publisherUserID := room.ID, viewer.UserID, publisher.UserID
+		pc.OnICECandidate(func(candidate *webrtc.ICECandidate) {
+			if candidate != nil {
+				s.sendIceCandidate(roomID, viewerID, publisherUserID, candidate.ToJSON())
+			}
+		})
+
+		viewer.Subscribers[publisher.UserID] = subscriber
+	}
+
+	changed := false
+	for _, track := range publisher.Tracks {
+		if _, added := subscriber.senders[track]; added {
+			continue
+		}
+
+		sender, err := subscriber.PeerConnection.AddTrack(track.Local)
+		if err != nil {
+			return fmt.Errorf("failed to add track to subscriber: %w", err)
+		}
+		subscriber.senders[track] = sender
+		changed = true
+
+		go s.readRTCP(sender, publisher.Publisher, track)
+	}
+
+	if changed {
+		s.negotiate(room.ID, subscriber)
+	}
+
+	return nil
+}
+
+// negotiate mengirim offer baru ke subscriber. Jika offer sebelumnya belum
+// dijawab, negosiasi ditunda sampai answer datang. Dipanggil saat s.mu dipegang.
+func (s *SFU) negotiate(roomID string, subscriber *Subscriber) {
+	if subscriber.negotiating {
+		subscriber.renegotiate = true
+		return
+	}
+
+	pc := subscriber.PeerConnection
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		s.logger.Errorf("Failed to create subscriber offer: %v", err)
+		return
+	}
+	if err := pc.SetLocalDescription(offer); err != nil {
+		s.logger.Errorf("Failed to set subscriber offer: %v", err)
+		return
+	}
+	subscriber.negotiating = true
+
+	// Browser menjawab offer ini dengan pesan answer ke publisher user
+	s.sendOffer(roomID, subscriber, offer.SDP)
+}
+
+// readRTCP membaca RTCP dari subscriber dan meneruskan permintaan keyframe ke publisher
+func (s *SFU) readRTCP(sender *webrtc.RTPSender, publisher *webrtc.PeerConnection, track *ForwardedTrack) {
+	for {
+		packets, _, err := sender.ReadRTCP()
+		if err != nil {
+			return
+		}
+
+		if track.Kind != webrtc.RTPCodecTypeVideo || publisher == nil {
+			continue
+		}
+
+		for _, packet := range packets {
+			switch packet.(type) {
+			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				if err := publisher.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: track.SSRC}}); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// unpublish menghapus publisher yang koneksinya gagal dan memberitahu user lain
+func (s *SFU) unpublish(roomID, userID string, pc *webrtc.PeerConnection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return
+	}
+	participant, exists := room.Participants[userID]
+	if !exists || participant.Publisher != pc {
+		return
+	}
+
+	s.dropPublisher(room, participant, "unpublished")
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+	}).Warn("Publisher connection failed, unpublished feed")
+}
+
+// dropPublisher menutup koneksi publisher participant dan semua subscriber yang
+// menontonnya. Participant tetap berada di room. Dipanggil saat s.mu dipegang.
+func (s *SFU) dropPublisher(room *Room, participant *Participant, event string) {
+	if err := participant.Publisher.Close(); err != nil {
+		s.logger.Errorf("Failed to close publisher connection: %v", err)
+	}
+	participant.Publisher = nil
+	participant.pendingCandidates = nil
+
+	s.removePublisher(room, participant, event)
+	participant.Tracks = nil
+}
+
+// removePublisher menutup koneksi subscriber yang menonton publisher dan
+// mengirim event (leaving atau unpublished) ke user lain. Dipanggil saat s.mu dipegang.
+func (s *SFU) removePublisher(room *Room, publisher *Participant, event string) {
+	for viewerID, viewer := range room.Participants {
+		if viewerID == publisher.UserID {
+			continue
+		}
+
+		if subscriber, exists := viewer.Subscribers[publisher.UserID]; exists {
+			if err := subscriber.PeerConnection.Close(); err != nil {
+				s.logger.Errorf("Failed to close subscriber connection: %v", err)
+			}
+			delete(viewer.Subscribers, publisher.UserID)
+		}
+
+		if len(publisher.Tracks) > 0 {
+			s.sendMediaEvent(room.ID, viewerID, event, map[string]interface{}{
+				"feedId": publisher.FeedID,
+			})
+		}
+	}
+}
+
+// describePublishers mengembalikan publisher di room yang sudah mengirim media, kecuali user sendiri
+func (s *SFU) describePublishers(room *Room, excludeUserID string) []map[string]interface{} {
+	publishers := make([]map[string]interface{}, 0)
+	for userID, participant := range room.Participants {
+		if userID == excludeUserID || len(participant.Tracks) == 0 {
+			continue
+		}
+		publishers = append(publishers, describePublisher(participant))
+	}
+	return publishers
+}
+
+// describePublisher mengubah participant menjadi data publisher untuk client
+func describePublisher(participant *Participant) map[string]interface{} {
+	return map[string]interface{}{
+		"feedId":  participant.FeedID,
+		"display": participant.Display,
+		"userId":  participant.UserID,
+	}
+}
+
+// sendAnswer mengirim answer SFU ke publisher
+func (s *SFU) sendAnswer(roomID, userID, sdp string) {
+	s.sendToUser(userID, websocket.Message{
+		Type:   websocket.MessageTypeAnswer,
+		RoomID: roomID,
+		UserID: userID,
+		Data: websocket.AnswerData{
+			RoomID:     roomID,
+			FromUserID: userID,
+			ToUserID:   userID,
+			SDP:        sdp,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendOffer mengirim offer subscriber ke user yang menonton atas nama publisher
+func (s *SFU) sendOffer(roomID string, subscriber *Subscriber, sdp string) {
+	s.sendToUser(subscriber.UserID, websocket.Message{
+		Type:   websocket.MessageTypeOffer,
+		RoomID: roomID,
+		UserID: subscriber.PublisherUserID,
+		Data: websocket.OfferData{
+			RoomID:     roomID,
+			FromUserID: subscriber.PublisherUserID,
+			ToUserID:   subscriber.UserID,
+			SDP:        sdp,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendIceCandidate mengirim ICE candidate SFU ke user
+func (s *SFU) sendIceCandidate(roomID, userID, fromUserID string, candidate webrtc.ICECandidateInit) {
+	data := websocket.IceCandidateData{
+		RoomID:     roomID,
+		FromUserID: fromUserID,
+		ToUserID:   userID,
+		Candidate:  candidate.Candidate,
+	}
+	if candidate.SDPMid != nil {
+		data.SDPMID = *candidate.SDPMid
+	}
+	if candidate.SDPMLineIndex != nil {
+		data.SDPMLineIndex = int(*candidate.SDPMLineIndex)
+	}
+
+	s.sendToUser(userID, websocket.Message{
+		Type:      websocket.MessageTypeIceCandidate,
+		RoomID:    roomID,
+		UserID:    fromUserID,
+		Data:      data,
+		Timestamp: time.Now(),
+	})
+}
+
+// sendMediaEvent mengirim event media server ke user
+func (s *SFU) sendMediaEvent(roomID, userID, event string, data interface{}) {
+	s.sendToUser(userID, websocket.Message{
+		Type:   websocket.MessageTypeMediaEvent,
+		RoomID: roomID,
+		UserID: userID,
+		Data: websocket.MediaEventData{
+			RoomID: roomID,
+			UserID: userID,
+			Event:  event,
+			Data:   data,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendToUser mengirim pesan ke user melalui hub.
+// SFU juga dipanggil dari dalam loop hub, jadi pengiriman tidak boleh memblokir;
+// pesan diantre di outbox agar urutan signaling untuk setiap peer tetap terjaga.
+func (s *SFU) sendToUser(userID string, message websocket.Message) {
+	if s.outbox == nil {
+		return
+	}
+
+	if err := s.outbox.Send(userID, message); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"type":    message.Type,
+		}).Errorf("Dropped message to user: %v", err)
+	}
+}
@@ -0,0 +1,584 @@
+package sfu
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	media "github.com/webrtc-meeting/backend/internal/webrtc"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// Jumlah maksimum ICE candidate yang di-buffer per PeerConnection
+const maxPendingCandidates = 64
+
+// Config adalah konfigurasi SFU embedded
+type Config struct {
+	// URL STUN/TURN server untuk ICE (kosong = hanya host candidate)
+	ICEServers []string
+
+	// Rentang port UDP untuk media (0 = port acak)
+	UDPPortMin uint16
+	UDPPortMax uint16
+
+	// IP publik yang diumumkan sebagai host candidate (NAT 1:1, misalnya di dalam container)
+	PublicIPs []string
+}
+
+// SFU adalah selective forwarding unit berbasis pion/webrtc yang berjalan di
+// dalam proses websocket server, sehingga deployment kecil dan CI tidak
+// membutuhkan container Janus. Setiap publisher memiliki satu PeerConnection
+// untuk mengirim media, dan setiap pasangan penonton-publisher memiliki
+// PeerConnection subscriber sendiri, sama seperti handle subscriber Janus.
+type SFU struct {
+	// Hub WebSocket
+	Hub *websocket.Hub
+
+	// Room yang sedang aktif
+	Rooms map[string]*Room
+
+	// API pion dengan codec dan interceptor default
+	api *webrtc.API
+
+	// Konfigurasi ICE untuk setiap PeerConnection
+	config webrtc.Configuration
+
+	// Pembaca kebijakan media room dari RoomSetting (nil = tanpa batas bitrate)
+	MediaPolicyLookup func(roomID string) (mediaplane.MediaPolicy, bool)
+
+	// Antrean pesan ke hub agar urutan signaling setiap peer terjaga
+	outbox *websocket.Outbox
+
+	// Mutex untuk thread safety
+	mu sync.Mutex
+
+	// Logger
+	logger *logrus.Logger
+}
+
+// Room merepresentasikan room di SFU
+type Room struct {
+	ID           string
+	Participants map[string]*Participant
+	CreatedAt    time.Time
+
+	// Kebijakan media room; SFU menerapkan batas bitrate dan fir_freq
+	Policy mediaplane.MediaPolicy
+}
+
+// Participant merepresentasikan user di room beserta koneksi publisher-nya
+type Participant struct {
+	UserID  string
+	Display string
+	FeedID  uint64
+
+	// PeerConnection dari browser ke SFU (nil sampai user mengirim offer)
+	Publisher *webrtc.PeerConnection
+
+	// Track publisher yang diteruskan ke subscriber
+	Tracks []*ForwardedTrack
+
+	// Subscriber milik user ini, key adalah user ID publisher yang ditonton
+	Subscribers map[string]*Subscriber
+
+	// ICE candidate publisher yang datang sebelum remote description diset
+	pendingCandidates []webrtc.ICECandidateInit
+
+	// Jenis media yang di-mute oleh host, berlaku juga untuk track yang ditambahkan kemudian
+	muted map[webrtc.RTPCodecType]bool
+
+	CreatedAt time.Time
+}
+
+// Subscriber merepresentasikan koneksi dari SFU ke user untuk satu publisher
+type Subscriber struct {
+	UserID          string
+	PublisherUserID string
+	FeedID          uint64
+	PeerConnection  *webrtc.PeerConnection
+	IsSubscribed    bool
+	CreatedAt       time.Time
+
+	// Track yang sudah ditambahkan ke koneksi ini
+	senders map[*ForwardedTrack]*webrtc.RTPSender
+
+	// Offer sudah dikirim dan menunggu answer
+	negotiating bool
+
+	// Track berubah saat menunggu answer, negosiasi ulang setelah answer datang
+	renegotiate bool
+
+	// ICE candidate yang datang sebelum answer diterima
+	pendingCandidates []webrtc.ICECandidateInit
+}
+
+// ForwardedTrack adalah track publisher yang diteruskan ke subscriber
+type ForwardedTrack struct {
+	Local *webrtc.TrackLocalStaticRTP
+	SSRC  uint32
+	Kind  webrtc.RTPCodecType
+
+	// Paket RTP dibuang selama track di-mute oleh host
+	muted atomic.Bool
+}
+
+// Pastikan SFU memenuhi MediaBackend
+var _ media.MediaBackend = (*SFU)(nil)
+
+// New membuat instance SFU baru
+func New(hub *websocket.Hub, config Config) (*SFU, error) {
+	mediaEngine := &webrtc.MediaEngine{}
+	if err := mediaEngine.RegisterDefaultCodecs(); err != nil {
+		return nil, fmt.Errorf("failed to register codecs: %w", err)
+	}
+
+	registry := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(mediaEngine, registry); err != nil {
+		return nil, fmt.Errorf("failed to register interceptors: %w", err)
+	}
+
+	settingEngine := webrtc.SettingEngine{}
+	if config.UDPPortMin > 0 && config.UDPPortMax >= config.UDPPortMin {
+		if err := settingEngine.SetEphemeralUDPPortRange(config.UDPPortMin, config.UDPPortMax); err != nil {
+			return nil, fmt.Errorf("invalid udp port range: %w", err)
+		}
+	}
+	if len(config.PublicIPs) > 0 {
+		settingEngine.SetNAT1To1IPs(config.PublicIPs, webrtc.ICECandidateTypeHost)
+	}
+
+	var iceServers []webrtc.ICEServer
+	if len(config.ICEServers) > 0 {
+		iceServers = append(iceServers, webrtc.ICEServer{URLs: config.ICEServers})
+	}
+
+	var outbox *websocket.Outbox
+	if hub != nil {
+		outbox = websocket.NewOutbox(hub)
+	}
+
+	return &SFU{
+		Hub:    hub,
+		Rooms:  make(map[string]*Room),
+		outbox: outbox,
+		api: webrtc.NewAPI(
+			webrtc.WithMediaEngine(mediaEngine),
+			webrtc.WithInterceptorRegistry(registry),
+			webrtc.WithSettingEngine(settingEngine),
+		),
+		config: webrtc.Configuration{
+			ICEServers: iceServers,
+		},
+		logger: logrus.New(),
+	}, nil
+}
+
+// CreateRoom membuat room di SFU
+func (s *SFU) CreateRoom(roomID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.getOrCreateRoom(roomID)
+	return nil
+}
+
+// DestroyRoom menutup semua koneksi di room dan menghapus room
+func (s *SFU) DestroyRoom(roomID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return nil
+	}
+
+	for userID, participant := range room.Participants {
+		s.closeParticipant(participant)
+		s.sendMediaEvent(roomID, userID, "destroyed", nil)
+	}
+	delete(s.Rooms, roomID)
+
+	s.logger.WithField("room_id", roomID).Info("Destroyed SFU room")
+
+	return nil
+}
+
+// HandleJoinRoom menangani user yang bergabung ke room
+func (s *SFU) HandleJoinRoom(roomID, userID, displayName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id":      roomID,
+		"user_id":      userID,
+		"display_name": displayName,
+	}).Info("User joining SFU room")
+
+	room := s.getOrCreateRoom(roomID)
+
+	participant, exists := room.Participants[userID]
+	if !exists {
+		participant = &Participant{
+			UserID:      userID,
+			Display:     displayName,
+			FeedID:      feedID(userID),
+			Subscribers: make(map[string]*Subscriber),
+			CreatedAt:   time.Now(),
+		}
+		room.Participants[userID] = participant
+	}
+
+	// Beritahu user tentang publisher yang sudah ada di room
+	s.sendMediaEvent(roomID, userID, "joined", map[string]interface{}{
+		"janusId":    participant.FeedID,
+		"publishers": s.describePublishers(room, userID),
+	})
+
+	// Subscribe ke publisher yang sudah ada
+	for publisherUserID, publisher := range room.Participants {
+		if publisherUserID == userID || len(publisher.Tracks) == 0 {
+			continue
+		}
+		if err := s.subscribe(room, participant, publisher); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"room_id": roomID,
+				"user_id": userID,
+				"feed_id": publisher.FeedID,
+			}).Errorf("Failed to subscribe to feed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// HandleLeaveRoom menangani user yang keluar dari room
+func (s *SFU) HandleLeaveRoom(roomID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return fmt.Errorf("room not found: %s", roomID)
+	}
+
+	participant, exists := room.Participants[userID]
+	if !exists {
+		return fmt.Errorf("participant not found: %s", userID)
+	}
+
+	s.closeParticipant(participant)
+	delete(room.Participants, userID)
+
+	// Tutup koneksi subscriber user lain yang menonton user ini
+	if participant.Publisher != nil {
+		s.removePublisher(room, participant, "leaving")
+	}
+
+	if len(room.Participants) == 0 {
+		delete(s.Rooms, roomID)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+	}).Info("User left SFU room")
+
+	return nil
+}
+
+// HandleOffer menerima offer publisher dan mengirim answer dari SFU
+func (s *SFU) HandleOffer(roomID, fromUserID, toUserID, sdp string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return fmt.Errorf("room not found: %s", roomID)
+	}
+
+	participant, exists := room.Participants[fromUserID]
+	if !exists {
+		return fmt.Errorf("participant not found: %s", fromUserID)
+	}
+
+	if participant.Publisher == nil {
+		pc, err := s.newPublisherConnection(roomID, participant)
+		if err != nil {
+			return err
+		}
+		participant.Publisher = pc
+	}
+
+	pc := participant.Publisher
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp}); err != nil {
+		return fmt.Errorf("failed to set publisher offer: %w", err)
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create publisher answer: %w", err)
+	}
+	if err := pc.SetLocalDescription(answer); err != nil {
+		return fmt.Errorf("failed to set publisher answer: %w", err)
+	}
+
+	participant.pendingCandidates = s.flushCandidates(pc, participant.pendingCandidates)
+
+	s.sendAnswer(roomID, fromUserID, answer.SDP)
+
+	return nil
+}
+
+// HandleAnswer menerima answer dari user (from) untuk koneksi subscriber ke publisher (to)
+func (s *SFU) HandleAnswer(roomID, fromUserID, toUserID, sdp string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return fmt.Errorf("room not found: %s", roomID)
+	}
+
+	participant, exists := room.Participants[fromUserID]
+	if !exists {
+		return fmt.Errorf("participant not found: %s", fromUserID)
+	}
+
+	subscriber, exists := participant.Subscribers[toUserID]
+	if !exists {
+		return fmt.Errorf("subscriber not found: %s -> %s", fromUserID, toUserID)
+	}
+
+	if err := subscriber.PeerConnection.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: sdp}); err != nil {
+		return fmt.Errorf("failed to set subscriber answer: %w", err)
+	}
+	subscriber.negotiating = false
+	subscriber.IsSubscribed = true
+
+	subscriber.pendingCandidates = s.flushCandidates(subscriber.PeerConnection, subscriber.pendingCandidates)
+
+	// Publisher menambah track saat offer sebelumnya belum dijawab
+	if subscriber.renegotiate {
+		subscriber.renegotiate = false
+		s.negotiate(roomID, subscriber)
+	}
+
+	return nil
+}
+
+// HandleIceCandidate menambahkan ICE candidate dari browser ke PeerConnection tujuan.
+// Candidate tanpa to user (atau untuk diri sendiri) milik koneksi publisher,
+// selain itu milik koneksi subscriber from user yang menonton to user.
+func (s *SFU) HandleIceCandidate(roomID, fromUserID, toUserID, candidate, sdpMid string, sdpMLineIndex int) error {
+	// Candidate kosong adalah penanda akhir gathering dari browser
+	if candidate == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return fmt.Errorf("room not found: %s", roomID)
+	}
+
+	participant, exists := room.Participants[fromUserID]
+	if !exists {
+		return fmt.Errorf("participant not found: %s", fromUserID)
+	}
+
+	mLineIndex := uint16(sdpMLineIndex)
+	init := webrtc.ICECandidateInit{
+		Candidate:     candidate,
+		SDPMid:        &sdpMid,
+		SDPMLineIndex: &mLineIndex,
+	}
+
+	if toUserID == "" || toUserID == fromUserID {
+		participant.pendingCandidates = s.addCandidate(participant.Publisher, participant.pendingCandidates, init)
+		return nil
+	}
+
+	subscriber, exists := participant.Subscribers[toUserID]
+	if !exists {
+		return fmt.Errorf("subscriber not found: %s -> %s", fromUserID, toUserID)
+	}
+	subscriber.pendingCandidates = s.addCandidate(subscriber.PeerConnection, subscriber.pendingCandidates, init)
+
+	return nil
+}
+
+// HandleSelectLayer tidak didukung: SFU embedded meneruskan satu encoding per
+// track apa adanya, tanpa simulcast maupun SVC
+func (s *SFU) HandleSelectLayer(roomID, fromUserID, toUserID string, data websocket.SelectLayerData) error {
+	return fmt.Errorf("layer selection is not supported by the embedded SFU")
+}
+
+// StartRecording tidak didukung: SFU embedded tidak menulis media ke disk
+func (s *SFU) StartRecording(roomID, directory string) error {
+	return fmt.Errorf("recording is not supported by the embedded SFU")
+}
+
+// StopRecording tidak didukung: SFU embedded tidak menulis media ke disk
+func (s *SFU) StopRecording(roomID string) error {
+	return fmt.Errorf("recording is not supported by the embedded SFU")
+}
+
+// StartRTPForward tidak didukung: SFU embedded tidak meneruskan media ke luar proses
+func (s *SFU) StartRTPForward(roomID string, forward websocket.RTPForward) (*websocket.RTPForwardStatus, error) {
+	return nil, fmt.Errorf("rtp forwarding is not supported by the embedded SFU")
+}
+
+// StopRTPForward tidak didukung: SFU embedded tidak meneruskan media ke luar proses
+func (s *SFU) StopRTPForward(roomID, forwardID string) error {
+	return fmt.Errorf("rtp forwarding is not supported by the embedded SFU")
+}
+
+// GetRoomStats mengembalikan statistik room
+func (s *SFU) GetRoomStats(roomID string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"room_id": roomID,
+		"exists":  false,
+		"backend": "sfu",
+	}
+
+	room, exists := s.Rooms[roomID]
+	if !exists {
+		return stats
+	}
+
+	publishers := make([]string, 0)
+	subscribers := make([]map[string]interface{}, 0)
+	for userID, participant := range room.Participants {
+		if participant.Publisher != nil {
+			publishers = append(publishers, userID)
+		}
+		for _, subscriber := range participant.Subscribers {
+			subscribers = append(subscribers, map[string]interface{}{
+				"user_id":           subscriber.UserID,
+				"feed_id":           subscriber.FeedID,
+				"publisher_user_id": subscriber.PublisherUserID,
+				"is_subscribed":     subscriber.IsSubscribed,
+			})
+		}
+	}
+
+	stats["exists"] = true
+	stats["participant_count"] = len(room.Participants)
+	stats["publisher_count"] = len(publishers)
+	stats["subscriber_count"] = len(subscribers)
+	stats["publishers"] = publishers
+	stats["subscribers"] = subscribers
+	stats["created_at"] = room.CreatedAt
+
+	return stats
+}
+
+// Close menutup semua PeerConnection di semua room
+func (s *SFU) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for roomID, room := range s.Rooms {
+		for _, participant := range room.Participants {
+			s.closeParticipant(participant)
+		}
+		delete(s.Rooms, roomID)
+	}
+
+	if s.outbox != nil {
+		s.outbox.Close()
+	}
+
+	return nil
+}
+
+// getOrCreateRoom membuat atau mendapatkan room
+func (s *SFU) getOrCreateRoom(roomID string) *Room {
+	if room, exists := s.Rooms[roomID]; exists {
+		return room
+	}
+
+	room := &Room{
+		ID:           roomID,
+		Participants: make(map[string]*Participant),
+		CreatedAt:    time.Now(),
+	}
+	if s.MediaPolicyLookup != nil {
+		if policy, ok := s.MediaPolicyLookup(roomID); ok {
+			room.Policy = policy
+		}
+	}
+	s.Rooms[roomID] = room
+
+	s.logger.WithField("room_id", roomID).Info("Created SFU room")
+
+	return room
+}
+
+// addCandidate menambahkan candidate ke PeerConnection, atau menyimpannya jika
+// remote description belum diset
+func (s *SFU) addCandidate(pc *webrtc.PeerConnection, pending []webrtc.ICECandidateInit, candidate webrtc.ICECandidateInit) []webrtc.ICECandidateInit {
+	if pc == nil || pc.RemoteDescription() == nil {
+		if len(pending) >= maxPendingCandidates {
+			s.logger.Warn("Too many pending ICE candidates, dropping candidate")
+			return pending
+		}
+		return append(pending, candidate)
+	}
+
+	if err := pc.AddICECandidate(candidate); err != nil {
+		s.logger.Errorf("Failed to add ICE candidate: %v", err)
+	}
+	return pending
+}
+
+// flushCandidates menambahkan candidate yang di-buffer setelah remote description diset
+func (s *SFU) flushCandidates(pc *webrtc.PeerConnection, pending []webrtc.ICECandidateInit) []webrtc.ICECandidateInit {
+	for _, candidate := range pending {
+		if err := pc.AddICECandidate(candidate); err != nil {
+			s.logger.Errorf("Failed to add buffered ICE candidate: %v", err)
+		}
+	}
+	return nil
+}
+
+// closeParticipant menutup koneksi publisher dan semua subscriber milik participant
+func (s *SFU) closeParticipant(participant *Participant) {
+	if participant.Publisher != nil {
+		if err := participant.Publisher.Close(); err != nil {
+			s.logger.Errorf("Failed to close publisher connection: %v", err)
+		}
+	}
+
+	for publisherUserID, subscriber := range participant.Subscribers {
+		if err := subscriber.PeerConnection.Close(); err != nil {
+			s.logger.Errorf("Failed to close subscriber connection: %v", err)
+		}
+		delete(participant.Subscribers, publisherUserID)
+	}
+}
+
+// feedID menghasilkan feed ID numerik dari user ID, sama dengan Janus user ID
+func feedID(userID string) uint64 {
+	hash := uint64(0)
+	for _, c := range userID {
+		hash = hash*31 + uint64(c)
+	}
+
+	if hash == 0 {
+		hash = 1
+	}
+
+	return hash
+}
@@ -0,0 +1,281 @@
+package sfu
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// testPeer adalah browser headless: satu PeerConnection publisher dan satu
+// PeerConnection subscriber per publisher yang ditonton
+type testPeer struct {
+	t      *testing.T
+	sfu    *SFU
+	roomID string
+	userID string
+
+	mu          sync.Mutex
+	publisher   *webrtc.PeerConnection
+	subscribers map[string]*webrtc.PeerConnection
+
+	// Candidate SFU yang tiba sebelum remote description koneksinya diset,
+	// key adalah from user ID seperti pada pesan ice-candidate
+	pending map[string][]webrtc.ICECandidateInit
+
+	// Track yang diterima dari SFU, dikirim sekali per track
+	tracks chan *webrtc.TrackRemote
+}
+
+func newTestPeer(t *testing.T, s *SFU, roomID, userID string) *testPeer {
+	return &testPeer{
+		t:           t,
+		sfu:         s,
+		roomID:      roomID,
+		userID:      userID,
+		subscribers: make(map[string]*webrtc.PeerConnection),
+		pending:     make(map[string][]webrtc.ICECandidateInit),
+		tracks:      make(chan *webrtc.TrackRemote, 4),
+	}
+}
+
+// newPeerConnection membuat PeerConnection yang mengirim candidate lokal ke SFU
+func (p *testPeer) newPeerConnection(toUserID string) *webrtc.PeerConnection {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		p.t.Fatalf("failed to create peer connection: %v", err)
+	}
+	p.t.Cleanup(func() { pc.Close() })
+
+	pc.OnICECandidate(func(candidate *webrtc.ICECandidate) {
+		if candidate == nil {
+			return
+		}
+		init := candidate.ToJSON()
+		sdpMid := ""
+		if init.SDPMid != nil {
+			sdpMid = *init.SDPMid
+		}
+		mLineIndex := 0
+		if init.SDPMLineIndex != nil {
+			mLineIndex = int(*init.SDPMLineIndex)
+		}
+		if err := p.sfu.HandleIceCandidate(p.roomID, p.userID, toUserID, init.Candidate, sdpMid, mLineIndex); err != nil {
+			p.t.Errorf("%s: failed to send candidate: %v", p.userID, err)
+		}
+	})
+
+	return pc
+}
+
+// publish mengirim offer dengan satu track video VP8 ke SFU
+func (p *testPeer) publish() *webrtc.TrackLocalStaticRTP {
+	track, err := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, "video", p.userID)
+	if err != nil {
+		p.t.Fatalf("failed to create track: %v", err)
+	}
+
+	pc := p.newPeerConnection("")
+	if _, err := pc.AddTrack(track); err != nil {
+		p.t.Fatalf("failed to add track: %v", err)
+	}
+
+	p.mu.Lock()
+	p.publisher = pc
+	p.mu.Unlock()
+
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		p.t.Fatalf("failed to create offer: %v", err)
+	}
+	if err := pc.SetLocalDescription(offer); err != nil {
+		p.t.Fatalf("failed to set offer: %v", err)
+	}
+	if err := p.sfu.HandleOffer(p.roomID, p.userID, "", offer.SDP); err != nil {
+		p.t.Fatalf("%s: offer failed: %v", p.userID, err)
+	}
+
+	return track
+}
+
+// handle memproses pesan signaling dari SFU seperti yang dilakukan frontend
+func (p *testPeer) handle(message websocket.Message) {
+	switch data := message.Data.(type) {
+	case websocket.AnswerData:
+		p.mu.Lock()
+		pc := p.publisher
+		p.mu.Unlock()
+		if err := pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: data.SDP}); err != nil {
+			p.t.Errorf("%s: failed to set answer: %v", p.userID, err)
+			return
+		}
+		p.flushCandidates(p.userID, pc)
+
+	case websocket.OfferData:
+		p.mu.Lock()
+		pc, exists := p.subscribers[data.FromUserID]
+		if !exists {
+			pc = p.newPeerConnection(data.FromUserID)
+			pc.OnTrack(func(remote *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
+				p.tracks <- remote
+			})
+			p.subscribers[data.FromUserID] = pc
+		}
+		p.mu.Unlock()
+
+		if err := pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: data.SDP}); err != nil {
+			p.t.Errorf("%s: failed to set offer: %v", p.userID, err)
+			return
+		}
+		p.flushCandidates(data.FromUserID, pc)
+		answer, err := pc.CreateAnswer(nil)
+		if err != nil {
+			p.t.Errorf("%s: failed to create answer: %v", p.userID, err)
+			return
+		}
+		if err := pc.SetLocalDescription(answer); err != nil {
+			p.t.Errorf("%s: failed to set answer: %v", p.userID, err)
+			return
+		}
+		if err := p.sfu.HandleAnswer(p.roomID, p.userID, data.FromUserID, answer.SDP); err != nil {
+			p.t.Errorf("%s: answer failed: %v", p.userID, err)
+		}
+
+	case websocket.IceCandidateData:
+		mLineIndex := uint16(data.SDPMLineIndex)
+		candidate := webrtc.ICECandidateInit{
+			Candidate:     data.Candidate,
+			SDPMid:        &data.SDPMID,
+			SDPMLineIndex: &mLineIndex,
+		}
+
+		p.mu.Lock()
+		pc := p.publisher
+		if data.FromUserID != p.userID {
+			pc = p.subscribers[data.FromUserID]
+		}
+		if pc == nil || pc.RemoteDescription() == nil {
+			p.pending[data.FromUserID] = append(p.pending[data.FromUserID], candidate)
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+
+		if err := pc.AddICECandidate(candidate); err != nil {
+			p.t.Errorf("%s: failed to add candidate: %v", p.userID, err)
+		}
+	}
+}
+
+// flushCandidates menambahkan candidate yang di-buffer setelah remote description diset
+func (p *testPeer) flushCandidates(fromUserID string, pc *webrtc.PeerConnection) {
+	p.mu.Lock()
+	pending := p.pending[fromUserID]
+	delete(p.pending, fromUserID)
+	p.mu.Unlock()
+
+	for _, candidate := range pending {
+		if err := pc.AddICECandidate(candidate); err != nil {
+			p.t.Errorf("%s: failed to add buffered candidate: %v", p.userID, err)
+		}
+	}
+}
+
+// relaySignaling meneruskan pesan dari hub ke peer tujuan sampai done ditutup
+func relaySignaling(hub *websocket.Hub, peers map[string]*testPeer, done <-chan struct{}) {
+	for {
+		select {
+		case userMessage := <-hub.UserMessage:
+			if peer, exists := peers[userMessage.UserID]; exists {
+				peer.handle(userMessage.Message)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func TestTwoPeersExchangeMediaThroughSFU(t *testing.T) {
+	hub := websocket.NewHub()
+	s, err := New(hub, Config{})
+	if err != nil {
+		t.Fatalf("failed to create sfu: %v", err)
+	}
+	// Didaftarkan sebelum peer agar PeerConnection test ditutup lebih dulu
+	t.Cleanup(func() { s.Close() })
+
+	alice := newTestPeer(t, s, "1001", "alice")
+	bob := newTestPeer(t, s, "1001", "bob")
+
+	done := make(chan struct{})
+	defer close(done)
+	go relaySignaling(hub, map[string]*testPeer{"alice": alice, "bob": bob}, done)
+
+	if err := s.HandleJoinRoom("1001", "alice", "Alice"); err != nil {
+		t.Fatalf("alice join failed: %v", err)
+	}
+	if err := s.HandleJoinRoom("1001", "bob", "Bob"); err != nil {
+		t.Fatalf("bob join failed: %v", err)
+	}
+
+	track := alice.publish()
+
+	// Kirim paket VP8 sampai bob menerima track alice
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+
+		packet := &rtp.Packet{
+			Header: rtp.Header{Version: 2, Marker: true},
+			// Payload descriptor VP8 diikuti header keyframe
+			Payload: []byte{0x10, 0x00, 0x00, 0x9d, 0x01, 0x2a, 0x01, 0x00, 0x01, 0x00},
+		}
+		for {
+			select {
+			case <-ticker.C:
+				packet.SequenceNumber++
+				packet.Timestamp += 3000
+				if err := track.WriteRTP(packet); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var remote *webrtc.TrackRemote
+	select {
+	case remote = <-bob.tracks:
+	case <-time.After(10 * time.Second):
+		t.Fatal("bob did not receive alice's track")
+	}
+
+	if remote.StreamID() != "alice" || remote.Kind() != webrtc.RTPCodecTypeVideo {
+		t.Fatalf("unexpected track: stream %s kind %s", remote.StreamID(), remote.Kind())
+	}
+
+	received := make(chan error, 1)
+	go func() {
+		_, _, err := remote.ReadRTP()
+		received <- err
+	}()
+	select {
+	case err := <-received:
+		if err != nil {
+			t.Fatalf("failed to read forwarded rtp: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no rtp forwarded from alice to bob")
+	}
+
+	stats := s.GetRoomStats("1001")
+	subscribers, _ := stats["subscribers"].([]map[string]interface{})
+	if stats["publisher_count"] != 1 || len(subscribers) != 1 || subscribers[0]["is_subscribed"] != true {
+		t.Fatalf("unexpected room stats: %+v", stats)
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// inlineTypes adalah content type yang aman ditampilkan langsung di browser; tipe lain
+// (misalnya HTML atau SVG hasil upload) selalu diunduh sebagai attachment
+var inlineTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "video/", "audio/", "application/ogg", "application/pdf"}
+
+// Handler struct untuk storage handler yang melayani signed URL LocalBlob
+type Handler struct {
+	local  *LocalBlob
+	logger *logger.Logger
+}
+
+// NewHandler membuat storage handler baru. Untuk backend selain local, signed URL
+// dilayani langsung oleh storage dan handler tidak mendaftarkan route.
+func NewHandler(blob Blob, log *logger.Logger) *Handler {
+	local, _ := blob.(*LocalBlob)
+	return &Handler{
+		local:  local,
+		logger: log,
+	}
+}
+
+// RegisterRoutes registrasi routes untuk file storage (di bawah group /public)
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	if h.local == nil {
+		return
+	}
+
+	router.GET("/files/*key", h.ServeFile)
+}
+
+// ServeFile handler untuk signed file URL endpoint
+func (h *Handler) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	if err := h.local.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		h.ErrorResponse(c, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+
+	reader, object, err := h.local.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidKey) {
+			h.ErrorResponse(c, http.StatusNotFound, "File not found", nil)
+			return
+		}
+		h.logger.WithError(err).WithField("key", key).Error("Failed to open stored file")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Failed to open file", nil)
+		return
+	}
+	defer reader.Close()
+
+	disposition := "attachment"
+	for _, prefix := range inlineTypes {
+		if strings.HasPrefix(object.ContentType, prefix) {
+			disposition = "inline"
+			break
+		}
+	}
+
+	c.Header("Content-Type", object.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": path.Base(key)}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, max-age=300")
+
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, path.Base(key), object.LastModified, seeker)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, object.Size, object.ContentType, reader, nil)
+}
+
+// ErrorResponse helper function for error response
+func (h *Handler) ErrorResponse(c *gin.Context, statusCode int, message string, details interface{}) {
+	response := gin.H{
+		"error": message,
+	}
+
+	if details != nil {
+		response["details"] = details
+	}
+
+	c.JSON(statusCode, response)
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalFilesPath adalah path API publik yang melayani signed URL LocalBlob
+const LocalFilesPath = "/api/v1/public/files/"
+
+// LocalBlob menyimpan objek di filesystem lokal. Signed URL mengarah ke endpoint
+// publik API server yang memverifikasi tanda tangan HMAC dan waktu kedaluwarsa.
+type LocalBlob struct {
+	root      string
+	publicURL string
+	secret    []byte
+}
+
+// NewLocalBlob membuat LocalBlob dengan root direktori. publicURL adalah base URL
+// API server untuk signed URL (kosong = URL relatif).
+func NewLocalBlob(root, publicURL, secret string) (*LocalBlob, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("storage signing secret is required")
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalBlob{
+		root:      root,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		secret:    []byte(secret),
+	}, nil
+}
+
+// Put menyimpan objek ke file sementara lalu me-rename-nya agar pembaca tidak
+// pernah melihat objek yang belum lengkap
+func (b *LocalBlob) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*Object, error) {
+	name, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(temp.Name())
+
+	written, err := io.Copy(temp, reader)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("short write: %d of %d bytes", written, size)
+	}
+
+	if err := os.Rename(temp.Name(), name); err != nil {
+		return nil, err
+	}
+
+	return b.Stat(ctx, key)
+}
+
+// Get membuka file objek
+func (b *LocalBlob) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	object, err := b.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, _ := b.path(key)
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, object, nil
+}
+
+// Stat mengembalikan metadata file; content type ditebak dari ekstensi
+func (b *LocalBlob) Stat(ctx context.Context, key string) (*Object, error) {
+	name, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  contentType,
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// Delete menghapus file objek
+func (b *LocalBlob) Delete(ctx context.Context, key string) error {
+	name, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL membuat URL ke LocalFilesPath dengan parameter expires dan signature
+func (b *LocalBlob) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", b.sign(key, expires))
+
+	return b.publicURL + LocalFilesPath + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// Verify memeriksa tanda tangan dan waktu kedaluwarsa signed URL
+func (b *LocalBlob) Verify(key, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiry")
+	}
+
+	if time.Now().Unix() > unix {
+		return fmt.Errorf("link has expired")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(b.sign(key, expires))) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+// sign menghitung HMAC-SHA256 dari key dan waktu kedaluwarsa
+func (b *LocalBlob) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, b.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path mengubah key menjadi path file di bawah root
+func (b *LocalBlob) path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(b.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// newTestLogger membuat logger yang tidak menulis output
+func newTestLogger() *logger.Logger {
+	log, _ := test.NewNullLogger()
+	return &logger.Logger{Logger: log}
+}
+
+// newTestLocalBlob membuat LocalBlob di direktori sementara
+func newTestLocalBlob(t *testing.T) *LocalBlob {
+	t.Helper()
+
+	blob, err := NewLocalBlob(filepath.Join(t.TempDir(), "files"), "", "test-secret")
+	if err != nil {
+		t.Fatalf("failed to create local blob: %v", err)
+	}
+	return blob
+}
+
+// signedQuery memisahkan path dan query signed URL LocalBlob
+func signedQuery(t *testing.T, signed string) (string, url.Values) {
+	t.Helper()
+
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("invalid signed url %s: %v", signed, err)
+	}
+	return strings.TrimPrefix(parsed.Path, LocalFilesPath), parsed.Query()
+}
+
+func TestCleanKeyRejectsTraversal(t *testing.T) {
+	valid := map[string]string{
+		"recordings/room/1.webm": "recordings/room/1.webm",
+		"/avatars/user.png":      "avatars/user.png",
+		"a/b..c/d":               "a/b..c/d",
+	}
+	for key, expected := range valid {
+		cleaned, err := CleanKey(key)
+		if err != nil || cleaned != expected {
+			t.Errorf("CleanKey(%q) = %q, %v; expected %q", key, cleaned, err, expected)
+		}
+	}
+
+	invalid := []string{
+		"",
+		".",
+		"/",
+		"..",
+		"../secret",
+		"../../etc/passwd",
+		"recordings/../../etc/passwd",
+		"recordings/../avatars/user.png",
+		"recordings/./1.webm",
+		"recordings//1.webm",
+		"recordings/",
+		`..\secret`,
+		`recordings\..\..\secret`,
+	}
+	for _, key := range invalid {
+		if cleaned, err := CleanKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("CleanKey(%q) = %q, %v; expected ErrInvalidKey", key, cleaned, err)
+		}
+	}
+}
+
+func TestLocalBlobStaysInsideRoot(t *testing.T) {
+	blob := newTestLocalBlob(t)
+	ctx := context.Background()
+
+	outside := filepath.Join(filepath.Dir(blob.root), "outside.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatalf("failed to write file outside root: %v", err)
+	}
+
+	if _, err := blob.Put(ctx, "../outside.txt", strings.NewReader("overwrite"), -1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("put outside root: expected ErrInvalidKey, got %v", err)
+	}
+	if _, _, err := blob.Get(ctx, "../outside.txt"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("get outside root: expected ErrInvalidKey, got %v", err)
+	}
+	if err := blob.Delete(ctx, "../outside.txt"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("delete outside root: expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := blob.SignedURL(ctx, "../outside.txt", time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("sign outside root: expected ErrInvalidKey, got %v", err)
+	}
+
+	if data, err := os.ReadFile(outside); err != nil || string(data) != "secret" {
+		t.Fatalf("file outside root was modified: %q, %v", data, err)
+	}
+}
+
+func TestLocalBlobPutRejectsShortWrite(t *testing.T) {
+	blob := newTestLocalBlob(t)
+
+	if _, err := blob.Put(context.Background(), "attachments/a.txt", strings.NewReader("abc"), 10, "text/plain"); err == nil {
+		t.Fatal("short upload was stored")
+	}
+	if _, err := blob.Stat(context.Background(), "attachments/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("partial object is visible: %v", err)
+	}
+}
+
+func TestLocalBlobVerifiesSignedURL(t *testing.T) {
+	blob := newTestLocalBlob(t)
+	ctx := context.Background()
+
+	signed, err := blob.SignedURL(ctx, "recordings/room/1.webm", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign url: %v", err)
+	}
+	key, query := signedQuery(t, signed)
+	expires, signature := query.Get("expires"), query.Get("signature")
+
+	if err := blob.Verify(key, expires, signature); err != nil {
+		t.Fatalf("valid signed url rejected: %v", err)
+	}
+
+	// Tanda tangan berlaku untuk satu key dan satu waktu kedaluwarsa
+	later := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	tampered := map[string][3]string{
+		"other key":         {"recordings/room/2.webm", expires, signature},
+		"extended expiry":   {key, later, signature},
+		"flipped signature": {key, expires, strings.Repeat("0", len(signature))},
+		"empty signature":   {key, expires, ""},
+		"invalid expiry":    {key, "tomorrow", signature},
+	}
+	for name, params := range tampered {
+		if err := blob.Verify(params[0], params[1], params[2]); err == nil {
+			t.Errorf("%s: tampered signed url accepted", name)
+		}
+	}
+
+	// Secret lain menghasilkan tanda tangan yang berbeda
+	other, err := NewLocalBlob(t.TempDir(), "", "other-secret")
+	if err != nil {
+		t.Fatalf("failed to create local blob: %v", err)
+	}
+	if err := other.Verify(key, expires, signature); err == nil {
+		t.Fatal("signed url accepted with a different secret")
+	}
+}
+
+func TestLocalBlobRejectsExpiredSignedURL(t *testing.T) {
+	blob := newTestLocalBlob(t)
+
+	signed, err := blob.SignedURL(context.Background(), "recordings/room/1.webm", -time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign url: %v", err)
+	}
+	key, query := signedQuery(t, signed)
+
+	err = blob.Verify(key, query.Get("expires"), query.Get("signature"))
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected expired link error, got %v", err)
+	}
+}
+
+func TestServeFileChecksSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	blob := newTestLocalBlob(t)
+	ctx := context.Background()
+	if _, err := blob.Put(ctx, "attachments/room/note.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("failed to store file: %v", err)
+	}
+
+	router := gin.New()
+	NewHandler(blob, newTestLogger()).RegisterRoutes(router.Group("/api/v1/public"))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	signed, err := blob.SignedURL(ctx, "attachments/room/note.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign url: %v", err)
+	}
+	if response := serve(signed); response.Code != http.StatusOK || response.Body.String() != "hello" {
+		t.Fatalf("signed url not served: %d %s", response.Code, response.Body.String())
+	}
+
+	if response := serve(strings.Replace(signed, "signature=", "signature=00", 1)); response.Code != http.StatusForbidden {
+		t.Fatalf("tampered url served with status %d", response.Code)
+	}
+
+	expired, err := blob.SignedURL(ctx, "attachments/room/note.txt", -time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign url: %v", err)
+	}
+	if response := serve(expired); response.Code != http.StatusForbidden {
+		t.Fatalf("expired url served with status %d", response.Code)
+	}
+
+	// Tanda tangan sah untuk key traversal tetap tidak membuka file di luar root
+	expires := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+	traversal := "attachments/../../outside.txt"
+	target := LocalFilesPath + traversal + "?expires=" + expires + "&signature=" + blob.sign(traversal, expires)
+	if response := serve(target); response.Code == http.StatusOK {
+		t.Fatalf("traversal key served: %s", response.Body.String())
+	}
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options konfigurasi S3Blob
+type S3Options struct {
+	// Endpoint S3 yang dipakai API server, misalnya "minio:9000" atau "https://s3.amazonaws.com"
+	Endpoint string
+
+	// Endpoint yang dapat dijangkau browser untuk signed URL (kosong = Endpoint)
+	PublicEndpoint string
+
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string
+	UseSSL    bool
+}
+
+// S3Blob menyimpan objek di bucket S3-compatible (AWS S3, MinIO). Signed URL adalah
+// presigned GET URL S3.
+type S3Blob struct {
+	client *minio.Client
+
+	// presigner memakai endpoint publik; region diisi agar presign tidak memanggil server
+	presigner *minio.Client
+	bucket    string
+}
+
+// NewS3Blob membuat S3Blob dan membuat bucket jika belum ada
+func NewS3Blob(options S3Options) (*S3Blob, error) {
+	if options.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if options.Region == "" {
+		options.Region = "us-east-1"
+	}
+
+	client, err := newMinioClient(options.Endpoint, options)
+	if err != nil {
+		return nil, err
+	}
+
+	presigner := client
+	if options.PublicEndpoint != "" {
+		if presigner, err = newMinioClient(options.PublicEndpoint, options); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, options.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check s3 bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, options.Bucket, minio.MakeBucketOptions{Region: options.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create s3 bucket: %w", err)
+		}
+	}
+
+	return &S3Blob{
+		client:    client,
+		presigner: presigner,
+		bucket:    options.Bucket,
+	}, nil
+}
+
+// newMinioClient membuat client untuk endpoint "host:port" atau URL lengkap
+func newMinioClient(endpoint string, options S3Options) (*minio.Client, error) {
+	secure := options.UseSSL
+	if strings.Contains(endpoint, "://") {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 endpoint %s: %w", endpoint, err)
+		}
+		endpoint = parsed.Host
+		secure = parsed.Scheme == "https"
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(options.AccessKey, options.SecretKey, ""),
+		Secure: secure,
+		Region: options.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	return client, nil
+}
+
+// Put mengunggah objek
+func (b *S3Blob) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*Object, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := b.client.PutObject(ctx, b.bucket, key, reader, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  contentType,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+// Get membuka objek untuk dibaca
+func (b *S3Blob) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	object, err := b.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := b.client.GetObject(ctx, b.bucket, object.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(err)
+	}
+
+	return reader, object, nil
+}
+
+// Stat mengembalikan metadata objek
+func (b *S3Blob) Stat(ctx context.Context, key string) (*Object, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := b.client.StatObject(ctx, b.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+// Delete menghapus objek
+func (b *S3Blob) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	return s3Error(b.client.RemoveObject(ctx, b.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+// SignedURL membuat presigned GET URL
+func (b *S3Blob) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := b.presigner.PresignedGetObject(ctx, b.bucket, key, expiry, url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	return signed.String(), nil
+}
+
+// s3Error mengubah error NoSuchKey menjadi ErrNotFound
+func s3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
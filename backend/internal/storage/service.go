@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/models"
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// extensions adalah ekstensi key untuk content type yang umum. Key selalu memakai
+// ekstensi dari content type hasil sniffing, bukan dari nama file upload.
+var extensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/bmp":       ".bmp",
+	"video/webm":      ".webm",
+	"video/mp4":       ".mp4",
+	"audio/ogg":       ".ogg",
+	"application/ogg": ".ogg",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+func init() {
+	// Image Alpine tidak memiliki /etc/mime.types; LocalBlob menebak content type dari ekstensi
+	for contentType, extension := range extensions {
+		if mime.TypeByExtension(extension) == "" {
+			mime.AddExtensionType(extension, contentType)
+		}
+	}
+}
+
+// Service mengelola file di Blob beserta catatan StoredFile-nya: sniffing content
+// type, quota storage per room dan signed URL
+type Service struct {
+	db        *gorm.DB
+	logger    *logger.Logger
+	blob      Blob
+	urlExpiry time.Duration
+	roomQuota int64
+
+	// Batas ukuran per jenis file (0 = tanpa batas)
+	maxSize map[models.FileKind]int64
+}
+
+// StoreRequest adalah parameter penyimpanan file
+type StoreRequest struct {
+	Kind    models.FileKind
+	OwnerID uuid.UUID
+
+	// Room yang quota-nya dipakai (nil = tidak dihitung ke quota room)
+	RoomID *uuid.UUID
+
+	// Key tanpa ekstensi; ekstensi ditambahkan dari content type hasil sniffing
+	Key string
+
+	// Nama file asli dan ukurannya (-1 jika tidak diketahui)
+	Name string
+	Size int64
+
+	// Prefix content type yang diizinkan, misalnya "image/" (kosong = semua)
+	AllowedTypes []string
+}
+
+// NewService membuat storage service baru
+func NewService(db *gorm.DB, log *logger.Logger, blob Blob, cfg config.StorageConfig) *Service {
+	urlExpiry := cfg.URLExpiry
+	if urlExpiry <= 0 {
+		urlExpiry = 15 * time.Minute
+	}
+
+	return &Service{
+		db:        db,
+		logger:    log,
+		blob:      blob,
+		urlExpiry: urlExpiry,
+		roomQuota: cfg.RoomQuota,
+		maxSize: map[models.FileKind]int64{
+			models.FileKindAttachment: cfg.MaxAttachmentSize,
+			models.FileKindAvatar:     cfg.MaxAvatarSize,
+		},
+	}
+}
+
+// Blob mengembalikan Blob yang dipakai service
+func (s *Service) Blob() Blob {
+	return s.blob
+}
+
+// Store menyimpan file ke Blob dan mencatatnya. File dengan key yang sama diganti.
+func (s *Service) Store(ctx context.Context, req StoreRequest, reader io.Reader) (*models.StoredFile, error) {
+	if limit := s.maxSize[req.Kind]; limit > 0 && (req.Size < 0 || req.Size > limit) {
+		return nil, fmt.Errorf("file is too large (max %d MB)", limit>>20)
+	}
+
+	contentType, reader, err := DetectContentType(reader, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file")
+	}
+
+	if !allowedType(contentType, req.AllowedTypes) {
+		return nil, fmt.Errorf("file type %s is not allowed", contentType)
+	}
+
+	if req.RoomID != nil {
+		if err := s.checkQuota(*req.RoomID, req.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	key := req.Key + extension(contentType)
+	object, err := s.blob.Put(ctx, key, reader, req.Size, contentType)
+	if err != nil {
+		s.logger.LogError(err, "Failed to store file")
+		return nil, fmt.Errorf("failed to store file")
+	}
+
+	file := &models.StoredFile{
+		Key:         object.Key,
+		Kind:        req.Kind,
+		RoomID:      req.RoomID,
+		OwnerID:     req.OwnerID,
+		Name:        req.Name,
+		ContentType: contentType,
+		Size:        object.Size,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("key = ?", object.Key).Delete(&models.StoredFile{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(file).Error
+	})
+	if err != nil {
+		s.logger.LogError(err, "Failed to record stored file")
+		if err := s.blob.Delete(ctx, object.Key); err != nil {
+			s.logger.LogError(err, "Failed to remove orphaned file")
+		}
+		return nil, fmt.Errorf("failed to store file")
+	}
+
+	s.logger.WithField("key", file.Key).
+		WithField("kind", string(file.Kind)).
+		WithField("size", file.Size).
+		Info("File stored successfully")
+	return file, nil
+}
+
+// GetFile mendapatkan catatan file
+func (s *Service) GetFile(fileID uuid.UUID) (*models.StoredFile, error) {
+	var file models.StoredFile
+	if err := s.db.First(&file, fileID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("file not found")
+		}
+		s.logger.LogError(err, "Failed to get stored file")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	return &file, nil
+}
+
+// GetFileByKey mendapatkan catatan file berdasarkan key
+func (s *Service) GetFileByKey(key string) (*models.StoredFile, error) {
+	var file models.StoredFile
+	if err := s.db.Where("key = ?", key).First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("file not found")
+		}
+		s.logger.LogError(err, "Failed to get stored file")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	return &file, nil
+}
+
+// SignedURL membuat URL download berbatas waktu untuk file
+func (s *Service) SignedURL(ctx context.Context, file *models.StoredFile) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.urlExpiry)
+
+	signed, err := s.blob.SignedURL(ctx, file.Key, s.urlExpiry)
+	if err != nil {
+		s.logger.LogError(err, "Failed to sign file URL")
+		return "", time.Time{}, fmt.Errorf("failed to create download url")
+	}
+
+	return signed, expiresAt, nil
+}
+
+// Delete menghapus file dari Blob beserta catatannya
+func (s *Service) Delete(ctx context.Context, file *models.StoredFile) error {
+	if err := s.blob.Delete(ctx, file.Key); err != nil {
+		s.logger.LogError(err, "Failed to delete file from storage")
+		return fmt.Errorf("failed to delete file")
+	}
+
+	if err := s.db.Delete(file).Error; err != nil {
+		s.logger.LogError(err, "Failed to delete stored file record")
+		return fmt.Errorf("failed to delete file")
+	}
+
+	return nil
+}
+
+// RoomUsage mengembalikan total ukuran file room dan quota-nya (0 = tanpa batas)
+func (s *Service) RoomUsage(roomID uuid.UUID) (int64, int64, error) {
+	var used int64
+	if err := s.db.Model(&models.StoredFile{}).
+		Where("room_id = ?", roomID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&used).Error; err != nil {
+		s.logger.LogError(err, "Failed to calculate room storage usage")
+		return 0, 0, fmt.Errorf("internal server error")
+	}
+
+	return used, s.roomQuota, nil
+}
+
+// checkQuota menolak file yang membuat penggunaan storage room melebihi quota.
+// Quota dihitung sebelum upload, sehingga upload bersamaan dapat sedikit melewatinya.
+func (s *Service) checkQuota(roomID uuid.UUID, size int64) error {
+	if s.roomQuota <= 0 {
+		return nil
+	}
+
+	used, _, err := s.RoomUsage(roomID)
+	if err != nil {
+		return err
+	}
+
+	if size < 0 {
+		size = 0
+	}
+
+	if used+size > s.roomQuota {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// allowedType memeriksa content type terhadap daftar prefix yang diizinkan
+func allowedType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, prefix := range allowed {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// extension mengembalikan ekstensi key untuk content type
+func extension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	if ext, exists := extensions[mediaType]; exists {
+		return ext
+	}
+
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+
+	return ".bin"
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// fakeDatabase adalah database/sql driver minimal untuk Service: query pemakaian
+// storage room mengembalikan usage, statement lain dicatat dan dianggap berhasil
+type fakeDatabase struct {
+	mu         sync.Mutex
+	usage      int64
+	statements []string
+}
+
+var (
+	fakeDatabasesMu sync.Mutex
+	fakeDatabases   = map[string]*fakeDatabase{}
+)
+
+func init() {
+	sql.Register("storagetest", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDatabasesMu.Lock()
+	defer fakeDatabasesMu.Unlock()
+	return &fakeConn{db: fakeDatabases[name]}, nil
+}
+
+type fakeConn struct {
+	db *fakeDatabase
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDatabase
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(s.query)
+
+	if strings.Contains(s.query, "SUM(size)") {
+		s.db.mu.Lock()
+		defer s.db.mu.Unlock()
+		return &fakeRows{columns: []string{"coalesce"}, values: [][]driver.Value{{s.db.usage}}}, nil
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func (db *fakeDatabase) record(query string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, query)
+}
+
+// inserted mengembalikan true jika catatan StoredFile dibuat
+func (db *fakeDatabase) inserted() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, statement := range db.statements {
+		if strings.HasPrefix(statement, `INSERT INTO "stored_files"`) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestService membuat Service dengan LocalBlob dan fake database
+func newTestService(t *testing.T, cfg config.StorageConfig) (*Service, *fakeDatabase) {
+	t.Helper()
+
+	fake := &fakeDatabase{}
+	name := t.Name()
+	fakeDatabasesMu.Lock()
+	fakeDatabases[name] = fake
+	fakeDatabasesMu.Unlock()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: "storagetest", DSN: name}), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open fake database: %v", err)
+	}
+
+	return NewService(db, newTestLogger(), newTestLocalBlob(t), cfg), fake
+}
+
+// pngData adalah awal file PNG agar content type terdeteksi sebagai image/png
+var pngData = "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 92)
+
+func TestServiceStoreEnforcesRoomQuota(t *testing.T) {
+	service, fake := newTestService(t, config.StorageConfig{RoomQuota: 1000})
+	roomID := uuid.New()
+	fake.usage = 950
+
+	_, err := service.Store(context.Background(), StoreRequest{
+		Kind:   models.FileKindAttachment,
+		RoomID: &roomID,
+		Key:    "attachments/" + roomID.String() + "/over",
+		Name:   "over.png",
+		Size:   int64(len(pngData)),
+	}, strings.NewReader(pngData))
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if fake.inserted() {
+		t.Fatal("file over quota was recorded")
+	}
+	if _, err := service.Blob().Stat(context.Background(), "attachments/"+roomID.String()+"/over.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("file over quota was written to storage: %v", err)
+	}
+
+	// Ruang yang tersisa cukup untuk file yang sama
+	fake.usage = 900
+	file, err := service.Store(context.Background(), StoreRequest{
+		Kind:   models.FileKindAttachment,
+		RoomID: &roomID,
+		Key:    "attachments/" + roomID.String() + "/fits",
+		Name:   "fits.png",
+		Size:   int64(len(pngData)),
+	}, strings.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("file within quota rejected: %v", err)
+	}
+	if file.Key != "attachments/"+roomID.String()+"/fits.png" || file.ContentType != "image/png" || file.Size != int64(len(pngData)) {
+		t.Fatalf("unexpected stored file: %+v", file)
+	}
+	if !fake.inserted() {
+		t.Fatal("stored file was not recorded")
+	}
+}
+
+func TestServiceStoreSkipsQuotaWithoutRoom(t *testing.T) {
+	service, fake := newTestService(t, config.StorageConfig{RoomQuota: 10})
+	fake.usage = 1 << 30
+
+	if _, err := service.Store(context.Background(), StoreRequest{
+		Kind: models.FileKindAvatar,
+		Key:  "avatars/" + uuid.NewString(),
+		Name: "avatar.png",
+		Size: int64(len(pngData)),
+	}, strings.NewReader(pngData)); err != nil {
+		t.Fatalf("file without room counted against quota: %v", err)
+	}
+}
+
+func TestServiceStoreRejectsOversizedAndDisallowedFiles(t *testing.T) {
+	service, fake := newTestService(t, config.StorageConfig{MaxAttachmentSize: 50})
+	roomID := uuid.New()
+
+	tests := map[string]StoreRequest{
+		"too large": {
+			Kind: models.FileKindAttachment, RoomID: &roomID, Key: "attachments/large", Name: "large.png", Size: int64(len(pngData)),
+		},
+		"unknown size": {
+			Kind: models.FileKindAttachment, RoomID: &roomID, Key: "attachments/unknown", Name: "unknown.png", Size: -1,
+		},
+		"disallowed type": {
+			Kind: models.FileKindAvatar, Key: "avatars/text", Name: "avatar.txt", Size: 5, AllowedTypes: []string{"image/"},
+		},
+	}
+	for name, req := range tests {
+		data := pngData
+		if name == "disallowed type" {
+			data = "hello"
+		}
+		if _, err := service.Store(context.Background(), req, strings.NewReader(data)); err == nil {
+			t.Errorf("%s: file was accepted", name)
+		}
+	}
+
+	if fake.inserted() {
+		t.Fatal("rejected file was recorded")
+	}
+}
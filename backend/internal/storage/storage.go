@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/config"
+)
+
+// Error yang dikembalikan implementasi Blob
+var (
+	ErrNotFound      = errors.New("object not found")
+	ErrInvalidKey    = errors.New("invalid object key")
+	ErrQuotaExceeded = errors.New("room storage quota exceeded")
+)
+
+// Blob adalah penyimpanan objek (recording, lampiran chat, avatar) yang dialamatkan
+// dengan key berbentuk path relatif, misalnya "recordings/<room>/<id>.webm"
+type Blob interface {
+	// Put menyimpan objek. Size -1 berarti ukuran tidak diketahui.
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*Object, error)
+
+	// Get membuka objek untuk dibaca
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+
+	// Stat mengembalikan metadata objek
+	Stat(ctx context.Context, key string) (*Object, error)
+
+	// Delete menghapus objek; objek yang tidak ada tidak dianggap error
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL membuat URL download yang berlaku selama expiry
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Object adalah metadata objek di Blob
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// NewBlob membuat Blob sesuai STORAGE_BACKEND ("local" atau "s3")
+func NewBlob(cfg config.StorageConfig) (Blob, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalBlob(cfg.LocalDir, cfg.PublicURL, cfg.SigningSecret)
+	case "s3":
+		return NewS3Blob(S3Options{
+			Endpoint:       cfg.S3Endpoint,
+			PublicEndpoint: cfg.S3PublicEndpoint,
+			AccessKey:      cfg.S3AccessKey,
+			SecretKey:      cfg.S3SecretKey,
+			Bucket:         cfg.S3Bucket,
+			Region:         cfg.S3Region,
+			UseSSL:         cfg.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Backend)
+	}
+}
+
+// CleanKey menormalkan key objek dan menolak key yang keluar dari root
+func CleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(key, "\\", "/"))
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || cleaned == "." || cleaned != strings.TrimPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// DetectContentType menebak content type dari isi awal objek, dengan ekstensi nama
+// file sebagai cadangan. Reader yang dikembalikan tetap berisi seluruh data.
+func DetectContentType(reader io.Reader, name string) (string, io.Reader, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" || strings.HasPrefix(contentType, "text/plain") {
+		if byExtension := mime.TypeByExtension(path.Ext(name)); byExtension != "" {
+			contentType = byExtension
+		}
+	}
+
+	return contentType, io.MultiReader(bytes.NewReader(head), reader), nil
+}
@@ -0,0 +1,136 @@
+package streaming
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ffmpegProcess adalah proses ffmpeg yang berjalan lama, misalnya penerima RTP
+// forward yang menulis HLS atau mengirim ke server RTMP
+type ffmpegProcess struct {
+	cmd    *exec.Cmd
+	stderr *tailWriter
+	done   chan struct{}
+}
+
+// startFFmpeg menjalankan ffmpeg dengan args. Output -progress ditulis ke stdout
+// (nil = dibuang). onExit dipanggil ketika ffmpeg berhenti dengan sendirinya
+// (bukan karena stop) beserta baris error terakhirnya.
+func startFFmpeg(ffmpegPath string, args []string, stdout io.Writer, onExit func(error)) (*ffmpegProcess, error) {
+	process := &ffmpegProcess{
+		cmd:    exec.Command(ffmpegPath, args...),
+		stderr: &tailWriter{limit: 4096},
+		done:   make(chan struct{}),
+	}
+	process.cmd.Stdout = stdout
+	process.cmd.Stderr = process.stderr
+
+	if err := process.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	go func() {
+		err := process.cmd.Wait()
+		if err == nil {
+			err = fmt.Errorf("ffmpeg exited")
+		}
+		if line := process.stderr.lastLine(); line != "" {
+			err = fmt.Errorf("%v: %s", err, line)
+		}
+		close(process.done)
+		onExit(err)
+	}()
+
+	return process, nil
+}
+
+// stop menghentikan ffmpeg: SIGINT agar output ditutup dengan benar, lalu kill
+func (p *ffmpegProcess) stop() {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+
+	_ = p.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-p.done:
+	case <-time.After(5 * time.Second):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+// tailWriter menyimpan beberapa byte terakhir output proses yang berjalan lama
+type tailWriter struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+// Write menambahkan output dan membuang bagian paling lama di atas limit
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.data = append(w.data, p...)
+	if len(w.data) > w.limit {
+		w.data = w.data[len(w.data)-w.limit:]
+	}
+	return len(p), nil
+}
+
+// lastLine mengembalikan baris terakhir yang tidak kosong
+func (w *tailWriter) lastLine() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	lines := strings.Split(strings.TrimSpace(string(w.data)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// progressWriter membaca output -progress ffmpeg dan memanggil onOutput sekali
+// ketika ffmpeg mulai menulis output (out_time lebih dari nol)
+type progressWriter struct {
+	onOutput func()
+	line     []byte
+	reported bool
+}
+
+// Write memproses output progress per baris
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.line = append(w.line, p...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i < 0 {
+			break
+		}
+		w.handleLine(strings.TrimSpace(string(w.line[:i])))
+		w.line = w.line[i+1:]
+	}
+	return len(p), nil
+}
+
+// handleLine memeriksa satu baris key=value progress
+func (w *progressWriter) handleLine(line string) {
+	if w.reported {
+		return
+	}
+
+	key, value, found := strings.Cut(line, "=")
+	if !found || (key != "out_time_us" && key != "out_time_ms") {
+		return
+	}
+
+	if outTime, err := strconv.ParseInt(value, 10, 64); err == nil && outTime > 0 {
+		w.reported = true
+		w.onOutput()
+	}
+}
@@ -0,0 +1,157 @@
+package streaming
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFFmpeg menulis script pengganti ffmpeg yang mencatat argumennya ke
+// <script>.args, menambah satu baris ke <script>.runs setiap dijalankan, lalu
+// menjalankan body
+func fakeFFmpeg(t *testing.T, body string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\n" +
+		"printf '%s\\n' \"$@\" > '" + file + ".args'\n" +
+		"echo run >> '" + file + ".runs'\n" +
+		body + "\n"
+	if err := os.WriteFile(file, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake ffmpeg: %v", err)
+	}
+	return file
+}
+
+// fakeArgs membaca argumen terakhir yang diterima ffmpeg palsu
+func fakeArgs(t *testing.T, ffmpeg string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(ffmpeg + ".args")
+	if err != nil {
+		t.Fatalf("ffmpeg was not run: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// fakeRuns mengembalikan berapa kali ffmpeg palsu dijalankan
+func fakeRuns(ffmpeg string) int {
+	data, err := os.ReadFile(ffmpeg + ".runs")
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(data), "\n")
+}
+
+// argValue mengembalikan nilai setelah flag pertama di args
+func argValue(args []string, flag string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func TestStartFFmpegReportsLastStderrLine(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, "echo 'first line' >&2\necho 'rtmp://host/app: I/O error' >&2\nexit 1")
+
+	exited := make(chan error, 1)
+	if _, err := startFFmpeg(ffmpeg, []string{"-i", "input"}, nil, func(err error) { exited <- err }); err != nil {
+		t.Fatalf("failed to start ffmpeg: %v", err)
+	}
+
+	select {
+	case err := <-exited:
+		if err == nil || err.Error() != "exit status 1: rtmp://host/app: I/O error" {
+			t.Fatalf("unexpected exit error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("onExit was not called")
+	}
+}
+
+func TestStartFFmpegReportsCleanExit(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, "exit 0")
+
+	exited := make(chan error, 1)
+	if _, err := startFFmpeg(ffmpeg, nil, nil, func(err error) { exited <- err }); err != nil {
+		t.Fatalf("failed to start ffmpeg: %v", err)
+	}
+
+	// ffmpeg penerima RTP tidak pernah selesai dengan sendirinya, exit 0 tetap dilaporkan
+	select {
+	case err := <-exited:
+		if err == nil || err.Error() != "ffmpeg exited" {
+			t.Fatalf("unexpected exit error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("onExit was not called")
+	}
+}
+
+func TestStartFFmpegMissingBinary(t *testing.T) {
+	_, err := startFFmpeg(filepath.Join(t.TempDir(), "ffmpeg"), nil, nil, func(error) {
+		t.Error("onExit called for a process that never started")
+	})
+	if err == nil {
+		t.Fatal("missing ffmpeg was started")
+	}
+}
+
+func TestFFmpegProcessStopInterrupts(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, "exec sleep 30")
+
+	process, err := startFFmpeg(ffmpeg, nil, nil, func(error) {})
+	if err != nil {
+		t.Fatalf("failed to start ffmpeg: %v", err)
+	}
+
+	started := time.Now()
+	process.stop()
+	if time.Since(started) >= 5*time.Second {
+		t.Fatal("ffmpeg ignored SIGINT and was killed")
+	}
+
+	// stop pada proses yang sudah berhenti langsung kembali
+	process.stop()
+}
+
+func TestProgressWriterReportsFirstOutputOnce(t *testing.T) {
+	calls := 0
+	writer := &progressWriter{onOutput: func() { calls++ }}
+
+	chunks := []string{
+		"frame=0\nout_time_us=0\nprogress=continue\n",
+		"out_time_us=N/A\nout_ti",
+		"me_us=2000",
+		"00\nprogress=continue\nout_time_us=400000\n",
+	}
+	for i, chunk := range chunks {
+		if _, err := writer.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		if i < 2 && calls != 0 {
+			t.Fatalf("onOutput called before output started (chunk %d)", i)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected onOutput once, got %d", calls)
+	}
+}
+
+func TestTailWriterKeepsLastLine(t *testing.T) {
+	writer := &tailWriter{limit: 16}
+	writer.Write([]byte("a very long first line\nsecond"))
+	writer.Write([]byte(" line\n\n"))
+
+	if line := writer.lastLine(); line != "second line" {
+		t.Fatalf("unexpected last line: %q", line)
+	}
+	if len(writer.data) > 16 {
+		t.Fatalf("tail writer exceeded its limit: %d bytes", len(writer.data))
+	}
+}
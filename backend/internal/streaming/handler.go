@@ -0,0 +1,304 @@
+package streaming
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// Handler struct untuk live stream handler
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler membuat live stream handler baru
+func NewHandler(service *Service, log *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  log,
+	}
+}
+
+// RegisterRoutes registrasi routes untuk live stream (di bawah group /webrtc yang sudah terautentikasi)
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	rooms := router.Group("/rooms")
+	{
+		rooms.GET("/:roomId/stream", h.GetStatus)
+		rooms.POST("/:roomId/stream/start", h.StartStream)
+		rooms.POST("/:roomId/stream/stop", h.StopStream)
+		rooms.GET("/:roomId/stream/hls/:file", h.ServeHLS)
+
+		// Restream RTMP ke platform eksternal
+		rooms.GET("/:roomId/live-streams", h.ListLiveStreams)
+		rooms.POST("/:roomId/live-streams", h.CreateLiveStream)
+		rooms.GET("/:roomId/live-streams/:liveStreamId", h.GetLiveStream)
+		rooms.DELETE("/:roomId/live-streams/:liveStreamId", h.DeleteLiveStream)
+		rooms.POST("/:roomId/live-streams/:liveStreamId/start", h.StartLiveStream)
+		rooms.POST("/:roomId/live-streams/:liveStreamId/stop", h.StopLiveStream)
+	}
+}
+
+// StartStream handler untuk start live stream endpoint
+func (h *Handler) StartStream(c *gin.Context) {
+	roomUUID, userUUID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	var req StartRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.ErrorResponse(c, http.StatusBadRequest, "Invalid request data", err.Error())
+			return
+		}
+	}
+
+	status, err := h.service.StartStream(roomUUID, userUUID, req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to start live stream")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Live stream started successfully")
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Live stream started successfully",
+		"data":    status,
+	})
+}
+
+// StopStream handler untuk stop live stream endpoint
+func (h *Handler) StopStream(c *gin.Context) {
+	roomUUID, userUUID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.StopStream(roomUUID, userUUID); err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to stop live stream")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Live stream stopped successfully")
+	h.SuccessResponse(c, "Live stream stopped successfully", nil)
+}
+
+// GetStatus handler untuk get live stream status endpoint
+func (h *Handler) GetStatus(c *gin.Context) {
+	roomUUID, userUUID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetStatus(roomUUID, userUUID)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Live stream status retrieved successfully", status)
+}
+
+// ServeHLS handler untuk playlist dan segment HLS. Path segment di playlist relatif,
+// sehingga player memakai endpoint (dan header Authorization) yang sama.
+func (h *Handler) ServeHLS(c *gin.Context) {
+	roomUUID, userUUID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	path, err := h.service.GetFile(roomUUID, userUUID, c.Param("file"))
+	if err != nil {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	if filepath.Ext(path) == ".m3u8" {
+		c.Header("Content-Type", "application/vnd.apple.mpegurl")
+		c.Header("Cache-Control", "no-cache")
+	} else {
+		c.Header("Content-Type", "video/mp2t")
+		c.Header("Cache-Control", "private, max-age=60")
+	}
+
+	c.File(path)
+}
+
+// CreateLiveStream handler untuk create restream RTMP endpoint
+func (h *Handler) CreateLiveStream(c *gin.Context) {
+	roomUUID, userUUID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	var req CreateLiveStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid request data", err.Error())
+		return
+	}
+
+	liveStream, err := h.service.CreateLiveStream(roomUUID, userUUID, req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to create live stream")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Live stream created successfully")
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Live stream created successfully",
+		"data":    liveStream,
+	})
+}
+
+// ListLiveStreams handler untuk list restream RTMP room endpoint
+func (h *Handler) ListLiveStreams(c *gin.Context) {
+	roomUUID, userUUID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	liveStreams, err := h.service.ListLiveStreams(roomUUID, userUUID)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Live streams retrieved successfully", liveStreams)
+}
+
+// GetLiveStream handler untuk get restream RTMP endpoint
+func (h *Handler) GetLiveStream(c *gin.Context) {
+	roomUUID, liveStreamUUID, userUUID, ok := h.liveStreamParams(c)
+	if !ok {
+		return
+	}
+
+	liveStream, err := h.service.GetLiveStream(roomUUID, liveStreamUUID, userUUID)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Live stream retrieved successfully", liveStream)
+}
+
+// DeleteLiveStream handler untuk delete restream RTMP endpoint
+func (h *Handler) DeleteLiveStream(c *gin.Context) {
+	roomUUID, liveStreamUUID, userUUID, ok := h.liveStreamParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteLiveStream(roomUUID, liveStreamUUID, userUUID); err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to delete live stream")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.SuccessResponse(c, "Live stream deleted successfully", nil)
+}
+
+// StartLiveStream handler untuk start restream RTMP endpoint
+func (h *Handler) StartLiveStream(c *gin.Context) {
+	roomUUID, liveStreamUUID, userUUID, ok := h.liveStreamParams(c)
+	if !ok {
+		return
+	}
+
+	liveStream, err := h.service.StartLiveStream(roomUUID, liveStreamUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to start restream")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Restream started successfully")
+	h.SuccessResponse(c, "Restream started successfully", liveStream)
+}
+
+// StopLiveStream handler untuk stop restream RTMP endpoint
+func (h *Handler) StopLiveStream(c *gin.Context) {
+	roomUUID, liveStreamUUID, userUUID, ok := h.liveStreamParams(c)
+	if !ok {
+		return
+	}
+
+	liveStream, err := h.service.StopLiveStream(roomUUID, liveStreamUUID, userUUID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to stop restream")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).WithField("room_id", roomUUID.String()).Info("Restream stopped successfully")
+	h.SuccessResponse(c, "Restream stopped successfully", liveStream)
+}
+
+// liveStreamParams membaca room ID dan live stream ID dari path dan user ID dari context
+func (h *Handler) liveStreamParams(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	roomUUID, userUUID, ok := h.params(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	liveStreamUUID, err := uuid.Parse(c.Param("liveStreamId"))
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid live stream ID", nil)
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return roomUUID, liveStreamUUID, userUUID, true
+}
+
+// params membaca room ID dari path dan user ID dari context
+func (h *Handler) params(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	roomUUID, err := uuid.Parse(c.Param("roomId"))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid room ID in parameter")
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid room ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return roomUUID, userUUID, true
+}
+
+// SuccessResponse helper function for success response
+func (h *Handler) SuccessResponse(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+	})
+}
+
+// ErrorResponse helper function for error response
+func (h *Handler) ErrorResponse(c *gin.Context, statusCode int, message string, details interface{}) {
+	response := gin.H{
+		"error": message,
+	}
+
+	if details != nil {
+		response["details"] = details
+	}
+
+	c.JSON(statusCode, response)
+}
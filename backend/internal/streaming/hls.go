@@ -0,0 +1,95 @@
+package streaming
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// Nama file HLS di direktori stream
+const (
+	playlistName = "index.m3u8"
+	sdpName      = "stream.sdp"
+)
+
+// writeSDP menulis SDP yang menjelaskan port dan codec RTP forward untuk ffmpeg
+func writeSDP(path string, forward websocket.RTPForward, status *websocket.RTPForwardStatus) error {
+	var sdp strings.Builder
+	sdp.WriteString("v=0\r\n")
+	sdp.WriteString("o=- 0 0 IN IP4 127.0.0.1\r\n")
+	sdp.WriteString("s=webinar\r\n")
+	sdp.WriteString("c=IN IP4 0.0.0.0\r\n")
+	sdp.WriteString("t=0 0\r\n")
+
+	media := 0
+	for _, m := range []struct {
+		kind  string
+		codec string
+		port  int
+	}{
+		{"audio", status.AudioCodec, forward.AudioPort},
+		{"video", status.VideoCodec, forward.VideoPort},
+	} {
+		if m.codec == "" {
+			continue
+		}
+
+		payload, ok := websocket.RTPPayloads[m.codec]
+		if !ok {
+			return fmt.Errorf("unsupported %s codec: %s", m.kind, m.codec)
+		}
+
+		fmt.Fprintf(&sdp, "m=%s %d RTP/AVP %d\r\n", m.kind, m.port, payload.Type)
+		fmt.Fprintf(&sdp, "a=rtpmap:%d %s\r\n", payload.Type, payload.RTPMap)
+		if payload.FMTP != "" {
+			fmt.Fprintf(&sdp, "a=fmtp:%d %s\r\n", payload.Type, payload.FMTP)
+		}
+		sdp.WriteString("a=recvonly\r\n")
+		media++
+	}
+
+	if media == 0 {
+		return fmt.Errorf("stream has no audio or video")
+	}
+
+	return os.WriteFile(path, []byte(sdp.String()), 0644)
+}
+
+// startHLS menjalankan ffmpeg yang mengemas RTP dari SDP di directory menjadi segment
+// HLS. Video selalu di-transcode ke H.264 dan audio ke AAC karena HLS tidak mendukung
+// VP8/VP9 dan Opus di MPEG-TS. onExit dipanggil ketika ffmpeg berhenti dengan
+// sendirinya (bukan karena stop).
+func startHLS(ffmpegPath, directory string, segment time.Duration, playlistSize int, hasVideo bool, onExit func(error)) (*ffmpegProcess, error) {
+	seconds := strconv.FormatFloat(segment.Seconds(), 'f', -1, 64)
+
+	args := []string{
+		"-hide_banner", "-nostdin", "-loglevel", "error",
+		"-protocol_whitelist", "file,udp,rtp",
+		"-analyzeduration", "10000000",
+		"-fflags", "+genpts",
+		"-i", filepath.Join(directory, sdpName),
+	}
+	if hasVideo {
+		args = append(args,
+			"-c:v", "libx264", "-preset", "veryfast", "-tune", "zerolatency",
+			"-pix_fmt", "yuv420p", "-sc_threshold", "0",
+			"-force_key_frames", "expr:gte(t,n_forced*"+seconds+")",
+		)
+	}
+	args = append(args,
+		"-c:a", "aac", "-b:a", "128k", "-ar", "48000",
+		"-f", "hls",
+		"-hls_time", seconds,
+		"-hls_list_size", strconv.Itoa(playlistSize),
+		"-hls_flags", "delete_segments+independent_segments",
+		"-hls_segment_filename", filepath.Join(directory, "seg_%05d.ts"),
+		filepath.Join(directory, playlistName),
+	)
+
+	return startFFmpeg(ffmpegPath, args, nil, onExit)
+}
@@ -0,0 +1,109 @@
+package streaming
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+func TestWriteSDPDescribesForwardedMedia(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sdpName)
+	forward := websocket.RTPForward{AudioPort: 40000, VideoPort: 40002}
+
+	if err := writeSDP(path, forward, &websocket.RTPForwardStatus{AudioCodec: "opus", VideoCodec: "h264"}); err != nil {
+		t.Fatalf("failed to write sdp: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read sdp: %v", err)
+	}
+	sdp := string(data)
+	for _, line := range []string{
+		"m=audio 40000 RTP/AVP 111\r\n",
+		"a=rtpmap:111 opus/48000/2\r\n",
+		"m=video 40002 RTP/AVP 102\r\n",
+		"a=rtpmap:102 H264/90000\r\n",
+		"a=fmtp:102 packetization-mode=1\r\n",
+	} {
+		if !strings.Contains(sdp, line) {
+			t.Errorf("sdp is missing %q:\n%s", line, sdp)
+		}
+	}
+}
+
+func TestWriteSDPAudioOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sdpName)
+
+	if err := writeSDP(path, websocket.RTPForward{AudioPort: 40000, VideoPort: 40002}, &websocket.RTPForwardStatus{AudioCodec: "pcmu"}); err != nil {
+		t.Fatalf("failed to write sdp: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "m=audio 40000 RTP/AVP 0\r\n") || strings.Contains(string(data), "m=video") {
+		t.Fatalf("unexpected audio only sdp:\n%s", data)
+	}
+}
+
+func TestWriteSDPRejectsUnknownOrMissingCodec(t *testing.T) {
+	directory := t.TempDir()
+	forward := websocket.RTPForward{AudioPort: 40000, VideoPort: 40002}
+
+	if err := writeSDP(filepath.Join(directory, "unknown.sdp"), forward, &websocket.RTPForwardStatus{AudioCodec: "opus", VideoCodec: "h265"}); err == nil {
+		t.Fatal("unknown video codec accepted")
+	}
+	if err := writeSDP(filepath.Join(directory, "empty.sdp"), forward, &websocket.RTPForwardStatus{}); err == nil {
+		t.Fatal("stream without media accepted")
+	}
+
+	if entries, _ := os.ReadDir(directory); len(entries) != 0 {
+		t.Fatalf("sdp written for a rejected stream: %v", entries)
+	}
+}
+
+func TestStartHLSArguments(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, "exit 0")
+	directory := t.TempDir()
+
+	startAndWait := func(hasVideo bool) []string {
+		exited := make(chan error, 1)
+		if _, err := startHLS(ffmpeg, directory, 1500*time.Millisecond, 4, hasVideo, func(err error) { exited <- err }); err != nil {
+			t.Fatalf("failed to start hls: %v", err)
+		}
+		<-exited
+		return fakeArgs(t, ffmpeg)
+	}
+
+	args := startAndWait(true)
+	expected := map[string]string{
+		"-i":                    filepath.Join(directory, sdpName),
+		"-protocol_whitelist":   "file,udp,rtp",
+		"-c:v":                  "libx264",
+		"-force_key_frames":     "expr:gte(t,n_forced*1.5)",
+		"-c:a":                  "aac",
+		"-f":                    "hls",
+		"-hls_time":             "1.5",
+		"-hls_list_size":        "4",
+		"-hls_segment_filename": filepath.Join(directory, "seg_%05d.ts"),
+	}
+	for flag, value := range expected {
+		if got := argValue(args, flag); got != value {
+			t.Errorf("%s = %q, expected %q", flag, got, value)
+		}
+	}
+	if last := args[len(args)-1]; last != filepath.Join(directory, playlistName) {
+		t.Errorf("playlist is not the output: %s", last)
+	}
+
+	args = startAndWait(false)
+	if argValue(args, "-c:v") != "" || argValue(args, "-force_key_frames") != "" {
+		t.Errorf("video encoded for an audio only stream: %v", args)
+	}
+	if argValue(args, "-c:a") != "aac" {
+		t.Errorf("audio not encoded to aac: %v", args)
+	}
+}
@@ -0,0 +1,631 @@
+package streaming
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// Backoff restart ffmpeg restream yang gagal (variabel agar dapat dipersingkat di test)
+var (
+	restreamBackoffMin = 2 * time.Second
+	restreamBackoffMax = 30 * time.Second
+
+	// ffmpeg yang berjalan selama ini dianggap stabil; hitungan restart berturut-turut diulang
+	restreamStableAfter = time.Minute
+)
+
+// CreateLiveStreamRequest struct untuk request membuat restream RTMP. Stream key
+// disimpan terenkripsi dan ditambahkan ke target URL saat restream dimulai.
+type CreateLiveStreamRequest struct {
+	Name      string `json:"name" binding:"max=100"`
+	TargetURL string `json:"target_url" binding:"required,max=500"`
+	StreamKey string `json:"stream_key" binding:"max=500"`
+	Mode      string `json:"mode" binding:"omitempty,oneof=publisher active-speaker"`
+	UserID    string `json:"user_id"`
+}
+
+// restream adalah restream RTMP yang berjalan di API server ini. Supervisor
+// menjalankan ulang ffmpeg yang berhenti sampai restream dihentikan atau batas
+// restart berturut-turut terlewati; RTP forward di media server tetap berjalan.
+type restream struct {
+	id       uuid.UUID
+	roomID   uuid.UUID
+	basePort int
+	dir      string
+	hasVideo bool
+
+	// URL RTMP lengkap dengan stream key; key tidak boleh masuk log maupun pesan error
+	target string
+	key    string
+
+	// Host room dan user yang memulai restream, penerima status lewat websocket
+	notify []uuid.UUID
+
+	restarts atomic.Int32
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// CreateLiveStream menyimpan tujuan restream RTMP room (host atau moderator)
+func (s *Service) CreateLiveStream(roomID, userID uuid.UUID, req CreateLiveStreamRequest) (*models.LiveStream, error) {
+	if s.cipher == nil {
+		return nil, fmt.Errorf("rtmp restreaming is not available")
+	}
+
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status == models.RoomStatusEnded {
+		return nil, fmt.Errorf("room has ended")
+	}
+
+	if err := s.checkControl(room, userID); err != nil {
+		return nil, err
+	}
+
+	targetURL, err := parseTargetURL(req.TargetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = websocket.RTPForwardActiveSpeaker
+		if req.UserID != "" {
+			mode = websocket.RTPForwardPublisher
+		}
+	}
+	if mode == websocket.RTPForwardPublisher && req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required for publisher mode")
+	}
+
+	liveStream := &models.LiveStream{
+		ID:        uuid.New(),
+		RoomID:    roomID,
+		CreatedBy: userID,
+		Name:      strings.TrimSpace(req.Name),
+		TargetURL: targetURL,
+		Mode:      mode,
+		Status:    models.LiveStreamStatusIdle,
+	}
+	if mode == websocket.RTPForwardPublisher {
+		liveStream.UserID = req.UserID
+	}
+
+	if key := strings.TrimSpace(req.StreamKey); key != "" {
+		encrypted, err := s.cipher.encrypt(key, liveStream.ID[:])
+		if err != nil {
+			s.logger.LogError(err, "Failed to encrypt stream key")
+			return nil, fmt.Errorf("failed to create live stream")
+		}
+		liveStream.StreamKey = encrypted
+	}
+
+	if err := s.db.Create(liveStream).Error; err != nil {
+		s.logger.LogError(err, "Failed to create live stream")
+		return nil, fmt.Errorf("failed to create live stream")
+	}
+
+	s.logger.WithUserID(userID.String()).
+		WithField("room_id", roomID.String()).
+		WithField("live_stream_id", liveStream.ID.String()).
+		Info("Live stream created")
+
+	return liveStream, nil
+}
+
+// ListLiveStreams mendapatkan semua restream RTMP room (host atau moderator)
+func (s *Service) ListLiveStreams(roomID, userID uuid.UUID) ([]models.LiveStream, error) {
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkControl(room, userID); err != nil {
+		return nil, err
+	}
+
+	var liveStreams []models.LiveStream
+	if err := s.db.Where("room_id = ?", roomID).Order("created_at ASC").Find(&liveStreams).Error; err != nil {
+		s.logger.LogError(err, "Failed to list live streams")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	return liveStreams, nil
+}
+
+// GetLiveStream mendapatkan restream RTMP room (host atau moderator)
+func (s *Service) GetLiveStream(roomID, liveStreamID, userID uuid.UUID) (*models.LiveStream, error) {
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkControl(room, userID); err != nil {
+		return nil, err
+	}
+
+	return s.findLiveStream(roomID, liveStreamID)
+}
+
+// DeleteLiveStream menghapus restream RTMP room, menghentikannya lebih dulu jika berjalan
+func (s *Service) DeleteLiveStream(roomID, liveStreamID, userID uuid.UUID) error {
+	liveStream, err := s.GetLiveStream(roomID, liveStreamID, userID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	rs := s.restreams[liveStream.ID]
+	s.mu.Unlock()
+	if rs != nil {
+		s.stopRestream(rs)
+	}
+
+	if err := s.db.Delete(liveStream).Error; err != nil {
+		s.logger.LogError(err, "Failed to delete live stream")
+		return fmt.Errorf("failed to delete live stream")
+	}
+
+	s.logger.WithUserID(userID.String()).
+		WithField("room_id", roomID.String()).
+		WithField("live_stream_id", liveStreamID.String()).
+		Info("Live stream deleted")
+	return nil
+}
+
+// StartLiveStream mulai restream RTMP room (host atau moderator)
+func (s *Service) StartLiveStream(roomID, liveStreamID, userID uuid.UUID) (*models.LiveStream, error) {
+	if s.media == nil || s.cipher == nil {
+		return nil, fmt.Errorf("rtmp restreaming is not available")
+	}
+
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != models.RoomStatusActive {
+		return nil, fmt.Errorf("room is not active")
+	}
+
+	if err := s.checkControl(room, userID); err != nil {
+		return nil, err
+	}
+
+	liveStream, err := s.findLiveStream(roomID, liveStreamID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := ""
+	if liveStream.StreamKey != "" {
+		key, err = s.cipher.decrypt(liveStream.StreamKey, liveStream.ID[:])
+		if err != nil {
+			s.logger.LogError(err, "Failed to decrypt stream key")
+			return nil, fmt.Errorf("stream key cannot be decrypted, please recreate the live stream")
+		}
+	}
+
+	rs, err := s.reserveRestream(liveStream, key)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.notify = []uuid.UUID{room.HostID}
+	if userID != room.HostID {
+		rs.notify = append(rs.notify, userID)
+	}
+
+	if err := s.launchRestream(rs, liveStream); err != nil {
+		// done ditutup karena supervisor tidak pernah berjalan; stopRestream yang
+		// bersamaan (misalnya room diakhiri) tidak menunggu selamanya
+		s.unregisterRestream(rs)
+		close(rs.done)
+		if removeErr := os.RemoveAll(rs.dir); removeErr != nil {
+			s.logger.LogError(removeErr, "Failed to remove restream directory")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(liveStream).Updates(map[string]interface{}{
+		"status":     models.LiveStreamStatusStarting,
+		"error":      "",
+		"restarts":   0,
+		"started_by": userID,
+		"started_at": now,
+		"stopped_at": nil,
+	}).Error; err != nil {
+		s.logger.LogError(err, "Failed to update live stream status")
+	}
+	s.notifyLiveStream(rs, models.LiveStreamStatusStarting, "")
+
+	go s.superviseRestream(rs)
+
+	s.logger.WithUserID(userID.String()).
+		WithField("room_id", roomID.String()).
+		WithField("live_stream_id", liveStreamID.String()).
+		Info("Live stream restream started")
+
+	return s.findLiveStream(roomID, liveStreamID)
+}
+
+// StopLiveStream menghentikan restream RTMP room (host atau moderator)
+func (s *Service) StopLiveStream(roomID, liveStreamID, userID uuid.UUID) (*models.LiveStream, error) {
+	liveStream, err := s.GetLiveStream(roomID, liveStreamID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	rs := s.restreams[liveStream.ID]
+	s.mu.Unlock()
+	if rs == nil {
+		return nil, fmt.Errorf("live stream is not running")
+	}
+
+	s.stopRestream(rs)
+
+	s.logger.WithUserID(userID.String()).
+		WithField("room_id", roomID.String()).
+		WithField("live_stream_id", liveStreamID.String()).
+		Info("Live stream restream stopped")
+
+	return s.findLiveStream(roomID, liveStreamID)
+}
+
+// StopRoomLiveStreams menghentikan semua restream RTMP room tanpa pengecekan akses,
+// misalnya ketika room diakhiri
+func (s *Service) StopRoomLiveStreams(roomID uuid.UUID) error {
+	s.mu.Lock()
+	var running []*restream
+	for _, rs := range s.restreams {
+		if rs.roomID == roomID {
+			running = append(running, rs)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, rs := range running {
+		s.stopRestream(rs)
+	}
+	return nil
+}
+
+// reserveRestream mendaftarkan restream baru beserta port UDP-nya
+func (s *Service) reserveRestream(liveStream *models.LiveStream, key string) (*restream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.restreams[liveStream.ID]; exists {
+		return nil, fmt.Errorf("live stream is already running")
+	}
+
+	basePort := s.allocatePort()
+	if basePort == 0 {
+		return nil, fmt.Errorf("too many live streams are running")
+	}
+
+	rs := &restream{
+		id:       liveStream.ID,
+		roomID:   liveStream.RoomID,
+		basePort: basePort,
+		dir:      filepath.Join(s.cfg.Dir, "rtmp", liveStream.ID.String()),
+		target:   joinTarget(liveStream.TargetURL, key),
+		key:      key,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	s.restreams[liveStream.ID] = rs
+
+	return rs, nil
+}
+
+// launchRestream memulai RTP forward di media server dan menulis SDP-nya untuk ffmpeg
+func (s *Service) launchRestream(rs *restream, liveStream *models.LiveStream) error {
+	if err := os.MkdirAll(rs.dir, 0755); err != nil {
+		s.logger.LogError(err, "Failed to create restream directory")
+		return fmt.Errorf("failed to start live stream")
+	}
+
+	forward := websocket.RTPForward{
+		ID:        rs.id.String(),
+		Mode:      liveStream.Mode,
+		UserID:    liveStream.UserID,
+		Host:      s.cfg.ForwardHost,
+		AudioPort: rs.basePort,
+		VideoPort: rs.basePort + 2,
+		AudioSSRC: rand.Uint32(),
+		VideoSSRC: rand.Uint32(),
+	}
+
+	forwarded, err := s.media.StartRTPForward(rs.roomID.String(), forward)
+	if err != nil {
+		if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			return fmt.Errorf("no publisher is streaming in this room")
+		}
+		s.logger.LogError(err, "Failed to start RTP forward for restream")
+		return fmt.Errorf("failed to start live stream")
+	}
+
+	if err := writeSDP(filepath.Join(rs.dir, sdpName), forward, forwarded); err != nil {
+		if stopErr := s.media.StopRTPForward(rs.roomID.String(), rs.id.String()); stopErr != nil {
+			s.logger.LogError(stopErr, "Failed to stop RTP forward of failed restream")
+		}
+		s.logger.LogError(err, "Failed to write restream SDP")
+		return fmt.Errorf("failed to start live stream")
+	}
+
+	rs.hasVideo = forwarded.VideoCodec != ""
+	return nil
+}
+
+// superviseRestream menjalankan ffmpeg restream dan memulainya ulang dengan backoff
+// ketika berhenti, misalnya karena server RTMP memutus koneksi
+func (s *Service) superviseRestream(rs *restream) {
+	defer close(rs.done)
+
+	backoff := restreamBackoffMin
+	failures := 0
+
+	for {
+		exited := make(chan error, 1)
+		startedAt := time.Now()
+
+		progress := &progressWriter{onOutput: func() {
+			s.updateRestream(rs, models.LiveStreamStatusLive, "")
+		}}
+
+		process, err := startRTMP(s.cfg.FFmpegPath, rs.dir, rs.target, rs.hasVideo, progress,
+			func(err error) { exited <- err })
+		if err == nil {
+			select {
+			case <-rs.stop:
+				process.stop()
+				return
+			case err = <-exited:
+			}
+		}
+
+		if time.Since(startedAt) >= restreamStableAfter {
+			failures = 0
+			backoff = restreamBackoffMin
+		}
+		failures++
+
+		message := rs.redact(err.Error())
+		s.logger.WithField("live_stream_id", rs.id.String()).
+			WithField("attempt", failures).
+			Warnf("Restream ffmpeg exited: %s", message)
+
+		if failures > s.cfg.RestreamMaxRestarts {
+			if s.unregisterRestream(rs) {
+				s.releaseRestream(rs)
+				s.updateRestream(rs, models.LiveStreamStatusFailed, message)
+			}
+			return
+		}
+
+		rs.restarts.Add(1)
+		s.updateRestream(rs, models.LiveStreamStatusReconnecting, message)
+
+		select {
+		case <-rs.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > restreamBackoffMax {
+			backoff = restreamBackoffMax
+		}
+	}
+}
+
+// stopRestream menghentikan supervisor dan ffmpeg restream lalu membebaskan forward-nya
+func (s *Service) stopRestream(rs *restream) {
+	if !s.unregisterRestream(rs) {
+		return
+	}
+
+	close(rs.stop)
+	<-rs.done
+
+	s.releaseRestream(rs)
+	s.updateRestream(rs, models.LiveStreamStatusStopped, "")
+}
+
+// unregisterRestream menghapus restream dari daftar yang berjalan. Hanya pemanggil
+// yang mendapat true yang boleh membebaskan restream.
+func (s *Service) unregisterRestream(rs *restream) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.restreams[rs.id] != rs {
+		return false
+	}
+	delete(s.restreams, rs.id)
+	return true
+}
+
+// releaseRestream menghentikan RTP forward di media server dan menghapus direktori restream
+func (s *Service) releaseRestream(rs *restream) {
+	if s.media != nil {
+		if err := s.media.StopRTPForward(rs.roomID.String(), rs.id.String()); err != nil {
+			s.logger.LogError(err, "Failed to stop RTP forward of restream")
+		}
+	}
+
+	if err := os.RemoveAll(rs.dir); err != nil {
+		s.logger.LogError(err, "Failed to remove restream directory")
+	}
+}
+
+// updateRestream menyimpan status restream dan mengirimnya ke host
+func (s *Service) updateRestream(rs *restream, status models.LiveStreamStatus, message string) {
+	updates := map[string]interface{}{
+		"status":   status,
+		"error":    message,
+		"restarts": rs.restarts.Load(),
+	}
+	if status == models.LiveStreamStatusStopped || status == models.LiveStreamStatusFailed {
+		updates["stopped_at"] = time.Now()
+	}
+
+	if err := s.db.Model(&models.LiveStream{}).Where("id = ?", rs.id).Updates(updates).Error; err != nil {
+		s.logger.LogError(err, "Failed to update live stream status")
+	}
+
+	s.notifyLiveStream(rs, status, message)
+}
+
+// notifyLiveStream mengirim status restream ke host dan user yang memulainya
+func (s *Service) notifyLiveStream(rs *restream, status models.LiveStreamStatus, message string) {
+	if s.notifier == nil {
+		return
+	}
+
+	data := websocket.LiveStreamData{
+		RoomID:       rs.roomID.String(),
+		LiveStreamID: rs.id.String(),
+		Status:       string(status),
+		Error:        message,
+		Restarts:     int(rs.restarts.Load()),
+	}
+
+	for _, userID := range rs.notify {
+		if err := s.notifier.NotifyLiveStream(userID.String(), data); err != nil {
+			s.logger.WithField("live_stream_id", rs.id.String()).
+				WithError(err).
+				Warn("Failed to notify live stream status")
+		}
+	}
+}
+
+// redact menyembunyikan stream key dari pesan error ffmpeg yang dapat memuat URL target
+func (rs *restream) redact(message string) string {
+	if rs.key == "" {
+		return message
+	}
+	return strings.ReplaceAll(message, rs.key, "****")
+}
+
+// stopRestreams menghentikan semua restream, dipanggil saat API server berhenti
+func (s *Service) stopRestreams() {
+	s.mu.Lock()
+	running := make([]*restream, 0, len(s.restreams))
+	for _, rs := range s.restreams {
+		running = append(running, rs)
+	}
+	s.mu.Unlock()
+
+	for _, rs := range running {
+		s.stopRestream(rs)
+	}
+}
+
+// resetLiveStreams menandai restream yang masih tercatat berjalan dari proses sebelumnya
+// sebagai gagal, karena ffmpeg-nya ikut berhenti bersama API server
+func (s *Service) resetLiveStreams() {
+	result := s.db.Model(&models.LiveStream{}).
+		Where("status IN ?", []models.LiveStreamStatus{
+			models.LiveStreamStatusStarting,
+			models.LiveStreamStatusLive,
+			models.LiveStreamStatusReconnecting,
+		}).
+		Updates(map[string]interface{}{
+			"status":     models.LiveStreamStatusFailed,
+			"error":      "interrupted by server restart",
+			"stopped_at": time.Now(),
+		})
+	if result.Error != nil {
+		s.logger.LogError(result.Error, "Failed to reset interrupted live streams")
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		s.logger.WithField("count", result.RowsAffected).Warn("Marked interrupted live streams as failed")
+	}
+}
+
+// findLiveStream mencari restream RTMP milik room
+func (s *Service) findLiveStream(roomID, liveStreamID uuid.UUID) (*models.LiveStream, error) {
+	var liveStream models.LiveStream
+	if err := s.db.Where("id = ? AND room_id = ?", liveStreamID, roomID).First(&liveStream).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("live stream not found")
+		}
+		s.logger.LogError(err, "Failed to find live stream")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	return &liveStream, nil
+}
+
+// startRTMP menjalankan ffmpeg yang mengirim RTP dari SDP di directory ke server RTMP.
+// Video di-transcode ke H.264 dan audio ke AAC karena FLV tidak mendukung VP8/Opus;
+// keyframe setiap 2 detik sesuai rekomendasi platform streaming.
+func startRTMP(ffmpegPath, directory, target string, hasVideo bool, progress io.Writer, onExit func(error)) (*ffmpegProcess, error) {
+	args := []string{
+		"-hide_banner", "-nostdin", "-loglevel", "error",
+		"-progress", "pipe:1",
+		"-protocol_whitelist", "file,udp,rtp",
+		"-analyzeduration", "10000000",
+		"-fflags", "+genpts",
+		"-i", filepath.Join(directory, sdpName),
+	}
+	if hasVideo {
+		args = append(args,
+			"-c:v", "libx264", "-preset", "veryfast", "-tune", "zerolatency",
+			"-pix_fmt", "yuv420p", "-sc_threshold", "0",
+			"-b:v", "2500k", "-maxrate", "2500k", "-bufsize", "5000k",
+			"-force_key_frames", "expr:gte(t,n_forced*2)",
+		)
+	}
+	args = append(args,
+		"-c:a", "aac", "-b:a", "128k", "-ar", "44100",
+		"-f", "flv", target,
+	)
+
+	return startFFmpeg(ffmpegPath, args, progress, onExit)
+}
+
+// parseTargetURL memvalidasi URL server RTMP tujuan restream
+func parseTargetURL(raw string) (string, error) {
+	target, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || target.Host == "" {
+		return "", fmt.Errorf("invalid target url")
+	}
+
+	if target.Scheme != "rtmp" && target.Scheme != "rtmps" {
+		return "", fmt.Errorf("target url must use rtmp or rtmps")
+	}
+
+	return target.String(), nil
+}
+
+// joinTarget menambahkan stream key ke URL server RTMP
+func joinTarget(targetURL, key string) string {
+	if key == "" {
+		return targetURL
+	}
+	return strings.TrimRight(targetURL, "/") + "/" + key
+}
@@ -0,0 +1,262 @@
+package streaming
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/webrtc-meeting/backend/internal/websocket"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// fakeNotifier mencatat status restream yang dikirim ke host
+type fakeNotifier struct {
+	mu      sync.Mutex
+	updates []websocket.LiveStreamData
+}
+
+func (n *fakeNotifier) NotifyLiveStream(userID string, data websocket.LiveStreamData) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.updates = append(n.updates, data)
+	return nil
+}
+
+// statuses mengembalikan urutan status yang sudah dikirim
+func (n *fakeNotifier) statuses() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	statuses := make([]string, 0, len(n.updates))
+	for _, update := range n.updates {
+		statuses = append(statuses, update.Status)
+	}
+	return statuses
+}
+
+// last mengembalikan status terakhir yang dikirim
+func (n *fakeNotifier) last() websocket.LiveStreamData {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.updates) == 0 {
+		return websocket.LiveStreamData{}
+	}
+	return n.updates[len(n.updates)-1]
+}
+
+// shortBackoff mempersingkat backoff restart restream selama test
+func shortBackoff(t *testing.T, stableAfter time.Duration) {
+	t.Helper()
+
+	min, max, stable := restreamBackoffMin, restreamBackoffMax, restreamStableAfter
+	restreamBackoffMin, restreamBackoffMax, restreamStableAfter = 10*time.Millisecond, 20*time.Millisecond, stableAfter
+	t.Cleanup(func() {
+		restreamBackoffMin, restreamBackoffMax, restreamStableAfter = min, max, stable
+	})
+}
+
+// startTestRestream menjalankan restream ke server RTMP palsu seperti StartLiveStream,
+// tanpa pengecekan room di database
+func startTestRestream(t *testing.T, service *Service, key string) (*restream, *fakeNotifier) {
+	t.Helper()
+
+	notifier := &fakeNotifier{}
+	service.SetNotifier(notifier)
+
+	liveStream := &models.LiveStream{
+		ID:        uuid.New(),
+		RoomID:    uuid.New(),
+		TargetURL: "rtmp://live.example.com/app",
+		Mode:      websocket.RTPForwardActiveSpeaker,
+	}
+	rs, err := service.reserveRestream(liveStream, key)
+	if err != nil {
+		t.Fatalf("failed to reserve restream: %v", err)
+	}
+	rs.notify = []uuid.UUID{uuid.New()}
+
+	if err := service.launchRestream(rs, liveStream); err != nil {
+		t.Fatalf("failed to launch restream: %v", err)
+	}
+	go service.superviseRestream(rs)
+
+	return rs, notifier
+}
+
+// waitDone menunggu supervisor restream berhenti
+func waitDone(t *testing.T, rs *restream) {
+	t.Helper()
+
+	select {
+	case <-rs.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("restream supervisor did not stop")
+	}
+}
+
+func TestStartRTMPArguments(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, "exit 0")
+	directory := t.TempDir()
+	target := "rtmp://live.example.com/app/key"
+
+	startAndWait := func(hasVideo bool) []string {
+		exited := make(chan error, 1)
+		if _, err := startRTMP(ffmpeg, directory, target, hasVideo, nil, func(err error) { exited <- err }); err != nil {
+			t.Fatalf("failed to start rtmp: %v", err)
+		}
+		<-exited
+		return fakeArgs(t, ffmpeg)
+	}
+
+	args := startAndWait(true)
+	expected := map[string]string{
+		"-progress":         "pipe:1",
+		"-c:v":              "libx264",
+		"-force_key_frames": "expr:gte(t,n_forced*2)",
+		"-c:a":              "aac",
+		"-ar":               "44100",
+		"-f":                "flv",
+	}
+	for flag, value := range expected {
+		if got := argValue(args, flag); got != value {
+			t.Errorf("%s = %q, expected %q", flag, got, value)
+		}
+	}
+	if last := args[len(args)-1]; last != target {
+		t.Errorf("rtmp target is not the output: %s", last)
+	}
+
+	args = startAndWait(false)
+	if argValue(args, "-c:v") != "" {
+		t.Errorf("video encoded for an audio only restream: %v", args)
+	}
+}
+
+func TestJoinTargetAndParseTargetURL(t *testing.T) {
+	if target := joinTarget("rtmp://live.example.com/app/", "key"); target != "rtmp://live.example.com/app/key" {
+		t.Fatalf("unexpected target: %s", target)
+	}
+	if target := joinTarget("rtmp://live.example.com/app", ""); target != "rtmp://live.example.com/app" {
+		t.Fatalf("unexpected target without key: %s", target)
+	}
+
+	for _, raw := range []string{"", "live.example.com/app", "http://live.example.com/app", "rtmp:///app"} {
+		if _, err := parseTargetURL(raw); err == nil {
+			t.Errorf("invalid target url %q accepted", raw)
+		}
+	}
+	if _, err := parseTargetURL(" rtmps://live.example.com:443/app "); err != nil {
+		t.Errorf("rtmps target rejected: %v", err)
+	}
+}
+
+func TestRestreamRestartsUntilLimit(t *testing.T) {
+	shortBackoff(t, time.Minute)
+
+	// ffmpeg sempat mengirim output lalu server RTMP memutus koneksi; pesan error memuat URL lengkap
+	ffmpeg := fakeFFmpeg(t, "echo out_time_us=40000\n"+
+		"echo 'rtmp://live.example.com/app/secret-key: Broken pipe' >&2\n"+
+		"exit 1")
+	service, media := newTestService(t, ffmpeg)
+	rs, notifier := startTestRestream(t, service, "secret-key")
+	waitDone(t, rs)
+
+	// RestreamMaxRestarts 2: percobaan pertama ditambah dua restart
+	if runs := fakeRuns(ffmpeg); runs != 3 {
+		t.Fatalf("expected ffmpeg to run 3 times, got %d", runs)
+	}
+	if args := fakeArgs(t, ffmpeg); args[len(args)-1] != "rtmp://live.example.com/app/secret-key" {
+		t.Fatalf("ffmpeg not sent to the target with stream key: %v", args)
+	}
+
+	expected := "live reconnecting live reconnecting live failed"
+	if statuses := strings.Join(notifier.statuses(), " "); statuses != expected {
+		t.Fatalf("unexpected status sequence: %s", statuses)
+	}
+
+	last := notifier.last()
+	if last.Restarts != 2 {
+		t.Fatalf("expected 2 restarts, got %d", last.Restarts)
+	}
+	if strings.Contains(last.Error, "secret-key") || !strings.Contains(last.Error, "rtmp://live.example.com/app/****") {
+		t.Fatalf("stream key not redacted from error: %q", last.Error)
+	}
+
+	service.mu.Lock()
+	_, running := service.restreams[rs.id]
+	service.mu.Unlock()
+	if running {
+		t.Fatal("failed restream is still registered")
+	}
+	if stops := media.stops(); len(stops) != 1 || stops[0] != rs.id.String() {
+		t.Fatalf("rtp forward of failed restream not stopped: %v", stops)
+	}
+	if _, err := os.Stat(rs.dir); !os.IsNotExist(err) {
+		t.Fatalf("restream directory not removed: %v", err)
+	}
+}
+
+func TestRestreamResetsRestartsAfterStableRun(t *testing.T) {
+	// Setiap ffmpeg dianggap stabil sehingga batas restart berturut-turut tidak pernah tercapai
+	shortBackoff(t, 0)
+
+	ffmpeg := fakeFFmpeg(t, "exit 1")
+	service, _ := newTestService(t, ffmpeg)
+	rs, notifier := startTestRestream(t, service, "")
+
+	waitFor(t, 10*time.Second, "ffmpeg was not restarted past the limit", func() bool {
+		return fakeRuns(ffmpeg) > service.cfg.RestreamMaxRestarts+2
+	})
+
+	if err := service.StopRoomLiveStreams(rs.roomID); err != nil {
+		t.Fatalf("failed to stop restream: %v", err)
+	}
+	waitDone(t, rs)
+
+	statuses := notifier.statuses()
+	for _, status := range statuses[:len(statuses)-1] {
+		if status != string(models.LiveStreamStatusReconnecting) {
+			t.Fatalf("unexpected status before stop: %v", statuses)
+		}
+	}
+	if last := statuses[len(statuses)-1]; last != string(models.LiveStreamStatusStopped) {
+		t.Fatalf("restream ended with %s instead of stopped", last)
+	}
+}
+
+func TestStopRestreamStopsFFmpeg(t *testing.T) {
+	shortBackoff(t, time.Minute)
+
+	ffmpeg := fakeFFmpeg(t, "exec sleep 30")
+	service, media := newTestService(t, ffmpeg)
+	rs, notifier := startTestRestream(t, service, "key")
+	waitFor(t, 5*time.Second, "ffmpeg was not started", func() bool { return fakeRuns(ffmpeg) == 1 })
+
+	started := time.Now()
+	if err := service.StopRoomLiveStreams(rs.roomID); err != nil {
+		t.Fatalf("failed to stop restream: %v", err)
+	}
+	if time.Since(started) >= 5*time.Second {
+		t.Fatal("ffmpeg ignored SIGINT and was killed")
+	}
+	waitDone(t, rs)
+
+	if runs := fakeRuns(ffmpeg); runs != 1 {
+		t.Fatalf("stopped restream was restarted: %d runs", runs)
+	}
+	if statuses := strings.Join(notifier.statuses(), " "); statuses != "stopped" {
+		t.Fatalf("unexpected status sequence: %s", statuses)
+	}
+	if stops := media.stops(); len(stops) != 1 {
+		t.Fatalf("rtp forward should be stopped once, got %v", stops)
+	}
+
+	// Stop kedua tidak melakukan apa pun
+	service.stopRestream(rs)
+	if stops := media.stops(); len(stops) != 1 {
+		t.Fatalf("rtp forward stopped again: %v", stops)
+	}
+}
@@ -0,0 +1,65 @@
+package streaming
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// keyCipher mengenkripsi stream key RTMP yang disimpan di database dengan AES-256-GCM.
+// Kunci diturunkan dari secret konfigurasi; ID live stream dipakai sebagai additional
+// data sehingga ciphertext tidak dapat dipindahkan ke baris lain.
+type keyCipher struct {
+	aead cipher.AEAD
+}
+
+// newKeyCipher membuat keyCipher dari secret
+func newKeyCipher(secret string) (*keyCipher, error) {
+	key := sha256.Sum256([]byte("live-stream-key:" + secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyCipher{aead: aead}, nil
+}
+
+// encrypt mengenkripsi plaintext dan mengembalikan base64(nonce || ciphertext)
+func (c *keyCipher) encrypt(plaintext string, additionalData []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), additionalData)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt membuka hasil encrypt
+func (c *keyCipher) decrypt(encoded string, additionalData []byte) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext is too short")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
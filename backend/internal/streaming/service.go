@@ -0,0 +1,526 @@
+package streaming
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+	"github.com/webrtc-meeting/backend/models"
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// State adalah status live stream room
+type State string
+
+const (
+	StateIdle     State = "idle"
+	StateStarting State = "starting"
+	StateLive     State = "live"
+	StateFailed   State = "failed"
+)
+
+// hlsForwardID adalah ID RTP forward live stream HLS; restream RTMP memakai ID live stream
+const hlsForwardID = "hls"
+
+// hlsFilePattern membatasi file HLS yang dapat diunduh penonton
+var hlsFilePattern = regexp.MustCompile(`^(index\.m3u8|seg_\d+\.ts)$`)
+
+// Status adalah status live stream HLS sebuah room
+type Status struct {
+	RoomID      uuid.UUID  `json:"room_id"`
+	State       State      `json:"state"`
+	Mode        string     `json:"mode,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+	AudioCodec  string     `json:"audio_codec,omitempty"`
+	VideoCodec  string     `json:"video_codec,omitempty"`
+	PlaylistURL string     `json:"playlist_url,omitempty"`
+	StartedBy   *uuid.UUID `json:"started_by,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// StartRequest struct untuk request start live stream. Tanpa mode, stream mengikuti
+// user_id jika diisi dan pembicara aktif jika tidak.
+type StartRequest struct {
+	Mode   string `json:"mode" binding:"omitempty,oneof=publisher active-speaker"`
+	UserID string `json:"user_id"`
+}
+
+// MediaForwarder meneruskan media room sebagai RTP di media plane
+// (websocket server dan media backend-nya)
+type MediaForwarder interface {
+	StartRTPForward(roomID string, forward websocket.RTPForward) (*websocket.RTPForwardStatus, error)
+	StopRTPForward(roomID, forwardID string) error
+}
+
+// LiveStreamNotifier mengirim status restream RTMP ke user melalui websocket hub
+type LiveStreamNotifier interface {
+	NotifyLiveStream(userID string, data websocket.LiveStreamData) error
+}
+
+// Service mengelola live stream HLS webinar dan restream RTMP: RTP forward publisher
+// dari Janus ke ffmpeg lokal yang menulis segment HLS atau mengirim ke server RTMP
+type Service struct {
+	db       *gorm.DB
+	logger   *logger.Logger
+	media    MediaForwarder
+	notifier LiveStreamNotifier
+	cipher   *keyCipher
+	cfg      config.StreamingConfig
+
+	mu        sync.Mutex
+	streams   map[uuid.UUID]*stream
+	restreams map[uuid.UUID]*restream
+}
+
+// stream adalah live stream yang berjalan di API server ini
+type stream struct {
+	status   Status
+	dir      string
+	basePort int
+	process  *ffmpegProcess
+}
+
+// NewService membuat streaming service baru
+func NewService(db *gorm.DB, log *logger.Logger, cfg config.StreamingConfig) *Service {
+	if cfg.SegmentDuration <= 0 {
+		cfg.SegmentDuration = 2 * time.Second
+	}
+	if cfg.PlaylistSize <= 0 {
+		cfg.PlaylistSize = 6
+	}
+	if cfg.RestreamMaxRestarts <= 0 {
+		cfg.RestreamMaxRestarts = 5
+	}
+
+	s := &Service{
+		db:        db,
+		logger:    log,
+		cfg:       cfg,
+		streams:   make(map[uuid.UUID]*stream),
+		restreams: make(map[uuid.UUID]*restream),
+	}
+
+	cipher, err := newKeyCipher(cfg.KeySecret)
+	if err != nil {
+		log.LogError(err, "Failed to create stream key cipher, RTMP restreaming is disabled")
+	}
+	s.cipher = cipher
+
+	s.resetLiveStreams()
+
+	return s
+}
+
+// SetMediaForwarder mengatur media forwarder (nil = live stream tidak tersedia)
+func (s *Service) SetMediaForwarder(media MediaForwarder) {
+	s.media = media
+}
+
+// SetNotifier mengatur pengirim status restream ke host (nil = status hanya tersedia lewat API)
+func (s *Service) SetNotifier(notifier LiveStreamNotifier) {
+	s.notifier = notifier
+}
+
+// PlaylistPath mengembalikan path API playlist HLS room
+func PlaylistPath(roomID uuid.UUID) string {
+	return "/api/v1/webrtc/rooms/" + roomID.String() + "/stream/hls/" + playlistName
+}
+
+// StartStream mulai live stream HLS webinar (host atau moderator)
+func (s *Service) StartStream(roomID, userID uuid.UUID, req StartRequest) (*Status, error) {
+	if s.media == nil {
+		return nil, fmt.Errorf("live streaming is not available")
+	}
+
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != models.RoomStatusActive {
+		return nil, fmt.Errorf("room is not active")
+	}
+
+	if room.Type != models.RoomTypeWebinar {
+		return nil, fmt.Errorf("live streaming is only available for webinar rooms")
+	}
+
+	if err := s.checkControl(room, userID); err != nil {
+		return nil, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = websocket.RTPForwardActiveSpeaker
+		if req.UserID != "" {
+			mode = websocket.RTPForwardPublisher
+		}
+	}
+	if mode == websocket.RTPForwardPublisher && req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required for publisher mode")
+	}
+
+	st, err := s.reserve(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	st.status.Mode = mode
+	st.status.StartedBy = &userID
+	st.status.StartedAt = &now
+
+	forward := websocket.RTPForward{
+		ID:        hlsForwardID,
+		Mode:      mode,
+		Host:      s.cfg.ForwardHost,
+		AudioPort: st.basePort,
+		VideoPort: st.basePort + 2,
+		AudioSSRC: rand.Uint32(),
+		VideoSSRC: rand.Uint32(),
+	}
+	if mode == websocket.RTPForwardPublisher {
+		forward.UserID = req.UserID
+	}
+
+	if err := s.launch(st, forward); err != nil {
+		s.release(roomID, st)
+		return nil, err
+	}
+
+	s.logger.WithUserID(userID.String()).
+		WithField("room_id", roomID.String()).
+		WithField("mode", mode).
+		Info("Live stream started")
+
+	return s.StreamStatus(roomID), nil
+}
+
+// StopStream menghentikan live stream room (host atau moderator)
+func (s *Service) StopStream(roomID, userID uuid.UUID) error {
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkControl(room, userID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	_, exists := s.streams[roomID]
+	s.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("live stream is not running")
+	}
+
+	if err := s.StopRoomStream(roomID); err != nil {
+		return err
+	}
+
+	s.logger.WithUserID(userID.String()).WithField("room_id", roomID.String()).Info("Live stream stopped")
+	return nil
+}
+
+// StopRoomStream menghentikan live stream room tanpa pengecekan akses, misalnya
+// ketika room diakhiri
+func (s *Service) StopRoomStream(roomID uuid.UUID) error {
+	s.mu.Lock()
+	st, exists := s.streams[roomID]
+	s.mu.Unlock()
+	if !exists {
+		return nil
+	}
+
+	if s.media != nil {
+		if err := s.media.StopRTPForward(roomID.String(), hlsForwardID); err != nil {
+			s.logger.LogError(err, "Failed to stop RTP forward of live stream")
+		}
+	}
+
+	s.release(roomID, st)
+	return nil
+}
+
+// GetStatus mendapatkan status live stream untuk user yang memiliki akses ke room
+func (s *Service) GetStatus(roomID, userID uuid.UUID) (*Status, error) {
+	if err := s.checkAccess(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.StreamStatus(roomID), nil
+}
+
+// StreamStatus mengembalikan status live stream room tanpa pengecekan akses
+func (s *Service) StreamStatus(roomID uuid.UUID) *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, exists := s.streams[roomID]
+	if !exists {
+		return &Status{RoomID: roomID, State: StateIdle}
+	}
+
+	// Stream dianggap live setelah ffmpeg menulis playlist pertama
+	if st.status.State == StateStarting {
+		if _, err := os.Stat(filepath.Join(st.dir, playlistName)); err == nil {
+			st.status.State = StateLive
+		}
+	}
+
+	status := st.status
+	return &status
+}
+
+// GetFile mengembalikan path file HLS (playlist atau segment) untuk user yang memiliki akses ke room
+func (s *Service) GetFile(roomID, userID uuid.UUID, name string) (string, error) {
+	if !hlsFilePattern.MatchString(name) {
+		return "", fmt.Errorf("file not found")
+	}
+
+	if err := s.checkAccess(roomID, userID); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	st, exists := s.streams[roomID]
+	s.mu.Unlock()
+	if !exists {
+		return "", fmt.Errorf("live stream is not running")
+	}
+
+	path := filepath.Join(st.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("file not found")
+	}
+
+	return path, nil
+}
+
+// Shutdown menghentikan semua live stream dan restream, dipanggil saat API server berhenti
+func (s *Service) Shutdown() {
+	s.stopRestreams()
+
+	s.mu.Lock()
+	roomIDs := make([]uuid.UUID, 0, len(s.streams))
+	for roomID := range s.streams {
+		roomIDs = append(roomIDs, roomID)
+	}
+	s.mu.Unlock()
+
+	for _, roomID := range roomIDs {
+		if err := s.StopRoomStream(roomID); err != nil {
+			s.logger.LogError(err, "Failed to stop live stream on shutdown")
+		}
+	}
+}
+
+// reserve mendaftarkan stream baru berstatus starting beserta port UDP-nya.
+// Stream yang gagal sebelumnya dibersihkan lebih dulu.
+func (s *Service) reserve(roomID uuid.UUID) (*stream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, exists := s.streams[roomID]; exists {
+		if st.status.State != StateFailed {
+			return nil, fmt.Errorf("live stream is already running")
+		}
+		delete(s.streams, roomID)
+		_ = os.RemoveAll(st.dir)
+	}
+
+	basePort := s.allocatePort()
+	if basePort == 0 {
+		return nil, fmt.Errorf("too many live streams are running")
+	}
+
+	st := &stream{
+		status: Status{
+			RoomID: roomID,
+			State:  StateStarting,
+		},
+		dir:      filepath.Join(s.cfg.Dir, roomID.String()),
+		basePort: basePort,
+	}
+	s.streams[roomID] = st
+
+	return st, nil
+}
+
+// allocatePort mencari port dasar yang belum dipakai stream HLS maupun restream
+// (0 = rentang port penuh). Harus dipanggil dengan lock.
+func (s *Service) allocatePort() int {
+	used := make(map[int]bool, len(s.streams)+len(s.restreams))
+	for _, st := range s.streams {
+		used[st.basePort] = true
+	}
+	for _, rs := range s.restreams {
+		used[rs.basePort] = true
+	}
+
+	for port := s.cfg.PortMin; port+3 <= s.cfg.PortMax; port += 4 {
+		if !used[port] {
+			return port
+		}
+	}
+	return 0
+}
+
+// launch memulai RTP forward di media server lalu ffmpeg yang menerimanya
+func (s *Service) launch(st *stream, forward websocket.RTPForward) error {
+	roomID := st.status.RoomID
+
+	if err := os.RemoveAll(st.dir); err != nil {
+		s.logger.LogError(err, "Failed to clean live stream directory")
+		return fmt.Errorf("failed to start live stream")
+	}
+	if err := os.MkdirAll(st.dir, 0755); err != nil {
+		s.logger.LogError(err, "Failed to create live stream directory")
+		return fmt.Errorf("failed to start live stream")
+	}
+
+	forwarded, err := s.media.StartRTPForward(roomID.String(), forward)
+	if err != nil {
+		if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			return fmt.Errorf("no publisher is streaming in this room")
+		}
+		s.logger.LogError(err, "Failed to start RTP forward for live stream")
+		return fmt.Errorf("failed to start live stream")
+	}
+
+	fail := func(err error) error {
+		if stopErr := s.media.StopRTPForward(roomID.String(), hlsForwardID); stopErr != nil {
+			s.logger.LogError(stopErr, "Failed to stop RTP forward of failed live stream")
+		}
+		s.logger.LogError(err, "Failed to start live stream")
+		return fmt.Errorf("failed to start live stream")
+	}
+
+	if err := writeSDP(filepath.Join(st.dir, sdpName), forward, forwarded); err != nil {
+		return fail(err)
+	}
+
+	process, err := startHLS(s.cfg.FFmpegPath, st.dir, s.cfg.SegmentDuration, s.cfg.PlaylistSize,
+		forwarded.VideoCodec != "", func(err error) { s.exited(roomID, st, err) })
+	if err != nil {
+		return fail(err)
+	}
+
+	s.mu.Lock()
+	st.process = process
+	st.status.UserID = forwarded.UserID
+	st.status.AudioCodec = forwarded.AudioCodec
+	st.status.VideoCodec = forwarded.VideoCodec
+	st.status.PlaylistURL = PlaylistPath(roomID)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// exited menandai stream gagal ketika ffmpeg berhenti tanpa diminta. Forward di
+// media server dihentikan, status gagal tetap terlihat sampai stream dimulai ulang.
+func (s *Service) exited(roomID uuid.UUID, st *stream, err error) {
+	s.mu.Lock()
+	if s.streams[roomID] != st || st.status.State == StateIdle {
+		s.mu.Unlock()
+		return
+	}
+	st.status.State = StateFailed
+	st.status.Error = err.Error()
+	s.mu.Unlock()
+
+	s.logger.WithField("room_id", roomID.String()).WithError(err).Error("Live stream ffmpeg exited")
+
+	if s.media != nil {
+		if err := s.media.StopRTPForward(roomID.String(), hlsForwardID); err != nil {
+			s.logger.LogError(err, "Failed to stop RTP forward of failed live stream")
+		}
+	}
+}
+
+// release menghentikan ffmpeg, menghapus segment dan membebaskan port stream
+func (s *Service) release(roomID uuid.UUID, st *stream) {
+	s.mu.Lock()
+	if s.streams[roomID] == st {
+		delete(s.streams, roomID)
+	}
+	// State idle menandai stop yang disengaja bagi exited
+	st.status.State = StateIdle
+	process := st.process
+	s.mu.Unlock()
+
+	if process != nil {
+		process.stop()
+	}
+
+	if err := os.RemoveAll(st.dir); err != nil {
+		s.logger.LogError(err, "Failed to remove live stream directory")
+	}
+}
+
+// findRoom mencari room
+func (s *Service) findRoom(roomID uuid.UUID) (*models.Room, error) {
+	var room models.Room
+	if err := s.db.First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("room not found")
+		}
+		s.logger.LogError(err, "Failed to find room for live stream")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	return &room, nil
+}
+
+// checkControl memastikan user adalah host atau moderator yang sedang berada di room
+func (s *Service) checkControl(room *models.Room, userID uuid.UUID) error {
+	if room.HostID == userID {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ? AND role = ? AND status = ?",
+			room.ID, userID, models.ParticipantRoleModerator, models.ParticipantStatusJoined).
+		Count(&count).Error; err != nil {
+		s.logger.LogError(err, "Failed to check live stream permission")
+		return fmt.Errorf("internal server error")
+	}
+
+	if count == 0 {
+		return fmt.Errorf("only host or moderator can control live stream")
+	}
+
+	return nil
+}
+
+// checkAccess memastikan room publik atau user adalah host atau peserta room
+func (s *Service) checkAccess(roomID, userID uuid.UUID) error {
+	var count int64
+	if err := s.db.Model(&models.Room{}).
+		Where("id = ?", roomID).
+		Where("is_public = ? OR host_id = ? OR id IN (SELECT room_id FROM room_participants WHERE user_id = ?)",
+			true, userID, userID).
+		Count(&count).Error; err != nil {
+		s.logger.LogError(err, "Failed to check room access")
+		return fmt.Errorf("internal server error")
+	}
+
+	if count == 0 {
+		return fmt.Errorf("room not found or access denied")
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package streaming
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/webrtc-meeting/backend/internal/config"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// fakeDriver adalah database/sql driver yang menerima semua statement tanpa
+// menyimpan apa pun, cukup untuk pembaruan status yang dilakukan Service
+type fakeDriver struct{}
+
+func init() {
+	sql.Register("streamingtest", fakeDriver{})
+}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return nil }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+// fakeMedia adalah MediaForwarder yang mencatat forward yang dimulai dan dihentikan
+type fakeMedia struct {
+	mu      sync.Mutex
+	status  websocket.RTPForwardStatus
+	started []websocket.RTPForward
+	stopped []string
+}
+
+func (m *fakeMedia) StartRTPForward(roomID string, forward websocket.RTPForward) (*websocket.RTPForwardStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = append(m.started, forward)
+	status := m.status
+	return &status, nil
+}
+
+func (m *fakeMedia) StopRTPForward(roomID, forwardID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = append(m.stopped, forwardID)
+	return nil
+}
+
+// stops mengembalikan ID forward yang sudah dihentikan
+func (m *fakeMedia) stops() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.stopped...)
+}
+
+// newTestService membuat Service dengan fake database, fake media forwarder dan
+// ffmpeg palsu di direktori sementara
+func newTestService(t *testing.T, ffmpegPath string) (*Service, *fakeMedia) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: "streamingtest", DSN: t.Name()}), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open fake database: %v", err)
+	}
+
+	log, _ := test.NewNullLogger()
+	service := NewService(db, &logger.Logger{Logger: log}, config.StreamingConfig{
+		Dir:                 t.TempDir(),
+		FFmpegPath:          ffmpegPath,
+		ForwardHost:         "127.0.0.1",
+		PortMin:             40000,
+		PortMax:             40100,
+		KeySecret:           "test-secret",
+		RestreamMaxRestarts: 2,
+	})
+
+	media := &fakeMedia{status: websocket.RTPForwardStatus{UserID: "user-1", AudioCodec: "opus", VideoCodec: "vp8"}}
+	service.SetMediaForwarder(media)
+	return service, media
+}
+
+// waitFor menunggu sampai condition terpenuhi atau gagal setelah timeout
+func waitFor(t *testing.T, timeout time.Duration, message string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServiceMarksStreamFailedWhenFFmpegExits(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, "echo 'Connection refused' >&2\nexit 1")
+	service, media := newTestService(t, ffmpeg)
+	roomID := uuid.New()
+
+	st, err := service.reserve(roomID)
+	if err != nil {
+		t.Fatalf("failed to reserve stream: %v", err)
+	}
+	if err := service.launch(st, websocket.RTPForward{ID: hlsForwardID, AudioPort: st.basePort, VideoPort: st.basePort + 2}); err != nil {
+		t.Fatalf("failed to launch stream: %v", err)
+	}
+
+	waitFor(t, 5*time.Second, "stream was not marked failed", func() bool {
+		return service.StreamStatus(roomID).State == StateFailed
+	})
+
+	status := service.StreamStatus(roomID)
+	if status.Error != "exit status 1: Connection refused" {
+		t.Fatalf("unexpected stream error: %q", status.Error)
+	}
+	if status.VideoCodec != "vp8" || status.PlaylistURL != PlaylistPath(roomID) {
+		t.Fatalf("unexpected stream status: %+v", status)
+	}
+	if stops := media.stops(); len(stops) != 1 || stops[0] != hlsForwardID {
+		t.Fatalf("rtp forward of failed stream not stopped: %v", stops)
+	}
+
+	// Stream yang gagal dapat dimulai ulang pada room yang sama
+	if _, err := service.reserve(roomID); err != nil {
+		t.Fatalf("failed stream blocks a new stream: %v", err)
+	}
+}
+
+func TestServiceStopDoesNotReportFailure(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, "exec sleep 30")
+	service, media := newTestService(t, ffmpeg)
+	roomID := uuid.New()
+
+	st, err := service.reserve(roomID)
+	if err != nil {
+		t.Fatalf("failed to reserve stream: %v", err)
+	}
+	if err := service.launch(st, websocket.RTPForward{ID: hlsForwardID, AudioPort: st.basePort, VideoPort: st.basePort + 2}); err != nil {
+		t.Fatalf("failed to launch stream: %v", err)
+	}
+	waitFor(t, 5*time.Second, "ffmpeg was not started", func() bool { return fakeRuns(ffmpeg) == 1 })
+
+	if err := service.StopRoomStream(roomID); err != nil {
+		t.Fatalf("failed to stop stream: %v", err)
+	}
+
+	select {
+	case <-st.process.done:
+	default:
+		t.Fatal("ffmpeg still running after stop")
+	}
+	if status := service.StreamStatus(roomID); status.State != StateIdle {
+		t.Fatalf("stopped stream has state %s", status.State)
+	}
+	if stops := media.stops(); len(stops) != 1 {
+		t.Fatalf("rtp forward should be stopped once, got %v", stops)
+	}
+}
@@ -0,0 +1,117 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/webrtc-meeting/backend/internal/storage"
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// avatarTypes adalah content type avatar yang diizinkan (SVG ditolak karena dapat berisi script)
+var avatarTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// SetStorage mengatur storage avatar (nil = avatar berupa URL bebas di profile)
+func (s *Service) SetStorage(files *storage.Service) {
+	s.storage = files
+}
+
+// AvatarPath mengembalikan path API publik avatar user yang disimpan di User.Avatar
+func AvatarPath(userID uuid.UUID) string {
+	return "/api/v1/public/avatars/" + userID.String()
+}
+
+// UploadAvatar menyimpan avatar baru ke storage dan menghapus avatar sebelumnya
+func (s *Service) UploadAvatar(ctx context.Context, userID uuid.UUID, header *multipart.FileHeader) (*models.User, error) {
+	if s.storage == nil {
+		return nil, fmt.Errorf("avatar upload is not available")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+		s.logger.LogError(err, "Failed to find user for avatar upload")
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	reader, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file")
+	}
+	defer reader.Close()
+
+	file, err := s.storage.Store(ctx, storage.StoreRequest{
+		Kind:         models.FileKindAvatar,
+		OwnerID:      userID,
+		Key:          path.Join("avatars", userID.String(), uuid.New().String()),
+		Name:         path.Base(strings.ReplaceAll(header.Filename, "\\", "/")),
+		Size:         header.Size,
+		AllowedTypes: avatarTypes,
+	}, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	s.removeAvatars(ctx, userID, &file.ID)
+
+	user.Avatar = AvatarPath(userID)
+	if err := s.db.Model(&user).Update("avatar", user.Avatar).Error; err != nil {
+		s.logger.LogError(err, "Failed to update user avatar")
+		return nil, fmt.Errorf("failed to update avatar")
+	}
+
+	s.logger.WithUserID(userID.String()).Info("User avatar uploaded successfully")
+
+	user.Password = ""
+	return &user, nil
+}
+
+// GetAvatarURL membuat signed URL avatar terbaru user
+func (s *Service) GetAvatarURL(ctx context.Context, userID uuid.UUID) (string, time.Time, error) {
+	if s.storage == nil {
+		return "", time.Time{}, fmt.Errorf("avatar not found")
+	}
+
+	var file models.StoredFile
+	if err := s.db.Where("owner_id = ? AND kind = ?", userID, models.FileKindAvatar).
+		Order("created_at DESC").
+		First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", time.Time{}, fmt.Errorf("avatar not found")
+		}
+		s.logger.LogError(err, "Failed to find user avatar")
+		return "", time.Time{}, fmt.Errorf("internal server error")
+	}
+
+	return s.storage.SignedURL(ctx, &file)
+}
+
+// removeAvatars menghapus avatar user di storage kecuali file keep
+func (s *Service) removeAvatars(ctx context.Context, userID uuid.UUID, keep *uuid.UUID) {
+	query := s.db.Where("owner_id = ? AND kind = ?", userID, models.FileKindAvatar)
+	if keep != nil {
+		query = query.Where("id <> ?", *keep)
+	}
+
+	var files []models.StoredFile
+	if err := query.Find(&files).Error; err != nil {
+		s.logger.LogError(err, "Failed to find previous avatars")
+		return
+	}
+
+	for i := range files {
+		if err := s.storage.Delete(ctx, &files[i]); err != nil {
+			s.logger.LogError(err, "Failed to delete previous avatar")
+		}
+	}
+}
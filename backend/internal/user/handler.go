@@ -32,6 +32,7 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 		users.GET("/profile", h.GetProfile)
 		users.PUT("/profile", h.UpdateProfile)
 		users.PUT("/password", h.ChangePassword)
+		users.POST("/avatar", h.UploadAvatar)
 
 		// Contact endpoints
 		users.GET("/contacts", h.GetContacts)
@@ -116,6 +117,55 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	h.SuccessResponse(c, "Profile updated successfully", profile)
 }
 
+// UploadAvatar handler untuk upload avatar endpoint (multipart: file)
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid user ID in context")
+		h.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "File is required", err.Error())
+		return
+	}
+
+	profile, err := h.service.UploadAvatar(c.Request.Context(), userUUID, header)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userUUID).Error("Failed to upload avatar")
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.logger.WithUserID(userUUID.String()).Info("Avatar uploaded successfully")
+	h.SuccessResponse(c, "Avatar uploaded successfully", profile)
+}
+
+// GetAvatar handler untuk public avatar endpoint, mengarahkan ke signed URL avatar
+func (h *Handler) GetAvatar(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	url, _, err := h.service.GetAvatarURL(c.Request.Context(), userUUID)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
 // ChangePassword handler untuk change password endpoint
 func (h *Handler) ChangePassword(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	"github.com/webrtc-meeting/backend/internal/storage"
 	"github.com/webrtc-meeting/backend/models"
 	"github.com/webrtc-meeting/backend/pkg/logger"
 )
@@ -18,6 +20,9 @@ import (
 type Service struct {
 	db     *gorm.DB
 	logger *logger.Logger
+
+	// Storage avatar (nil = avatar berupa URL bebas di profile)
+	storage *storage.Service
 }
 
 // NewService membuat user service baru
@@ -93,9 +98,17 @@ func (s *Service) UpdateProfile(userID uuid.UUID, req *UpdateProfileRequest) (*m
 	// Update user fields
 	user.FirstName = req.FirstName
 	user.LastName = req.LastName
-	user.Avatar = req.Avatar
 	user.Phone = req.Phone
 
+	// Avatar di storage diunggah lewat endpoint avatar; di sini hanya dapat dihapus
+	if s.storage != nil && req.Avatar != user.Avatar {
+		if req.Avatar != "" {
+			return nil, fmt.Errorf("avatar must be uploaded via the avatar endpoint")
+		}
+		s.removeAvatars(context.Background(), userID, nil)
+	}
+	user.Avatar = req.Avatar
+
 	if err := s.db.Save(&user).Error; err != nil {
 		s.logger.LogError(err, "Failed to update user profile")
 		return nil, fmt.Errorf("failed to update profile")
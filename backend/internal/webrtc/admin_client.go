@@ -0,0 +1,343 @@
+package webrtc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// JanusAdminClient adalah client untuk Janus Admin API.
+// Admin API dipakai untuk introspeksi session dan handle saat debugging.
+type JanusAdminClient struct {
+	// URL Admin API Janus server
+	AdminURL string
+
+	// Admin secret untuk autentikasi
+	AdminSecret string
+
+	// HTTP client
+	HTTPClient *http.Client
+
+	// Logger
+	logger *logrus.Logger
+}
+
+// JanusAdminInstance adalah client Admin API untuk satu instance Janus di pool
+type JanusAdminInstance struct {
+	// Nama instance, sama dengan nama JanusInstance di pool
+	Name string
+
+	// Client Admin API instance
+	Client *JanusAdminClient
+}
+
+// JanusAdminRequest adalah struktur request ke Janus Admin API
+type JanusAdminRequest struct {
+	Janus       string   `json:"janus"`
+	Transaction string   `json:"transaction"`
+	AdminSecret string   `json:"admin_secret,omitempty"`
+	Level       *int     `json:"level,omitempty"`
+	Token       string   `json:"token,omitempty"`
+	Plugins     []string `json:"plugins,omitempty"`
+}
+
+// JanusAdminResponse adalah struktur response dari Janus Admin API
+type JanusAdminResponse struct {
+	Janus       string          `json:"janus"`
+	Transaction string          `json:"transaction"`
+	SessionID   uint64          `json:"session_id,omitempty"`
+	HandleID    uint64          `json:"handle_id,omitempty"`
+	Sessions    []uint64        `json:"sessions,omitempty"`
+	Handles     []uint64        `json:"handles,omitempty"`
+	Info        json.RawMessage `json:"info,omitempty"`
+	Level       int             `json:"level,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Error       *JanusError     `json:"error,omitempty"`
+}
+
+// StoredToken adalah stored token Janus beserta plugin yang boleh diakses
+type StoredToken struct {
+	Token          string   `json:"token"`
+	AllowedPlugins []string `json:"allowed_plugins"`
+}
+
+// HandleInfo adalah informasi detail sebuah handle dari handle_info
+type HandleInfo struct {
+	SessionID      uint64             `json:"session_id"`
+	HandleID       uint64             `json:"handle_id"`
+	OpaqueID       string             `json:"opaque_id,omitempty"`
+	Plugin         string             `json:"plugin"`
+	Created        int64              `json:"created,omitempty"`
+	ICEMode        string             `json:"ice-mode,omitempty"`
+	ICERole        string             `json:"ice-role,omitempty"`
+	PluginSpecific json.RawMessage    `json:"plugin_specific,omitempty"`
+	Flags          json.RawMessage    `json:"flags,omitempty"`
+	SDPs           json.RawMessage    `json:"sdps,omitempty"`
+	Streams        []HandleStreamInfo `json:"streams,omitempty"`
+
+	// Raw berisi info lengkap dari Janus
+	Raw json.RawMessage `json:"raw,omitempty"`
+}
+
+// HandleStreamInfo adalah informasi stream media pada sebuah handle
+type HandleStreamInfo struct {
+	ID         int                   `json:"id"`
+	Ready      int                   `json:"ready"`
+	RTCPStats  json.RawMessage       `json:"rtcp_stats,omitempty"`
+	Components []HandleComponentInfo `json:"components,omitempty"`
+}
+
+// HandleComponentInfo adalah informasi komponen ICE pada sebuah stream
+type HandleComponentInfo struct {
+	ID               int             `json:"id"`
+	State            string          `json:"state"`
+	SelectedPair     string          `json:"selected-pair,omitempty"`
+	LocalCandidates  []string        `json:"local-candidates,omitempty"`
+	RemoteCandidates []string        `json:"remote-candidates,omitempty"`
+	DTLS             *HandleDTLSInfo `json:"dtls,omitempty"`
+	InStats          json.RawMessage `json:"in_stats,omitempty"`
+	OutStats         json.RawMessage `json:"out_stats,omitempty"`
+}
+
+// HandleDTLSInfo adalah informasi DTLS pada sebuah komponen
+type HandleDTLSInfo struct {
+	Role        string `json:"dtls-role"`
+	State       string `json:"dtls-state"`
+	Valid       bool   `json:"valid"`
+	SRTPEnabled bool   `json:"srtp-enabled"`
+	Ready       bool   `json:"ready"`
+}
+
+// NewJanusAdminClient membuat instance JanusAdminClient baru
+func NewJanusAdminClient(adminURL, adminSecret string) *JanusAdminClient {
+	return &JanusAdminClient{
+		AdminURL:    adminURL,
+		AdminSecret: adminSecret,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		logger: logrus.New(),
+	}
+}
+
+// Admin mengembalikan client Admin API yang memakai AdminURL dan AdminSecret dari JanusClient
+func (jc *JanusClient) Admin() *JanusAdminClient {
+	return &JanusAdminClient{
+		AdminURL:    jc.AdminURL,
+		AdminSecret: jc.AdminSecret,
+		HTTPClient:  jc.HTTPClient,
+		logger:      jc.logger,
+	}
+}
+
+// ListSessions mengembalikan semua session yang aktif di Janus
+func (ac *JanusAdminClient) ListSessions() ([]uint64, error) {
+	resp, err := ac.request(ac.AdminURL, JanusAdminRequest{Janus: "list_sessions"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	if resp.Sessions == nil {
+		return []uint64{}, nil
+	}
+
+	return resp.Sessions, nil
+}
+
+// ListHandles mengembalikan semua handle milik session tertentu
+func (ac *JanusAdminClient) ListHandles(sessionID uint64) ([]uint64, error) {
+	url := fmt.Sprintf("%s/%d", ac.AdminURL, sessionID)
+
+	resp, err := ac.request(url, JanusAdminRequest{Janus: "list_handles"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list handles: %w", err)
+	}
+
+	if resp.Handles == nil {
+		return []uint64{}, nil
+	}
+
+	return resp.Handles, nil
+}
+
+// HandleInfo mengembalikan informasi detail sebuah handle, termasuk state ICE,
+// state DTLS, pasangan candidate yang terpilih dan statistik RTCP
+func (ac *JanusAdminClient) HandleInfo(sessionID, handleID uint64) (*HandleInfo, error) {
+	url := fmt.Sprintf("%s/%d/%d", ac.AdminURL, sessionID, handleID)
+
+	resp, err := ac.request(url, JanusAdminRequest{Janus: "handle_info"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get handle info: %w", err)
+	}
+
+	var info HandleInfo
+	if err := json.Unmarshal(resp.Info, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse handle info: %w", err)
+	}
+
+	if info.SessionID == 0 {
+		info.SessionID = sessionID
+	}
+	if info.HandleID == 0 {
+		info.HandleID = handleID
+	}
+	info.Raw = resp.Info
+
+	return &info, nil
+}
+
+// FindHandlesByOpaqueID mencari semua handle dengan opaque ID tertentu di semua session
+func (ac *JanusAdminClient) FindHandlesByOpaqueID(opaqueID string) ([]*HandleInfo, error) {
+	sessions, err := ac.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	handles := make([]*HandleInfo, 0)
+	for _, sessionID := range sessions {
+		handleIDs, err := ac.ListHandles(sessionID)
+		if err != nil {
+			// Session bisa saja hilang di antara dua request
+			ac.logger.WithError(err).WithField("session_id", sessionID).Warn("Failed to list handles")
+			continue
+		}
+
+		for _, handleID := range handleIDs {
+			info, err := ac.HandleInfo(sessionID, handleID)
+			if err != nil {
+				ac.logger.WithError(err).WithFields(logrus.Fields{
+					"session_id": sessionID,
+					"handle_id":  handleID,
+				}).Warn("Failed to get handle info")
+				continue
+			}
+
+			if info.OpaqueID == opaqueID {
+				handles = append(handles, info)
+			}
+		}
+	}
+
+	return handles, nil
+}
+
+// SetLogLevel mengubah level log Janus (0-7) dan mengembalikan level yang berlaku
+func (ac *JanusAdminClient) SetLogLevel(level int) (int, error) {
+	if level < 0 || level > 7 {
+		return 0, fmt.Errorf("invalid log level: %d", level)
+	}
+
+	resp, err := ac.request(ac.AdminURL, JanusAdminRequest{
+		Janus: "set_log_level",
+		Level: &level,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to set log level: %w", err)
+	}
+
+	ac.logger.WithField("level", resp.Level).Info("Changed Janus log level")
+
+	return resp.Level, nil
+}
+
+// DestroySession menghapus session tertentu beserta semua handle-nya
+func (ac *JanusAdminClient) DestroySession(sessionID uint64) error {
+	url := fmt.Sprintf("%s/%d", ac.AdminURL, sessionID)
+
+	if _, err := ac.request(url, JanusAdminRequest{Janus: "destroy_session"}); err != nil {
+		return fmt.Errorf("failed to destroy session: %w", err)
+	}
+
+	ac.logger.WithField("session_id", sessionID).Info("Destroyed Janus session via admin API")
+
+	return nil
+}
+
+// AddToken menambahkan stored token yang hanya boleh mengakses plugin tertentu.
+// Jika plugins kosong, token dapat mengakses semua plugin.
+func (ac *JanusAdminClient) AddToken(token string, plugins []string) error {
+	if _, err := ac.request(ac.AdminURL, JanusAdminRequest{
+		Janus:   "add_token",
+		Token:   token,
+		Plugins: plugins,
+	}); err != nil {
+		return fmt.Errorf("failed to add token: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveToken menghapus stored token
+func (ac *JanusAdminClient) RemoveToken(token string) error {
+	if _, err := ac.request(ac.AdminURL, JanusAdminRequest{
+		Janus: "remove_token",
+		Token: token,
+	}); err != nil {
+		return fmt.Errorf("failed to remove token: %w", err)
+	}
+
+	return nil
+}
+
+// ListTokens mengembalikan semua stored token yang terdaftar di Janus
+func (ac *JanusAdminClient) ListTokens() ([]StoredToken, error) {
+	resp, err := ac.request(ac.AdminURL, JanusAdminRequest{Janus: "list_tokens"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+
+	var data struct {
+		Tokens []StoredToken `json:"tokens"`
+	}
+	if len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse tokens: %w", err)
+		}
+	}
+
+	if data.Tokens == nil {
+		return []StoredToken{}, nil
+	}
+
+	return data.Tokens, nil
+}
+
+// request mengirim request ke Admin API dan memeriksa error dari Janus
+func (ac *JanusAdminClient) request(url string, request JanusAdminRequest) (*JanusAdminResponse, error) {
+	request.Transaction = uuid.New().String()
+	request.AdminSecret = ac.AdminSecret
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := ac.HTTPClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var adminResp JanusAdminResponse
+	if err := json.NewDecoder(resp.Body).Decode(&adminResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if adminResp.Janus == "error" {
+		if adminResp.Error != nil {
+			return nil, adminResp.Error
+		}
+		return nil, fmt.Errorf("unexpected response: %s", adminResp.Janus)
+	}
+
+	if adminResp.Janus != "success" {
+		return nil, fmt.Errorf("unexpected response: %s", adminResp.Janus)
+	}
+
+	return &adminResp, nil
+}
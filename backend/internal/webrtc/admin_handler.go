@@ -0,0 +1,364 @@
+package webrtc
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/webrtc-meeting/backend/pkg/logger"
+)
+
+// Jumlah karakter awal token yang ditampilkan, sisanya disamarkan
+const tokenPrefixLength = 4
+
+// AdminHandler struct untuk endpoint admin Janus. Setiap endpoint mencakup semua
+// instance pool; session ID hanya unik per instance, sehingga endpoint session
+// menerima query instance atau mencari instance yang memiliki session tersebut.
+type AdminHandler struct {
+	instances []*JanusAdminInstance
+	logger    *logger.Logger
+}
+
+// SetLogLevelRequest adalah request untuk mengubah level log Janus
+type SetLogLevelRequest struct {
+	Level *int `json:"level" binding:"required"`
+}
+
+// MaskedToken adalah stored token Janus yang disamarkan untuk respons admin
+type MaskedToken struct {
+	Prefix         string   `json:"prefix"`
+	AllowedPlugins []string `json:"allowed_plugins"`
+}
+
+// NewAdminHandler membuat admin handler Janus baru untuk instance pool
+func NewAdminHandler(instances []*JanusAdminInstance, log *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		instances: instances,
+		logger:    log,
+	}
+}
+
+// RegisterRoutes registrasi routes untuk introspeksi Janus (admin only)
+func (h *AdminHandler) RegisterRoutes(router *gin.RouterGroup) {
+	janus := router.Group("/janus")
+	{
+		// Session dan handle
+		janus.GET("/sessions", h.ListSessions)
+		janus.DELETE("/sessions/:sessionId", h.DestroySession)
+		janus.GET("/sessions/:sessionId/handles", h.ListHandles)
+		janus.GET("/sessions/:sessionId/handles/:handleId", h.HandleInfo)
+
+		// Handle milik user tertentu
+		janus.GET("/users/:userId/handles", h.GetUserHandles)
+
+		// Stored token
+		janus.GET("/tokens", h.ListTokens)
+
+		// Log level
+		janus.PUT("/log-level", h.SetLogLevel)
+	}
+}
+
+// ListSessions handler untuk list session Janus di setiap instance
+func (h *AdminHandler) ListSessions(c *gin.Context) {
+	results := make([]gin.H, 0, len(h.instances))
+	total := 0
+	failed := 0
+
+	for _, instance := range h.instances {
+		sessions, err := instance.Client.ListSessions()
+		if err != nil {
+			h.logger.WithError(err).WithField("instance", instance.Name).Error("Failed to list Janus sessions")
+			results = append(results, gin.H{"instance": instance.Name, "error": err.Error()})
+			failed++
+			continue
+		}
+
+		results = append(results, gin.H{
+			"instance": instance.Name,
+			"sessions": sessions,
+			"total":    len(sessions),
+		})
+		total += len(sessions)
+	}
+
+	if failed == len(h.instances) {
+		h.ErrorResponse(c, http.StatusBadGateway, "Failed to list Janus sessions", results)
+		return
+	}
+
+	h.SuccessResponse(c, "Janus sessions retrieved successfully", gin.H{
+		"instances": results,
+		"total":     total,
+	})
+}
+
+// ListHandles handler untuk list handle dalam session Janus
+func (h *AdminHandler) ListHandles(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID", nil)
+		return
+	}
+
+	instance := h.sessionInstance(c, sessionID)
+	if instance == nil {
+		return
+	}
+
+	handles, err := instance.Client.ListHandles(sessionID)
+	if err != nil {
+		h.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to list Janus handles")
+		h.ErrorResponse(c, http.StatusBadGateway, "Failed to list Janus handles", err.Error())
+		return
+	}
+
+	h.SuccessResponse(c, "Janus handles retrieved successfully", gin.H{
+		"instance":   instance.Name,
+		"session_id": sessionID,
+		"handles":    handles,
+		"total":      len(handles),
+	})
+}
+
+// HandleInfo handler untuk detail handle Janus (ICE, DTLS, candidate pair, RTCP)
+func (h *AdminHandler) HandleInfo(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID", nil)
+		return
+	}
+
+	handleID, err := strconv.ParseUint(c.Param("handleId"), 10, 64)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid handle ID", nil)
+		return
+	}
+
+	instance := h.sessionInstance(c, sessionID)
+	if instance == nil {
+		return
+	}
+
+	info, err := instance.Client.HandleInfo(sessionID, handleID)
+	if err != nil {
+		h.logger.WithError(err).WithField("handle_id", handleID).Error("Failed to get Janus handle info")
+		h.ErrorResponse(c, http.StatusBadGateway, "Failed to get Janus handle info", err.Error())
+		return
+	}
+
+	h.SuccessResponse(c, "Janus handle info retrieved successfully", gin.H{
+		"instance": instance.Name,
+		"handle":   info,
+	})
+}
+
+// GetUserHandles handler untuk mencari semua handle milik user berdasarkan opaque ID di setiap instance
+func (h *AdminHandler) GetUserHandles(c *gin.Context) {
+	userID := c.Param("userId")
+
+	results := make([]gin.H, 0, len(h.instances))
+	total := 0
+	failed := 0
+
+	for _, instance := range h.instances {
+		handles, err := instance.Client.FindHandlesByOpaqueID(userID)
+		if err != nil {
+			h.logger.WithError(err).WithField("instance", instance.Name).WithField("user_id", userID).Error("Failed to find Janus handles for user")
+			results = append(results, gin.H{"instance": instance.Name, "error": err.Error()})
+			failed++
+			continue
+		}
+
+		results = append(results, gin.H{
+			"instance": instance.Name,
+			"handles":  handles,
+			"total":    len(handles),
+		})
+		total += len(handles)
+	}
+
+	if failed == len(h.instances) {
+		h.ErrorResponse(c, http.StatusBadGateway, "Failed to find Janus handles for user", results)
+		return
+	}
+
+	h.SuccessResponse(c, "Janus handles for user retrieved successfully", gin.H{
+		"user_id":   userID,
+		"instances": results,
+		"total":     total,
+	})
+}
+
+// DestroySession handler untuk menghapus session Janus
+func (h *AdminHandler) DestroySession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID", nil)
+		return
+	}
+
+	instance := h.sessionInstance(c, sessionID)
+	if instance == nil {
+		return
+	}
+
+	if err := instance.Client.DestroySession(sessionID); err != nil {
+		h.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to destroy Janus session")
+		h.ErrorResponse(c, http.StatusBadGateway, "Failed to destroy Janus session", err.Error())
+		return
+	}
+
+	h.logger.WithField("instance", instance.Name).WithField("session_id", sessionID).Info("Janus session destroyed by admin")
+	h.SuccessResponse(c, "Janus session destroyed successfully", gin.H{
+		"instance": instance.Name,
+	})
+}
+
+// ListTokens handler untuk list stored token Janus di setiap instance. Token
+// adalah kredensial, sehingga hanya jumlah dan prefix yang disamarkan yang dikembalikan.
+func (h *AdminHandler) ListTokens(c *gin.Context) {
+	results := make([]gin.H, 0, len(h.instances))
+	total := 0
+	failed := 0
+
+	for _, instance := range h.instances {
+		tokens, err := instance.Client.ListTokens()
+		if err != nil {
+			h.logger.WithError(err).WithField("instance", instance.Name).Error("Failed to list Janus tokens")
+			results = append(results, gin.H{"instance": instance.Name, "error": err.Error()})
+			failed++
+			continue
+		}
+
+		masked := make([]MaskedToken, 0, len(tokens))
+		for _, token := range tokens {
+			masked = append(masked, MaskedToken{
+				Prefix:         maskToken(token.Token),
+				AllowedPlugins: token.AllowedPlugins,
+			})
+		}
+
+		results = append(results, gin.H{
+			"instance": instance.Name,
+			"tokens":   masked,
+			"total":    len(masked),
+		})
+		total += len(masked)
+	}
+
+	if failed == len(h.instances) {
+		h.ErrorResponse(c, http.StatusBadGateway, "Failed to list Janus tokens", results)
+		return
+	}
+
+	h.SuccessResponse(c, "Janus tokens retrieved successfully", gin.H{
+		"instances": results,
+		"total":     total,
+	})
+}
+
+// SetLogLevel handler untuk mengubah level log Janus di semua instance
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req SetLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	if *req.Level < 0 || *req.Level > 7 {
+		h.ErrorResponse(c, http.StatusBadRequest, "Failed to set Janus log level", "level must be between 0 and 7")
+		return
+	}
+
+	levels := make(map[string]int, len(h.instances))
+	failures := make(map[string]string)
+	for _, instance := range h.instances {
+		level, err := instance.Client.SetLogLevel(*req.Level)
+		if err != nil {
+			h.logger.WithError(err).WithField("instance", instance.Name).Error("Failed to set Janus log level")
+			failures[instance.Name] = err.Error()
+			continue
+		}
+		levels[instance.Name] = level
+	}
+
+	if len(failures) > 0 {
+		h.ErrorResponse(c, http.StatusBadGateway, "Failed to set Janus log level", gin.H{
+			"levels": levels,
+			"errors": failures,
+		})
+		return
+	}
+
+	h.logger.WithField("level", *req.Level).Info("Janus log level changed by admin")
+	h.SuccessResponse(c, "Janus log level updated successfully", gin.H{
+		"levels": levels,
+	})
+}
+
+// sessionInstance menentukan instance pemilik session dari query instance, atau
+// mencari session di setiap instance. Mengirim respons error dan mengembalikan nil
+// jika instance tidak ditemukan.
+func (h *AdminHandler) sessionInstance(c *gin.Context, sessionID uint64) *JanusAdminInstance {
+	if name := c.Query("instance"); name != "" {
+		for _, instance := range h.instances {
+			if instance.Name == name {
+				return instance
+			}
+		}
+		h.ErrorResponse(c, http.StatusNotFound, "Janus instance not found", name)
+		return nil
+	}
+
+	if len(h.instances) == 1 {
+		return h.instances[0]
+	}
+
+	for _, instance := range h.instances {
+		sessions, err := instance.Client.ListSessions()
+		if err != nil {
+			h.logger.WithError(err).WithField("instance", instance.Name).Warn("Failed to list Janus sessions")
+			continue
+		}
+		for _, id := range sessions {
+			if id == sessionID {
+				return instance
+			}
+		}
+	}
+
+	h.ErrorResponse(c, http.StatusNotFound, "Janus session not found", nil)
+	return nil
+}
+
+// maskToken menyamarkan token sehingga hanya beberapa karakter awal yang terlihat
+func maskToken(token string) string {
+	if len(token) <= tokenPrefixLength*2 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:tokenPrefixLength] + strings.Repeat("*", len(token)-tokenPrefixLength)
+}
+
+// SuccessResponse helper function for success response
+func (h *AdminHandler) SuccessResponse(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+	})
+}
+
+// ErrorResponse helper function for error response
+func (h *AdminHandler) ErrorResponse(c *gin.Context, statusCode int, message string, details interface{}) {
+	response := gin.H{
+		"error": message,
+	}
+
+	if details != nil {
+		response["details"] = details
+	}
+
+	c.JSON(statusCode, response)
+}
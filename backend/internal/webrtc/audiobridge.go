@@ -0,0 +1,221 @@
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Batas volume kontribusi peserta ke mix audio bridge dalam persen
+const (
+	MinParticipantVolume = 0
+	MaxParticipantVolume = 400
+)
+
+// pluginName mengembalikan plugin Janus yang melayani room dengan opsi ini
+func (o VideoRoomOptions) pluginName() string {
+	if o.AudioBridge {
+		return AudioBridgePlugin
+	}
+	return "janus.plugin.videoroom"
+}
+
+// createJanusRoom membuat video room atau audio bridge sesuai opsi room
+func (sh *SignalingHandler) createJanusRoom(plugin *PluginHandle, janusRoom uint64, roomID string, options VideoRoomOptions) error {
+	if options.AudioBridge {
+		return plugin.CreateAudioBridge(janusRoom, fmt.Sprintf("Room %s", roomID), options)
+	}
+	return plugin.CreateVideoRoom(janusRoom, fmt.Sprintf("Room %s", roomID), options)
+}
+
+// audioBridgeRoom memeriksa apakah room dilayani (atau akan dilayani) audio bridge.
+// Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) audioBridgeRoom(roomID string) bool {
+	if roomSession, exists := sh.RoomSessions[roomID]; exists {
+		return roomSession.Options.AudioBridge
+	}
+	return sh.roomOptions(roomID).AudioBridge
+}
+
+// joinAudioBridge memasukkan publisher ke audio bridge lalu mengirim media-event
+// "joined" berisi peserta yang sudah ada. Tidak ada subscriber: setelah offer
+// browser di-configure, user menerima satu stream audio hasil mix.
+// Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) joinAudioBridge(roomSession *RoomSession, publisherSession *PublisherSession) error {
+	if publisherSession.Plugin == nil {
+		return fmt.Errorf("%w: %s", ErrPublisherReconnecting, publisherSession.UserID)
+	}
+	joined, err := publisherSession.Plugin.JoinAudioBridge(roomSession.JanusRoom, publisherSession.JanusID, publisherSession.Display, "", false)
+	if err != nil {
+		return fmt.Errorf("failed to join audio bridge: %w", err)
+	}
+
+	sh.sendMediaEvent(roomSession.RoomID, publisherSession.UserID, "joined", map[string]interface{}{
+		"janusId":      publisherSession.JanusID,
+		"plugin":       "audiobridge",
+		"participants": sh.describeAudioParticipants(roomSession, joined.Participants),
+		"publishers":   []map[string]interface{}{},
+	})
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":  roomSession.RoomID,
+		"user_id":  publisherSession.UserID,
+		"janus_id": publisherSession.JanusID,
+	}).Info("User joined audio bridge successfully")
+
+	return nil
+}
+
+// publishToAudioBridge meneruskan offer browser ke audio bridge dengan configure dan
+// mengirim answer Janus ke user. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) publishToAudioBridge(roomSession *RoomSession, publisherSession *PublisherSession, jsep *JSEP) error {
+	if publisherSession.Plugin == nil {
+		return fmt.Errorf("%w: %s", ErrPublisherReconnecting, publisherSession.UserID)
+	}
+	answer, err := publisherSession.Plugin.ConfigureAudioBridge(AudioBridgeConfigureRequest{}, jsep)
+	if err != nil {
+		return fmt.Errorf("failed to publish offer: %w", err)
+	}
+	publisherSession.IsPublishing = true
+	publisherSession.Mids = sdpMids(jsep.SDP)
+
+	if answer != nil {
+		publisherSession.AudioCodec = sdpCodecs(answer.SDP)["audio"]
+		sh.sendAnswer(roomSession.RoomID, publisherSession.UserID, answer)
+	}
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomSession.RoomID,
+		"user_id": publisherSession.UserID,
+	}).Info("WebRTC offer handled by audio bridge")
+
+	return nil
+}
+
+// rejoinAudioBridge join ulang publisher ke audio bridge yang dibuat ulang dan meminta
+// client melakukan negosiasi ulang. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) rejoinAudioBridge(roomSession *RoomSession, publisherSession *PublisherSession) {
+	if publisherSession.Plugin == nil {
+		return
+	}
+
+	joined, err := publisherSession.Plugin.JoinAudioBridge(roomSession.JanusRoom, publisherSession.JanusID, publisherSession.Display, publisherSession.AudioCodec, false)
+	if err != nil {
+		sh.logger.WithFields(logrus.Fields{
+			"room_id": roomSession.RoomID,
+			"user_id": publisherSession.UserID,
+		}).Errorf("Failed to rejoin audio bridge: %v", err)
+		return
+	}
+
+	sh.sendMediaEvent(roomSession.RoomID, publisherSession.UserID, "session-reset", map[string]interface{}{
+		"janusId":      publisherSession.JanusID,
+		"participants": sh.describeAudioParticipants(roomSession, joined.Participants),
+		"publishers":   []map[string]interface{}{},
+	})
+}
+
+// handleAudioBridgeEvent meneruskan event audiobridge ke user sebagai media-event
+// "participants", "talking" dan "leaving"
+func (sh *SignalingHandler) handleAudioBridgeEvent(roomID, userID string, event *JanusResponse) {
+	var data AudioBridgeEvent
+	if err := event.DecodePluginData(&data); err != nil {
+		sh.sendHandleEvent(roomID, userID, 0, "error", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	switch {
+	case data.AudioBridge == "talking" || data.AudioBridge == "stopped-talking":
+		sh.mu.RLock()
+		talkingUserID := ""
+		if roomSession := sh.RoomSessions[roomID]; roomSession != nil {
+			talkingUserID = sh.publisherUserID(roomSession, data.ID)
+		}
+		sh.mu.RUnlock()
+
+		sh.sendMediaEvent(roomID, userID, "talking", map[string]interface{}{
+			"feedId":  data.ID,
+			"userId":  talkingUserID,
+			"talking": data.AudioBridge == "talking",
+		})
+	case len(data.Participants) > 0:
+		sh.mu.RLock()
+		var participants []map[string]interface{}
+		if roomSession := sh.RoomSessions[roomID]; roomSession != nil {
+			participants = sh.describeAudioParticipants(roomSession, data.Participants)
+		}
+		sh.mu.RUnlock()
+
+		sh.sendMediaEvent(roomID, userID, "participants", map[string]interface{}{
+			"participants": participants,
+		})
+	case len(data.Kicked) > 0:
+		sh.sendMediaEvent(roomID, userID, "leaving", map[string]interface{}{
+			"feedId": rawFeedID(data.Kicked),
+			"reason": "kicked",
+		})
+	case len(data.Leaving) > 0:
+		sh.sendMediaEvent(roomID, userID, "leaving", map[string]interface{}{
+			"feedId": rawFeedID(data.Leaving),
+		})
+	}
+}
+
+// describeAudioParticipants mengubah daftar peserta audio bridge menjadi data untuk client
+func (sh *SignalingHandler) describeAudioParticipants(roomSession *RoomSession, participants []AudioBridgeParticipant) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(participants))
+	for _, participant := range participants {
+		result = append(result, map[string]interface{}{
+			"feedId":  participant.ID,
+			"display": participant.Display,
+			"userId":  sh.publisherUserID(roomSession, participant.ID),
+			"setup":   participant.Setup,
+			"muted":   participant.Muted,
+			"talking": participant.Talking,
+		})
+	}
+	return result
+}
+
+// SetParticipantVolume mengubah volume kontribusi user ke mix audio bridge dalam
+// persen (100 = tidak diubah). Hanya berlaku untuk room audio bridge.
+func (sh *SignalingHandler) SetParticipantVolume(roomID, userID string, volume int) error {
+	if volume < MinParticipantVolume || volume > MaxParticipantVolume {
+		return fmt.Errorf("volume must be between %d and %d", MinParticipantVolume, MaxParticipantVolume)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, publisherSession, err := sh.findPublisher(roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !roomSession.Options.AudioBridge {
+		return fmt.Errorf("participant volume requires an audio bridge room: %s", roomID)
+	}
+
+	if _, err := publisherSession.Plugin.ConfigureAudioBridge(AudioBridgeConfigureRequest{Volume: &volume}, nil); err != nil {
+		return err
+	}
+
+	sh.sendMediaEvent(roomID, userID, "volume", map[string]interface{}{
+		"volume": volume,
+	})
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+		"volume":  volume,
+	}).Info("Participant volume changed in audio bridge")
+
+	return nil
+}
+
+// errAudioBridgeUnsupported membuat error untuk fitur videoroom yang tidak tersedia di audio bridge
+func errAudioBridgeUnsupported(feature, roomID string) error {
+	return fmt.Errorf("%s is not supported for audio bridge room %s", feature, roomID)
+}
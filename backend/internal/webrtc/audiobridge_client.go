@@ -0,0 +1,268 @@
+package webrtc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AudioBridgePlugin adalah nama plugin Janus yang me-mix audio semua peserta di server
+const AudioBridgePlugin = "janus.plugin.audiobridge"
+
+// Sampling rate mix audiobridge (Opus fullband)
+const audioBridgeSamplingRate = 48000
+
+// AudioBridgeCreateRequest adalah request untuk membuat audio bridge
+type AudioBridgeCreateRequest struct {
+	Request      string `json:"request"`
+	Room         uint64 `json:"room,omitempty"`
+	Description  string `json:"description,omitempty"`
+	IsPrivate    bool   `json:"is_private,omitempty"`
+	Secret       string `json:"secret,omitempty"`
+	SamplingRate int    `json:"sampling_rate,omitempty"`
+
+	AudioLevelEvent    bool `json:"audiolevel_event,omitempty"`
+	AudioActivePackets int  `json:"audio_active_packets,omitempty"`
+	AudioLevelAverage  int  `json:"audio_level_average,omitempty"`
+}
+
+// AudioBridgeDestroyRequest adalah request untuk menghapus audio bridge
+type AudioBridgeDestroyRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// AudioBridgeJoinRequest adalah request untuk join audio bridge
+type AudioBridgeJoinRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	ID      uint64 `json:"id"`
+	Display string `json:"display,omitempty"`
+	Token   string `json:"token,omitempty"`
+	Muted   bool   `json:"muted,omitempty"`
+
+	// Codec peserta ("opus", "pcmu", "pcma" atau "g722"), kosong = opus
+	Codec string `json:"codec,omitempty"`
+}
+
+// AudioBridgeConfigureRequest adalah request configure peserta audio bridge.
+// Field nil tidak diubah oleh Janus.
+type AudioBridgeConfigureRequest struct {
+	Request string  `json:"request"`
+	Muted   *bool   `json:"muted,omitempty"`
+	Display *string `json:"display,omitempty"`
+
+	// Volume kontribusi peserta ke mix dalam persen (100 = tidak diubah)
+	Volume *int `json:"volume,omitempty"`
+}
+
+// AudioBridgeModerateRequest adalah request mute/unmute peserta oleh moderator
+// ("mute" atau "unmute")
+type AudioBridgeModerateRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	ID      uint64 `json:"id"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// AudioBridgeKickRequest adalah request untuk mengeluarkan peserta dari audio bridge
+type AudioBridgeKickRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	ID      uint64 `json:"id"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// AudioBridgeListParticipantsRequest adalah request daftar peserta audio bridge
+type AudioBridgeListParticipantsRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+}
+
+// AudioBridgeEvent adalah data event dari plugin audiobridge
+type AudioBridgeEvent struct {
+	AudioBridge  string                   `json:"audiobridge"`
+	Room         uint64                   `json:"room,omitempty"`
+	ID           uint64                   `json:"id,omitempty"`
+	Participants []AudioBridgeParticipant `json:"participants,omitempty"`
+	Leaving      json.RawMessage          `json:"leaving,omitempty"`
+	Kicked       json.RawMessage          `json:"kicked,omitempty"`
+	Result       string                   `json:"result,omitempty"`
+}
+
+// AudioBridgeParticipant adalah informasi peserta di audio bridge
+type AudioBridgeParticipant struct {
+	ID      uint64 `json:"id"`
+	Display string `json:"display,omitempty"`
+	Setup   bool   `json:"setup"`
+	Muted   bool   `json:"muted"`
+	Talking bool   `json:"talking,omitempty"`
+}
+
+// CreateAudioBridge membuat audio bridge baru. Deteksi pembicara memakai parameter
+// audio level yang sama dengan video room.
+func (ph *PluginHandle) CreateAudioBridge(roomID uint64, description string, options VideoRoomOptions) error {
+	body := AudioBridgeCreateRequest{
+		Request:      "create",
+		Room:         roomID,
+		Description:  description,
+		SamplingRate: audioBridgeSamplingRate,
+
+		AudioLevelEvent:    options.AudioLevelEvent,
+		AudioActivePackets: options.AudioActivePackets,
+		AudioLevelAverage:  options.AudioLevelAverage,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to create audio bridge: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"handle_id": ph.ID(),
+	}).Info("Created audio bridge")
+
+	return nil
+}
+
+// DestroyAudioBridge menghapus audio bridge. Semua peserta akan menerima event destroyed.
+func (ph *PluginHandle) DestroyAudioBridge(roomID uint64) error {
+	body := AudioBridgeDestroyRequest{
+		Request: "destroy",
+		Room:    roomID,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to destroy audio bridge: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"handle_id": ph.ID(),
+	}).Info("Destroyed audio bridge")
+
+	return nil
+}
+
+// JoinAudioBridge bergabung ke audio bridge dan mengembalikan event joined
+// yang berisi daftar peserta yang sudah ada. Codec kosong berarti opus.
+func (ph *PluginHandle) JoinAudioBridge(roomID, userID uint64, displayName, codec string, muted bool) (*AudioBridgeEvent, error) {
+	body := AudioBridgeJoinRequest{
+		Request: "join",
+		Room:    roomID,
+		ID:      userID,
+		Display: displayName,
+		Token:   ph.Token(),
+		Muted:   muted,
+		Codec:   codec,
+	}
+
+	resp, err := ph.sendMessage(body, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join audio bridge: %w", err)
+	}
+
+	var joined AudioBridgeEvent
+	if err := resp.DecodePluginData(&joined); err != nil {
+		return nil, fmt.Errorf("failed to parse join response: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":      roomID,
+		"user_id":      userID,
+		"display_name": displayName,
+		"handle_id":    ph.ID(),
+	}).Info("Joined audio bridge")
+
+	return &joined, nil
+}
+
+// ConfigureAudioBridge mengirim configure peserta audio bridge. Jika jsep berisi
+// offer browser, JSEP answer dari Janus (berisi audio hasil mix) dikembalikan.
+func (ph *PluginHandle) ConfigureAudioBridge(request AudioBridgeConfigureRequest, jsep *JSEP) (*JSEP, error) {
+	request.Request = "configure"
+
+	resp, err := ph.sendMessage(request, jsep, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure audio bridge participant: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+		"offer":     jsep != nil,
+	}).Debug("Configured audio bridge participant")
+
+	return resp.Jsep, nil
+}
+
+// MuteAudioBridge mute atau unmute peserta audio bridge. Selama di-mute,
+// audio peserta tidak dimasukkan ke mix.
+func (ph *PluginHandle) MuteAudioBridge(roomID, participantID uint64, mute bool) error {
+	request := "unmute"
+	if mute {
+		request = "mute"
+	}
+
+	body := AudioBridgeModerateRequest{
+		Request: request,
+		Room:    roomID,
+		ID:      participantID,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to moderate participant: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":        roomID,
+		"participant_id": participantID,
+		"mute":           mute,
+		"handle_id":      ph.ID(),
+	}).Info("Moderated audio bridge participant")
+
+	return nil
+}
+
+// KickFromAudioBridge mengeluarkan peserta dari audio bridge. Janus menutup
+// PeerConnection peserta dan memberi tahu peserta lain dengan event kicked.
+func (ph *PluginHandle) KickFromAudioBridge(roomID, participantID uint64) error {
+	body := AudioBridgeKickRequest{
+		Request: "kick",
+		Room:    roomID,
+		ID:      participantID,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to kick participant: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":        roomID,
+		"participant_id": participantID,
+		"handle_id":      ph.ID(),
+	}).Info("Kicked participant from audio bridge")
+
+	return nil
+}
+
+// ListAudioBridgeParticipants mengembalikan daftar peserta audio bridge
+func (ph *PluginHandle) ListAudioBridgeParticipants(roomID uint64) ([]AudioBridgeParticipant, error) {
+	body := AudioBridgeListParticipantsRequest{
+		Request: "listparticipants",
+		Room:    roomID,
+	}
+
+	resp, err := ph.sendMessage(body, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audio bridge participants: %w", err)
+	}
+
+	var list AudioBridgeEvent
+	if err := resp.DecodePluginData(&list); err != nil {
+		return nil, fmt.Errorf("failed to list audio bridge participants: %w", err)
+	}
+
+	return list.Participants, nil
+}
@@ -0,0 +1,86 @@
+package webrtc
+
+import (
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// MediaBackend adalah media server yang dipakai hub untuk signaling WebRTC.
+//
+// Semua implementasi memakai alur pesan yang sama ke browser:
+//   - setelah join, user menerima media-event "joined" berisi publisher yang sudah ada
+//   - user mem-publish dengan mengirim offer dan menerima answer dari backend
+//   - untuk setiap publisher lain, backend mengirim offer atas nama publisher
+//     tersebut dan browser menjawab dengan answer ke publisher user
+//   - publisher baru dan publisher yang keluar diumumkan dengan media-event
+//     "publishers" dan "leaving"
+//   - moderasi host diberitahukan ke user yang terkena dengan media-event
+//     "kicked", "moderated" dan "force-unpublished"
+//   - perubahan kebijakan media room diumumkan dengan media-event "media-policy"
+//   - user yang tidak dapat dipulihkan setelah failover media server menerima
+//     media-event "evicted" dan harus join ulang
+//
+// Pengecualiannya adalah room audio bridge (MediaPolicy.AudioBridge, hanya backend
+// Janus): media-event "joined" berisi plugin "audiobridge", answer atas offer user
+// membawa audio hasil mix semua peserta, dan perubahan peserta diumumkan dengan
+// media-event "participants", "talking" dan "leaving" tanpa offer per publisher.
+//
+// Dengan begitu frontend tidak perlu tahu apakah media dilayani Janus atau SFU embedded.
+type MediaBackend interface {
+	// CreateRoom menyiapkan room di media server
+	CreateRoom(roomID string) error
+
+	// DestroyRoom mengeluarkan semua user dan menghapus room dari media server
+	DestroyRoom(roomID string) error
+
+	// HandleJoinRoom memasukkan user ke room sebagai (calon) publisher
+	HandleJoinRoom(roomID, userID, displayName string) error
+
+	// HandleLeaveRoom mengeluarkan user dari room beserta semua subscription-nya
+	HandleLeaveRoom(roomID, userID string) error
+
+	// HandleOffer mem-publish media user dari offer browser
+	HandleOffer(roomID, fromUserID, toUserID, sdp string) error
+
+	// HandleAnswer memulai subscription user (from) ke publisher (to)
+	HandleAnswer(roomID, fromUserID, toUserID, sdp string) error
+
+	// HandleIceCandidate meneruskan ICE candidate dari browser (trickle)
+	HandleIceCandidate(roomID, fromUserID, toUserID, candidate, sdpMid string, sdpMLineIndex int) error
+
+	// HandleSelectLayer memilih layer simulcast/SVC yang diterima user (from) dari publisher (to)
+	HandleSelectLayer(roomID, fromUserID, toUserID string, data websocket.SelectLayerData) error
+
+	// KickParticipant memutus media user dari room atas perintah host
+	KickParticipant(roomID, userID string) error
+
+	// MuteParticipant mute atau unmute media user ("audio" atau "video") di sisi server
+	MuteParticipant(roomID, userID, kind string, mute bool) error
+
+	// UnpublishParticipant menghentikan publish user tanpa mengeluarkannya dari room
+	UnpublishParticipant(roomID, userID string) error
+
+	// ApplyMediaPolicy menerapkan kebijakan media (bitrate, codec, keyframe) ke room yang sedang berjalan
+	ApplyMediaPolicy(roomID string, policy mediaplane.MediaPolicy) error
+
+	// StartRecording mulai merekam room ke directory (relatif terhadap direktori recording backend)
+	StartRecording(roomID, directory string) error
+
+	// StopRecording menghentikan recording room
+	StopRecording(roomID string) error
+
+	// StartRTPForward mulai meneruskan media publisher room sebagai RTP ke host UDP
+	StartRTPForward(roomID string, forward websocket.RTPForward) (*websocket.RTPForwardStatus, error)
+
+	// StopRTPForward menghentikan satu RTP forwarding room
+	StopRTPForward(roomID, forwardID string) error
+
+	// GetRoomStats mengembalikan statistik room
+	GetRoomStats(roomID string) map[string]interface{}
+
+	// Close melepas semua resource media server
+	Close() error
+}
+
+// Pastikan SignalingHandler (backend Janus) memenuhi MediaBackend
+var _ MediaBackend = (*SignalingHandler)(nil)
@@ -0,0 +1,417 @@
+package webrtc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Jumlah percobaan attach dan join ulang publisher di instance baru
+	migrationJoinAttempts = 3
+
+	// Jeda antar percobaan join ulang publisher
+	migrationRetryDelay = 500 * time.Millisecond
+)
+
+// ErrPublisherReconnecting dikembalikan ketika handle Janus publisher sedang
+// dipindahkan ke instance lain sehingga request media belum dapat dilayani
+var ErrPublisherReconnecting = errors.New("publisher is reconnecting")
+
+// roomMigration adalah perpindahan satu room ke instance Janus lain. Handle di
+// instance baru disiapkan tanpa memegang sh.mu sehingga signaling room lain tetap
+// berjalan selama round-trip ke Janus.
+type roomMigration struct {
+	roomID      string
+	roomSession *RoomSession
+	client      *JanusClient
+	janusRoom   uint64
+	options     VideoRoomOptions
+	textRoom    bool
+	publishers  []*migratedPublisher
+
+	// Handle room di instance baru
+	roomPlugin     *PluginHandle
+	textRoomPlugin *PluginHandle
+}
+
+// migratedPublisher adalah state publisher yang di-snapshot saat migrasi dimulai
+// beserta hasil attach dan join ulangnya di instance baru
+type migratedPublisher struct {
+	session     *PublisherSession
+	userID      string
+	janusID     uint64
+	display     string
+	audioCodec  string
+	dataChannel bool
+
+	plugin       *PluginHandle
+	joined       bool
+	privateID    uint64
+	publishers   []VideoRoomPublisher
+	participants []AudioBridgeParticipant
+	dataPlugin   *PluginHandle
+	dataToken    string
+	dataOffer    *JSEP
+}
+
+// migrateRoomSession memindahkan room ke instance Janus lain setelah instance
+// lamanya gagal health check. Handle lama hanya dilepas secara lokal karena
+// instance lama tidak dapat dihubungi. sh.mu hanya dipegang saat membaca dan
+// memasang state, tidak selama request ke Janus.
+func (sh *SignalingHandler) migrateRoomSession(roomID string) {
+	migration := sh.beginMigration(roomID)
+	if migration == nil {
+		return
+	}
+
+	migration.attach(sh)
+
+	for _, handle := range sh.finishMigration(migration) {
+		if err := handle.DetachPlugin(); err != nil {
+			sh.logger.WithField("room_id", roomID).Errorf("Failed to detach stale plugin after migration: %v", err)
+		}
+	}
+}
+
+// beginMigration memilih instance baru, melepas handle lama dan mengambil snapshot
+// publisher room. Mengembalikan nil jika room tidak perlu atau tidak dapat dipindahkan.
+func (sh *SignalingHandler) beginMigration(roomID string) *roomMigration {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return nil
+	}
+
+	client, err := sh.clientForRoom(roomID)
+	if err != nil {
+		sh.logger.WithField("room_id", roomID).Errorf("Failed to move room to another Janus instance: %v", err)
+		return nil
+	}
+
+	migration := &roomMigration{
+		roomID:      roomID,
+		roomSession: roomSession,
+		client:      client,
+		janusRoom:   roomSession.JanusRoom,
+		options:     roomSession.Options,
+		textRoom:    roomSession.TextRoom != nil,
+	}
+
+	// Lepas semua handle di instance lama
+	if roomSession.Plugin != nil {
+		roomSession.Plugin.release()
+		roomSession.Plugin = nil
+	}
+	if roomSession.TextRoom != nil {
+		roomSession.TextRoom.release()
+		roomSession.TextRoom = nil
+	}
+	for key, subscriberSession := range roomSession.Subscribers {
+		if subscriberSession.Plugin != nil {
+			subscriberSession.Plugin.release()
+		}
+		delete(roomSession.Subscribers, key)
+	}
+	for userID, publisherSession := range roomSession.Publishers {
+		migration.publishers = append(migration.publishers, &migratedPublisher{
+			session:     publisherSession,
+			userID:      userID,
+			janusID:     publisherSession.JanusID,
+			display:     publisherSession.Display,
+			audioCodec:  publisherSession.AudioCodec,
+			dataChannel: publisherSession.DataPlugin != nil,
+		})
+
+		if publisherSession.Plugin != nil {
+			publisherSession.Plugin.release()
+			publisherSession.Plugin = nil
+		}
+		if publisherSession.DataPlugin != nil {
+			publisherSession.DataPlugin.release()
+			publisherSession.DataPlugin = nil
+			publisherSession.DataToken = ""
+		}
+		publisherSession.IsPublishing = false
+	}
+
+	// ICE candidate untuk handle lama tidak berlaku di instance baru
+	for key, pending := range sh.pendingCandidates {
+		if pending.RoomID == roomID {
+			delete(sh.pendingCandidates, key)
+		}
+	}
+
+	// Forwarder ikut hilang; forward dilanjutkan saat publisher publish ulang
+	for _, rf := range sh.forwards[roomID] {
+		rf.FeedID = 0
+		rf.StreamIDs = nil
+	}
+
+	roomSession.Client = client
+	sh.rebuildUserHandleIndex()
+
+	return migration
+}
+
+// attach membuat ulang room, text room dan handle setiap publisher di instance
+// baru. Dipanggil tanpa memegang sh.mu dan hanya mengubah state migrasi.
+func (m *roomMigration) attach(sh *SignalingHandler) {
+	logger := sh.logger.WithField("room_id", m.roomID)
+
+	roomPlugin, err := m.client.AttachPlugin(m.options.pluginName())
+	if err != nil {
+		logger.Errorf("Failed to attach room plugin on new Janus instance: %v", err)
+	} else {
+		m.roomPlugin = roomPlugin
+		if err := sh.createJanusRoom(roomPlugin, m.janusRoom, m.roomID, m.options); err != nil {
+			logger.Warnf("Failed to recreate room (might already exist): %v", err)
+		}
+	}
+
+	if m.textRoom {
+		// Room session sementara agar text room dibuat tanpa menyentuh state bersama
+		textRoomSession := &RoomSession{
+			RoomID:    m.roomID,
+			JanusRoom: m.janusRoom,
+			Client:    m.client,
+		}
+		sh.createTextRoom(textRoomSession)
+		m.textRoomPlugin = textRoomSession.TextRoom
+	}
+
+	for _, publisher := range m.publishers {
+		m.attachPublisher(sh, publisher)
+	}
+}
+
+// attachPublisher attach dan join ulang satu publisher dengan beberapa percobaan,
+// lalu menyiapkan data channel-nya jika sebelumnya aktif
+func (m *roomMigration) attachPublisher(sh *SignalingHandler, publisher *migratedPublisher) {
+	logger := sh.logger.WithFields(logrus.Fields{
+		"room_id": m.roomID,
+		"user_id": publisher.userID,
+	})
+
+	attachOptions, err := sh.attachOptions(m.client, publisher.userID)
+	if err != nil {
+		logger.Errorf("Failed to prepare publisher plugin: %v", err)
+		return
+	}
+
+	for attempt := 1; attempt <= migrationJoinAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(migrationRetryDelay)
+		}
+		if err := m.joinPublisher(sh, publisher, attachOptions); err != nil {
+			logger.WithField("attempt", attempt).Errorf("Failed to rejoin publisher on new Janus instance: %v", err)
+			continue
+		}
+		break
+	}
+	if !publisher.joined {
+		return
+	}
+
+	if !publisher.dataChannel || m.textRoomPlugin == nil {
+		return
+	}
+
+	dataPlugin, err := m.client.AttachPluginWithOptions(TextRoomPlugin, attachOptions)
+	if err != nil {
+		logger.Errorf("Failed to attach text room plugin on new Janus instance: %v", err)
+		return
+	}
+	publisher.dataPlugin = dataPlugin
+
+	token, err := newTextRoomToken()
+	if err != nil {
+		logger.Errorf("Failed to generate text room token: %v", err)
+		return
+	}
+	if err := m.textRoomPlugin.AllowTextRoom(m.janusRoom, "add", []string{token}); err != nil {
+		logger.Errorf("Failed to allow text room token: %v", err)
+		return
+	}
+	publisher.dataToken = token
+
+	offer, err := dataPlugin.SetupTextRoom()
+	if err != nil {
+		logger.Errorf("Failed to set up data channel: %v", err)
+		return
+	}
+	publisher.dataOffer = offer
+}
+
+// joinPublisher melakukan satu percobaan attach dan join publisher di instance
+// baru. Handle yang gagal join langsung di-detach agar percobaan berikutnya
+// dimulai dari awal.
+func (m *roomMigration) joinPublisher(sh *SignalingHandler, publisher *migratedPublisher, attachOptions AttachOptions) error {
+	plugin, err := m.client.AttachPluginWithOptions(m.options.pluginName(), attachOptions)
+	if err != nil {
+		return fmt.Errorf("failed to attach publisher plugin: %w", err)
+	}
+
+	if m.options.AudioBridge {
+		joined, err := plugin.JoinAudioBridge(m.janusRoom, publisher.janusID, publisher.display, publisher.audioCodec, false)
+		if err != nil {
+			m.detachFailed(sh, publisher, plugin)
+			return fmt.Errorf("failed to rejoin audio bridge: %w", err)
+		}
+		publisher.participants = joined.Participants
+	} else {
+		joined, err := plugin.JoinVideoRoom(m.janusRoom, publisher.janusID, publisher.display)
+		if err != nil {
+			m.detachFailed(sh, publisher, plugin)
+			return fmt.Errorf("failed to rejoin video room: %w", err)
+		}
+		publisher.privateID = joined.PrivateID
+		publisher.publishers = joined.Publishers
+	}
+
+	publisher.plugin = plugin
+	publisher.joined = true
+	return nil
+}
+
+// detachFailed melepas handle publisher yang gagal join di instance baru
+func (m *roomMigration) detachFailed(sh *SignalingHandler, publisher *migratedPublisher, plugin *PluginHandle) {
+	if err := plugin.DetachPlugin(); err != nil {
+		sh.logger.WithFields(logrus.Fields{
+			"room_id": m.roomID,
+			"user_id": publisher.userID,
+		}).Errorf("Failed to detach publisher plugin after failed rejoin: %v", err)
+	}
+}
+
+// finishMigration memasang handle baru ke room session dan meminta client
+// negosiasi ulang. Handle yang tidak lagi dibutuhkan (room dihapus, dipindahkan
+// lagi, atau publisher keluar selama migrasi) dikembalikan untuk di-detach
+// setelah sh.mu dilepas.
+func (sh *SignalingHandler) finishMigration(m *roomMigration) []*PluginHandle {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession := m.roomSession
+	if sh.RoomSessions[m.roomID] != roomSession || roomSession.Client != m.client {
+		sh.logger.WithField("room_id", m.roomID).Warn("Room changed during migration, discarding new Janus handles")
+		return m.handles()
+	}
+
+	var stale []*PluginHandle
+
+	roomSession.Plugin = m.roomPlugin
+	roomSession.TextRoom = m.textRoomPlugin
+
+	for _, publisher := range m.publishers {
+		publisherSession := roomSession.Publishers[publisher.userID]
+		if publisherSession != publisher.session || publisherSession.Plugin != nil {
+			stale = append(stale, publisher.handles()...)
+			continue
+		}
+
+		// Publisher yang tetap gagal join setelah semua percobaan dikeluarkan dari
+		// room; client harus join ulang
+		if !publisher.joined {
+			stale = append(stale, publisher.handles()...)
+			sh.evictPublisher(roomSession, publisherSession)
+			continue
+		}
+
+		publisherSession.Plugin = publisher.plugin
+		go sh.watchHandle(m.roomID, publisher.userID, 0, publisher.plugin)
+
+		if m.options.AudioBridge {
+			sh.sendMediaEvent(m.roomID, publisher.userID, "session-reset", map[string]interface{}{
+				"janusId":      publisherSession.JanusID,
+				"participants": sh.describeAudioParticipants(roomSession, publisher.participants),
+				"publishers":   []map[string]interface{}{},
+			})
+		} else {
+			publisherSession.PrivateID = publisher.privateID
+			sh.sendMediaEvent(m.roomID, publisher.userID, "session-reset", map[string]interface{}{
+				"janusId":    publisherSession.JanusID,
+				"publishers": sh.describePublishers(roomSession, publisher.publishers),
+			})
+		}
+
+		if publisher.dataPlugin != nil {
+			publisherSession.DataPlugin = publisher.dataPlugin
+			publisherSession.DataToken = publisher.dataToken
+			go sh.watchTextRoom(m.roomID, publisher.userID, publisher.dataPlugin)
+
+			if publisher.dataOffer != nil {
+				sh.sendDataChannelOffer(roomSession, publisherSession, publisher.dataOffer)
+			}
+		}
+	}
+
+	sh.rebuildUserHandleIndex()
+
+	sh.logger.WithField("room_id", m.roomID).Info("Room moved to another Janus instance")
+
+	return stale
+}
+
+// evictPublisher mengeluarkan publisher yang tidak dapat dipulihkan di instance
+// baru dan memberi tahu client dengan media-event "evicted" agar join ulang.
+// Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) evictPublisher(roomSession *RoomSession, publisherSession *PublisherSession) {
+	roomID, userID := roomSession.RoomID, publisherSession.UserID
+
+	delete(roomSession.Publishers, userID)
+	sh.forwardLostLocked(roomID, publisherSession.JanusID)
+
+	for key, pending := range sh.pendingCandidates {
+		if pending.RoomID == roomID && pending.UserID == userID {
+			delete(sh.pendingCandidates, key)
+		}
+	}
+
+	if userSession, exists := sh.UserSessions[userID]; exists {
+		delete(userSession.RoomIDs, roomID)
+		if len(userSession.RoomIDs) == 0 {
+			delete(sh.UserSessions, userID)
+		}
+	}
+
+	sh.sendMediaEvent(roomID, userID, "evicted", map[string]interface{}{
+		"reason": "media-server-failover",
+	})
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+	}).Warn("Publisher evicted after failing to rejoin on new Janus instance")
+}
+
+// handles mengembalikan semua handle yang dibuat migrasi di instance baru
+func (m *roomMigration) handles() []*PluginHandle {
+	var handles []*PluginHandle
+	if m.roomPlugin != nil {
+		handles = append(handles, m.roomPlugin)
+	}
+	if m.textRoomPlugin != nil {
+		handles = append(handles, m.textRoomPlugin)
+	}
+	for _, publisher := range m.publishers {
+		handles = append(handles, publisher.handles()...)
+	}
+	return handles
+}
+
+// handles mengembalikan handle publisher yang dibuat migrasi di instance baru
+func (p *migratedPublisher) handles() []*PluginHandle {
+	var handles []*PluginHandle
+	if p.plugin != nil {
+		handles = append(handles, p.plugin)
+	}
+	if p.dataPlugin != nil {
+		handles = append(handles, p.dataPlugin)
+	}
+	return handles
+}
@@ -0,0 +1,242 @@
+package webrtc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/webrtc/janustest"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// failoverTest adalah pool dua instance janustest dengan satu room berisi alice
+type failoverTest struct {
+	sh          *SignalingHandler
+	hub         *websocket.Hub
+	pool        *JanusPool
+	oldInstance *JanusInstance
+	newInstance *JanusInstance
+	newServer   *janustest.Server
+	oldServer   *janustest.Server
+}
+
+func newFailoverTest(t *testing.T) *failoverTest {
+	t.Helper()
+
+	servers := map[string]*janustest.Server{}
+	var instances []*JanusInstance
+	for _, name := range []string{"janus-1", "janus-2"} {
+		server := janustest.NewServer()
+		t.Cleanup(server.Close)
+		servers[name] = server
+		instances = append(instances, &JanusInstance{Name: name, Client: newHTTPTestClient(t, server)})
+	}
+
+	pool, err := NewJanusPool(instances)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	for _, instance := range instances {
+		pool.checkInstance(instance)
+	}
+
+	sh, hub := newTestSignalingHandler(nil)
+	sh.UsePool(pool)
+
+	if err := sh.HandleJoinRoom("1001", "alice", "Alice"); err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	waitMediaEvent(t, hub, "alice", "joined")
+
+	ft := &failoverTest{sh: sh, hub: hub, pool: pool}
+
+	sh.mu.RLock()
+	ft.oldInstance = pool.InstanceForClient(sh.RoomSessions["1001"].Client)
+	sh.mu.RUnlock()
+
+	for _, instance := range instances {
+		if instance != ft.oldInstance {
+			ft.newInstance = instance
+		}
+	}
+	ft.oldServer = servers[ft.oldInstance.Name]
+	ft.newServer = servers[ft.newInstance.Name]
+
+	return ft
+}
+
+// failOldInstance mematikan instance room dan menjalankan health check sampai
+// failover dipicu, lalu menunggu migrasi mulai attach di instance baru
+func (ft *failoverTest) failOldInstance(t *testing.T) {
+	t.Helper()
+
+	ft.oldServer.Close()
+	for i := 0; i < healthCheckFailureThreshold; i++ {
+		ft.pool.checkInstance(ft.oldInstance)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for countRequests(ft.newServer, "attach") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("room was not migrated to the healthy instance")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFailoverMigratesRoomWithoutHoldingSignalingLock(t *testing.T) {
+	ft := newFailoverTest(t)
+	sh, hub, newInstance, newServer := ft.sh, ft.hub, ft.newInstance, ft.newServer
+
+	// Setiap round-trip ke instance baru lambat, sehingga migrasi berlangsung lama
+	newServer.SetLatency(300*time.Millisecond, 0)
+	ft.failOldInstance(t)
+
+	// Signaling room lain tidak boleh menunggu round-trip migrasi
+	start := time.Now()
+	sh.GetAllStats()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("signaling lock held during migration for %v", elapsed)
+	}
+
+	waitMediaEvent(t, hub, "alice", "session-reset")
+
+	sh.mu.RLock()
+	roomSession := sh.RoomSessions["1001"]
+	publisher := roomSession.Publishers["alice"]
+	sh.mu.RUnlock()
+
+	if roomSession.Client != newInstance.Client {
+		t.Fatal("room session still uses the failed instance")
+	}
+	if publisher.Plugin == nil || publisher.Plugin.SessionID() != newInstance.Client.currentSession() {
+		t.Fatal("publisher handle was not attached on the new instance")
+	}
+
+	room, exists := newServer.Room(roomSession.JanusRoom)
+	if !exists || len(room.Participants) != 1 || room.Participants[0] != publisher.JanusID {
+		t.Fatalf("publisher did not rejoin the room on the new instance: %+v", room)
+	}
+}
+
+func TestFailoverRejectsOfferWhilePublisherReconnects(t *testing.T) {
+	ft := newFailoverTest(t)
+
+	ft.newServer.SetLatency(300*time.Millisecond, 0)
+	ft.failOldInstance(t)
+
+	// Handle publisher belum terpasang selama migrasi berlangsung
+	err := ft.sh.HandleOffer("1001", "alice", "", testOfferSDP)
+	if !errors.Is(err, ErrPublisherReconnecting) {
+		t.Fatalf("expected reconnecting error during migration, got %v", err)
+	}
+
+	waitMediaEvent(t, ft.hub, "alice", "session-reset")
+
+	if err := ft.sh.HandleOffer("1001", "alice", "", testOfferSDP); err != nil {
+		t.Fatalf("offer after migration failed: %v", err)
+	}
+}
+
+func TestFailoverEvictsPublisherWhenRejoinFails(t *testing.T) {
+	ft := newFailoverTest(t)
+
+	// Publisher tidak pernah berhasil join di instance baru
+	ft.newServer.InjectFault(janustest.Fault{
+		Janus:   "message",
+		Request: "join",
+		Code:    janustest.VideoRoomErrorUnauthorized,
+		Reason:  "Unauthorized",
+		Times:   -1,
+	})
+	ft.failOldInstance(t)
+
+	evicted := waitMediaEvent(t, ft.hub, "alice", "evicted")
+	if evicted["reason"] != "media-server-failover" {
+		t.Fatalf("unexpected eviction reason: %+v", evicted)
+	}
+
+	if joins := countPluginRequests(ft.newServer, "join"); joins != migrationJoinAttempts {
+		t.Fatalf("expected %d join attempts, got %d", migrationJoinAttempts, joins)
+	}
+
+	// Offer setelah re-attach gagal harus ditolak tanpa panic
+	if err := ft.sh.HandleOffer("1001", "alice", "", testOfferSDP); err == nil {
+		t.Fatal("offer from evicted publisher was accepted")
+	}
+
+	ft.sh.mu.RLock()
+	_, userExists := ft.sh.UserSessions["alice"]
+	roomSession := ft.sh.RoomSessions["1001"]
+	ft.sh.mu.RUnlock()
+
+	if userExists || len(roomSession.Publishers) != 0 {
+		t.Fatal("evicted publisher is still part of the room")
+	}
+
+	// Hanya handle room yang tersisa di instance baru
+	deadline := time.Now().Add(2 * time.Second)
+	for len(ft.newServer.Handles()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("failed publisher handles were not detached: %+v", ft.newServer.Handles())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// User dapat join ulang setelah janus menerima join lagi
+	ft.newServer.ClearFaults()
+	if err := ft.sh.HandleJoinRoom("1001", "alice", "Alice"); err != nil {
+		t.Fatalf("rejoin after eviction failed: %v", err)
+	}
+	waitMediaEvent(t, ft.hub, "alice", "joined")
+}
+
+func TestJanusPoolStartsWithUnreachableInstance(t *testing.T) {
+	server := janustest.NewServer()
+	defer server.Close()
+
+	instances, err := ParseJanusInstances("ws://127.0.0.1:1/janus,"+server.WSURL, "", "")
+	if err != nil {
+		t.Fatalf("unreachable instance must not fail parsing: %v", err)
+	}
+	for _, instance := range instances {
+		if instance.Healthy {
+			t.Fatalf("instance %s healthy before health check", instance.Name)
+		}
+	}
+
+	pool, err := NewJanusPool(instances)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	pool.Start(time.Hour)
+
+	if instances[0].Healthy || !instances[1].Healthy {
+		t.Fatalf("unexpected health after start: %v %v", instances[0].Healthy, instances[1].Healthy)
+	}
+
+	client, err := pool.ClientForRoom("1001", nil)
+	if err != nil {
+		t.Fatalf("no client for room: %v", err)
+	}
+	if client != instances[1].Client {
+		t.Fatal("room assigned to the unreachable instance")
+	}
+}
+
+// countPluginRequests menghitung request plugin dengan nama tertentu di fake server
+func countPluginRequests(server *janustest.Server, name string) int {
+	count := 0
+	for _, request := range server.Requests() {
+		var body struct {
+			Request string `json:"request"`
+		}
+		if request.Janus == "message" && json.Unmarshal(request.Body, &body) == nil && body.Request == name {
+			count++
+		}
+	}
+	return count
+}
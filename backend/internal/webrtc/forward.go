@@ -0,0 +1,360 @@
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// Jeda minimum sebelum RTP forward active-speaker berpindah publisher lagi,
+// agar forward tidak berpindah-pindah ketika beberapa orang berbicara bersamaan
+const activeSpeakerHoldTime = 3 * time.Second
+
+// rtpForward adalah satu RTP forwarding aktif sebuah room. Codec ditetapkan saat forward
+// dimulai karena penerima (ffmpeg) membaca SDP sekali; publisher pengganti harus
+// memakai codec yang sama.
+type rtpForward struct {
+	websocket.RTPForward
+
+	AudioCodec string
+	VideoCodec string
+
+	// Publisher yang sedang diteruskan (FeedID 0 = menunggu publisher publish)
+	UserID     string
+	FeedID     uint64
+	StreamIDs  []uint64
+	SwitchedAt time.Time
+}
+
+// status mengembalikan status forward untuk API server
+func (rf *rtpForward) status() *websocket.RTPForwardStatus {
+	return &websocket.RTPForwardStatus{
+		UserID:     rf.UserID,
+		AudioCodec: rf.AudioCodec,
+		VideoCodec: rf.VideoCodec,
+	}
+}
+
+// StartRTPForward mulai meneruskan media publisher room sebagai RTP ke host UDP.
+// Mode publisher meneruskan satu user; mode active-speaker mulai dari publisher
+// paling awal lalu mengikuti event talking Janus.
+func (sh *SignalingHandler) StartRTPForward(roomID string, forward websocket.RTPForward) (*websocket.RTPForwardStatus, error) {
+	if forward.Host == "" || (forward.AudioPort == 0 && forward.VideoPort == 0) {
+		return nil, fmt.Errorf("forward host and port are required")
+	}
+	if !sh.forwardHostAllowed(forward.Host) {
+		return nil, fmt.Errorf("%w: %s", websocket.ErrForwardHostNotAllowed, forward.Host)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists || roomSession.Plugin == nil {
+		return nil, fmt.Errorf("%w: room %s", mediaplane.ErrParticipantNotFound, roomID)
+	}
+
+	if roomSession.Options.AudioBridge {
+		return nil, errAudioBridgeUnsupported("rtp forwarding", roomID)
+	}
+
+	var publisherSession *PublisherSession
+	switch forward.Mode {
+	case websocket.RTPForwardPublisher:
+		publisherSession = roomSession.Publishers[forward.UserID]
+		if publisherSession == nil || !publisherSession.IsPublishing {
+			return nil, fmt.Errorf("%w: user %s is not publishing", mediaplane.ErrParticipantNotFound, forward.UserID)
+		}
+	case websocket.RTPForwardActiveSpeaker:
+		publisherSession = sh.firstPublisher(roomSession, 0)
+		if publisherSession == nil {
+			return nil, fmt.Errorf("%w: no publisher in room %s", mediaplane.ErrParticipantNotFound, roomID)
+		}
+	default:
+		return nil, fmt.Errorf("unknown forward mode: %s", forward.Mode)
+	}
+
+	// Forward lama dengan ID yang sama diganti
+	if previous, exists := sh.forwards[roomID][forward.ID]; exists {
+		sh.stopForwarders(roomSession, previous)
+		delete(sh.forwards[roomID], forward.ID)
+	}
+
+	rf := &rtpForward{
+		RTPForward: forward,
+		AudioCodec: publisherSession.AudioCodec,
+		VideoCodec: publisherSession.VideoCodec,
+	}
+	if forward.AudioPort == 0 {
+		rf.AudioCodec = ""
+	}
+	if forward.VideoPort == 0 {
+		rf.VideoCodec = ""
+	}
+
+	if err := sh.forwardPublisher(roomSession, rf, publisherSession); err != nil {
+		return nil, err
+	}
+	if sh.forwards[roomID] == nil {
+		sh.forwards[roomID] = make(map[string]*rtpForward)
+	}
+	sh.forwards[roomID][forward.ID] = rf
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":     roomID,
+		"forward_id":  forward.ID,
+		"mode":        forward.Mode,
+		"user_id":     rf.UserID,
+		"host":        forward.Host,
+		"audio_codec": rf.AudioCodec,
+		"video_codec": rf.VideoCodec,
+	}).Info("Started RTP forward")
+
+	return rf.status(), nil
+}
+
+// forwardHostAllowed memastikan media hanya diteruskan ke host yang dikonfigurasi,
+// bukan ke host sembarang yang dikirim melalui endpoint internal
+func (sh *SignalingHandler) forwardHostAllowed(host string) bool {
+	for _, allowed := range sh.ForwardHosts {
+		if strings.EqualFold(strings.TrimSpace(allowed), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// StopRTPForward menghentikan satu RTP forwarding room
+func (sh *SignalingHandler) StopRTPForward(roomID, forwardID string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	rf, exists := sh.forwards[roomID][forwardID]
+	if !exists {
+		return nil
+	}
+	delete(sh.forwards[roomID], forwardID)
+	if len(sh.forwards[roomID]) == 0 {
+		delete(sh.forwards, roomID)
+	}
+
+	if roomSession, exists := sh.RoomSessions[roomID]; exists {
+		sh.stopForwarders(roomSession, rf)
+	}
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":    roomID,
+		"forward_id": forwardID,
+	}).Info("Stopped RTP forward")
+
+	return nil
+}
+
+// forwardPublisher meneruskan media publisher ke tujuan forward. Harus dipanggil dengan lock.
+func (sh *SignalingHandler) forwardPublisher(roomSession *RoomSession, rf *rtpForward, publisherSession *PublisherSession) error {
+	if !sameCodec(rf.AudioCodec, publisherSession.AudioCodec) || !sameCodec(rf.VideoCodec, publisherSession.VideoCodec) {
+		return fmt.Errorf("publisher %s uses %s/%s, forward requires %s/%s", publisherSession.UserID,
+			publisherSession.AudioCodec, publisherSession.VideoCodec, rf.AudioCodec, rf.VideoCodec)
+	}
+
+	request := VideoRoomRTPForwardRequest{
+		Room:        roomSession.JanusRoom,
+		PublisherID: publisherSession.JanusID,
+		Host:        rf.Host,
+	}
+	if payload, ok := websocket.RTPPayloads[rf.AudioCodec]; ok {
+		request.AudioPort = rf.AudioPort
+		request.AudioPT = payload.Type
+		request.AudioSSRC = rf.AudioSSRC
+	}
+	if payload, ok := websocket.RTPPayloads[rf.VideoCodec]; ok {
+		request.VideoPort = rf.VideoPort
+		request.VideoPT = payload.Type
+		request.VideoSSRC = rf.VideoSSRC
+	}
+	if request.AudioPort == 0 && request.VideoPort == 0 {
+		return fmt.Errorf("publisher %s has no forwardable media (%s/%s)", publisherSession.UserID,
+			publisherSession.AudioCodec, publisherSession.VideoCodec)
+	}
+
+	streamIDs, err := roomSession.Plugin.RTPForward(request)
+	if err != nil {
+		return err
+	}
+
+	rf.UserID = publisherSession.UserID
+	rf.FeedID = publisherSession.JanusID
+	rf.StreamIDs = streamIDs
+	rf.SwitchedAt = time.Now()
+
+	return nil
+}
+
+// stopForwarders menghentikan forwarder Janus milik forward. Harus dipanggil dengan lock.
+func (sh *SignalingHandler) stopForwarders(roomSession *RoomSession, rf *rtpForward) {
+	if roomSession.Plugin != nil {
+		for _, streamID := range rf.StreamIDs {
+			if err := roomSession.Plugin.StopRTPForward(roomSession.JanusRoom, rf.FeedID, streamID); err != nil {
+				sh.logger.WithField("room_id", roomSession.RoomID).Warnf("Failed to stop RTP forwarder: %v", err)
+			}
+		}
+	}
+
+	rf.FeedID = 0
+	rf.StreamIDs = nil
+}
+
+// switchActiveSpeaker memindahkan forward active-speaker room ke publisher yang sedang berbicara
+func (sh *SignalingHandler) switchActiveSpeaker(roomID string, feedID uint64) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists || len(sh.forwards[roomID]) == 0 {
+		return
+	}
+
+	var speaker *PublisherSession
+	for _, publisherSession := range roomSession.Publishers {
+		if publisherSession.JanusID == feedID && publisherSession.IsPublishing {
+			speaker = publisherSession
+			break
+		}
+	}
+	if speaker == nil {
+		return
+	}
+
+	for forwardID, rf := range sh.forwards[roomID] {
+		if rf.Mode != websocket.RTPForwardActiveSpeaker || rf.FeedID == feedID {
+			continue
+		}
+		if rf.FeedID != 0 && time.Since(rf.SwitchedAt) < activeSpeakerHoldTime {
+			continue
+		}
+		if !sameCodec(rf.AudioCodec, speaker.AudioCodec) || !sameCodec(rf.VideoCodec, speaker.VideoCodec) {
+			continue
+		}
+
+		sh.stopForwarders(roomSession, rf)
+		if err := sh.forwardPublisher(roomSession, rf, speaker); err != nil {
+			sh.logger.WithField("room_id", roomID).Errorf("Failed to forward active speaker: %v", err)
+			continue
+		}
+
+		sh.logger.WithFields(logrus.Fields{
+			"room_id":    roomID,
+			"forward_id": forwardID,
+			"user_id":    speaker.UserID,
+		}).Debug("RTP forward switched to active speaker")
+	}
+}
+
+// forwardLost dipanggil ketika publisher berhenti publish atau keluar. Janus sudah
+// menghapus forwarder-nya; mode active-speaker langsung pindah ke publisher lain.
+func (sh *SignalingHandler) forwardLost(roomID string, feedID uint64) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.forwardLostLocked(roomID, feedID)
+}
+
+// forwardLostLocked sama dengan forwardLost tetapi harus dipanggil dengan lock
+func (sh *SignalingHandler) forwardLostLocked(roomID string, feedID uint64) {
+	if feedID == 0 {
+		return
+	}
+
+	roomSession := sh.RoomSessions[roomID]
+	for _, rf := range sh.forwards[roomID] {
+		if rf.FeedID != feedID {
+			continue
+		}
+
+		rf.FeedID = 0
+		rf.StreamIDs = nil
+
+		if roomSession == nil || rf.Mode != websocket.RTPForwardActiveSpeaker {
+			continue
+		}
+
+		if next := sh.firstPublisher(roomSession, feedID, rf.AudioCodec, rf.VideoCodec); next != nil {
+			if err := sh.forwardPublisher(roomSession, rf, next); err != nil {
+				sh.logger.WithField("room_id", roomID).Errorf("Failed to forward next publisher: %v", err)
+			}
+		}
+	}
+}
+
+// resumeForward meneruskan publisher yang baru (ulang) publish ke forward room yang
+// sedang menunggu publisher. Harus dipanggil dengan lock.
+func (sh *SignalingHandler) resumeForward(roomSession *RoomSession, publisherSession *PublisherSession) {
+	for _, rf := range sh.forwards[roomSession.RoomID] {
+		if rf.FeedID != 0 {
+			continue
+		}
+		if rf.Mode == websocket.RTPForwardPublisher && rf.UserID != publisherSession.UserID {
+			continue
+		}
+
+		if err := sh.forwardPublisher(roomSession, rf, publisherSession); err != nil {
+			sh.logger.WithField("room_id", roomSession.RoomID).Warnf("Failed to resume RTP forward: %v", err)
+		}
+	}
+}
+
+// firstPublisher mengembalikan publisher aktif paling awal selain feed exclude.
+// Jika codec diberikan (audio, video), hanya publisher dengan codec yang sama dipilih.
+func (sh *SignalingHandler) firstPublisher(roomSession *RoomSession, exclude uint64, codecs ...string) *PublisherSession {
+	var first *PublisherSession
+	for _, publisherSession := range roomSession.Publishers {
+		if !publisherSession.IsPublishing || publisherSession.JanusID == exclude {
+			continue
+		}
+		if len(codecs) == 2 && (!sameCodec(codecs[0], publisherSession.AudioCodec) || !sameCodec(codecs[1], publisherSession.VideoCodec)) {
+			continue
+		}
+		if first == nil || publisherSession.CreatedAt.Before(first.CreatedAt) {
+			first = publisherSession
+		}
+	}
+	return first
+}
+
+// sameCodec memeriksa codec publisher terhadap codec forward (kosong = media tidak diteruskan)
+func sameCodec(forwarded, published string) bool {
+	return forwarded == "" || strings.EqualFold(forwarded, published)
+}
+
+// sdpCodecs mengembalikan codec utama (payload type pertama) setiap m-line aktif
+// pada SDP, misalnya {"audio": "opus", "video": "vp8"}
+func sdpCodecs(sdp string) map[string]string {
+	codecs := make(map[string]string)
+
+	kind, payloadType := "", ""
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "m="):
+			// m=<media> <port> <proto> <fmt> ...; port 0 berarti m-line ditolak
+			fields := strings.Fields(strings.TrimPrefix(line, "m="))
+			kind, payloadType = "", ""
+			if len(fields) >= 4 && fields[1] != "0" {
+				kind, payloadType = fields[0], fields[3]
+			}
+		case kind != "" && strings.HasPrefix(line, "a=rtpmap:"+payloadType+" "):
+			encoding := strings.TrimPrefix(line, "a=rtpmap:"+payloadType+" ")
+			if _, exists := codecs[kind]; !exists {
+				codecs[kind] = strings.ToLower(strings.SplitN(encoding, "/", 2)[0])
+			}
+		}
+	}
+
+	return codecs
+}
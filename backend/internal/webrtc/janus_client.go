@@ -1,8 +1,8 @@
 package webrtc
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -12,6 +12,24 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// Batas waktu menunggu balasan dari Janus
+	janusRequestTimeout = 30 * time.Second
+
+	// Ukuran buffer event per plugin handle
+	pluginEventBufferSize = 64
+)
+
+// ErrJanusDisconnected dikembalikan request yang balasannya hilang karena koneksi
+// ke Janus terputus
+var ErrJanusDisconnected = errors.New("janus connection lost")
+
+// Kode error Janus yang ditangani secara khusus
+const (
+	JanusErrorSessionNotFound = 458
+	JanusErrorHandleNotFound  = 459
+)
+
 // JanusClient adalah client untuk berkomunikasi dengan Janus WebRTC server
 type JanusClient struct {
 	// URL Janus server
@@ -35,6 +53,27 @@ type JanusClient struct {
 	// Plugin handles yang aktif
 	PluginHandles map[uint64]*PluginHandle
 
+	// Transport untuk berkomunikasi dengan Janus
+	transport Transport
+
+	// Request yang sedang menunggu balasan, dikelompokkan per transaction
+	pending map[string]*pendingRequest
+
+	// Mutex untuk pending requests
+	pendingMu sync.Mutex
+
+	// Mutex agar pembuatan ulang session tidak berjalan bersamaan
+	recreateMu sync.Mutex
+
+	// Callback setelah session dibuat ulang
+	onSessionRecreated func(oldSessionID, newSessionID uint64)
+
+	// Penerbit token untuk handle yang di-attach ulang (nil = tanpa token)
+	tokenIssuer func(opaqueID string) (string, error)
+
+	// Channel untuk menghentikan keepalive loop
+	stopKeepalive chan struct{}
+
 	// Mutex untuk thread safety
 	mu sync.RWMutex
 
@@ -42,23 +81,71 @@ type JanusClient struct {
 	logger *logrus.Logger
 }
 
+// pendingRequest adalah request yang menunggu balasan dari Janus
+type pendingRequest struct {
+	response  chan *JanusResponse
+	failed    chan error
+	waitEvent bool
+}
+
 // PluginHandle merepresentasikan handle ke plugin Janus
 type PluginHandle struct {
-	ID        uint64
-	Plugin    string
-	SessionID uint64
-	Client    *JanusClient
+	Plugin string
+	Client *JanusClient
+
+	// OpaqueID adalah pengenal bebas yang terlihat di Admin API (misalnya user ID)
+	OpaqueID string
+
+	// id, sessionID dan token (stored token Janus untuk attach dan join room)
+	// diganti ketika session dibuat ulang, sehingga hanya dibaca melalui ID,
+	// SessionID dan Token
+	idMu      sync.RWMutex
+	id        uint64
+	sessionID uint64
+	token     string
+
+	// Events menerima event asinkron Janus untuk handle ini
+	// (event plugin, JSEP, webrtcup, media, hangup, dll)
+	Events chan *JanusResponse
+
+	eventsMu     sync.Mutex
+	eventsClosed bool
 }
 
 // JanusResponse adalah struktur response dari Janus
 type JanusResponse struct {
-	Janus       string      `json:"janus"`
-	Transaction string      `json:"transaction"`
-	SessionID   uint64      `json:"session_id,omitempty"`
-	HandleID    uint64      `json:"handle_id,omitempty"`
-	Plugin      string      `json:"plugin,omitempty"`
-	Data        interface{} `json:"data,omitempty"`
-	Error       *JanusError `json:"error,omitempty"`
+	Janus       string          `json:"janus"`
+	Transaction string          `json:"transaction"`
+	SessionID   uint64          `json:"session_id,omitempty"`
+	HandleID    uint64          `json:"handle_id,omitempty"`
+	Sender      uint64          `json:"sender,omitempty"`
+	Plugin      string          `json:"plugin,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	PluginData  *PluginData     `json:"plugindata,omitempty"`
+	Jsep        *JSEP           `json:"jsep,omitempty"`
+	Candidate   *ICECandidate   `json:"candidate,omitempty"`
+	Error       *JanusError     `json:"error,omitempty"`
+
+	// Field tambahan untuk event media
+	Type      string `json:"type,omitempty"`
+	Receiving *bool  `json:"receiving,omitempty"`
+	Uplink    *bool  `json:"uplink,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// AttachOptions adalah opsi tambahan untuk attach plugin
+type AttachOptions struct {
+	// OpaqueID agar handle dapat ditemukan kembali melalui Admin API
+	OpaqueID string
+
+	// Token stored token Janus yang membatasi akses ke plugin tertentu
+	Token string
+}
+
+// PluginData adalah data yang dikirim oleh plugin Janus
+type PluginData struct {
+	Plugin string          `json:"plugin"`
+	Data   json.RawMessage `json:"data"`
 }
 
 // JanusError adalah struktur error dari Janus
@@ -67,6 +154,22 @@ type JanusError struct {
 	Reason string `json:"reason"`
 }
 
+// Error mengimplementasikan interface error
+func (e *JanusError) Error() string {
+	return fmt.Sprintf("janus error %d: %s", e.Code, e.Reason)
+}
+
+// PluginError adalah error yang dikembalikan oleh plugin Janus
+type PluginError struct {
+	Code   int    `json:"error_code"`
+	Reason string `json:"error"`
+}
+
+// Error mengimplementasikan interface error
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Reason)
+}
+
 // JanusRequest adalah struktur request ke Janus
 type JanusRequest struct {
 	Janus       string      `json:"janus"`
@@ -74,8 +177,13 @@ type JanusRequest struct {
 	SessionID   uint64      `json:"session_id,omitempty"`
 	HandleID    uint64      `json:"handle_id,omitempty"`
 	Plugin      string      `json:"plugin,omitempty"`
+	OpaqueID    string      `json:"opaque_id,omitempty"`
+	APISecret   string      `json:"apisecret,omitempty"`
+	Token       string      `json:"token,omitempty"`
 	Body        interface{} `json:"body,omitempty"`
 	Jsep        interface{} `json:"jsep,omitempty"`
+	Candidate   interface{} `json:"candidate,omitempty"`
+	Candidates  interface{} `json:"candidates,omitempty"`
 }
 
 // SessionCreateResponse adalah response untuk session create
@@ -109,6 +217,95 @@ type VideoRoomCreateRequest struct {
 	Record      bool     `json:"record,omitempty"`
 	RecDir      string   `json:"rec_dir,omitempty"`
 	Allowed     []string `json:"allowed,omitempty"`
+	VideoCodec  string   `json:"videocodec,omitempty"`
+	VP9Profile  string   `json:"vp9_profile,omitempty"`
+	H264Profile string   `json:"h264_profile,omitempty"`
+	VideoSVC    bool     `json:"video_svc,omitempty"`
+
+	BitrateCap         bool   `json:"bitrate_cap,omitempty"`
+	FirFreq            int    `json:"fir_freq,omitempty"`
+	AudioCodec         string `json:"audiocodec,omitempty"`
+	AudioLevelEvent    bool   `json:"audiolevel_event,omitempty"`
+	AudioActivePackets int    `json:"audio_active_packets,omitempty"`
+	AudioLevelAverage  int    `json:"audio_level_average,omitempty"`
+}
+
+// VideoRoomOptions adalah parameter media yang dipakai saat membuat video room
+type VideoRoomOptions struct {
+	// Codec video yang diizinkan, urut prioritas (misalnya "vp8,vp9,h264")
+	VideoCodec string
+
+	// Profil VP9 dan H.264 yang dinegosiasikan (kosong = bebas)
+	VP9Profile  string
+	H264Profile string
+
+	// Publisher diminta mengirim simulcast. Janus menerima simulcast VP8/H.264
+	// tanpa opsi room tambahan, flag ini diteruskan ke browser saat join.
+	Simulcast bool
+
+	// Aktifkan VP9-SVC (opsi room Janus 0.x, diabaikan Janus 1.x yang mendeteksi SVC otomatis)
+	VideoSVC bool
+
+	// Batas bitrate video per publisher dalam bit/detik (0 = tanpa batas)
+	Bitrate uint64
+
+	// Codec audio yang diizinkan, urut prioritas (kosong = default Janus, opus)
+	AudioCodec string
+
+	// Interval permintaan keyframe berkala ke publisher dalam detik (0 = nonaktif)
+	FirFreq int
+
+	// Event talking/stopped-talking beserta parameter deteksinya (0 = default Janus)
+	AudioLevelEvent    bool
+	AudioActivePackets int
+	AudioLevelAverage  int
+
+	// Rekam semua publisher ke file .mjr di RecDir (path di host Janus)
+	Record bool
+	RecDir string
+
+	// Room dilayani plugin audiobridge (audio di-mix Janus) alih-alih videoroom.
+	// Plugin tidak dapat diganti selama room berjalan.
+	AudioBridge bool
+
+	// Room juga memiliki text room Janus untuk data channel peserta (chat, reaksi,
+	// kursor). Hanya berlaku untuk room baru.
+	DataChannels bool
+}
+
+// DefaultVideoRoomOptions mengembalikan opsi room default: simulcast aktif
+// dengan VP8 sebagai codec utama dan VP9 (SVC) sebagai alternatif
+func DefaultVideoRoomOptions() VideoRoomOptions {
+	return VideoRoomOptions{
+		VideoCodec: "vp8,vp9,h264",
+		Simulcast:  true,
+	}
+}
+
+// VideoRoomEditRequest adalah request untuk mengubah parameter video room yang sedang berjalan.
+// Field nil tidak diubah oleh Janus.
+type VideoRoomEditRequest struct {
+	Request    string  `json:"request"`
+	Room       uint64  `json:"room"`
+	Secret     string  `json:"secret,omitempty"`
+	NewBitrate *uint64 `json:"new_bitrate,omitempty"`
+	NewFirFreq *int    `json:"new_fir_freq,omitempty"`
+	NewRecDir  string  `json:"new_rec_dir,omitempty"`
+}
+
+// VideoRoomEnableRecordingRequest adalah request untuk mulai/berhenti merekam semua publisher room
+type VideoRoomEnableRecordingRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	Secret  string `json:"secret,omitempty"`
+	Record  bool   `json:"record"`
+}
+
+// VideoRoomDestroyRequest adalah request untuk menghapus video room
+type VideoRoomDestroyRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	Secret  string `json:"secret,omitempty"`
 }
 
 // VideoRoomJoinRequest adalah request untuk join video room
@@ -129,12 +326,132 @@ type VideoRoomPublishRequest struct {
 	Data    bool   `json:"data,omitempty"`
 }
 
-// VideoRoomSubscribeRequest adalah request untuk subscribe video room
+// VideoRoomSubscribeRequest adalah request untuk join video room sebagai subscriber
 type VideoRoomSubscribeRequest struct {
-	Request string                 `json:"request"`
-	Room    uint64                 `json:"room"`
-	Feed    uint64                 `json:"feed"`
-	Jsep    map[string]interface{} `json:"jsep,omitempty"`
+	Request   string `json:"request"`
+	Room      uint64 `json:"room"`
+	PTYPE     string `json:"ptype"`
+	Feed      uint64 `json:"feed"`
+	PrivateID uint64 `json:"private_id,omitempty"`
+}
+
+// VideoRoomStartRequest adalah request untuk memulai aliran media ke subscriber
+type VideoRoomStartRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room,omitempty"`
+}
+
+// VideoRoomKickRequest adalah request untuk mengeluarkan peserta dari video room
+type VideoRoomKickRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	ID      uint64 `json:"id"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// VideoRoomModerateRequest adalah request untuk mute/unmute m-line milik publisher
+type VideoRoomModerateRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	ID      uint64 `json:"id"`
+	Mid     string `json:"mid"`
+	Mute    bool   `json:"mute"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// VideoLayer adalah pilihan layer video untuk subscriber. Substream/Temporal
+// berlaku untuk simulcast, SpatialLayer/TemporalLayer untuk VP9-SVC.
+// Field nil tidak diubah oleh Janus.
+type VideoLayer struct {
+	Substream     *int `json:"substream,omitempty"`
+	Temporal      *int `json:"temporal,omitempty"`
+	SpatialLayer  *int `json:"spatial_layer,omitempty"`
+	TemporalLayer *int `json:"temporal_layer,omitempty"`
+}
+
+// VideoRoomConfigureRequest adalah request configure untuk subscriber
+type VideoRoomConfigureRequest struct {
+	Request string `json:"request"`
+	VideoLayer
+
+	// Batas bitrate publisher (hanya untuk handle publisher)
+	Bitrate *uint64 `json:"bitrate,omitempty"`
+}
+
+// VideoRoomUnpublishRequest adalah request untuk berhenti publish tanpa keluar dari room
+type VideoRoomUnpublishRequest struct {
+	Request string `json:"request"`
+}
+
+// VideoRoomRTPForwardRequest adalah request untuk meneruskan media publisher sebagai RTP
+// ke host UDP. Memakai parameter audio_port/video_port yang diterima Janus 0.x dan 1.x.
+type VideoRoomRTPForwardRequest struct {
+	Request     string `json:"request"`
+	Room        uint64 `json:"room"`
+	PublisherID uint64 `json:"publisher_id"`
+	Host        string `json:"host"`
+	AudioPort   int    `json:"audio_port,omitempty"`
+	AudioPT     int    `json:"audio_pt,omitempty"`
+	AudioSSRC   uint32 `json:"audio_ssrc,omitempty"`
+	VideoPort   int    `json:"video_port,omitempty"`
+	VideoPT     int    `json:"video_pt,omitempty"`
+	VideoSSRC   uint32 `json:"video_ssrc,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+// VideoRoomStopRTPForwardRequest adalah request untuk menghentikan satu RTP forwarder
+type VideoRoomStopRTPForwardRequest struct {
+	Request     string `json:"request"`
+	Room        uint64 `json:"room"`
+	PublisherID uint64 `json:"publisher_id"`
+	StreamID    uint64 `json:"stream_id"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+// VideoRoomRTPForwardResponse adalah response rtp_forward. Janus 0.x mengembalikan
+// rtp_stream, Janus 1.x mengembalikan daftar forwarders.
+type VideoRoomRTPForwardResponse struct {
+	RTPStream *struct {
+		AudioStreamID uint64 `json:"audio_stream_id,omitempty"`
+		VideoStreamID uint64 `json:"video_stream_id,omitempty"`
+	} `json:"rtp_stream,omitempty"`
+	Forwarders []struct {
+		StreamID uint64 `json:"stream_id"`
+	} `json:"forwarders,omitempty"`
+}
+
+// VideoRoomEvent adalah data event dari plugin videoroom
+type VideoRoomEvent struct {
+	VideoRoom   string               `json:"videoroom"`
+	Room        uint64               `json:"room,omitempty"`
+	ID          uint64               `json:"id,omitempty"`
+	PrivateID   uint64               `json:"private_id,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Publishers  []VideoRoomPublisher `json:"publishers,omitempty"`
+	Leaving     json.RawMessage      `json:"leaving,omitempty"`
+	Unpublished json.RawMessage      `json:"unpublished,omitempty"`
+	Kicked      json.RawMessage      `json:"kicked,omitempty"`
+	Reason      string               `json:"reason,omitempty"`
+	Moderation  string               `json:"moderation,omitempty"`
+	Mid         string               `json:"mid,omitempty"`
+
+	// Layer yang sedang diterima subscriber (dikirim Janus saat layer berpindah)
+	Substream     *int `json:"substream,omitempty"`
+	Temporal      *int `json:"temporal,omitempty"`
+	SpatialLayer  *int `json:"spatial_layer,omitempty"`
+	TemporalLayer *int `json:"temporal_layer,omitempty"`
+
+	Configured string `json:"configured,omitempty"`
+	Started    string `json:"started,omitempty"`
+}
+
+// VideoRoomPublisher adalah informasi publisher di video room
+type VideoRoomPublisher struct {
+	ID         uint64 `json:"id"`
+	Display    string `json:"display,omitempty"`
+	AudioCodec string `json:"audio_codec,omitempty"`
+	VideoCodec string `json:"video_codec,omitempty"`
+	Talking    bool   `json:"talking,omitempty"`
 }
 
 // JSEP adalah struktur untuk WebRTC session description
@@ -143,10 +460,34 @@ type JSEP struct {
 	SDP  string `json:"sdp"`
 }
 
-// NewJanusClient membuat instance JanusClient baru
+// NewJanusClient membuat instance JanusClient baru dengan transport HTTP
 func NewJanusClient(baseURL, adminURL, apiSecret, adminSecret string) *JanusClient {
+	transport := NewHTTPTransport(baseURL)
+	transport.APISecret = apiSecret
+
+	jc := newJanusClient(transport, adminURL, apiSecret, adminSecret)
+	jc.BaseURL = baseURL
+
+	// Transport HTTP tidak membuka koneksi saat start, jadi tidak akan gagal
+	jc.transport.Start(jc.handleMessage, jc.handleDisconnect)
+
+	return jc
+}
+
+// NewJanusClientWithTransport membuat instance JanusClient dengan transport tertentu
+func NewJanusClientWithTransport(transport Transport, adminURL, apiSecret, adminSecret string) (*JanusClient, error) {
+	jc := newJanusClient(transport, adminURL, apiSecret, adminSecret)
+
+	if err := transport.Start(jc.handleMessage, jc.handleDisconnect); err != nil {
+		return nil, fmt.Errorf("failed to start janus transport: %w", err)
+	}
+
+	return jc, nil
+}
+
+// newJanusClient membuat JanusClient tanpa memulai transport
+func newJanusClient(transport Transport, adminURL, apiSecret, adminSecret string) *JanusClient {
 	return &JanusClient{
-		BaseURL:     baseURL,
 		AdminURL:    adminURL,
 		APISecret:   apiSecret,
 		AdminSecret: adminSecret,
@@ -154,38 +495,30 @@ func NewJanusClient(baseURL, adminURL, apiSecret, adminSecret string) *JanusClie
 			Timeout: 30 * time.Second,
 		},
 		PluginHandles: make(map[uint64]*PluginHandle),
+		transport:     transport,
+		pending:       make(map[string]*pendingRequest),
 		logger:        logrus.New(),
 	}
 }
 
 // CreateSession membuat session baru dengan Janus server
 func (jc *JanusClient) CreateSession() (uint64, error) {
-	transaction := uuid.New().String()
-
 	request := JanusRequest{
-		Janus:       "create",
-		Transaction: transaction,
-	}
-
-	if jc.APISecret != "" {
-		// TODO: Add API secret to request
+		Janus: "create",
 	}
 
-	resp, err := jc.makeRequest(request)
+	resp, err := jc.request(request, false)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create session: %w", err)
 	}
 
 	if resp.Janus != "success" {
-		if resp.Error != nil {
-			return 0, fmt.Errorf("janus error: %s", resp.Error.Reason)
-		}
 		return 0, fmt.Errorf("unexpected response: %s", resp.Janus)
 	}
 
 	// Parse session ID dari response
 	var sessionResp SessionCreateResponse
-	if err := json.Unmarshal(resp.Data.([]byte), &sessionResp); err != nil {
+	if err := json.Unmarshal(resp.Data, &sessionResp.Data); err != nil {
 		return 0, fmt.Errorf("failed to parse session response: %w", err)
 	}
 
@@ -193,271 +526,740 @@ func (jc *JanusClient) CreateSession() (uint64, error) {
 	jc.SessionID = sessionResp.Data.ID
 	jc.mu.Unlock()
 
-	jc.logger.WithField("session_id", jc.SessionID).Info("Created Janus session")
+	jc.logger.WithField("session_id", sessionResp.Data.ID).Info("Created Janus session")
 
-	return jc.SessionID, nil
+	return sessionResp.Data.ID, nil
 }
 
 // AttachPlugin men-attach plugin ke session
 func (jc *JanusClient) AttachPlugin(pluginName string) (*PluginHandle, error) {
-	if jc.SessionID == 0 {
+	return jc.AttachPluginWithOptions(pluginName, AttachOptions{})
+}
+
+// AttachPluginWithOptions men-attach plugin ke session dengan opaque ID dan/atau token
+func (jc *JanusClient) AttachPluginWithOptions(pluginName string, options AttachOptions) (*PluginHandle, error) {
+	if jc.currentSession() == 0 {
 		return nil, fmt.Errorf("no active session")
 	}
 
-	transaction := uuid.New().String()
-
 	request := JanusRequest{
-		Janus:       "attach",
-		Transaction: transaction,
-		SessionID:   jc.SessionID,
-		Plugin:      pluginName,
+		Janus:    "attach",
+		Plugin:   pluginName,
+		OpaqueID: options.OpaqueID,
+		Token:    options.Token,
 	}
 
-	resp, err := jc.makeRequest(request)
+	resp, err := jc.sessionRequest(nil, request, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to attach plugin: %w", err)
 	}
 
 	if resp.Janus != "success" {
-		if resp.Error != nil {
-			return nil, fmt.Errorf("janus error: %s", resp.Error.Reason)
-		}
 		return nil, fmt.Errorf("unexpected response: %s", resp.Janus)
 	}
 
 	// Parse handle ID dari response
 	var attachResp PluginAttachResponse
-	if err := json.Unmarshal(resp.Data.([]byte), &attachResp); err != nil {
+	if err := json.Unmarshal(resp.Data, &attachResp.Data); err != nil {
 		return nil, fmt.Errorf("failed to parse attach response: %w", err)
 	}
 
+	sessionID := resp.SessionID
+	if sessionID == 0 {
+		sessionID = jc.currentSession()
+	}
+
 	handle := &PluginHandle{
-		ID:        attachResp.Data.ID,
 		Plugin:    pluginName,
-		SessionID: jc.SessionID,
 		Client:    jc,
+		id:        attachResp.Data.ID,
+		sessionID: sessionID,
+		token:     options.Token,
+		OpaqueID:  options.OpaqueID,
+		Events:    make(chan *JanusResponse, pluginEventBufferSize),
 	}
 
 	jc.mu.Lock()
-	jc.PluginHandles[handle.ID] = handle
+	jc.PluginHandles[attachResp.Data.ID] = handle
 	jc.mu.Unlock()
 
 	jc.logger.WithFields(logrus.Fields{
-		"handle_id": handle.ID,
+		"handle_id": attachResp.Data.ID,
 		"plugin":    pluginName,
 	}).Info("Attached plugin")
 
 	return handle, nil
 }
 
-// makeRequest mengirim request ke Janus server
-func (jc *JanusClient) makeRequest(request JanusRequest) (*JanusResponse, error) {
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// Close menghentikan keepalive dan menutup transport ke Janus
+func (jc *JanusClient) Close() error {
+	jc.StopKeepalive()
+	return jc.transport.Close()
+}
+
+// currentSession mengembalikan session ID yang aktif
+func (jc *JanusClient) currentSession() uint64 {
+	jc.mu.RLock()
+	defer jc.mu.RUnlock()
+	return jc.SessionID
+}
+
+// request mengirim request ke Janus dan menunggu balasan dengan transaction yang sama.
+// Jika waitEvent true, balasan "ack" diabaikan dan request menunggu event asinkron
+// yang membawa hasil dari plugin.
+func (jc *JanusClient) request(request JanusRequest, waitEvent bool) (*JanusResponse, error) {
+	if request.Transaction == "" {
+		request.Transaction = uuid.New().String()
 	}
 
-	url := fmt.Sprintf("%s/%s", jc.BaseURL, request.Janus)
-	resp, err := jc.HTTPClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	// API secret wajib disertakan di setiap request jika Janus mengaktifkannya
+	if request.APISecret == "" {
+		request.APISecret = jc.APISecret
+	}
+
+	pending := &pendingRequest{
+		response:  make(chan *JanusResponse, 1),
+		failed:    make(chan error, 1),
+		waitEvent: waitEvent,
 	}
-	defer resp.Body.Close()
 
-	var janusResp JanusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&janusResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	jc.pendingMu.Lock()
+	jc.pending[request.Transaction] = pending
+	jc.pendingMu.Unlock()
+
+	defer func() {
+		jc.pendingMu.Lock()
+		delete(jc.pending, request.Transaction)
+		jc.pendingMu.Unlock()
+	}()
+
+	if err := jc.transport.Send(request); err != nil {
+		return nil, err
 	}
 
-	return &janusResp, nil
+	select {
+	case resp := <-pending.response:
+		if resp.Janus == "error" {
+			if resp.Error != nil {
+				return nil, resp.Error
+			}
+			return nil, fmt.Errorf("unexpected response: %s", resp.Janus)
+		}
+		return resp, nil
+	case err := <-pending.failed:
+		return nil, fmt.Errorf("%w while waiting for %s: %v", ErrJanusDisconnected, request.Janus, err)
+	case <-time.After(janusRequestTimeout):
+		return nil, fmt.Errorf("timeout waiting for janus response to %s", request.Janus)
+	}
 }
 
-// CreateVideoRoom membuat video room baru
-func (ph *PluginHandle) CreateVideoRoom(roomID uint64, description string) error {
-	transaction := uuid.New().String()
+// handleDisconnect menggagalkan semua request yang menunggu balasan ketika koneksi
+// transport terputus, agar pemanggil tidak menunggu sampai janusRequestTimeout
+func (jc *JanusClient) handleDisconnect(cause error) {
+	jc.pendingMu.Lock()
+	failed := jc.pending
+	jc.pending = make(map[string]*pendingRequest)
+	jc.pendingMu.Unlock()
+
+	if len(failed) > 0 {
+		jc.logger.WithError(cause).WithField("pending", len(failed)).Warn("Janus connection lost, failing pending requests")
+	}
 
+	for _, pending := range failed {
+		pending.failed <- cause
+	}
+}
+
+// handleMessage menerima semua pesan dari transport dan meneruskannya
+// ke request yang menunggu atau ke channel event plugin handle
+func (jc *JanusClient) handleMessage(message *JanusResponse) {
+	if message.Transaction != "" {
+		jc.pendingMu.Lock()
+		pending, exists := jc.pending[message.Transaction]
+		deliver := exists && !(message.Janus == "ack" && pending.waitEvent)
+		if deliver {
+			delete(jc.pending, message.Transaction)
+		}
+		jc.pendingMu.Unlock()
+
+		if deliver {
+			pending.response <- message
+			return
+		}
+		if exists {
+			// Ack untuk request asinkron, hasilnya akan datang sebagai event
+			return
+		}
+	}
+
+	if message.Janus == "ack" {
+		return
+	}
+
+	// Session hilang di Janus (timeout atau Janus restart), buat ulang di background
+	if message.Janus == "timeout" || (message.Janus == "error" && message.Error != nil && message.Error.Code == JanusErrorSessionNotFound) {
+		sessionID := message.SessionID
+		if sessionID == 0 {
+			sessionID = jc.currentSession()
+		}
+
+		jc.logger.WithField("session_id", sessionID).Warn("Janus session lost")
+
+		go func() {
+			if err := jc.recreateSession(sessionID); err != nil {
+				jc.logger.WithError(err).Error("Failed to recreate Janus session")
+			}
+		}()
+		return
+	}
+
+	if message.Sender == 0 {
+		jc.logger.WithFields(logrus.Fields{
+			"janus":      message.Janus,
+			"session_id": message.SessionID,
+		}).Debug("Received Janus session event")
+		return
+	}
+
+	jc.mu.RLock()
+	handle, exists := jc.PluginHandles[message.Sender]
+	jc.mu.RUnlock()
+
+	if !exists {
+		jc.logger.WithFields(logrus.Fields{
+			"janus":     message.Janus,
+			"handle_id": message.Sender,
+		}).Debug("Received event for unknown handle")
+		return
+	}
+
+	handle.deliver(message)
+}
+
+// DecodePluginData mem-parse data plugin dari response ke out.
+// Jika plugin mengembalikan error, PluginError akan dikembalikan.
+func (resp *JanusResponse) DecodePluginData(out interface{}) error {
+	if resp.PluginData == nil {
+		return fmt.Errorf("response has no plugin data")
+	}
+
+	var pluginErr PluginError
+	if err := json.Unmarshal(resp.PluginData.Data, &pluginErr); err == nil && pluginErr.Code != 0 {
+		return &pluginErr
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(resp.PluginData.Data, out); err != nil {
+		return fmt.Errorf("failed to parse plugin data: %w", err)
+	}
+
+	return nil
+}
+
+// sendMessage mengirim pesan ke plugin melalui handle ini
+func (ph *PluginHandle) sendMessage(body interface{}, jsep *JSEP, waitEvent bool) (*JanusResponse, error) {
 	request := JanusRequest{
-		Janus:       "message",
-		Transaction: transaction,
-		SessionID:   ph.SessionID,
-		HandleID:    ph.ID,
-		Body: VideoRoomCreateRequest{
-			Request:     "create",
-			Room:        roomID,
-			Description: description,
-			IsPrivate:   false,
-		},
+		Janus: "message",
+		Body:  body,
+	}
+
+	if jsep != nil {
+		request.Jsep = jsep
 	}
 
-	resp, err := ph.Client.makeRequest(request)
+	resp, err := ph.Client.sessionRequest(ph, request, waitEvent)
 	if err != nil {
-		return fmt.Errorf("failed to create video room: %w", err)
+		return nil, err
 	}
 
-	if resp.Janus != "success" {
-		if resp.Error != nil {
-			return fmt.Errorf("janus error: %s", resp.Error.Reason)
-		}
-		return fmt.Errorf("unexpected response: %s", resp.Janus)
+	if err := resp.DecodePluginData(nil); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ID mengembalikan handle ID Janus saat ini
+func (ph *PluginHandle) ID() uint64 {
+	ph.idMu.RLock()
+	defer ph.idMu.RUnlock()
+	return ph.id
+}
+
+// SessionID mengembalikan session ID Janus pemilik handle saat ini
+func (ph *PluginHandle) SessionID() uint64 {
+	ph.idMu.RLock()
+	defer ph.idMu.RUnlock()
+	return ph.sessionID
+}
+
+// ids mengembalikan session ID dan handle ID yang berpasangan
+func (ph *PluginHandle) ids() (sessionID, handleID uint64) {
+	ph.idMu.RLock()
+	defer ph.idMu.RUnlock()
+	return ph.sessionID, ph.id
+}
+
+// Token mengembalikan stored token Janus milik handle (kosong = tanpa token)
+func (ph *PluginHandle) Token() string {
+	ph.idMu.RLock()
+	defer ph.idMu.RUnlock()
+	return ph.token
+}
+
+// reattached mengganti handle ID, session ID dan token sekaligus setelah re-attach
+func (ph *PluginHandle) reattached(handleID, sessionID uint64, token string) {
+	ph.idMu.Lock()
+	defer ph.idMu.Unlock()
+	ph.id = handleID
+	ph.sessionID = sessionID
+	ph.token = token
+}
+
+// deliver meneruskan event ke channel Events tanpa memblokir dispatcher
+func (ph *PluginHandle) deliver(event *JanusResponse) {
+	ph.eventsMu.Lock()
+	defer ph.eventsMu.Unlock()
+
+	if ph.eventsClosed {
+		return
+	}
+
+	select {
+	case ph.Events <- event:
+	default:
+		ph.Client.logger.WithFields(logrus.Fields{
+			"handle_id": ph.ID(),
+			"janus":     event.Janus,
+		}).Warn("Plugin event buffer full, dropping event")
+	}
+}
+
+// closeEvents menutup channel Events
+func (ph *PluginHandle) closeEvents() {
+	ph.eventsMu.Lock()
+	defer ph.eventsMu.Unlock()
+
+	if !ph.eventsClosed {
+		ph.eventsClosed = true
+		close(ph.Events)
+	}
+}
+
+// release melepas handle secara lokal tanpa mengirim request ke Janus,
+// misalnya ketika instance Janus pemilik handle sudah tidak dapat dihubungi
+func (ph *PluginHandle) release() {
+	ph.Client.mu.Lock()
+	delete(ph.Client.PluginHandles, ph.ID())
+	ph.Client.mu.Unlock()
+
+	ph.closeEvents()
+}
+
+// CreateVideoRoom membuat video room baru
+func (ph *PluginHandle) CreateVideoRoom(roomID uint64, description string, options VideoRoomOptions) error {
+	body := VideoRoomCreateRequest{
+		Request:     "create",
+		Room:        roomID,
+		Description: description,
+		IsPrivate:   false,
+		VideoCodec:  options.VideoCodec,
+		VP9Profile:  options.VP9Profile,
+		H264Profile: options.H264Profile,
+		VideoSVC:    options.VideoSVC,
+
+		Bitrate:            options.Bitrate,
+		BitrateCap:         options.Bitrate > 0,
+		FirFreq:            options.FirFreq,
+		AudioCodec:         options.AudioCodec,
+		AudioLevelEvent:    options.AudioLevelEvent,
+		AudioActivePackets: options.AudioActivePackets,
+		AudioLevelAverage:  options.AudioLevelAverage,
+		Record:             options.Record,
+		RecDir:             options.RecDir,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to create video room: %w", err)
 	}
 
 	ph.Client.logger.WithFields(logrus.Fields{
 		"room_id":   roomID,
-		"handle_id": ph.ID,
+		"handle_id": ph.ID(),
 	}).Info("Created video room")
 
 	return nil
 }
 
-// JoinVideoRoom bergabung ke video room
-func (ph *PluginHandle) JoinVideoRoom(roomID, userID uint64, displayName string) error {
-	transaction := uuid.New().String()
+// DestroyVideoRoom menghapus video room. Semua peserta akan menerima event destroyed.
+func (ph *PluginHandle) DestroyVideoRoom(roomID uint64) error {
+	body := VideoRoomDestroyRequest{
+		Request: "destroy",
+		Room:    roomID,
+	}
 
-	request := JanusRequest{
-		Janus:       "message",
-		Transaction: transaction,
-		SessionID:   ph.SessionID,
-		HandleID:    ph.ID,
-		Body: VideoRoomJoinRequest{
-			Request: "join",
-			Room:    roomID,
-			ID:      userID,
-			Display: displayName,
-			PTYPE:   "publisher",
-		},
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to destroy video room: %w", err)
 	}
 
-	resp, err := ph.Client.makeRequest(request)
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"handle_id": ph.ID(),
+	}).Info("Destroyed video room")
+
+	return nil
+}
+
+// JoinVideoRoom bergabung ke video room sebagai publisher dan mengembalikan
+// event joined yang berisi daftar publisher yang sudah ada
+func (ph *PluginHandle) JoinVideoRoom(roomID, userID uint64, displayName string) (*VideoRoomEvent, error) {
+	body := VideoRoomJoinRequest{
+		Request: "join",
+		Room:    roomID,
+		ID:      userID,
+		Display: displayName,
+		Token:   ph.Token(),
+		PTYPE:   "publisher",
+	}
+
+	resp, err := ph.sendMessage(body, nil, true)
 	if err != nil {
-		return fmt.Errorf("failed to join video room: %w", err)
+		return nil, fmt.Errorf("failed to join video room: %w", err)
 	}
 
-	if resp.Janus != "event" {
-		if resp.Error != nil {
-			return fmt.Errorf("janus error: %s", resp.Error.Reason)
-		}
-		return fmt.Errorf("unexpected response: %s", resp.Janus)
+	var joined VideoRoomEvent
+	if err := resp.DecodePluginData(&joined); err != nil {
+		return nil, fmt.Errorf("failed to parse join response: %w", err)
 	}
 
 	ph.Client.logger.WithFields(logrus.Fields{
 		"room_id":      roomID,
 		"user_id":      userID,
 		"display_name": displayName,
-		"handle_id":    ph.ID,
+		"handle_id":    ph.ID(),
 	}).Info("Joined video room")
 
-	return nil
+	return &joined, nil
 }
 
-// PublishToVideoRoom mempublish stream ke video room
-func (ph *PluginHandle) PublishToVideoRoom(jsep *JSEP) error {
-	transaction := uuid.New().String()
+// PublishToVideoRoom mempublish stream ke video room dan mengembalikan JSEP answer dari Janus
+func (ph *PluginHandle) PublishToVideoRoom(jsep *JSEP) (*JSEP, error) {
+	body := VideoRoomPublishRequest{
+		Request: "publish",
+		Audio:   true,
+		Video:   true,
+	}
 
-	request := JanusRequest{
-		Janus:       "message",
-		Transaction: transaction,
-		SessionID:   ph.SessionID,
-		HandleID:    ph.ID,
-		Body: VideoRoomPublishRequest{
-			Request: "publish",
-			Audio:   true,
-			Video:   true,
-		},
+	resp, err := ph.sendMessage(body, jsep, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish to video room: %w", err)
 	}
 
-	if jsep != nil {
-		request.Jsep = jsep
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+	}).Info("Published to video room")
+
+	return resp.Jsep, nil
+}
+
+// SubscribeToVideoRoom join ke video room sebagai subscriber untuk feed tertentu
+// dan mengembalikan JSEP offer dari Janus yang harus dijawab oleh browser
+func (ph *PluginHandle) SubscribeToVideoRoom(roomID, feedID, privateID uint64) (*JSEP, error) {
+	body := VideoRoomSubscribeRequest{
+		Request:   "join",
+		Room:      roomID,
+		PTYPE:     "subscriber",
+		Feed:      feedID,
+		PrivateID: privateID,
 	}
 
-	resp, err := ph.Client.makeRequest(request)
+	resp, err := ph.sendMessage(body, nil, true)
 	if err != nil {
-		return fmt.Errorf("failed to publish to video room: %w", err)
+		return nil, fmt.Errorf("failed to subscribe to video room: %w", err)
+	}
+
+	if resp.Jsep == nil || resp.Jsep.Type != "offer" {
+		return nil, fmt.Errorf("janus did not send an offer for feed %d", feedID)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"feed_id":   feedID,
+		"handle_id": ph.ID(),
+	}).Info("Subscribed to video room")
+
+	return resp.Jsep, nil
+}
+
+// StartVideoRoomSubscription mengirim JSEP answer dari browser ke Janus
+// sehingga media dari publisher mulai dikirim ke subscriber
+func (ph *PluginHandle) StartVideoRoomSubscription(roomID uint64, jsep *JSEP) error {
+	body := VideoRoomStartRequest{
+		Request: "start",
+		Room:    roomID,
+	}
+
+	if _, err := ph.sendMessage(body, jsep, true); err != nil {
+		return fmt.Errorf("failed to start subscription: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"handle_id": ph.ID(),
+	}).Info("Started video room subscription")
+
+	return nil
+}
+
+// ConfigureSubscriber memilih layer simulcast/SVC yang diteruskan Janus ke subscriber ini
+func (ph *PluginHandle) ConfigureSubscriber(layer VideoLayer) error {
+	body := VideoRoomConfigureRequest{
+		Request:    "configure",
+		VideoLayer: layer,
+	}
+
+	if _, err := ph.sendMessage(body, nil, true); err != nil {
+		return fmt.Errorf("failed to configure subscriber: %w", err)
 	}
 
-	if resp.Janus != "event" {
-		if resp.Error != nil {
-			return fmt.Errorf("janus error: %s", resp.Error.Reason)
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+		"layer":     layer.String(),
+	}).Debug("Configured video room subscriber")
+
+	return nil
+}
+
+// EditVideoRoom mengubah batas bitrate dan interval keyframe video room yang sedang berjalan.
+// Publisher yang sudah ada tetap memakai bitrate lamanya sampai di-configure ulang.
+func (ph *PluginHandle) EditVideoRoom(roomID, bitrate uint64, firFreq int) error {
+	body := VideoRoomEditRequest{
+		Request:    "edit",
+		Room:       roomID,
+		NewBitrate: &bitrate,
+		NewFirFreq: &firFreq,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to edit video room: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":  roomID,
+		"bitrate":  bitrate,
+		"fir_freq": firFreq,
+	}).Info("Edited video room")
+
+	return nil
+}
+
+// EnableVideoRoomRecording mulai atau berhenti merekam semua publisher video room.
+// Jika recDir diisi, direktori recording room diganti lebih dulu agar file
+// recording baru ditulis ke direktori tersebut.
+func (ph *PluginHandle) EnableVideoRoomRecording(roomID uint64, record bool, recDir string) error {
+	if recDir != "" {
+		edit := VideoRoomEditRequest{
+			Request:   "edit",
+			Room:      roomID,
+			NewRecDir: recDir,
+		}
+		if _, err := ph.sendMessage(edit, nil, false); err != nil {
+			return fmt.Errorf("failed to set recording directory: %w", err)
 		}
-		return fmt.Errorf("unexpected response: %s", resp.Janus)
+	}
+
+	body := VideoRoomEnableRecordingRequest{
+		Request: "enable_recording",
+		Room:    roomID,
+		Record:  record,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to toggle video room recording: %w", err)
 	}
 
 	ph.Client.logger.WithFields(logrus.Fields{
-		"handle_id": ph.ID,
-	}).Info("Published to video room")
+		"room_id": roomID,
+		"record":  record,
+		"rec_dir": recDir,
+	}).Info("Toggled video room recording")
 
 	return nil
 }
 
-// SubscribeToVideoRoom subscribe ke publisher di video room
-func (ph *PluginHandle) SubscribeToVideoRoom(roomID, feedID uint64, jsep *JSEP) error {
-	transaction := uuid.New().String()
+// ConfigurePublisherBitrate mengubah batas bitrate (REMB) yang dikirim Janus ke publisher ini
+func (ph *PluginHandle) ConfigurePublisherBitrate(bitrate uint64) error {
+	body := VideoRoomConfigureRequest{
+		Request: "configure",
+		Bitrate: &bitrate,
+	}
 
-	request := JanusRequest{
-		Janus:       "message",
-		Transaction: transaction,
-		SessionID:   ph.SessionID,
-		HandleID:    ph.ID,
-		Body: VideoRoomSubscribeRequest{
-			Request: "subscribe",
-			Room:    roomID,
-			Feed:    feedID,
-		},
+	if _, err := ph.sendMessage(body, nil, true); err != nil {
+		return fmt.Errorf("failed to configure publisher bitrate: %w", err)
 	}
 
-	if jsep != nil {
-		request.Jsep = jsep
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+		"bitrate":   bitrate,
+	}).Debug("Configured video room publisher bitrate")
+
+	return nil
+}
+
+// KickFromVideoRoom mengeluarkan peserta dari video room. Janus menutup
+// PeerConnection peserta dan memberi tahu peserta lain dengan event kicked.
+func (ph *PluginHandle) KickFromVideoRoom(roomID, participantID uint64) error {
+	body := VideoRoomKickRequest{
+		Request: "kick",
+		Room:    roomID,
+		ID:      participantID,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to kick participant: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":        roomID,
+		"participant_id": participantID,
+		"handle_id":      ph.ID(),
+	}).Info("Kicked participant from video room")
+
+	return nil
+}
+
+// ModerateVideoRoom mute atau unmute satu m-line (audio/video) milik publisher.
+// Selama di-mute, Janus berhenti meneruskan media m-line tersebut ke subscriber.
+func (ph *PluginHandle) ModerateVideoRoom(roomID, participantID uint64, mid string, mute bool) error {
+	body := VideoRoomModerateRequest{
+		Request: "moderate",
+		Room:    roomID,
+		ID:      participantID,
+		Mid:     mid,
+		Mute:    mute,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to moderate participant: %w", err)
 	}
 
-	resp, err := ph.Client.makeRequest(request)
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":        roomID,
+		"participant_id": participantID,
+		"mid":            mid,
+		"mute":           mute,
+		"handle_id":      ph.ID(),
+	}).Info("Moderated video room participant")
+
+	return nil
+}
+
+// UnpublishFromVideoRoom menghentikan publish handle publisher ini tanpa keluar dari room
+func (ph *PluginHandle) UnpublishFromVideoRoom() error {
+	body := VideoRoomUnpublishRequest{
+		Request: "unpublish",
+	}
+
+	if _, err := ph.sendMessage(body, nil, true); err != nil {
+		return fmt.Errorf("failed to unpublish from video room: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+	}).Info("Unpublished from video room")
+
+	return nil
+}
+
+// RTPForward meneruskan media publisher video room sebagai RTP dan mengembalikan
+// ID forwarder yang dibuat Janus
+func (ph *PluginHandle) RTPForward(request VideoRoomRTPForwardRequest) ([]uint64, error) {
+	request.Request = "rtp_forward"
+
+	resp, err := ph.sendMessage(request, nil, false)
 	if err != nil {
-		return fmt.Errorf("failed to subscribe to video room: %w", err)
+		return nil, fmt.Errorf("failed to start rtp forward: %w", err)
 	}
 
-	if resp.Janus != "event" {
-		if resp.Error != nil {
-			return fmt.Errorf("janus error: %s", resp.Error.Reason)
+	var forwarded VideoRoomRTPForwardResponse
+	if err := resp.DecodePluginData(&forwarded); err != nil {
+		return nil, fmt.Errorf("failed to start rtp forward: %w", err)
+	}
+
+	var streamIDs []uint64
+	if forwarded.RTPStream != nil {
+		for _, id := range []uint64{forwarded.RTPStream.AudioStreamID, forwarded.RTPStream.VideoStreamID} {
+			if id != 0 {
+				streamIDs = append(streamIDs, id)
+			}
 		}
-		return fmt.Errorf("unexpected response: %s", resp.Janus)
+	}
+	for _, forwarder := range forwarded.Forwarders {
+		streamIDs = append(streamIDs, forwarder.StreamID)
 	}
 
 	ph.Client.logger.WithFields(logrus.Fields{
-		"room_id":   roomID,
-		"feed_id":   feedID,
-		"handle_id": ph.ID,
-	}).Info("Subscribed to video room")
+		"room_id":      request.Room,
+		"publisher_id": request.PublisherID,
+		"host":         request.Host,
+		"stream_ids":   streamIDs,
+	}).Info("Started video room RTP forward")
+
+	return streamIDs, nil
+}
+
+// StopRTPForward menghentikan satu RTP forwarder publisher
+func (ph *PluginHandle) StopRTPForward(roomID, publisherID, streamID uint64) error {
+	body := VideoRoomStopRTPForwardRequest{
+		Request:     "stop_rtp_forward",
+		Room:        roomID,
+		PublisherID: publisherID,
+		StreamID:    streamID,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to stop rtp forward: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":      roomID,
+		"publisher_id": publisherID,
+		"stream_id":    streamID,
+	}).Info("Stopped video room RTP forward")
 
 	return nil
 }
 
 // DestroySession menghapus session Janus
 func (jc *JanusClient) DestroySession() error {
-	if jc.SessionID == 0 {
+	sessionID := jc.currentSession()
+	if sessionID == 0 {
 		return fmt.Errorf("no active session")
 	}
 
-	transaction := uuid.New().String()
-
 	request := JanusRequest{
-		Janus:       "destroy",
-		Transaction: transaction,
-		SessionID:   jc.SessionID,
+		Janus:     "destroy",
+		SessionID: sessionID,
 	}
 
-	resp, err := jc.makeRequest(request)
+	resp, err := jc.request(request, false)
 	if err != nil {
 		return fmt.Errorf("failed to destroy session: %w", err)
 	}
 
 	if resp.Janus != "success" {
-		if resp.Error != nil {
-			return fmt.Errorf("janus error: %s", resp.Error.Reason)
-		}
 		return fmt.Errorf("unexpected response: %s", resp.Janus)
 	}
 
-	jc.logger.WithField("session_id", jc.SessionID).Info("Destroyed Janus session")
+	jc.logger.WithField("session_id", sessionID).Info("Destroyed Janus session")
 
 	jc.mu.Lock()
+	for _, handle := range jc.PluginHandles {
+		handle.closeEvents()
+	}
 	jc.SessionID = 0
 	jc.PluginHandles = make(map[uint64]*PluginHandle)
 	jc.mu.Unlock()
@@ -467,35 +1269,26 @@ func (jc *JanusClient) DestroySession() error {
 
 // DetachPlugin melepas plugin dari session
 func (ph *PluginHandle) DetachPlugin() error {
-	transaction := uuid.New().String()
-
 	request := JanusRequest{
-		Janus:       "detach",
-		Transaction: transaction,
-		SessionID:   ph.SessionID,
-		HandleID:    ph.ID,
+		Janus: "detach",
 	}
 
-	resp, err := ph.Client.makeRequest(request)
+	// Handle selalu dilepas secara lokal walaupun request ke Janus gagal
+	defer ph.release()
+
+	resp, err := ph.Client.sessionRequest(ph, request, false)
 	if err != nil {
 		return fmt.Errorf("failed to detach plugin: %w", err)
 	}
 
 	if resp.Janus != "success" {
-		if resp.Error != nil {
-			return fmt.Errorf("janus error: %s", resp.Error.Reason)
-		}
 		return fmt.Errorf("unexpected response: %s", resp.Janus)
 	}
 
 	ph.Client.logger.WithFields(logrus.Fields{
-		"handle_id": ph.ID,
+		"handle_id": ph.ID(),
 		"plugin":    ph.Plugin,
 	}).Info("Detached plugin")
 
-	ph.Client.mu.Lock()
-	delete(ph.Client.PluginHandles, ph.ID)
-	ph.Client.mu.Unlock()
-
 	return nil
 }
@@ -0,0 +1,225 @@
+package webrtc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/webrtc/janustest"
+)
+
+// testOfferSDP adalah offer publisher dengan satu audio dan satu video
+const testOfferSDP = "v=0\r\n" +
+	"o=- 1 1 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"a=group:BUNDLE 0 1\r\n" +
+	"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+	"c=IN IP4 127.0.0.1\r\n" +
+	"a=mid:0\r\n" +
+	"a=rtpmap:111 opus/48000/2\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+	"c=IN IP4 127.0.0.1\r\n" +
+	"a=mid:1\r\n" +
+	"a=rtpmap:96 VP8/90000\r\n"
+
+// testTransports adalah transport yang dicakup test JanusClient
+var testTransports = []struct {
+	name      string
+	newClient func(t *testing.T, server *janustest.Server) *JanusClient
+}{
+	{name: "http", newClient: newHTTPTestClient},
+	{name: "ws", newClient: newWebSocketTestClient},
+}
+
+// waitHandleEvent menunggu event Janus dengan tipe tertentu pada handle
+func waitHandleEvent(t *testing.T, handle *PluginHandle, janus string) *JanusResponse {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-handle.Events:
+			if !ok {
+				t.Fatalf("events of handle %d closed while waiting for %s", handle.ID(), janus)
+			}
+			if event.Janus == janus {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s on handle %d", janus, handle.ID())
+			return nil
+		}
+	}
+}
+
+func TestJanusClientPublishesToVideoRoom(t *testing.T) {
+	for _, tt := range testTransports {
+		t.Run(tt.name, func(t *testing.T) {
+			server := janustest.NewServer()
+			defer server.Close()
+
+			client := tt.newClient(t, server)
+
+			handle, err := client.AttachPluginWithOptions("janus.plugin.videoroom", AttachOptions{OpaqueID: "alice"})
+			if err != nil {
+				t.Fatalf("attach failed: %v", err)
+			}
+
+			if err := handle.CreateVideoRoom(1234, "test", DefaultVideoRoomOptions()); err != nil {
+				t.Fatalf("create room failed: %v", err)
+			}
+
+			joined, err := handle.JoinVideoRoom(1234, 42, "Alice")
+			if err != nil {
+				t.Fatalf("join failed: %v", err)
+			}
+			if joined.VideoRoom != "joined" || joined.ID != 42 || joined.PrivateID == 0 {
+				t.Fatalf("unexpected join response: %+v", joined)
+			}
+
+			answer, err := handle.PublishToVideoRoom(&JSEP{Type: "offer", SDP: testOfferSDP})
+			if err != nil {
+				t.Fatalf("publish failed: %v", err)
+			}
+			if answer == nil || answer.Type != "answer" || answer.SDP == "" {
+				t.Fatalf("expected JSEP answer, got %+v", answer)
+			}
+
+			// Event asinkron setelah publish diteruskan ke channel Events handle
+			waitHandleEvent(t, handle, "webrtcup")
+
+			serverHandle, exists := server.Handle(handle.ID())
+			if !exists {
+				t.Fatal("handle not found on server")
+			}
+			if serverHandle.OpaqueID != "alice" || serverHandle.ParticipantID != 42 || !serverHandle.Publishing {
+				t.Fatalf("unexpected server handle state: %+v", serverHandle)
+			}
+
+			room, exists := server.Room(1234)
+			if !exists || len(room.Publishers) != 1 || room.Publishers[0] != 42 {
+				t.Fatalf("publisher not registered in room: %+v", room)
+			}
+
+			for _, request := range server.Requests() {
+				if request.Transport != tt.name {
+					t.Fatalf("request %s sent over %s, expected %s", request.Janus, request.Transport, tt.name)
+				}
+			}
+		})
+	}
+}
+
+func TestJanusClientDeliversPushedEvents(t *testing.T) {
+	for _, tt := range testTransports {
+		t.Run(tt.name, func(t *testing.T) {
+			server := janustest.NewServer()
+			defer server.Close()
+
+			client := tt.newClient(t, server)
+
+			handle, err := client.AttachPlugin("janus.plugin.videoroom")
+			if err != nil {
+				t.Fatalf("attach failed: %v", err)
+			}
+
+			if err := server.PushEvent(handle.ID(), "hangup", map[string]interface{}{"reason": "DTLS alert"}); err != nil {
+				t.Fatalf("push event failed: %v", err)
+			}
+			if event := waitHandleEvent(t, handle, "hangup"); event.Reason != "DTLS alert" {
+				t.Fatalf("unexpected hangup reason: %q", event.Reason)
+			}
+
+			offer := &janustest.JSEP{Type: "offer", SDP: testOfferSDP}
+			if err := server.PushPluginEvent(handle.ID(), map[string]interface{}{"videoroom": "event", "room": 1234}, offer); err != nil {
+				t.Fatalf("push plugin event failed: %v", err)
+			}
+			event := waitHandleEvent(t, handle, "event")
+			if event.Jsep == nil || event.Jsep.Type != "offer" {
+				t.Fatalf("plugin event jsep not delivered: %+v", event.Jsep)
+			}
+
+			var data VideoRoomEvent
+			if err := event.DecodePluginData(&data); err != nil || data.Room != 1234 {
+				t.Fatalf("unexpected plugin event data: %+v (%v)", data, err)
+			}
+		})
+	}
+}
+
+func TestJanusClientReturnsJanusErrors(t *testing.T) {
+	for _, tt := range testTransports {
+		t.Run(tt.name, func(t *testing.T) {
+			server := janustest.NewServer()
+			defer server.Close()
+
+			client := tt.newClient(t, server)
+
+			server.InjectFault(janustest.Fault{
+				Janus:  "attach",
+				Code:   janustest.ErrorPluginNotFound,
+				Reason: "No such plugin",
+				Times:  1,
+			})
+
+			_, err := client.AttachPlugin("janus.plugin.videoroom")
+			var janusErr *JanusError
+			if !errors.As(err, &janusErr) || janusErr.Code != janustest.ErrorPluginNotFound {
+				t.Fatalf("expected janus error %d, got %v", janustest.ErrorPluginNotFound, err)
+			}
+
+			// Fault hanya berlaku satu kali
+			if _, err := client.AttachPlugin("janus.plugin.videoroom"); err != nil {
+				t.Fatalf("attach after fault failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestJanusClientRecreatesExpiredSession(t *testing.T) {
+	for _, tt := range testTransports {
+		t.Run(tt.name, func(t *testing.T) {
+			server := janustest.NewServer()
+			defer server.Close()
+
+			client := tt.newClient(t, server)
+
+			recreated := make(chan uint64, 1)
+			client.SetSessionRecreatedHandler(func(oldSessionID, newSessionID uint64) {
+				recreated <- newSessionID
+			})
+
+			handle, err := client.AttachPlugin("janus.plugin.videoroom")
+			if err != nil {
+				t.Fatalf("attach failed: %v", err)
+			}
+			oldSessionID := client.currentSession()
+			oldHandleID := handle.ID()
+
+			server.ExpireSession(oldSessionID)
+
+			var newSessionID uint64
+			select {
+			case newSessionID = <-recreated:
+			case <-time.After(5 * time.Second):
+				t.Fatal("session was not recreated after it expired")
+			}
+
+			if newSessionID == oldSessionID || client.currentSession() != newSessionID {
+				t.Fatalf("client still uses session %d", client.currentSession())
+			}
+			if handle.SessionID() != newSessionID || handle.ID() == oldHandleID {
+				t.Fatalf("handle was not reattached: session %d handle %d", handle.SessionID(), handle.ID())
+			}
+
+			// Objek handle yang sama tetap dapat dipakai
+			if err := handle.CreateVideoRoom(1234, "test", DefaultVideoRoomOptions()); err != nil {
+				t.Fatalf("request on reattached handle failed: %v", err)
+			}
+			if serverHandle, exists := server.Handle(handle.ID()); !exists || serverHandle.SessionID != newSessionID {
+				t.Fatalf("reattached handle not found on server: %+v", serverHandle)
+			}
+		})
+	}
+}
@@ -0,0 +1,997 @@
+// Package janustest menyediakan fake Janus server berbasis httptest untuk
+// integration test JanusClient dan SignalingHandler tanpa Janus sungguhan.
+//
+// Server melayani Janus REST API (termasuk long-poll) dan WebSocket dengan
+// subprotocol janus-protocol, mengimplementasikan request core (create,
+// attach, message, trickle, keepalive, detach, destroy) dan semantik plugin
+// videoroom (create, join, publish, subscribe, start, leave). Event asinkron
+// dikirim berurutan per session seperti Janus, dan test dapat menyuntikkan
+// error serta latency melalui Fault.
+package janustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Kode error core Janus yang dipakai fake server
+const (
+	ErrorUnauthorized       = 403
+	ErrorUnknownRequest     = 453
+	ErrorMissingRequest     = 452
+	ErrorInvalidJSON        = 454
+	ErrorSessionNotFound    = 458
+	ErrorHandleNotFound     = 459
+	ErrorPluginNotFound     = 460
+	ErrorPluginMessage      = 463
+	ErrorTrickleInvalidJSEP = 467
+)
+
+const (
+	// Lama default long-poll menunggu event sebelum mengembalikan keepalive
+	DefaultLongPollTimeout = 30 * time.Second
+
+	// Ukuran antrean event per session
+	sessionEventBufferSize = 1024
+)
+
+// Request adalah request yang diterima fake server (dicatat untuk assertion test)
+type Request struct {
+	Janus       string          `json:"janus"`
+	Transaction string          `json:"transaction"`
+	SessionID   uint64          `json:"session_id,omitempty"`
+	HandleID    uint64          `json:"handle_id,omitempty"`
+	Plugin      string          `json:"plugin,omitempty"`
+	OpaqueID    string          `json:"opaque_id,omitempty"`
+	APISecret   string          `json:"apisecret,omitempty"`
+	Token       string          `json:"token,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	Jsep        *JSEP           `json:"jsep,omitempty"`
+	Candidate   json.RawMessage `json:"candidate,omitempty"`
+	Candidates  json.RawMessage `json:"candidates,omitempty"`
+
+	// Transport tempat request diterima ("http" atau "ws")
+	Transport string `json:"-"`
+}
+
+// JSEP adalah session description yang dipertukarkan dengan plugin
+type JSEP struct {
+	Type string `json:"type"`
+	SDP  string `json:"sdp"`
+}
+
+// Fault adalah gangguan yang disuntikkan ke request berikutnya yang cocok
+type Fault struct {
+	// Tipe request core (create, attach, message, trickle, keepalive, detach, destroy), kosong = semua
+	Janus string
+
+	// Request plugin di dalam body message (join, publish, start, ...), kosong = semua
+	Request string
+
+	// Kode error yang dikembalikan (0 = tidak ada error, hanya delay atau drop).
+	// Jika Request diisi, error dikembalikan sebagai error plugin (error_code).
+	Code int
+
+	// Alasan error
+	Reason string
+
+	// Latency tambahan sebelum membalas request
+	Delay time.Duration
+
+	// Request diabaikan tanpa balasan maupun event (untuk menguji timeout)
+	Drop bool
+
+	// Jumlah request yang terkena (0 = satu kali, negatif = selamanya)
+	Times int
+}
+
+// Handle adalah snapshot state plugin handle di fake server
+type Handle struct {
+	ID        uint64
+	SessionID uint64
+	Plugin    string
+	OpaqueID  string
+	Token     string
+
+	// State videoroom
+	Room          uint64
+	PType         string
+	ParticipantID uint64
+	PrivateID     uint64
+	Display       string
+	Feed          uint64
+	Publishing    bool
+	Started       bool
+
+	// Body request configure terakhir
+	Configure map[string]interface{}
+
+	// Mid publisher yang di-mute melalui request moderate
+	Muted map[string]bool
+
+	// ICE candidate yang diterima melalui trickle
+	Candidates       []json.RawMessage
+	TrickleCompleted bool
+}
+
+// session adalah session Janus di fake server
+type session struct {
+	id      uint64
+	handles map[uint64]*Handle
+
+	// Koneksi WebSocket pemilik session (nil untuk HTTP)
+	conn *wsConn
+
+	// Antrean event yang dikirim berurutan oleh dispatcher
+	events chan queuedEvent
+
+	// Event yang siap diambil long-poll
+	ready  []map[string]interface{}
+	notify chan struct{}
+
+	// Ditutup ketika session dihapus
+	gone    chan struct{}
+	removed bool
+
+	mu sync.Mutex
+}
+
+// queuedEvent adalah event yang menunggu waktu kirimnya
+type queuedEvent struct {
+	due     time.Time
+	message map[string]interface{}
+}
+
+// wsConn adalah koneksi WebSocket dari client
+type wsConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+// Server adalah fake Janus server
+type Server struct {
+	// URL Janus REST API (http://127.0.0.1:port/janus)
+	URL string
+
+	// URL WebSocket Janus (ws://127.0.0.1:port/)
+	WSURL string
+
+	// API secret yang diwajibkan pada setiap request (kosong = tidak diperiksa).
+	// Diset sebelum client pertama terhubung.
+	APISecret string
+
+	// Lama long-poll menunggu event sebelum mengembalikan keepalive
+	LongPollTimeout time.Duration
+
+	httpServer *httptest.Server
+	upgrader   websocket.Upgrader
+
+	sessions map[uint64]*session
+	handles  map[uint64]*Handle
+	rooms    map[uint64]*room
+	conns    map[*wsConn]bool
+
+	// Latency sebelum setiap balasan dan sebelum setiap event asinkron
+	latency      time.Duration
+	eventLatency time.Duration
+
+	faults   []*Fault
+	requests []Request
+
+	random *rand.Rand
+	closed chan struct{}
+	mu     sync.Mutex
+}
+
+// NewServer menjalankan fake Janus server baru pada port acak
+func NewServer() *Server {
+	s := &Server{
+		LongPollTimeout: DefaultLongPollTimeout,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{"janus-protocol"},
+			CheckOrigin:  func(r *http.Request) bool { return true },
+		},
+		sessions: make(map[uint64]*session),
+		handles:  make(map[uint64]*Handle),
+		rooms:    make(map[uint64]*room),
+		conns:    make(map[*wsConn]bool),
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		closed:   make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/janus", s.serveHTTP)
+	mux.HandleFunc("/janus/", s.serveHTTP)
+	mux.HandleFunc("/", s.serveWebSocket)
+
+	s.httpServer = httptest.NewServer(mux)
+	s.URL = s.httpServer.URL + "/janus"
+	s.WSURL = "ws" + strings.TrimPrefix(s.httpServer.URL, "http") + "/"
+
+	return s
+}
+
+// Close menghentikan server beserta semua long-poll dan koneksi WebSocket
+func (s *Server) Close() {
+	s.mu.Lock()
+	select {
+	case <-s.closed:
+		s.mu.Unlock()
+		return
+	default:
+	}
+	close(s.closed)
+	conns := make([]*wsConn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.conn.Close()
+	}
+
+	s.httpServer.Close()
+}
+
+// SetLatency mengatur latency sebelum setiap balasan request dan sebelum setiap event asinkron
+func (s *Server) SetLatency(request, event time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = request
+	s.eventLatency = event
+}
+
+// InjectFault menambahkan gangguan untuk request berikutnya yang cocok
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fault.Times == 0 {
+		fault.Times = 1
+	}
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults menghapus semua gangguan yang belum terpakai
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests mengembalikan semua request yang sudah diterima
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Sessions mengembalikan ID semua session yang aktif
+func (s *Server) Sessions() []uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]uint64, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Handle mengembalikan snapshot plugin handle
+func (s *Server) Handle(id uint64) (Handle, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	handle, exists := s.handles[id]
+	if !exists {
+		return Handle{}, false
+	}
+	return copyHandle(handle), true
+}
+
+// Handles mengembalikan snapshot semua plugin handle yang aktif
+func (s *Server) Handles() []Handle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	handles := make([]Handle, 0, len(s.handles))
+	for _, handle := range s.handles {
+		handles = append(handles, copyHandle(handle))
+	}
+	return handles
+}
+
+// PushEvent mengirim event core (misalnya hangup, slowlink, media) untuk sebuah handle.
+// Field janus, session_id dan sender diisi otomatis.
+func (s *Server) PushEvent(handleID uint64, janus string, fields map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	handle, exists := s.handles[handleID]
+	if !exists {
+		return fmt.Errorf("no such handle %d", handleID)
+	}
+
+	event := map[string]interface{}{
+		"janus":      janus,
+		"session_id": handle.SessionID,
+		"sender":     handle.ID,
+	}
+	for key, value := range fields {
+		event[key] = value
+	}
+
+	s.enqueue(handle.SessionID, event)
+	return nil
+}
+
+// PushPluginEvent mengirim event plugin tanpa transaction untuk sebuah handle
+func (s *Server) PushPluginEvent(handleID uint64, data map[string]interface{}, jsep *JSEP) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	handle, exists := s.handles[handleID]
+	if !exists {
+		return fmt.Errorf("no such handle %d", handleID)
+	}
+
+	s.enqueue(handle.SessionID, pluginEvent(handle, "", data, jsep))
+	return nil
+}
+
+// ExpireSession menghapus session seperti session timeout di Janus.
+// Client WebSocket menerima event timeout, client HTTP mendapat error 458 pada long-poll berikutnya.
+func (s *Server) ExpireSession(sessionID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.sessions[sessionID]; !exists {
+		return
+	}
+
+	s.enqueue(sessionID, map[string]interface{}{
+		"janus":      "timeout",
+		"session_id": sessionID,
+	})
+	s.removeSession(sessionID)
+}
+
+// Restart menghapus semua session dan room seperti Janus yang di-restart.
+// Koneksi WebSocket diputus.
+func (s *Server) Restart() {
+	s.mu.Lock()
+	for sessionID := range s.sessions {
+		s.removeSession(sessionID)
+	}
+	s.rooms = make(map[uint64]*room)
+	conns := make([]*wsConn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.conn.Close()
+	}
+}
+
+// serveHTTP melayani Janus REST API: POST untuk request, GET untuk long-poll
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/janus"), "/"), "/")
+
+	var sessionID, handleID uint64
+	if len(parts) > 0 && parts[0] != "" {
+		id, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		sessionID = id
+	}
+	if len(parts) > 1 {
+		id, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		handleID = id
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if sessionID == 0 || handleID != 0 {
+			http.NotFound(w, r)
+			return
+		}
+		s.longPoll(w, r, sessionID)
+
+	case http.MethodPost:
+		var request Request
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeJSON(w, errorMessage(0, "", ErrorInvalidJSON, "JSON error: "+err.Error()))
+			return
+		}
+
+		// Pada REST, session dan handle diambil dari path
+		request.SessionID = sessionID
+		request.HandleID = handleID
+		request.Transport = "http"
+
+		if reply := s.process(request, nil); reply != nil {
+			writeJSON(w, reply)
+		}
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// longPoll mengembalikan event session, atau keepalive jika tidak ada event sampai timeout
+func (s *Server) longPoll(w http.ResponseWriter, r *http.Request, sessionID uint64) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	sess, exists := s.sessions[sessionID]
+	secretOK := s.APISecret == "" || query.Get("apisecret") == s.APISecret
+	timeout := s.LongPollTimeout
+	s.mu.Unlock()
+
+	if !secretOK {
+		writeJSON(w, errorMessage(0, "", ErrorUnauthorized, "Unauthorized request (wrong or missing secret/token)"))
+		return
+	}
+	if !exists {
+		writeJSON(w, errorMessage(sessionID, "", ErrorSessionNotFound, fmt.Sprintf("No such session %d", sessionID)))
+		return
+	}
+
+	maxEvents := 1
+	if value := query.Get("maxev"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			maxEvents = parsed
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		sess.mu.Lock()
+		count := len(sess.ready)
+		if count > maxEvents {
+			count = maxEvents
+		}
+		events := append([]map[string]interface{}(nil), sess.ready[:count]...)
+		sess.ready = sess.ready[count:]
+		sess.mu.Unlock()
+
+		if len(events) > 0 {
+			// Dengan maxev Janus mengembalikan array, tanpa maxev satu object
+			if query.Get("maxev") != "" {
+				writeJSON(w, events)
+			} else {
+				writeJSON(w, events[0])
+			}
+			return
+		}
+
+		select {
+		case <-sess.notify:
+		case <-sess.gone:
+			writeJSON(w, errorMessage(sessionID, "", ErrorSessionNotFound, fmt.Sprintf("No such session %d", sessionID)))
+			return
+		case <-timer.C:
+			writeJSON(w, map[string]interface{}{"janus": "keepalive"})
+			return
+		case <-s.closed:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveWebSocket melayani koneksi WebSocket dengan subprotocol janus-protocol
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	client := &wsConn{conn: conn}
+
+	s.mu.Lock()
+	s.conns[client] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, client)
+		for _, sess := range s.sessions {
+			sess.mu.Lock()
+			if sess.conn == client {
+				sess.conn = nil
+			}
+			sess.mu.Unlock()
+		}
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var request Request
+		if err := json.Unmarshal(data, &request); err != nil {
+			client.write(errorMessage(0, "", ErrorInvalidJSON, "JSON error: "+err.Error()))
+			continue
+		}
+		request.Transport = "ws"
+
+		// Request diproses paralel seperti Janus, urutan event dijaga per session
+		go func() {
+			if reply := s.process(request, client); reply != nil {
+				client.write(reply)
+			}
+		}()
+	}
+}
+
+// process memproses satu request core dan mengembalikan balasan sinkronnya
+func (s *Server) process(request Request, conn *wsConn) map[string]interface{} {
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	fault := s.matchFault(request)
+	delay := s.latency
+	s.mu.Unlock()
+
+	if fault != nil {
+		delay += fault.Delay
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-s.closed:
+			return nil
+		}
+	}
+
+	if fault != nil && fault.Drop {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.APISecret != "" && request.APISecret != s.APISecret {
+		return errorMessage(request.SessionID, request.Transaction, ErrorUnauthorized, "Unauthorized request (wrong or missing secret/token)")
+	}
+
+	if fault != nil && fault.Code != 0 && fault.Request == "" {
+		return errorMessage(request.SessionID, request.Transaction, fault.Code, fault.Reason)
+	}
+
+	if request.Janus == "" {
+		return errorMessage(request.SessionID, request.Transaction, ErrorMissingRequest, "Missing mandatory element (janus)")
+	}
+
+	if request.Janus == "create" {
+		return s.createSession(request, conn)
+	}
+
+	sess, exists := s.sessions[request.SessionID]
+	if !exists {
+		return errorMessage(request.SessionID, request.Transaction, ErrorSessionNotFound, fmt.Sprintf("No such session %d", request.SessionID))
+	}
+
+	if request.HandleID == 0 {
+		switch request.Janus {
+		case "keepalive":
+			return map[string]interface{}{
+				"janus":       "ack",
+				"session_id":  sess.id,
+				"transaction": request.Transaction,
+			}
+		case "attach":
+			return s.attach(sess, request)
+		case "destroy":
+			s.removeSession(sess.id)
+			return successMessage(sess.id, request.Transaction)
+		default:
+			return errorMessage(sess.id, request.Transaction, ErrorUnknownRequest, fmt.Sprintf("Unknown request '%s'", request.Janus))
+		}
+	}
+
+	handle, exists := sess.handles[request.HandleID]
+	if !exists {
+		return errorMessage(sess.id, request.Transaction, ErrorHandleNotFound, fmt.Sprintf("No such handle %d in session %d", request.HandleID, sess.id))
+	}
+
+	switch request.Janus {
+	case "message":
+		return s.message(handle, request, fault)
+	case "trickle":
+		return s.trickle(handle, request)
+	case "detach":
+		s.detach(handle)
+		return successMessage(sess.id, request.Transaction)
+	case "hangup":
+		s.enqueue(sess.id, map[string]interface{}{
+			"janus":      "hangup",
+			"session_id": sess.id,
+			"sender":     handle.ID,
+			"reason":     "Janus API",
+		})
+		return successMessage(sess.id, request.Transaction)
+	default:
+		return errorMessage(sess.id, request.Transaction, ErrorUnknownRequest, fmt.Sprintf("Unknown request '%s'", request.Janus))
+	}
+}
+
+// matchFault mencari dan memakai gangguan yang cocok dengan request
+func (s *Server) matchFault(request Request) *Fault {
+	pluginRequest := ""
+	if request.Janus == "message" {
+		pluginRequest = bodyRequest(request.Body)
+	}
+
+	for i, fault := range s.faults {
+		if fault.Janus != "" && fault.Janus != request.Janus {
+			continue
+		}
+		if fault.Request != "" && fault.Request != pluginRequest {
+			continue
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		matched := *fault
+		return &matched
+	}
+
+	return nil
+}
+
+// createSession membuat session baru
+func (s *Server) createSession(request Request, conn *wsConn) map[string]interface{} {
+	sess := &session{
+		id:      s.newID(),
+		handles: make(map[uint64]*Handle),
+		conn:    conn,
+		events:  make(chan queuedEvent, sessionEventBufferSize),
+		notify:  make(chan struct{}, 1),
+		gone:    make(chan struct{}),
+	}
+	s.sessions[sess.id] = sess
+
+	go s.dispatch(sess)
+
+	return map[string]interface{}{
+		"janus":       "success",
+		"transaction": request.Transaction,
+		"data": map[string]interface{}{
+			"id": sess.id,
+		},
+	}
+}
+
+// removeSession menghapus session beserta semua handle-nya. Dipanggil saat s.mu dipegang.
+func (s *Server) removeSession(sessionID uint64) {
+	sess, exists := s.sessions[sessionID]
+	if !exists {
+		return
+	}
+
+	for _, handle := range sess.handles {
+		s.leaveVideoRoom(handle)
+		delete(s.handles, handle.ID)
+	}
+
+	delete(s.sessions, sessionID)
+	sess.removed = true
+	close(sess.events)
+	close(sess.gone)
+}
+
+// attach meng-attach plugin ke session
+func (s *Server) attach(sess *session, request Request) map[string]interface{} {
+	if request.Plugin != videoRoomPlugin {
+		return errorMessage(sess.id, request.Transaction, ErrorPluginNotFound, fmt.Sprintf("No such plugin '%s'", request.Plugin))
+	}
+
+	handle := &Handle{
+		ID:        s.newID(),
+		SessionID: sess.id,
+		Plugin:    request.Plugin,
+		OpaqueID:  request.OpaqueID,
+		Token:     request.Token,
+	}
+	sess.handles[handle.ID] = handle
+	s.handles[handle.ID] = handle
+
+	return map[string]interface{}{
+		"janus":       "success",
+		"session_id":  sess.id,
+		"transaction": request.Transaction,
+		"data": map[string]interface{}{
+			"id": handle.ID,
+		},
+	}
+}
+
+// detach melepas handle dari session
+func (s *Server) detach(handle *Handle) {
+	s.leaveVideoRoom(handle)
+
+	if sess, exists := s.sessions[handle.SessionID]; exists {
+		delete(sess.handles, handle.ID)
+	}
+	delete(s.handles, handle.ID)
+
+	s.enqueue(handle.SessionID, map[string]interface{}{
+		"janus":      "detached",
+		"session_id": handle.SessionID,
+		"sender":     handle.ID,
+	})
+}
+
+// message meneruskan body ke plugin. Request sinkron dibalas success dengan
+// plugindata, request asinkron dibalas ack lalu event.
+func (s *Server) message(handle *Handle, request Request, fault *Fault) map[string]interface{} {
+	if len(request.Body) == 0 {
+		return errorMessage(handle.SessionID, request.Transaction, ErrorMissingRequest, "Missing mandatory element (body)")
+	}
+
+	var reply *pluginReply
+	if fault != nil && fault.Code != 0 && fault.Request != "" {
+		reply = &pluginReply{
+			Data:  pluginError(fault.Code, fault.Reason),
+			Async: !syncVideoRoomRequests[fault.Request],
+		}
+	} else {
+		reply = s.videoRoomMessage(handle, request.Body, request.Jsep)
+	}
+
+	if !reply.Async {
+		for _, event := range reply.After {
+			s.enqueue(event.SessionID, event.Message)
+		}
+		return map[string]interface{}{
+			"janus":       "success",
+			"session_id":  handle.SessionID,
+			"transaction": request.Transaction,
+			"sender":      handle.ID,
+			"plugindata": map[string]interface{}{
+				"plugin": handle.Plugin,
+				"data":   reply.Data,
+			},
+		}
+	}
+
+	s.enqueue(handle.SessionID, pluginEvent(handle, request.Transaction, reply.Data, reply.Jsep))
+	for _, event := range reply.After {
+		s.enqueue(event.SessionID, event.Message)
+	}
+
+	return map[string]interface{}{
+		"janus":       "ack",
+		"session_id":  handle.SessionID,
+		"transaction": request.Transaction,
+	}
+}
+
+// trickle mencatat ICE candidate dari client
+func (s *Server) trickle(handle *Handle, request Request) map[string]interface{} {
+	var candidates []json.RawMessage
+	switch {
+	case len(request.Candidate) > 0:
+		candidates = append(candidates, request.Candidate)
+	case len(request.Candidates) > 0:
+		if err := json.Unmarshal(request.Candidates, &candidates); err != nil {
+			return errorMessage(handle.SessionID, request.Transaction, ErrorTrickleInvalidJSEP, "Invalid candidates array")
+		}
+	default:
+		return errorMessage(handle.SessionID, request.Transaction, ErrorMissingRequest, "Missing mandatory element (candidate|candidates)")
+	}
+
+	for _, candidate := range candidates {
+		var completed struct {
+			Completed bool `json:"completed"`
+		}
+		if err := json.Unmarshal(candidate, &completed); err == nil && completed.Completed {
+			handle.TrickleCompleted = true
+			continue
+		}
+		handle.Candidates = append(handle.Candidates, candidate)
+	}
+
+	return map[string]interface{}{
+		"janus":       "ack",
+		"session_id":  handle.SessionID,
+		"transaction": request.Transaction,
+	}
+}
+
+// enqueue memasukkan event ke antrean session. Dipanggil saat s.mu dipegang.
+func (s *Server) enqueue(sessionID uint64, message map[string]interface{}) {
+	sess, exists := s.sessions[sessionID]
+	if !exists || sess.removed {
+		return
+	}
+
+	event := queuedEvent{
+		due:     time.Now().Add(s.eventLatency),
+		message: message,
+	}
+
+	select {
+	case sess.events <- event:
+	case <-s.closed:
+	}
+}
+
+// dispatch mengirim event session sesuai urutan dan waktu kirimnya
+func (s *Server) dispatch(sess *session) {
+	for event := range sess.events {
+		if wait := time.Until(event.due); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-s.closed:
+				return
+			}
+		}
+
+		sess.mu.Lock()
+		conn := sess.conn
+		if conn == nil {
+			sess.ready = append(sess.ready, event.message)
+		}
+		sess.mu.Unlock()
+
+		if conn != nil {
+			conn.write(event.message)
+			continue
+		}
+
+		select {
+		case sess.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// newID membuat ID acak 53-bit seperti Janus. Dipanggil saat s.mu dipegang.
+func (s *Server) newID() uint64 {
+	for {
+		id := s.random.Uint64() >> 11
+		if id == 0 {
+			continue
+		}
+		if _, exists := s.sessions[id]; exists {
+			continue
+		}
+		if _, exists := s.handles[id]; exists {
+			continue
+		}
+		return id
+	}
+}
+
+// write mengirim pesan JSON ke koneksi WebSocket
+func (c *wsConn) write(message interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	c.conn.WriteJSON(message)
+}
+
+// writeJSON menulis balasan JSON ke response HTTP
+func writeJSON(w http.ResponseWriter, message interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(message)
+}
+
+// successMessage membuat balasan success tanpa data
+func successMessage(sessionID uint64, transaction string) map[string]interface{} {
+	message := map[string]interface{}{
+		"janus":       "success",
+		"transaction": transaction,
+	}
+	if sessionID != 0 {
+		message["session_id"] = sessionID
+	}
+	return message
+}
+
+// errorMessage membuat balasan error core Janus
+func errorMessage(sessionID uint64, transaction string, code int, reason string) map[string]interface{} {
+	message := map[string]interface{}{
+		"janus": "error",
+		"error": map[string]interface{}{
+			"code":   code,
+			"reason": reason,
+		},
+	}
+	if sessionID != 0 {
+		message["session_id"] = sessionID
+	}
+	if transaction != "" {
+		message["transaction"] = transaction
+	}
+	return message
+}
+
+// pluginEvent membuat event plugin untuk handle
+func pluginEvent(handle *Handle, transaction string, data map[string]interface{}, jsep *JSEP) map[string]interface{} {
+	event := map[string]interface{}{
+		"janus":      "event",
+		"session_id": handle.SessionID,
+		"sender":     handle.ID,
+		"plugindata": map[string]interface{}{
+			"plugin": handle.Plugin,
+			"data":   data,
+		},
+	}
+	if transaction != "" {
+		event["transaction"] = transaction
+	}
+	if jsep != nil {
+		event["jsep"] = jsep
+	}
+	return event
+}
+
+// bodyRequest mengambil nama request plugin dari body message
+func bodyRequest(body json.RawMessage) string {
+	var data struct {
+		Request string `json:"request"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return ""
+	}
+	return data.Request
+}
+
+// copyHandle menyalin handle agar snapshot aman dibaca di luar lock
+func copyHandle(handle *Handle) Handle {
+	snapshot := *handle
+	snapshot.Candidates = append([]json.RawMessage(nil), handle.Candidates...)
+	if handle.Configure != nil {
+		snapshot.Configure = make(map[string]interface{}, len(handle.Configure))
+		for key, value := range handle.Configure {
+			snapshot.Configure[key] = value
+		}
+	}
+	if handle.Muted != nil {
+		snapshot.Muted = make(map[string]bool, len(handle.Muted))
+		for mid, muted := range handle.Muted {
+			snapshot.Muted[mid] = muted
+		}
+	}
+	return snapshot
+}
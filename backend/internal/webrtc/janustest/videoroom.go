@@ -0,0 +1,1014 @@
+package janustest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const videoRoomPlugin = "janus.plugin.videoroom"
+
+// Kode error plugin videoroom
+const (
+	VideoRoomErrorInvalidRequest   = 423
+	VideoRoomErrorJoinFirst        = 424
+	VideoRoomErrorAlreadyJoined    = 425
+	VideoRoomErrorNoSuchRoom       = 426
+	VideoRoomErrorRoomExists       = 427
+	VideoRoomErrorNoSuchFeed       = 428
+	VideoRoomErrorMissingElement   = 429
+	VideoRoomErrorInvalidSDPType   = 431
+	VideoRoomErrorUnauthorized     = 433
+	VideoRoomErrorAlreadyPublished = 434
+	VideoRoomErrorNotPublished     = 435
+	VideoRoomErrorIDExists         = 436
+)
+
+// Request videoroom yang dibalas sinkron oleh Janus (success + plugindata)
+var syncVideoRoomRequests = map[string]bool{
+	"create":           true,
+	"destroy":          true,
+	"edit":             true,
+	"exists":           true,
+	"list":             true,
+	"listparticipants": true,
+	"allowed":          true,
+	"kick":             true,
+	"moderate":         true,
+	"enable_recording": true,
+	"rtp_forward":      true,
+	"stop_rtp_forward": true,
+	"listforwarders":   true,
+}
+
+// room adalah video room di fake server
+type room struct {
+	id          uint64
+	description string
+	secret      string
+	options     map[string]interface{}
+
+	// Participant publisher berdasarkan participant ID
+	participants map[uint64]*Handle
+
+	// Handle subscriber berdasarkan handle ID
+	subscribers map[uint64]*Handle
+
+	// RTP forwarder berdasarkan stream ID
+	forwarders map[uint64]Forwarder
+}
+
+// Forwarder adalah RTP forwarder publisher di fake server
+type Forwarder struct {
+	StreamID    uint64
+	PublisherID uint64
+	Host        string
+	Kind        string
+	Port        uint64
+	PT          uint64
+}
+
+// Room adalah snapshot video room di fake server
+type Room struct {
+	ID          uint64
+	Description string
+
+	// Parameter create selain request, room dan description
+	Options map[string]interface{}
+
+	// Participant ID semua publisher yang join
+	Participants []uint64
+
+	// Participant ID publisher yang sedang mem-publish media
+	Publishers []uint64
+
+	// Jumlah handle subscriber
+	Subscribers int
+
+	// RTP forwarder yang aktif
+	Forwarders []Forwarder
+}
+
+// pluginReply adalah hasil pemrosesan message oleh plugin
+type pluginReply struct {
+	Data  map[string]interface{}
+	Jsep  *JSEP
+	Async bool
+
+	// Event yang dikirim setelah balasan untuk handle ini
+	After []outgoingEvent
+}
+
+// outgoingEvent adalah event untuk session tertentu
+type outgoingEvent struct {
+	SessionID uint64
+	Message   map[string]interface{}
+}
+
+// CreateRoom membuat video room sebelum test dimulai
+func (s *Server) CreateRoom(roomID uint64, options map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rooms[roomID] = &room{
+		id:           roomID,
+		description:  fmt.Sprintf("Room %d", roomID),
+		options:      options,
+		participants: make(map[uint64]*Handle),
+		subscribers:  make(map[uint64]*Handle),
+		forwarders:   make(map[uint64]Forwarder),
+	}
+}
+
+// Room mengembalikan snapshot video room
+func (s *Server) Room(roomID uint64) (Room, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return Room{}, false
+	}
+	return r.snapshot(), true
+}
+
+// Rooms mengembalikan snapshot semua video room
+func (s *Server) Rooms() []Room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rooms := make([]Room, 0, len(s.rooms))
+	for _, r := range s.rooms {
+		rooms = append(rooms, r.snapshot())
+	}
+	return rooms
+}
+
+// snapshot menyalin state room
+func (r *room) snapshot() Room {
+	snapshot := Room{
+		ID:           r.id,
+		Description:  r.description,
+		Options:      make(map[string]interface{}, len(r.options)),
+		Participants: make([]uint64, 0, len(r.participants)),
+		Publishers:   make([]uint64, 0),
+		Subscribers:  len(r.subscribers),
+		Forwarders:   make([]Forwarder, 0, len(r.forwarders)),
+	}
+	for _, forwarder := range r.forwarders {
+		snapshot.Forwarders = append(snapshot.Forwarders, forwarder)
+	}
+	for key, value := range r.options {
+		snapshot.Options[key] = value
+	}
+	for id, participant := range r.participants {
+		snapshot.Participants = append(snapshot.Participants, id)
+		if participant.Publishing {
+			snapshot.Publishers = append(snapshot.Publishers, id)
+		}
+	}
+	return snapshot
+}
+
+// videoRoomMessage memproses body message untuk plugin videoroom. Dipanggil saat s.mu dipegang.
+func (s *Server) videoRoomMessage(handle *Handle, rawBody json.RawMessage, jsep *JSEP) *pluginReply {
+	decoder := json.NewDecoder(bytes.NewReader(rawBody))
+	decoder.UseNumber()
+
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return &pluginReply{Data: pluginError(VideoRoomErrorInvalidRequest, "Invalid JSON"), Async: true}
+	}
+
+	request, _ := body["request"].(string)
+	if request == "" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorMissingElement, "Missing element (request)"), Async: true}
+	}
+
+	switch request {
+	case "create":
+		return &pluginReply{Data: s.createRoom(body)}
+	case "destroy":
+		return &pluginReply{Data: s.destroyRoom(body)}
+	case "edit":
+		return &pluginReply{Data: s.editRoom(body)}
+	case "enable_recording":
+		return &pluginReply{Data: s.enableRecording(body)}
+	case "rtp_forward":
+		return &pluginReply{Data: s.rtpForward(body)}
+	case "stop_rtp_forward":
+		return &pluginReply{Data: s.stopRTPForward(body)}
+	case "exists":
+		roomID := uintField(body, "room")
+		_, exists := s.rooms[roomID]
+		return &pluginReply{Data: map[string]interface{}{
+			"videoroom": "success",
+			"room":      roomID,
+			"exists":    exists,
+		}}
+	case "list":
+		list := make([]map[string]interface{}, 0, len(s.rooms))
+		for _, r := range s.rooms {
+			list = append(list, map[string]interface{}{
+				"room":             r.id,
+				"description":      r.description,
+				"num_participants": len(r.participants),
+			})
+		}
+		return &pluginReply{Data: map[string]interface{}{
+			"videoroom": "success",
+			"list":      list,
+		}}
+	case "listparticipants":
+		r, exists := s.rooms[uintField(body, "room")]
+		if !exists {
+			return &pluginReply{Data: noSuchRoom(uintField(body, "room"))}
+		}
+		participants := make([]map[string]interface{}, 0, len(r.participants))
+		for id, participant := range r.participants {
+			participants = append(participants, map[string]interface{}{
+				"id":        id,
+				"display":   participant.Display,
+				"publisher": participant.Publishing,
+			})
+		}
+		return &pluginReply{Data: map[string]interface{}{
+			"videoroom":    "participants",
+			"room":         r.id,
+			"participants": participants,
+		}}
+	case "kick":
+		return s.kick(body)
+	case "moderate":
+		return s.moderate(body)
+	case "join":
+		reply := s.joinRoom(handle, body)
+		reply.Async = true
+		return reply
+	case "publish", "configure":
+		reply := s.configure(handle, request, body, jsep)
+		reply.Async = true
+		return reply
+	case "unpublish":
+		reply := s.unpublish(handle)
+		reply.Async = true
+		return reply
+	case "start":
+		reply := s.startSubscription(handle, jsep)
+		reply.Async = true
+		return reply
+	case "pause":
+		if handle.PType != "subscriber" {
+			return &pluginReply{Data: pluginError(VideoRoomErrorJoinFirst, "Join as a subscriber first"), Async: true}
+		}
+		handle.Started = false
+		return &pluginReply{Data: map[string]interface{}{
+			"videoroom": "event",
+			"room":      handle.Room,
+			"paused":    "ok",
+		}, Async: true}
+	case "leave":
+		return s.leave(handle)
+	default:
+		return &pluginReply{
+			Data:  pluginError(VideoRoomErrorInvalidRequest, fmt.Sprintf("Unknown request '%s'", request)),
+			Async: !syncVideoRoomRequests[request],
+		}
+	}
+}
+
+// createRoom menangani request create
+func (s *Server) createRoom(body map[string]interface{}) map[string]interface{} {
+	roomID := uintField(body, "room")
+	if roomID == 0 {
+		roomID = s.newID()
+	}
+
+	if _, exists := s.rooms[roomID]; exists {
+		return pluginError(VideoRoomErrorRoomExists, fmt.Sprintf("Room %d already exists", roomID))
+	}
+
+	description, _ := body["description"].(string)
+	if description == "" {
+		description = fmt.Sprintf("Room %d", roomID)
+	}
+	secret, _ := body["secret"].(string)
+
+	options := make(map[string]interface{})
+	for key, value := range body {
+		switch key {
+		case "request", "room", "description":
+			continue
+		}
+		options[key] = value
+	}
+
+	s.rooms[roomID] = &room{
+		id:           roomID,
+		description:  description,
+		secret:       secret,
+		options:      options,
+		participants: make(map[uint64]*Handle),
+		subscribers:  make(map[uint64]*Handle),
+		forwarders:   make(map[uint64]Forwarder),
+	}
+
+	return map[string]interface{}{
+		"videoroom": "created",
+		"room":      roomID,
+		"permanent": false,
+	}
+}
+
+// editRoom menangani request edit: setiap parameter new_<nama> disimpan sebagai
+// opsi <nama> room, kecuali new_description dan new_secret
+func (s *Server) editRoom(body map[string]interface{}) map[string]interface{} {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return noSuchRoom(roomID)
+	}
+
+	if secret, _ := body["secret"].(string); r.secret != "" && secret != r.secret {
+		return pluginError(VideoRoomErrorUnauthorized, "Unauthorized (wrong secret)")
+	}
+
+	for key, value := range body {
+		name, found := strings.CutPrefix(key, "new_")
+		if !found {
+			continue
+		}
+		switch name {
+		case "description":
+			r.description, _ = value.(string)
+		case "secret":
+			r.secret, _ = value.(string)
+		default:
+			r.options[name] = value
+		}
+	}
+
+	return map[string]interface{}{
+		"videoroom": "edited",
+		"room":      roomID,
+	}
+}
+
+// enableRecording menangani request enable_recording dengan menyimpan opsi record room
+func (s *Server) enableRecording(body map[string]interface{}) map[string]interface{} {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return noSuchRoom(roomID)
+	}
+
+	if secret, _ := body["secret"].(string); r.secret != "" && secret != r.secret {
+		return pluginError(VideoRoomErrorUnauthorized, "Unauthorized (wrong secret)")
+	}
+
+	record, ok := body["record"].(bool)
+	if !ok {
+		return pluginError(VideoRoomErrorMissingElement, "Missing element (record)")
+	}
+	r.options["record"] = record
+
+	return map[string]interface{}{
+		"videoroom": "success",
+		"record":    record,
+	}
+}
+
+// rtpForward menangani request rtp_forward dengan parameter audio_port/video_port
+// dan membalas dalam format Janus 0.x (rtp_stream)
+func (s *Server) rtpForward(body map[string]interface{}) map[string]interface{} {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return noSuchRoom(roomID)
+	}
+
+	if secret, _ := body["secret"].(string); r.secret != "" && secret != r.secret {
+		return pluginError(VideoRoomErrorUnauthorized, "Unauthorized (wrong secret)")
+	}
+
+	publisherID := uintField(body, "publisher_id")
+	publisher, exists := r.participants[publisherID]
+	if !exists || !publisher.Publishing {
+		return pluginError(VideoRoomErrorNoSuchFeed, fmt.Sprintf("No such feed (%d)", publisherID))
+	}
+
+	host, _ := body["host"].(string)
+	if host == "" {
+		return pluginError(VideoRoomErrorMissingElement, "Missing element (host)")
+	}
+
+	stream := map[string]interface{}{"host": host}
+	for _, kind := range []string{"audio", "video"} {
+		port := uintField(body, kind+"_port")
+		if port == 0 {
+			continue
+		}
+		forwarder := Forwarder{
+			StreamID:    s.newID(),
+			PublisherID: publisherID,
+			Host:        host,
+			Kind:        kind,
+			Port:        port,
+			PT:          uintField(body, kind+"_pt"),
+		}
+		r.forwarders[forwarder.StreamID] = forwarder
+		stream[kind] = port
+		stream[kind+"_stream_id"] = forwarder.StreamID
+	}
+
+	return map[string]interface{}{
+		"videoroom":    "rtp_forward",
+		"room":         roomID,
+		"publisher_id": publisherID,
+		"rtp_stream":   stream,
+	}
+}
+
+// stopRTPForward menangani request stop_rtp_forward
+func (s *Server) stopRTPForward(body map[string]interface{}) map[string]interface{} {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return noSuchRoom(roomID)
+	}
+
+	streamID := uintField(body, "stream_id")
+	forwarder, exists := r.forwarders[streamID]
+	if !exists || forwarder.PublisherID != uintField(body, "publisher_id") {
+		return pluginError(VideoRoomErrorNoSuchFeed, fmt.Sprintf("No such stream (%d)", streamID))
+	}
+	delete(r.forwarders, streamID)
+
+	return map[string]interface{}{
+		"videoroom":    "stop_rtp_forward",
+		"room":         roomID,
+		"publisher_id": forwarder.PublisherID,
+		"stream_id":    streamID,
+	}
+}
+
+// destroyRoom menangani request destroy dan memberitahu semua peserta
+func (s *Server) destroyRoom(body map[string]interface{}) map[string]interface{} {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return noSuchRoom(roomID)
+	}
+
+	if secret, _ := body["secret"].(string); r.secret != "" && secret != r.secret {
+		return pluginError(VideoRoomErrorUnauthorized, "Unauthorized (wrong secret)")
+	}
+
+	destroyed := map[string]interface{}{
+		"videoroom": "destroyed",
+		"room":      roomID,
+	}
+
+	for _, participant := range r.participants {
+		s.enqueue(participant.SessionID, pluginEvent(participant, "", destroyed, nil))
+		resetVideoRoomState(participant)
+	}
+	for _, subscriber := range r.subscribers {
+		s.enqueue(subscriber.SessionID, pluginEvent(subscriber, "", destroyed, nil))
+		resetVideoRoomState(subscriber)
+	}
+
+	delete(s.rooms, roomID)
+
+	return destroyed
+}
+
+// joinRoom menangani join sebagai publisher atau subscriber
+func (s *Server) joinRoom(handle *Handle, body map[string]interface{}) *pluginReply {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return &pluginReply{Data: noSuchRoom(roomID)}
+	}
+
+	if handle.PType != "" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorAlreadyJoined, "Already in as a "+handle.PType+" on this handle")}
+	}
+
+	ptype, _ := body["ptype"].(string)
+	switch ptype {
+	case "publisher":
+		participantID := uintField(body, "id")
+		if participantID == 0 {
+			participantID = s.newID()
+		}
+		if _, exists := r.participants[participantID]; exists {
+			return &pluginReply{Data: pluginError(VideoRoomErrorIDExists, fmt.Sprintf("User ID %d already exists", participantID))}
+		}
+
+		display, _ := body["display"].(string)
+
+		handle.Room = roomID
+		handle.PType = "publisher"
+		handle.ParticipantID = participantID
+		handle.PrivateID = s.newID()
+		handle.Display = display
+		r.participants[participantID] = handle
+
+		return &pluginReply{Data: map[string]interface{}{
+			"videoroom":   "joined",
+			"room":        roomID,
+			"description": r.description,
+			"id":          participantID,
+			"private_id":  handle.PrivateID,
+			"publishers":  describePublishers(r, participantID),
+		}}
+
+	case "subscriber", "listener":
+		feedID := uintField(body, "feed")
+		if feedID == 0 {
+			// Format multistream: streams: [{feed: id}]
+			if streams, ok := body["streams"].([]interface{}); ok && len(streams) > 0 {
+				if stream, ok := streams[0].(map[string]interface{}); ok {
+					feedID = uintField(stream, "feed")
+				}
+			}
+		}
+
+		publisher, exists := r.participants[feedID]
+		if !exists || !publisher.Publishing {
+			return &pluginReply{Data: pluginError(VideoRoomErrorNoSuchFeed, fmt.Sprintf("No such feed (%d)", feedID))}
+		}
+
+		handle.Room = roomID
+		handle.PType = "subscriber"
+		handle.Feed = feedID
+		handle.PrivateID = uintField(body, "private_id")
+		handle.Display = publisher.Display
+		r.subscribers[handle.ID] = handle
+
+		return &pluginReply{
+			Data: map[string]interface{}{
+				"videoroom": "attached",
+				"room":      roomID,
+				"id":        feedID,
+				"display":   publisher.Display,
+			},
+			Jsep: &JSEP{Type: "offer", SDP: fakeSDP("offer", handle.ID)},
+		}
+
+	default:
+		return &pluginReply{Data: pluginError(VideoRoomErrorInvalidRequest, fmt.Sprintf("Invalid element (ptype: %s)", ptype))}
+	}
+}
+
+// configure menangani publish dan configure pada publisher maupun subscriber
+func (s *Server) configure(handle *Handle, request string, body map[string]interface{}, jsep *JSEP) *pluginReply {
+	if handle.PType == "" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorJoinFirst, "Can't handle non-joined participant, join first")}
+	}
+
+	configure := make(map[string]interface{})
+	for key, value := range body {
+		if key != "request" {
+			configure[key] = value
+		}
+	}
+	handle.Configure = configure
+
+	if handle.PType == "subscriber" {
+		reply := &pluginReply{Data: map[string]interface{}{
+			"videoroom":  "event",
+			"room":       handle.Room,
+			"configured": "ok",
+		}}
+
+		// Seperti Janus, perpindahan layer simulcast/SVC diumumkan dengan event terpisah
+		layer := map[string]interface{}{
+			"videoroom": "event",
+			"room":      handle.Room,
+		}
+		for _, key := range []string{"substream", "temporal", "spatial_layer", "temporal_layer"} {
+			if value, exists := body[key]; exists {
+				layer[key] = value
+			}
+		}
+		if len(layer) > 2 {
+			reply.After = append(reply.After, outgoingEvent{
+				SessionID: handle.SessionID,
+				Message:   pluginEvent(handle, "", layer, nil),
+			})
+		}
+		return reply
+	}
+
+	if request == "publish" && handle.Publishing {
+		return &pluginReply{Data: pluginError(VideoRoomErrorAlreadyPublished, "Can't publish, already published")}
+	}
+
+	data := map[string]interface{}{
+		"videoroom":  "event",
+		"room":       handle.Room,
+		"configured": "ok",
+	}
+
+	if jsep == nil {
+		return &pluginReply{Data: data}
+	}
+
+	if jsep.Type != "offer" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorInvalidSDPType, fmt.Sprintf("Invalid SDP type (%s)", jsep.Type))}
+	}
+
+	firstPublish := !handle.Publishing
+	handle.Publishing = true
+	data["audio_codec"] = "opus"
+	data["video_codec"] = "vp8"
+
+	reply := &pluginReply{
+		Data: data,
+		Jsep: &JSEP{Type: "answer", SDP: fakeSDP("answer", handle.ID)},
+		After: []outgoingEvent{
+			{SessionID: handle.SessionID, Message: coreEvent(handle, "webrtcup", nil)},
+			{SessionID: handle.SessionID, Message: coreEvent(handle, "media", map[string]interface{}{"type": "audio", "receiving": true})},
+			{SessionID: handle.SessionID, Message: coreEvent(handle, "media", map[string]interface{}{"type": "video", "receiving": true})},
+		},
+	}
+
+	if firstPublish {
+		r := s.rooms[handle.Room]
+		publishers := map[string]interface{}{
+			"videoroom":  "event",
+			"room":       handle.Room,
+			"publishers": []map[string]interface{}{describePublisher(handle)},
+		}
+		for id, participant := range r.participants {
+			if id == handle.ParticipantID {
+				continue
+			}
+			reply.After = append(reply.After, outgoingEvent{
+				SessionID: participant.SessionID,
+				Message:   pluginEvent(participant, "", publishers, nil),
+			})
+		}
+	}
+
+	return reply
+}
+
+// unpublish menghentikan publish dan memberitahu peserta lain
+func (s *Server) unpublish(handle *Handle) *pluginReply {
+	if handle.PType != "publisher" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorJoinFirst, "Can't unpublish, not joined as a publisher")}
+	}
+	if !handle.Publishing {
+		return &pluginReply{Data: pluginError(VideoRoomErrorNotPublished, "Can't unpublish, not published")}
+	}
+
+	handle.Publishing = false
+	if r, exists := s.rooms[handle.Room]; exists {
+		r.dropForwarders(handle.ParticipantID)
+	}
+
+	return &pluginReply{
+		Data: map[string]interface{}{
+			"videoroom":   "event",
+			"room":        handle.Room,
+			"unpublished": "ok",
+		},
+		After: s.notifyParticipants(handle, "unpublished"),
+	}
+}
+
+// kick mengeluarkan publisher dari room. Participant yang di-kick menerima leaving
+// dengan reason kicked dan hangup, peserta lain menerima unpublished (jika sedang
+// publish) lalu kicked.
+func (s *Server) kick(body map[string]interface{}) *pluginReply {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return &pluginReply{Data: noSuchRoom(roomID)}
+	}
+
+	if secret, _ := body["secret"].(string); r.secret != "" && secret != r.secret {
+		return &pluginReply{Data: pluginError(VideoRoomErrorUnauthorized, "Unauthorized (wrong secret)")}
+	}
+
+	participantID := uintField(body, "id")
+	participant, exists := r.participants[participantID]
+	if !exists {
+		return &pluginReply{Data: pluginError(VideoRoomErrorNoSuchFeed, fmt.Sprintf("No such user %d in room %d", participantID, roomID))}
+	}
+
+	events := []outgoingEvent{
+		{SessionID: participant.SessionID, Message: pluginEvent(participant, "", map[string]interface{}{
+			"videoroom": "event",
+			"room":      roomID,
+			"leaving":   "ok",
+			"reason":    "kicked",
+		}, nil)},
+		{SessionID: participant.SessionID, Message: coreEvent(participant, "hangup", map[string]interface{}{
+			"reason": "Kicked",
+		})},
+	}
+	if participant.Publishing {
+		events = append(events, s.notifyParticipants(participant, "unpublished")...)
+	}
+	events = append(events, s.notifyParticipants(participant, "kicked")...)
+
+	delete(r.participants, participantID)
+	r.dropForwarders(participantID)
+	resetVideoRoomState(participant)
+
+	return &pluginReply{
+		Data: map[string]interface{}{
+			"videoroom": "success",
+		},
+		After: events,
+	}
+}
+
+// moderate mute atau unmute satu mid publisher dan memberitahu semua peserta room
+func (s *Server) moderate(body map[string]interface{}) *pluginReply {
+	roomID := uintField(body, "room")
+	r, exists := s.rooms[roomID]
+	if !exists {
+		return &pluginReply{Data: noSuchRoom(roomID)}
+	}
+
+	if secret, _ := body["secret"].(string); r.secret != "" && secret != r.secret {
+		return &pluginReply{Data: pluginError(VideoRoomErrorUnauthorized, "Unauthorized (wrong secret)")}
+	}
+
+	participantID := uintField(body, "id")
+	participant, exists := r.participants[participantID]
+	if !exists {
+		return &pluginReply{Data: pluginError(VideoRoomErrorNoSuchFeed, fmt.Sprintf("No such user %d in room %d", participantID, roomID))}
+	}
+
+	mid, _ := body["mid"].(string)
+	if mid == "" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorMissingElement, "Missing mandatory element (mid)")}
+	}
+	mute, _ := body["mute"].(bool)
+
+	if participant.Muted == nil {
+		participant.Muted = make(map[string]bool)
+	}
+	participant.Muted[mid] = mute
+
+	moderation := "unmuted"
+	if mute {
+		moderation = "muted"
+	}
+
+	data := map[string]interface{}{
+		"videoroom":  "event",
+		"room":       roomID,
+		"id":         participantID,
+		"mid":        mid,
+		"moderation": moderation,
+	}
+
+	var events []outgoingEvent
+	for _, other := range r.participants {
+		events = append(events, outgoingEvent{
+			SessionID: other.SessionID,
+			Message:   pluginEvent(other, "", data, nil),
+		})
+	}
+
+	return &pluginReply{
+		Data: map[string]interface{}{
+			"videoroom": "success",
+		},
+		After: events,
+	}
+}
+
+// startSubscription menangani start dengan JSEP answer dari subscriber
+func (s *Server) startSubscription(handle *Handle, jsep *JSEP) *pluginReply {
+	if handle.PType != "subscriber" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorJoinFirst, "Join as a subscriber first")}
+	}
+	if jsep == nil {
+		return &pluginReply{Data: pluginError(VideoRoomErrorMissingElement, "Missing mandatory element (jsep)")}
+	}
+	if jsep.Type != "answer" {
+		return &pluginReply{Data: pluginError(VideoRoomErrorInvalidSDPType, fmt.Sprintf("Invalid SDP type (%s)", jsep.Type))}
+	}
+
+	handle.Started = true
+
+	return &pluginReply{
+		Data: map[string]interface{}{
+			"videoroom": "event",
+			"room":      handle.Room,
+			"started":   "ok",
+		},
+		After: []outgoingEvent{
+			{SessionID: handle.SessionID, Message: coreEvent(handle, "webrtcup", nil)},
+		},
+	}
+}
+
+// leave menangani request leave dari publisher maupun subscriber
+func (s *Server) leave(handle *Handle) *pluginReply {
+	switch handle.PType {
+	case "publisher":
+		roomID := handle.Room
+		return &pluginReply{
+			Data: map[string]interface{}{
+				"videoroom": "event",
+				"room":      roomID,
+				"leaving":   "ok",
+			},
+			Async: true,
+			After: s.removeParticipant(handle),
+		}
+	case "subscriber":
+		roomID := handle.Room
+		s.removeParticipant(handle)
+		return &pluginReply{
+			Data: map[string]interface{}{
+				"videoroom": "event",
+				"room":      roomID,
+				"left":      "ok",
+			},
+			Async: true,
+		}
+	default:
+		return &pluginReply{Data: pluginError(VideoRoomErrorJoinFirst, "Can't leave, not joined"), Async: true}
+	}
+}
+
+// leaveVideoRoom mengeluarkan handle dari room ketika handle di-detach atau session dihapus.
+// Dipanggil saat s.mu dipegang.
+func (s *Server) leaveVideoRoom(handle *Handle) {
+	for _, event := range s.removeParticipant(handle) {
+		s.enqueue(event.SessionID, event.Message)
+	}
+}
+
+// removeParticipant mengeluarkan handle dari room dan mengembalikan event
+// untuk peserta lain (unpublished jika sedang publish, lalu leaving)
+func (s *Server) removeParticipant(handle *Handle) []outgoingEvent {
+	r, exists := s.rooms[handle.Room]
+	if !exists {
+		resetVideoRoomState(handle)
+		return nil
+	}
+
+	var events []outgoingEvent
+	switch handle.PType {
+	case "publisher":
+		if handle.Publishing {
+			events = append(events, s.notifyParticipants(handle, "unpublished")...)
+		}
+		events = append(events, s.notifyParticipants(handle, "leaving")...)
+		delete(r.participants, handle.ParticipantID)
+		r.dropForwarders(handle.ParticipantID)
+	case "subscriber":
+		delete(r.subscribers, handle.ID)
+	}
+
+	resetVideoRoomState(handle)
+	return events
+}
+
+// notifyParticipants membuat event (unpublished, leaving atau kicked) untuk publisher lain di room
+func (s *Server) notifyParticipants(handle *Handle, field string) []outgoingEvent {
+	r, exists := s.rooms[handle.Room]
+	if !exists {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"videoroom": "event",
+		"room":      handle.Room,
+		field:       handle.ParticipantID,
+	}
+
+	var events []outgoingEvent
+	for id, participant := range r.participants {
+		if id == handle.ParticipantID {
+			continue
+		}
+		events = append(events, outgoingEvent{
+			SessionID: participant.SessionID,
+			Message:   pluginEvent(participant, "", data, nil),
+		})
+	}
+	return events
+}
+
+// dropForwarders menghapus RTP forwarder publisher yang berhenti publish, seperti Janus
+func (r *room) dropForwarders(publisherID uint64) {
+	for streamID, forwarder := range r.forwarders {
+		if forwarder.PublisherID == publisherID {
+			delete(r.forwarders, streamID)
+		}
+	}
+}
+
+// resetVideoRoomState menghapus state videoroom dari handle
+func resetVideoRoomState(handle *Handle) {
+	handle.Room = 0
+	handle.PType = ""
+	handle.ParticipantID = 0
+	handle.PrivateID = 0
+	handle.Feed = 0
+	handle.Publishing = false
+	handle.Started = false
+	handle.Muted = nil
+}
+
+// describePublishers mengembalikan publisher aktif di room kecuali participant tertentu
+func describePublishers(r *room, excludeID uint64) []map[string]interface{} {
+	publishers := make([]map[string]interface{}, 0)
+	for id, participant := range r.participants {
+		if id == excludeID || !participant.Publishing {
+			continue
+		}
+		publishers = append(publishers, describePublisher(participant))
+	}
+	return publishers
+}
+
+// describePublisher mengubah participant menjadi entri daftar publisher
+func describePublisher(participant *Handle) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          participant.ParticipantID,
+		"display":     participant.Display,
+		"audio_codec": "opus",
+		"video_codec": "vp8",
+	}
+}
+
+// coreEvent membuat event core Janus (webrtcup, media, ...) untuk handle
+func coreEvent(handle *Handle, janus string, fields map[string]interface{}) map[string]interface{} {
+	event := map[string]interface{}{
+		"janus":      janus,
+		"session_id": handle.SessionID,
+		"sender":     handle.ID,
+	}
+	for key, value := range fields {
+		event[key] = value
+	}
+	return event
+}
+
+// pluginError membuat data error plugin videoroom
+func pluginError(code int, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"videoroom":  "event",
+		"error_code": code,
+		"error":      reason,
+	}
+}
+
+// noSuchRoom membuat error room tidak ditemukan
+func noSuchRoom(roomID uint64) map[string]interface{} {
+	return pluginError(VideoRoomErrorNoSuchRoom, fmt.Sprintf("No such room (%d)", roomID))
+}
+
+// uintField membaca field angka dari body tanpa kehilangan presisi uint64
+func uintField(body map[string]interface{}, key string) uint64 {
+	switch value := body[key].(type) {
+	case json.Number:
+		parsed, err := strconv.ParseUint(value.String(), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return parsed
+	case float64:
+		return uint64(value)
+	case string:
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0
+		}
+		return parsed
+	default:
+		return 0
+	}
+}
+
+// fakeSDP membuat SDP sederhana dengan satu audio dan satu video
+func fakeSDP(kind string, id uint64) string {
+	return fmt.Sprintf("v=0\r\n"+
+		"o=- %d 1 IN IP4 127.0.0.1\r\n"+
+		"s=janustest %s\r\n"+
+		"t=0 0\r\n"+
+		"a=group:BUNDLE 0 1\r\n"+
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n"+
+		"c=IN IP4 127.0.0.1\r\n"+
+		"a=mid:0\r\n"+
+		"a=rtpmap:111 opus/48000/2\r\n"+
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n"+
+		"c=IN IP4 127.0.0.1\r\n"+
+		"a=mid:1\r\n"+
+		"a=rtpmap:96 VP8/90000\r\n", id, kind)
+}
@@ -0,0 +1,133 @@
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+)
+
+// WithPolicy mengembalikan salinan opsi room yang parameter medianya diganti dengan
+// kebijakan media room. Codec kosong pada kebijakan tidak mengubah codec opsi, dan
+// data channel hanya aktif jika diaktifkan di opsi dan di kebijakan.
+func (o VideoRoomOptions) WithPolicy(policy mediaplane.MediaPolicy) VideoRoomOptions {
+	if policy.VideoCodec != "" {
+		o.VideoCodec = policy.VideoCodec
+	}
+	if policy.AudioCodec != "" {
+		o.AudioCodec = policy.AudioCodec
+	}
+	o.Bitrate = policy.Bitrate
+	o.FirFreq = policy.FirFreq
+	o.AudioLevelEvent = policy.AudioLevelEvent
+	o.AudioActivePackets = policy.AudioActivePackets
+	o.AudioLevelAverage = policy.AudioLevelAverage
+	o.AudioBridge = policy.AudioBridge
+	o.DataChannels = o.DataChannels && policy.DataChannels
+	return o
+}
+
+// ApplyMediaPolicy menerapkan kebijakan media baru ke room yang sedang berjalan:
+// batas bitrate dan fir_freq diubah dengan request edit, lalu setiap publisher
+// di-configure ulang agar batas bitrate barunya langsung berlaku. Codec dan
+// audiolevel_event tidak dapat diubah oleh edit Janus dan baru berlaku saat
+// room dibuat ulang, begitu juga perpindahan antara videoroom dan audiobridge
+// serta aktif tidaknya data channel.
+// Room yang belum aktif tidak diubah.
+func (sh *SignalingHandler) ApplyMediaPolicy(roomID string, policy mediaplane.MediaPolicy) error {
+	sh.mu.Lock()
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		sh.mu.Unlock()
+		return nil
+	}
+
+	previous := roomSession.Options
+	roomSession.Options = previous.WithPolicy(policy)
+	roomSession.Options.AudioBridge = previous.AudioBridge
+	roomSession.Options.DataChannels = previous.DataChannels
+	options := roomSession.Options
+	roomPlugin := roomSession.Plugin
+	janusRoom := roomSession.JanusRoom
+
+	// Handle publisher disalin karena migrasi failover dapat mengosongkan
+	// publisherSession.Plugin setelah lock dilepas
+	publishers := make(map[string]*PluginHandle)
+	userIDs := make([]string, 0, len(roomSession.Publishers))
+	for userID, publisherSession := range roomSession.Publishers {
+		userIDs = append(userIDs, userID)
+		if publisherSession.IsPublishing && publisherSession.Plugin != nil {
+			publishers[userID] = publisherSession.Plugin
+		}
+	}
+	sh.mu.Unlock()
+
+	if roomPlugin == nil {
+		return fmt.Errorf("room plugin not available: %s", roomID)
+	}
+
+	if policy.AudioBridge != options.AudioBridge {
+		sh.logger.WithField("room_id", roomID).Info("Plugin change applies when the room is recreated")
+	}
+
+	// Audio bridge tidak memiliki batas bitrate video maupun keyframe
+	if options.AudioBridge {
+		for _, userID := range userIDs {
+			sh.sendMediaEvent(roomID, userID, "media-policy", policy)
+		}
+		return nil
+	}
+
+	// Edit sinkron, configure publisher menunggu event sehingga dikirim tanpa memegang lock
+	if err := roomPlugin.EditVideoRoom(janusRoom, options.Bitrate, options.FirFreq); err != nil {
+		return err
+	}
+
+	for userID, publisherPlugin := range publishers {
+		if err := publisherPlugin.ConfigurePublisherBitrate(options.Bitrate); err != nil {
+			sh.logger.WithFields(logrus.Fields{
+				"room_id": roomID,
+				"user_id": userID,
+			}).Errorf("Failed to configure publisher bitrate: %v", err)
+		}
+	}
+
+	if previous.VideoCodec != options.VideoCodec || previous.AudioCodec != options.AudioCodec ||
+		previous.AudioLevelEvent != options.AudioLevelEvent {
+		sh.logger.WithField("room_id", roomID).Info("Codec and audio level changes apply when the room is recreated")
+	}
+
+	// Browser dapat menyesuaikan maxBitrate encoder dengan batas baru
+	for _, userID := range userIDs {
+		sh.sendMediaEvent(roomID, userID, "media-policy", policy)
+	}
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":    roomID,
+		"bitrate":    options.Bitrate,
+		"fir_freq":   options.FirFreq,
+		"publishers": len(publishers),
+	}).Info("Applied media policy to room")
+
+	return nil
+}
+
+// roomOptions mengembalikan opsi untuk room baru: RoomOptions handler yang digabung
+// dengan kebijakan media room dari MediaPolicyLookup jika tersedia, ditambah
+// recording jika room sedang direkam. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) roomOptions(roomID string) VideoRoomOptions {
+	options := sh.RoomOptions
+	if sh.MediaPolicyLookup != nil {
+		if policy, ok := sh.MediaPolicyLookup(roomID); ok {
+			options = options.WithPolicy(policy)
+		}
+	}
+
+	if recDir, exists := sh.recordings[roomID]; exists {
+		options.Record = true
+		options.RecDir = recDir
+	}
+
+	return options
+}
@@ -0,0 +1,184 @@
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// KickParticipant mengeluarkan user dari video room Janus sehingga PeerConnection-nya
+// ditutup oleh Janus dan peserta lain menerima event kicked. Handle milik user
+// dilepas ketika hub memproses leave-room untuk user tersebut.
+func (sh *SignalingHandler) KickParticipant(roomID, userID string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, publisherSession, err := sh.findPublisher(roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if roomSession.Plugin == nil {
+		return fmt.Errorf("room plugin not available: %s", roomID)
+	}
+
+	kick := roomSession.Plugin.KickFromVideoRoom
+	if roomSession.Options.AudioBridge {
+		kick = roomSession.Plugin.KickFromAudioBridge
+	}
+	if err := kick(roomSession.JanusRoom, publisherSession.JanusID); err != nil {
+		return err
+	}
+	publisherSession.IsPublishing = false
+
+	// Data channel ikut diputus agar user tidak dapat mengirim pesan lagi
+	if roomSession.TextRoom != nil && publisherSession.DataPlugin != nil {
+		if err := roomSession.TextRoom.KickFromTextRoom(roomSession.JanusRoom, userID); err != nil {
+			sh.logger.WithField("user_id", userID).Errorf("Failed to kick participant from text room: %v", err)
+		}
+	}
+
+	sh.sendMediaEvent(roomID, userID, "kicked", nil)
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":  roomID,
+		"user_id":  userID,
+		"janus_id": publisherSession.JanusID,
+	}).Info("Participant kicked from Janus room")
+
+	return nil
+}
+
+// MuteParticipant mute atau unmute semua m-line audio atau video milik publisher
+// melalui request moderate Janus. Di audio bridge, audio user dikeluarkan dari mix
+// dengan request mute.
+func (sh *SignalingHandler) MuteParticipant(roomID, userID, kind string, mute bool) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, publisherSession, err := sh.findPublisher(roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if roomSession.Plugin == nil {
+		return fmt.Errorf("room plugin not available: %s", roomID)
+	}
+
+	if roomSession.Options.AudioBridge {
+		if kind != "audio" {
+			return fmt.Errorf("participant is not publishing %s: %s", kind, userID)
+		}
+		if err := roomSession.Plugin.MuteAudioBridge(roomSession.JanusRoom, publisherSession.JanusID, mute); err != nil {
+			return err
+		}
+	} else {
+		mids := publisherSession.Mids[kind]
+		if !publisherSession.IsPublishing || len(mids) == 0 {
+			return fmt.Errorf("participant is not publishing %s: %s", kind, userID)
+		}
+
+		for _, mid := range mids {
+			if err := roomSession.Plugin.ModerateVideoRoom(roomSession.JanusRoom, publisherSession.JanusID, mid, mute); err != nil {
+				return err
+			}
+		}
+	}
+
+	sh.sendMediaEvent(roomID, userID, "moderated", websocket.ModerationData{
+		Kind:  kind,
+		Muted: mute,
+	})
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+		"kind":    kind,
+		"mute":    mute,
+	}).Info("Participant moderated in Janus room")
+
+	return nil
+}
+
+// UnpublishParticipant menghentikan publish user melalui handle publisher miliknya.
+// User tetap berada di room dan tetap menerima media dari publisher lain. Di audio
+// bridge, PeerConnection yang sama membawa mix untuk user sehingga audio user
+// hanya dikeluarkan dari mix.
+func (sh *SignalingHandler) UnpublishParticipant(roomID, userID string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, publisherSession, err := sh.findPublisher(roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !publisherSession.IsPublishing {
+		return fmt.Errorf("participant is not publishing: %s", userID)
+	}
+
+	if roomSession.Options.AudioBridge {
+		if roomSession.Plugin == nil {
+			return fmt.Errorf("room plugin not available: %s", roomID)
+		}
+		if err := roomSession.Plugin.MuteAudioBridge(roomSession.JanusRoom, publisherSession.JanusID, true); err != nil {
+			return err
+		}
+	} else if err := publisherSession.Plugin.UnpublishFromVideoRoom(); err != nil {
+		return err
+	}
+	publisherSession.IsPublishing = false
+	publisherSession.Mids = nil
+
+	sh.sendMediaEvent(roomID, userID, "force-unpublished", nil)
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+	}).Info("Participant unpublished from Janus room")
+
+	return nil
+}
+
+// findPublisher mencari room session dan publisher session user.
+// Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) findPublisher(roomID, userID string) (*RoomSession, *PublisherSession, error) {
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: room %s", mediaplane.ErrParticipantNotFound, roomID)
+	}
+
+	publisherSession, exists := roomSession.Publishers[userID]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: user %s", mediaplane.ErrParticipantNotFound, userID)
+	}
+	if publisherSession.Plugin == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrPublisherReconnecting, userID)
+	}
+
+	return roomSession, publisherSession, nil
+}
+
+// sdpMids mengembalikan mid setiap m-line pada SDP, dikelompokkan per jenis media
+// ("audio", "video", "application")
+func sdpMids(sdp string) map[string][]string {
+	mids := make(map[string][]string)
+
+	kind := ""
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "m="):
+			kind = strings.SplitN(strings.TrimPrefix(line, "m="), " ", 2)[0]
+		case strings.HasPrefix(line, "a=mid:") && kind != "":
+			mids[kind] = append(mids[kind], strings.TrimPrefix(line, "a=mid:"))
+		}
+	}
+
+	return mids
+}
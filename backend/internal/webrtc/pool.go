@@ -0,0 +1,366 @@
+package webrtc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Interval default health check instance Janus
+	DefaultHealthCheckInterval = 10 * time.Second
+
+	// Jumlah health check gagal berturut-turut sebelum instance dianggap mati
+	healthCheckFailureThreshold = 3
+)
+
+// JanusInstance merepresentasikan satu server Janus di dalam pool
+type JanusInstance struct {
+	// Nama instance (untuk log dan statistik)
+	Name string
+
+	// Client untuk instance ini
+	Client *JanusClient
+
+	// Bobot relatif kapasitas instance (lebih besar = lebih banyak room)
+	Weight int
+
+	// Status health instance
+	Healthy bool
+
+	// Jumlah health check gagal berturut-turut
+	FailureCount int
+
+	// Waktu health check terakhir
+	LastCheck time.Time
+}
+
+// JanusPool membagi room ke beberapa instance Janus. Setiap room dipasang
+// pada satu instance, room baru ditempatkan pada instance dengan beban
+// terkecil relatif terhadap bobotnya, dan room dipindahkan ketika
+// instance-nya gagal health check.
+type JanusPool struct {
+	// Instance Janus dalam pool
+	Instances []*JanusInstance
+
+	// Instance tempat setiap room dipasang
+	rooms map[string]*JanusInstance
+
+	// Callback ketika room harus dipindahkan karena instance-nya mati
+	onFailover func(instance *JanusInstance, roomIDs []string)
+
+	// Channel untuk menghentikan health check loop
+	stop chan struct{}
+
+	// Mutex untuk thread safety
+	mu sync.RWMutex
+
+	// Logger
+	logger *logrus.Logger
+}
+
+// NewJanusPool membuat instance JanusPool baru
+func NewJanusPool(instances []*JanusInstance) (*JanusPool, error) {
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("janus pool requires at least one instance")
+	}
+
+	for _, instance := range instances {
+		if instance.Weight <= 0 {
+			instance.Weight = 1
+		}
+	}
+
+	return &JanusPool{
+		Instances: instances,
+		rooms:     make(map[string]*JanusInstance),
+		logger:    logrus.New(),
+	}, nil
+}
+
+// ParseJanusInstances membuat daftar instance dari spesifikasi
+// "url|weight|adminURL,url|weight|adminURL". Bobot dan admin URL opsional.
+// URL ws:// atau wss:// memakai transport WebSocket, selain itu HTTP. Semua
+// instance dimulai tidak sehat sampai health check pertama berhasil.
+func ParseJanusInstances(spec, apiSecret, adminSecret string) ([]*JanusInstance, error) {
+	var instances []*JanusInstance
+
+	for i, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, "|")
+		janusURL := strings.TrimSpace(parts[0])
+
+		weight := 1
+		if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+			parsed, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid weight for janus instance %s: %w", janusURL, err)
+			}
+			weight = parsed
+		}
+
+		adminURL := ""
+		if len(parts) > 2 {
+			adminURL = strings.TrimSpace(parts[2])
+		}
+
+		// Koneksi tidak dibuka di sini: instance yang mati saat startup tidak boleh
+		// menghentikan server. Health check pool yang menyambungkan client dan
+		// menandai instance sehat.
+		var client *JanusClient
+		if strings.HasPrefix(janusURL, "ws://") || strings.HasPrefix(janusURL, "wss://") {
+			transport := NewWebSocketTransport(janusURL)
+			client = newJanusClient(transport, adminURL, apiSecret, adminSecret)
+			transport.bind(client.handleMessage, client.handleDisconnect)
+		} else {
+			client = NewJanusClient(janusURL, adminURL, apiSecret, adminSecret)
+		}
+
+		instances = append(instances, &JanusInstance{
+			Name:   fmt.Sprintf("janus-%d", i+1),
+			Client: client,
+			Weight: weight,
+		})
+	}
+
+	return instances, nil
+}
+
+// ParseJanusAdminInstances membuat client Admin API setiap instance dari spesifikasi
+// pool yang sama dengan ParseJanusInstances, tanpa membuka koneksi ke Janus.
+// Nama instance sama dengan ParseJanusInstances; instance tanpa admin URL dilewati.
+func ParseJanusAdminInstances(spec, adminSecret string) []*JanusAdminInstance {
+	var instances []*JanusAdminInstance
+
+	for i, entry := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "|")
+		if len(parts) < 3 || strings.TrimSpace(parts[2]) == "" {
+			continue
+		}
+
+		instances = append(instances, &JanusAdminInstance{
+			Name:   fmt.Sprintf("janus-%d", i+1),
+			Client: NewJanusAdminClient(strings.TrimSpace(parts[2]), adminSecret),
+		})
+	}
+
+	return instances
+}
+
+// SetFailoverHandler mengatur callback yang dipanggil ketika room harus
+// dipindahkan dari instance yang gagal health check
+func (p *JanusPool) SetFailoverHandler(handler func(instance *JanusInstance, roomIDs []string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onFailover = handler
+}
+
+// ClientForRoom mengembalikan client Janus untuk room. Room yang sudah
+// dipasang tetap di instance-nya selama instance sehat, room baru
+// ditempatkan pada instance dengan beban terkecil. loads adalah jumlah
+// publisher per room menurut pemanggil; pool tidak memanggil balik ke
+// pemanggil sehingga tidak ada urutan lock yang harus dijaga.
+func (p *JanusPool) ClientForRoom(roomID string, loads map[string]int) (*JanusClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if instance, exists := p.rooms[roomID]; exists && instance.Healthy {
+		return instance.Client, nil
+	}
+
+	instance := p.leastLoaded(loads)
+	if instance == nil {
+		return nil, fmt.Errorf("no healthy janus instance available")
+	}
+
+	p.rooms[roomID] = instance
+
+	p.logger.WithFields(logrus.Fields{
+		"room_id":  roomID,
+		"instance": instance.Name,
+	}).Info("Assigned room to Janus instance")
+
+	return instance.Client, nil
+}
+
+// ReleaseRoom melepas room dari instance-nya
+func (p *JanusPool) ReleaseRoom(roomID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.rooms, roomID)
+}
+
+// InstanceForClient mengembalikan instance milik client tertentu
+func (p *JanusPool) InstanceForClient(client *JanusClient) *JanusInstance {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, instance := range p.Instances {
+		if instance.Client == client {
+			return instance
+		}
+	}
+	return nil
+}
+
+// leastLoaded memilih instance sehat dengan beban per bobot terkecil
+func (p *JanusPool) leastLoaded(roomLoads map[string]int) *JanusInstance {
+	loads := make(map[*JanusInstance]int)
+	for roomID, instance := range p.rooms {
+		// Room tanpa publisher tetap dihitung agar room kosong tidak menumpuk
+		loads[instance] += 1 + roomLoads[roomID]
+	}
+
+	var best *JanusInstance
+	var bestScore float64
+	for _, instance := range p.Instances {
+		if !instance.Healthy {
+			continue
+		}
+
+		score := float64(loads[instance]) / float64(instance.Weight)
+		if best == nil || score < bestScore {
+			best = instance
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// Start membuat session di setiap instance dan memulai health check loop
+func (p *JanusPool) Start(interval time.Duration) {
+	for _, instance := range p.Instances {
+		p.checkInstance(instance)
+	}
+
+	p.mu.Lock()
+	if p.stop != nil {
+		p.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	p.stop = stop
+	p.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for _, instance := range p.Instances {
+					p.checkInstance(instance)
+				}
+			}
+		}
+	}()
+}
+
+// Close menghentikan health check dan menutup semua client
+func (p *JanusPool) Close() error {
+	p.mu.Lock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	p.mu.Unlock()
+
+	for _, instance := range p.Instances {
+		if err := instance.Client.Close(); err != nil {
+			p.logger.WithError(err).WithField("instance", instance.Name).Error("Failed to close Janus client")
+		}
+	}
+
+	return nil
+}
+
+// checkInstance melakukan health check dengan keepalive (yang juga menjaga
+// session tetap hidup) dan memindahkan room jika instance dinyatakan mati
+func (p *JanusPool) checkInstance(instance *JanusInstance) {
+	err := instance.Client.Keepalive()
+
+	p.mu.Lock()
+	instance.LastCheck = time.Now()
+
+	if err == nil {
+		if !instance.Healthy {
+			p.logger.WithField("instance", instance.Name).Info("Janus instance is healthy")
+		}
+		instance.Healthy = true
+		instance.FailureCount = 0
+		p.mu.Unlock()
+		return
+	}
+
+	instance.FailureCount++
+	p.logger.WithError(err).WithFields(logrus.Fields{
+		"instance": instance.Name,
+		"failures": instance.FailureCount,
+	}).Warn("Janus instance health check failed")
+
+	if !instance.Healthy || instance.FailureCount < healthCheckFailureThreshold {
+		p.mu.Unlock()
+		return
+	}
+
+	// Instance mati, lepaskan semua room-nya agar ditempatkan ulang
+	instance.Healthy = false
+	var roomIDs []string
+	for roomID, assigned := range p.rooms {
+		if assigned == instance {
+			roomIDs = append(roomIDs, roomID)
+			delete(p.rooms, roomID)
+		}
+	}
+	handler := p.onFailover
+	p.mu.Unlock()
+
+	p.logger.WithFields(logrus.Fields{
+		"instance":   instance.Name,
+		"room_count": len(roomIDs),
+	}).Error("Janus instance marked unhealthy, moving rooms")
+
+	if handler != nil && len(roomIDs) > 0 {
+		handler(instance, roomIDs)
+	}
+}
+
+// Stats mengembalikan statistik pool
+func (p *JanusPool) Stats() map[string]interface{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	roomCounts := make(map[*JanusInstance]int)
+	for _, instance := range p.rooms {
+		roomCounts[instance]++
+	}
+
+	instances := make([]map[string]interface{}, 0, len(p.Instances))
+	for _, instance := range p.Instances {
+		instances = append(instances, map[string]interface{}{
+			"name":          instance.Name,
+			"weight":        instance.Weight,
+			"healthy":       instance.Healthy,
+			"failure_count": instance.FailureCount,
+			"last_check":    instance.LastCheck,
+			"room_count":    roomCounts[instance],
+		})
+	}
+
+	return map[string]interface{}{
+		"instances":  instances,
+		"room_count": len(p.rooms),
+	}
+}
@@ -0,0 +1,81 @@
+package webrtc
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRecordingDir adalah direktori recording bawaan image Janus
+const DefaultRecordingDir = "/opt/janus/share/janus/recordings"
+
+// StartRecording mulai merekam semua publisher room ke directory (relatif terhadap
+// RecordingDir). Jika room belum aktif di Janus, recording dimulai saat room dibuat.
+func (sh *SignalingHandler) StartRecording(roomID, directory string) error {
+	recDir := path.Join(sh.RecordingDir, path.Clean("/"+directory))
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	// Recording .mjr per publisher hanya tersedia di videoroom
+	if sh.audioBridgeRoom(roomID) {
+		return errAudioBridgeUnsupported("recording", roomID)
+	}
+
+	sh.recordings[roomID] = recDir
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		sh.logger.WithFields(logrus.Fields{
+			"room_id": roomID,
+			"rec_dir": recDir,
+		}).Info("Recording scheduled until the room is created")
+		return nil
+	}
+
+	if roomSession.Plugin == nil {
+		delete(sh.recordings, roomID)
+		return fmt.Errorf("room plugin not available: %s", roomID)
+	}
+
+	if err := roomSession.Plugin.EnableVideoRoomRecording(roomSession.JanusRoom, true, recDir); err != nil {
+		delete(sh.recordings, roomID)
+		return err
+	}
+	roomSession.Options.Record = true
+	roomSession.Options.RecDir = recDir
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"rec_dir": recDir,
+	}).Info("Started room recording")
+
+	return nil
+}
+
+// StopRecording menghentikan recording room. File .mjr ditutup oleh Janus.
+func (sh *SignalingHandler) StopRecording(roomID string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	delete(sh.recordings, roomID)
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists || !roomSession.Options.Record {
+		return nil
+	}
+
+	if roomSession.Plugin == nil {
+		return fmt.Errorf("room plugin not available: %s", roomID)
+	}
+
+	if err := roomSession.Plugin.EnableVideoRoomRecording(roomSession.JanusRoom, false, ""); err != nil {
+		return err
+	}
+	roomSession.Options.Record = false
+
+	sh.logger.WithField("room_id", roomID).Info("Stopped room recording")
+
+	return nil
+}
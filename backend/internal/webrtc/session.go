@@ -0,0 +1,227 @@
+package webrtc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultKeepaliveInterval adalah interval keepalive default.
+// Janus menghapus session yang tidak aktif selama 60 detik.
+const DefaultKeepaliveInterval = 25 * time.Second
+
+// SetSessionRecreatedHandler mengatur callback yang dipanggil setelah session
+// dibuat ulang dan semua plugin handle di-attach ulang
+func (jc *JanusClient) SetSessionRecreatedHandler(handler func(oldSessionID, newSessionID uint64)) {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	jc.onSessionRecreated = handler
+}
+
+// SetTokenIssuer mengatur penerbit stored token untuk handle yang di-attach ulang
+// setelah session dibuat ulang. issue menerima opaque ID handle (user ID).
+func (jc *JanusClient) SetTokenIssuer(issue func(opaqueID string) (string, error)) {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	jc.tokenIssuer = issue
+}
+
+// StartKeepalive memulai loop keepalive di background
+func (jc *JanusClient) StartKeepalive(interval time.Duration) {
+	jc.mu.Lock()
+	if jc.stopKeepalive != nil {
+		jc.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	jc.stopKeepalive = stop
+	jc.mu.Unlock()
+
+	go jc.keepaliveLoop(interval, stop)
+
+	jc.logger.WithField("interval", interval).Info("Started Janus keepalive")
+}
+
+// StopKeepalive menghentikan loop keepalive
+func (jc *JanusClient) StopKeepalive() {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+
+	if jc.stopKeepalive != nil {
+		close(jc.stopKeepalive)
+		jc.stopKeepalive = nil
+	}
+}
+
+// keepaliveLoop mengirim keepalive secara berkala
+func (jc *JanusClient) keepaliveLoop(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := jc.Keepalive(); err != nil {
+				jc.logger.WithError(err).Warn("Janus keepalive failed")
+			}
+		}
+	}
+}
+
+// Keepalive mengirim keepalive untuk session yang aktif.
+// Jika belum ada session (misalnya Janus belum siap saat startup), session baru dibuat.
+func (jc *JanusClient) Keepalive() error {
+	if jc.currentSession() == 0 {
+		if _, err := jc.CreateSession(); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	request := JanusRequest{
+		Janus: "keepalive",
+	}
+
+	if _, err := jc.sessionRequest(nil, request, false); err != nil {
+		return fmt.Errorf("failed to send keepalive: %w", err)
+	}
+
+	return nil
+}
+
+// sessionRequest mengirim request yang terikat pada session (dan handle jika ada).
+// Jika Janus menjawab session tidak ditemukan, session dibuat ulang secara
+// transparan dan request diulang satu kali dengan ID yang baru.
+func (jc *JanusClient) sessionRequest(handle *PluginHandle, request JanusRequest, waitEvent bool) (*JanusResponse, error) {
+	for attempt := 0; ; attempt++ {
+		// Session dan handle ID dibaca berpasangan dari handle agar request tidak
+		// memakai session baru dengan handle ID lama saat session dibuat ulang
+		if handle != nil {
+			request.SessionID, request.HandleID = handle.ids()
+		} else {
+			request.SessionID = jc.currentSession()
+		}
+
+		resp, err := jc.request(request, waitEvent)
+		if attempt == 0 && isSessionNotFound(err) {
+			if recreateErr := jc.recreateSession(request.SessionID); recreateErr != nil {
+				return nil, fmt.Errorf("%w (session recreate failed: %v)", err, recreateErr)
+			}
+			continue
+		}
+
+		return resp, err
+	}
+}
+
+// recreateSession membuat session baru menggantikan session yang hilang dan
+// meng-attach ulang semua plugin handle yang dilacak. Objek PluginHandle
+// dipertahankan sehingga referensi yang dipegang pemanggil tetap valid.
+func (jc *JanusClient) recreateSession(lostSessionID uint64) error {
+	jc.recreateMu.Lock()
+	defer jc.recreateMu.Unlock()
+
+	// Session sudah dibuat ulang oleh goroutine lain
+	if current := jc.currentSession(); current != lostSessionID && current != 0 {
+		return nil
+	}
+
+	jc.logger.WithField("session_id", lostSessionID).Warn("Recreating Janus session")
+
+	newSessionID, err := jc.CreateSession()
+	if err != nil {
+		return err
+	}
+
+	jc.mu.Lock()
+	handles := make([]*PluginHandle, 0, len(jc.PluginHandles))
+	for _, handle := range jc.PluginHandles {
+		handles = append(handles, handle)
+	}
+	jc.PluginHandles = make(map[uint64]*PluginHandle)
+	issueToken := jc.tokenIssuer
+	jc.mu.Unlock()
+
+	for _, handle := range handles {
+		oldHandleID := handle.ID()
+
+		// Token lama tidak dikenal Janus yang baru restart atau sudah dicabut
+		// TokenManager, jadi handle yang memakai token mendapat token baru
+		token := ""
+		if handle.Token() != "" && issueToken != nil {
+			token, err = issueToken(handle.OpaqueID)
+			if err != nil {
+				jc.logger.WithError(err).WithField("handle_id", oldHandleID).Error("Failed to issue token for plugin handle")
+				handle.closeEvents()
+				continue
+			}
+		}
+
+		newHandleID, err := jc.attach(newSessionID, handle.Plugin, handle.OpaqueID, token)
+		if err != nil {
+			jc.logger.WithError(err).WithFields(logrus.Fields{
+				"handle_id": oldHandleID,
+				"plugin":    handle.Plugin,
+			}).Error("Failed to reattach plugin handle")
+			handle.closeEvents()
+			continue
+		}
+
+		handle.reattached(newHandleID, newSessionID, token)
+
+		jc.mu.Lock()
+		jc.PluginHandles[newHandleID] = handle
+		jc.mu.Unlock()
+
+		jc.logger.WithFields(logrus.Fields{
+			"old_handle_id": oldHandleID,
+			"handle_id":     newHandleID,
+			"plugin":        handle.Plugin,
+		}).Info("Reattached plugin handle")
+	}
+
+	jc.mu.RLock()
+	handler := jc.onSessionRecreated
+	jc.mu.RUnlock()
+
+	// Callback dijalankan terpisah karena pemanggil bisa saja sedang memegang lock miliknya
+	if handler != nil {
+		go handler(lostSessionID, newSessionID)
+	}
+
+	return nil
+}
+
+// attach melakukan attach plugin ke session tanpa mendaftarkan handle baru
+func (jc *JanusClient) attach(sessionID uint64, pluginName, opaqueID, token string) (uint64, error) {
+	request := JanusRequest{
+		Janus:     "attach",
+		SessionID: sessionID,
+		Plugin:    pluginName,
+		OpaqueID:  opaqueID,
+		Token:     token,
+	}
+
+	resp, err := jc.request(request, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to attach plugin: %w", err)
+	}
+
+	var attachResp PluginAttachResponse
+	if err := json.Unmarshal(resp.Data, &attachResp.Data); err != nil {
+		return 0, fmt.Errorf("failed to parse attach response: %w", err)
+	}
+
+	return attachResp.Data.ID, nil
+}
+
+// isSessionNotFound memeriksa apakah error berasal dari session Janus yang sudah tidak ada
+func isSessionNotFound(err error) bool {
+	var janusErr *JanusError
+	return errors.As(err, &janusErr) && janusErr.Code == JanusErrorSessionNotFound
+}
@@ -1,6 +1,7 @@
 package webrtc
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"sync"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
 	"github.com/webrtc-meeting/backend/internal/websocket"
 )
 
@@ -16,15 +18,62 @@ type SignalingHandler struct {
 	// Janus client
 	JanusClient *JanusClient
 
+	// Pool instance Janus (nil jika hanya memakai satu JanusClient)
+	Pool *JanusPool
+
 	// Hub WebSocket
 	Hub *websocket.Hub
 
+	// Antrean berurutan pesan ke hub (nil jika Hub nil)
+	outbox *websocket.Outbox
+
+	// Penerbit token Janus per user (nil jika token auth Janus tidak aktif)
+	TokenManager *TokenManager
+
+	// Opsi media untuk video room yang dibuat handler ini
+	RoomOptions VideoRoomOptions
+
+	// Pembaca UserSetting.VideoQuality user (nil = layer hanya dibatasi ukuran room)
+	VideoQualityLookup func(userID string) string
+
+	// Pembaca kebijakan media room dari RoomSetting (nil = semua room memakai RoomOptions)
+	MediaPolicyLookup func(roomID string) (mediaplane.MediaPolicy, bool)
+
+	// Direktori recording di host Janus, induk dari direktori setiap recording
+	RecordingDir string
+
+	// Direktori recording aktif per room, dipakai juga saat room dibuat ulang
+	recordings map[string]string
+
+	// Gateway SIP untuk peserta telepon (nil jika dial-in/dial-out tidak aktif)
+	SIP *SIPGateway
+
+	// URL endpoint websocket server yang menerima pesan text room dari Janus, misalnya
+	// "http://websocket:8081/api/v1/websocket/textroom" (kosong = pesan tidak diteruskan)
+	TextRoomPostURL string
+
+	// Kunci HMAC khusus untuk menandatangani URL post text room per room
+	// (kosong = pesan text room tidak diteruskan dan semua post ditolak)
+	TextRoomSecret string
+
+	// Penyimpan pesan chat data channel ke RoomMessage (nil = chat tidak disimpan)
+	MessageStore func(roomID, userID, message string) error
+
+	// Host yang boleh menjadi tujuan RTP forward (kosong = RTP forward ditolak)
+	ForwardHosts []string
+
+	// RTP forwarding aktif per room lalu per ID forward (misalnya HLS webinar dan restream RTMP)
+	forwards map[string]map[string]*rtpForward
+
 	// Room sessions
 	RoomSessions map[string]*RoomSession
 
 	// User sessions
 	UserSessions map[string]*UserSession
 
+	// ICE candidate yang datang sebelum handle tujuannya tersedia
+	pendingCandidates map[string]*PendingCandidates
+
 	// Mutex untuk thread safety
 	mu sync.RWMutex
 
@@ -32,11 +81,22 @@ type SignalingHandler struct {
 	logger *logrus.Logger
 }
 
+const (
+	// Jumlah maksimum ICE candidate yang di-buffer per handle tujuan
+	maxPendingCandidates = 64
+
+	// Lama ICE candidate yang di-buffer disimpan sebelum dibuang
+	pendingCandidateTTL = 30 * time.Second
+)
+
 // RoomSession merepresentasikan session untuk sebuah room
 type RoomSession struct {
 	RoomID      string
 	JanusRoom   uint64
+	Client      *JanusClient
 	Plugin      *PluginHandle
+	TextRoom    *PluginHandle
+	Options     VideoRoomOptions
 	Publishers  map[string]*PublisherSession
 	Subscribers map[string]*SubscriberSession
 	CreatedAt   time.Time
@@ -47,18 +107,28 @@ type PublisherSession struct {
 	UserID       string
 	Display      string
 	JanusID      uint64
+	PrivateID    uint64
 	Plugin       *PluginHandle
 	IsPublishing bool
+	Mids         map[string][]string
+	AudioCodec   string
+	VideoCodec   string
+	DataPlugin   *PluginHandle
+	DataToken    string
 	CreatedAt    time.Time
 }
 
 // SubscriberSession merepresentasikan subscriber session
 type SubscriberSession struct {
-	UserID       string
-	FeedID       uint64
-	Plugin       *PluginHandle
-	IsSubscribed bool
-	CreatedAt    time.Time
+	UserID          string
+	FeedID          uint64
+	PublisherUserID string
+	Plugin          *PluginHandle
+	IsSubscribed    bool
+	VideoCodec      string
+	Layer           VideoLayer
+	ManualLayer     bool
+	CreatedAt       time.Time
 }
 
 // UserSession merepresentasikan session untuk user
@@ -67,20 +137,86 @@ type UserSession struct {
 	RoomIDs       map[string]bool
 	PublisherIDs  map[uint64]bool
 	SubscriberIDs map[uint64]bool
+	VideoQuality  string
 	CreatedAt     time.Time
 }
 
+// PendingCandidates merepresentasikan ICE candidate yang menunggu handle tujuannya
+type PendingCandidates struct {
+	RoomID     string
+	UserID     string
+	Candidates []ICECandidate
+	CreatedAt  time.Time
+}
+
 // NewSignalingHandler membuat instance SignalingHandler baru
 func NewSignalingHandler(janusClient *JanusClient, hub *websocket.Hub) *SignalingHandler {
-	return &SignalingHandler{
-		JanusClient:  janusClient,
-		Hub:          hub,
-		RoomSessions: make(map[string]*RoomSession),
-		UserSessions: make(map[string]*UserSession),
-		logger:       logrus.New(),
+	sh := &SignalingHandler{
+		JanusClient:       janusClient,
+		Hub:               hub,
+		RoomSessions:      make(map[string]*RoomSession),
+		UserSessions:      make(map[string]*UserSession),
+		pendingCandidates: make(map[string]*PendingCandidates),
+		recordings:        make(map[string]string),
+		forwards:          make(map[string]map[string]*rtpForward),
+		RoomOptions:       DefaultVideoRoomOptions(),
+		RecordingDir:      DefaultRecordingDir,
+		logger:            logrus.New(),
+	}
+
+	if hub != nil {
+		sh.outbox = websocket.NewOutbox(hub)
+	}
+
+	// Bangun ulang room sessions setiap kali Janus session dibuat ulang
+	if janusClient != nil {
+		sh.watchSessionRecreated(janusClient)
+	}
+
+	return sh
+}
+
+// UsePool memakai pool instance Janus untuk menempatkan room.
+// Room baru ditempatkan pada instance dengan publisher paling sedikit dan
+// dipindahkan ke instance lain ketika instance-nya gagal health check.
+func (sh *SignalingHandler) UsePool(pool *JanusPool) {
+	sh.mu.Lock()
+	sh.Pool = pool
+	if sh.JanusClient == nil && len(pool.Instances) > 0 {
+		sh.JanusClient = pool.Instances[0].Client
+	}
+	sh.mu.Unlock()
+
+	// Setiap room dipindahkan sendiri-sendiri agar room yang lambat tidak menahan
+	// room lain maupun health check pool
+	pool.SetFailoverHandler(func(instance *JanusInstance, roomIDs []string) {
+		for _, roomID := range roomIDs {
+			go sh.migrateRoomSession(roomID)
+		}
+	})
+
+	for _, instance := range pool.Instances {
+		if instance.Client != sh.JanusClient {
+			sh.watchSessionRecreated(instance.Client)
+		}
 	}
 }
 
+// watchSessionRecreated membangun ulang room milik client setiap kali session Janus-nya
+// dibuat ulang. Handle yang di-attach ulang mendapat token baru dari TokenManager.
+func (sh *SignalingHandler) watchSessionRecreated(client *JanusClient) {
+	client.SetSessionRecreatedHandler(func(oldSessionID, newSessionID uint64) {
+		sh.RebuildRoomSessions(client)
+	})
+	client.SetTokenIssuer(func(userID string) (string, error) {
+		// TokenManager dapat dipasang setelah handler dibuat
+		if sh.TokenManager == nil {
+			return "", nil
+		}
+		return sh.TokenManager.Issue(client, userID)
+	})
+}
+
 // HandleJoinRoom menangani user yang bergabung ke room
 func (sh *SignalingHandler) HandleJoinRoom(roomID, userID, displayName string) error {
 	sh.mu.Lock()
@@ -101,6 +237,7 @@ func (sh *SignalingHandler) HandleJoinRoom(roomID, userID, displayName string) e
 	// Buat atau dapatkan user session
 	userSession := sh.getOrCreateUserSession(userID)
 	userSession.RoomIDs[roomID] = true
+	userSession.VideoQuality = sh.lookupVideoQuality(userID)
 
 	// Buat publisher session untuk user
 	publisherSession := &PublisherSession{
@@ -111,19 +248,62 @@ func (sh *SignalingHandler) HandleJoinRoom(roomID, userID, displayName string) e
 	}
 
 	// Attach plugin untuk publisher
-	publisherPlugin, err := sh.JanusClient.AttachPlugin("janus.plugin.videoroom")
+	attachOptions, err := sh.attachOptions(roomSession.Client, userID)
+	if err != nil {
+		return err
+	}
+
+	publisherPlugin, err := roomSession.Client.AttachPluginWithOptions(roomSession.Options.pluginName(), attachOptions)
 	if err != nil {
 		return fmt.Errorf("failed to attach publisher plugin: %w", err)
 	}
 
 	publisherSession.Plugin = publisherPlugin
 	roomSession.Publishers[userID] = publisherSession
-	userSession.PublisherIDs[publisherPlugin.ID] = true
+	userSession.PublisherIDs[publisherPlugin.ID()] = true
+
+	// Teruskan event asinkron dari handle publisher ke user
+	go sh.watchHandle(roomID, userID, 0, publisherPlugin)
+
+	// Kirim ICE candidate yang datang sebelum handle publisher tersedia
+	sh.flushCandidates(roomID, userID, "", publisherPlugin)
+
+	// Chat, reaksi dan kursor lewat data channel text room
+	if roomSession.TextRoom != nil {
+		sh.joinTextRoom(roomSession, publisherSession)
+	}
+
+	// Audio bridge me-mix audio di Janus sehingga tidak ada subscriber per publisher
+	if roomSession.Options.AudioBridge {
+		if err := sh.joinAudioBridge(roomSession, publisherSession); err != nil {
+			sh.abortJoin(roomSession, userSession, publisherSession)
+			return err
+		}
+		return nil
+	}
 
 	// Join user ke video room sebagai publisher
-	if err := publisherPlugin.JoinVideoRoom(roomSession.JanusRoom, publisherSession.JanusID, displayName); err != nil {
+	joined, err := publisherPlugin.JoinVideoRoom(roomSession.JanusRoom, publisherSession.JanusID, displayName)
+	if err != nil {
+		sh.abortJoin(roomSession, userSession, publisherSession)
 		return fmt.Errorf("failed to join video room: %w", err)
 	}
+	publisherSession.PrivateID = joined.PrivateID
+
+	// Beritahu user tentang publisher yang sudah ada di room
+	sh.sendMediaEvent(roomID, userID, "joined", map[string]interface{}{
+		"janusId":    publisherSession.JanusID,
+		"publishers": sh.describePublishers(roomSession, joined.Publishers),
+		"simulcast":  roomSession.Options.Simulcast,
+		"videoCodec": roomSession.Options.VideoCodec,
+		"bitrate":    roomSession.Options.Bitrate,
+		"recording":  roomSession.Options.Record,
+	})
+
+	// Subscribe ke publisher yang sudah ada setelah join selesai
+	if len(joined.Publishers) > 0 {
+		go sh.subscribeToPublishers(roomID, userID, joined.Publishers)
+	}
 
 	sh.logger.WithFields(logrus.Fields{
 		"room_id":  roomID,
@@ -134,6 +314,43 @@ func (sh *SignalingHandler) HandleJoinRoom(roomID, userID, displayName string) e
 	return nil
 }
 
+// abortJoin membatalkan join yang gagal setelah handle publisher di-attach:
+// handle di-detach (sehingga watchHandle berhenti) dan semua jejak publisher
+// di room session dan user session dihapus. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) abortJoin(roomSession *RoomSession, userSession *UserSession, publisherSession *PublisherSession) {
+	roomID, userID := roomSession.RoomID, publisherSession.UserID
+
+	sh.leaveTextRoom(roomSession, publisherSession)
+
+	if publisherPlugin := publisherSession.Plugin; publisherPlugin != nil {
+		delete(userSession.PublisherIDs, publisherPlugin.ID())
+		if err := publisherPlugin.DetachPlugin(); err != nil {
+			sh.logger.Errorf("Failed to detach publisher plugin: %v", err)
+		}
+	}
+	if roomSession.Publishers[userID] == publisherSession {
+		delete(roomSession.Publishers, userID)
+	}
+
+	for key, pending := range sh.pendingCandidates {
+		if pending.RoomID == roomID && pending.UserID == userID {
+			delete(sh.pendingCandidates, key)
+		}
+	}
+
+	delete(userSession.RoomIDs, roomID)
+	if len(userSession.RoomIDs) == 0 {
+		delete(sh.UserSessions, userID)
+	}
+
+	if len(roomSession.Publishers) == 0 && len(roomSession.Subscribers) == 0 {
+		delete(sh.RoomSessions, roomID)
+		if sh.Pool != nil {
+			sh.Pool.ReleaseRoom(roomID)
+		}
+	}
+}
+
 // HandleLeaveRoom menangani user yang keluar dari room
 func (sh *SignalingHandler) HandleLeaveRoom(roomID, userID string) error {
 	sh.mu.Lock()
@@ -158,9 +375,11 @@ func (sh *SignalingHandler) HandleLeaveRoom(roomID, userID string) error {
 
 	// Hapus publisher session
 	if publisherSession, exists := roomSession.Publishers[userID]; exists {
+		sh.leaveTextRoom(roomSession, publisherSession)
+
 		if publisherSession.Plugin != nil {
 			publisherPlugin := publisherSession.Plugin
-			delete(userSession.PublisherIDs, publisherPlugin.ID)
+			delete(userSession.PublisherIDs, publisherPlugin.ID())
 
 			// Detach plugin
 			if err := publisherPlugin.DetachPlugin(); err != nil {
@@ -168,21 +387,29 @@ func (sh *SignalingHandler) HandleLeaveRoom(roomID, userID string) error {
 			}
 		}
 		delete(roomSession.Publishers, userID)
+		sh.forwardLostLocked(roomID, publisherSession.JanusID)
 	}
 
 	// Hapus subscriber sessions untuk user
-	for feedID, subscriberSession := range roomSession.Subscribers {
+	for key, subscriberSession := range roomSession.Subscribers {
 		if subscriberSession.UserID == userID {
 			if subscriberSession.Plugin != nil {
 				subscriberPlugin := subscriberSession.Plugin
-				delete(userSession.SubscriberIDs, subscriberPlugin.ID)
+				delete(userSession.SubscriberIDs, subscriberPlugin.ID())
 
 				// Detach plugin
 				if err := subscriberPlugin.DetachPlugin(); err != nil {
 					sh.logger.Errorf("Failed to detach subscriber plugin: %v", err)
 				}
 			}
-			delete(roomSession.Subscribers, feedID)
+			delete(roomSession.Subscribers, key)
+		}
+	}
+
+	// Buang ICE candidate yang masih di-buffer untuk user di room ini
+	for key, pending := range sh.pendingCandidates {
+		if pending.RoomID == roomID && pending.UserID == userID {
+			delete(sh.pendingCandidates, key)
 		}
 	}
 
@@ -197,6 +424,12 @@ func (sh *SignalingHandler) HandleLeaveRoom(roomID, userID string) error {
 	// Jika room tidak ada publisher lagi, hapus room session
 	if len(roomSession.Publishers) == 0 && len(roomSession.Subscribers) == 0 {
 		delete(sh.RoomSessions, roomID)
+		if sh.Pool != nil {
+			sh.Pool.ReleaseRoom(roomID)
+		}
+	} else {
+		// Publisher berkurang, layer otomatis subscriber lain bisa dinaikkan
+		go sh.adaptRoomLayers(roomID)
 	}
 
 	sh.logger.WithFields(logrus.Fields{
@@ -229,6 +462,9 @@ func (sh *SignalingHandler) HandleOffer(roomID, fromUserID, toUserID, sdp string
 	if !exists {
 		return fmt.Errorf("publisher session not found: %s", fromUserID)
 	}
+	if publisherSession.Plugin == nil {
+		return fmt.Errorf("%w: %s", ErrPublisherReconnecting, fromUserID)
+	}
 
 	// Buat JSEP dari offer
 	jsep := &JSEP{
@@ -236,10 +472,36 @@ func (sh *SignalingHandler) HandleOffer(roomID, fromUserID, toUserID, sdp string
 		SDP:  sdp,
 	}
 
+	if roomSession.Options.AudioBridge {
+		return sh.publishToAudioBridge(roomSession, publisherSession, jsep)
+	}
+
 	// Publish offer ke Janus
-	if err := publisherSession.Plugin.PublishToVideoRoom(jsep); err != nil {
+	answer, err := publisherSession.Plugin.PublishToVideoRoom(jsep)
+	if err != nil {
 		return fmt.Errorf("failed to publish offer: %w", err)
 	}
+	wasPublishing := publisherSession.IsPublishing
+	publisherSession.IsPublishing = true
+	publisherSession.Mids = sdpMids(sdp)
+
+	// Codec hasil negosiasi dibaca dari answer Janus, dipakai untuk RTP forwarding
+	if answer != nil {
+		codecs := sdpCodecs(answer.SDP)
+		publisherSession.AudioCodec = codecs["audio"]
+		publisherSession.VideoCodec = codecs["video"]
+	}
+	sh.resumeForward(roomSession, publisherSession)
+
+	// Publisher baru bisa menurunkan layer otomatis subscriber lain
+	if !wasPublishing {
+		go sh.adaptRoomLayers(roomID)
+	}
+
+	// Kirim answer dari Janus ke user
+	if answer != nil {
+		sh.sendAnswer(roomID, fromUserID, answer)
+	}
 
 	sh.logger.Info("WebRTC offer handled successfully")
 
@@ -263,10 +525,25 @@ func (sh *SignalingHandler) HandleAnswer(roomID, fromUserID, toUserID, sdp strin
 		return fmt.Errorf("room session not found: %s", roomID)
 	}
 
-	// Dapatkan publisher session untuk to user (yang akan menerima answer)
-	publisherSession, exists := roomSession.Publishers[toUserID]
-	if !exists {
-		return fmt.Errorf("publisher session not found: %s", toUserID)
+	// Answer untuk koneksi data channel user
+	if toUserID == websocket.DataChannelPeer {
+		return sh.answerTextRoom(roomSession, fromUserID, sdp)
+	}
+
+	if roomSession.Options.AudioBridge {
+		return errAudioBridgeUnsupported("subscription", roomID)
+	}
+
+	// Answer dikirim oleh subscriber (from user) untuk feed milik publisher (to user)
+	var subscriberSession *SubscriberSession
+	for _, session := range roomSession.Subscribers {
+		if session.UserID == fromUserID && session.PublisherUserID == toUserID {
+			subscriberSession = session
+			break
+		}
+	}
+	if subscriberSession == nil {
+		return fmt.Errorf("subscriber session not found: %s -> %s", fromUserID, toUserID)
 	}
 
 	// Buat JSEP dari answer
@@ -275,10 +552,14 @@ func (sh *SignalingHandler) HandleAnswer(roomID, fromUserID, toUserID, sdp strin
 		SDP:  sdp,
 	}
 
-	// Subscribe ke publisher
-	if err := publisherSession.Plugin.SubscribeToVideoRoom(roomSession.JanusRoom, publisherSession.JanusID, jsep); err != nil {
-		return fmt.Errorf("failed to subscribe with answer: %w", err)
+	// Mulai aliran media dari publisher ke subscriber
+	if err := subscriberSession.Plugin.StartVideoRoomSubscription(roomSession.JanusRoom, jsep); err != nil {
+		return fmt.Errorf("failed to start subscription with answer: %w", err)
 	}
+	subscriberSession.IsSubscribed = true
+
+	// Pilih layer sesuai kualitas video user dan ukuran room
+	sh.applyAutoLayer(roomSession, subscriberSession)
 
 	sh.logger.Info("WebRTC answer handled successfully")
 
@@ -297,14 +578,816 @@ func (sh *SignalingHandler) HandleIceCandidate(roomID, fromUserID, toUserID, can
 		"candidate":    candidate,
 	}).Debug("Handling WebRTC ICE candidate")
 
-	// TODO: Implement ICE candidate handling dengan Janus
-	// Untuk saat ini, ICE candidate akan diteruskan melalui WebSocket
+	// Candidate kosong adalah penanda akhir gathering dari browser
+	iceCandidate := ICECandidate{
+		Candidate:     candidate,
+		SDPMid:        sdpMid,
+		SDPMLineIndex: sdpMLineIndex,
+		Completed:     candidate == "",
+	}
+
+	// Handle tujuan belum ada, simpan sampai handle dibuat
+	handle := sh.candidateTarget(roomID, fromUserID, toUserID)
+	if handle == nil {
+		sh.bufferCandidate(roomID, fromUserID, toUserID, iceCandidate)
+		return nil
+	}
+
+	if err := handle.Trickle(iceCandidate); err != nil {
+		return fmt.Errorf("failed to forward ice candidate: %w", err)
+	}
 
 	sh.logger.Debug("WebRTC ICE candidate handled successfully")
 
 	return nil
 }
 
+// candidateTarget menentukan handle Janus tujuan ICE candidate.
+// Candidate tanpa to user (atau untuk diri sendiri) milik koneksi publisher,
+// candidate untuk DataChannelPeer milik koneksi data channel, selain itu milik
+// koneksi subscriber from user yang menonton to user.
+func (sh *SignalingHandler) candidateTarget(roomID, fromUserID, toUserID string) *PluginHandle {
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return nil
+	}
+
+	if toUserID == "" || toUserID == fromUserID {
+		if publisherSession, exists := roomSession.Publishers[fromUserID]; exists {
+			return publisherSession.Plugin
+		}
+		return nil
+	}
+
+	if toUserID == websocket.DataChannelPeer {
+		if publisherSession, exists := roomSession.Publishers[fromUserID]; exists && publisherSession.DataPlugin != nil {
+			return publisherSession.DataPlugin
+		}
+		return nil
+	}
+
+	for _, subscriberSession := range roomSession.Subscribers {
+		if subscriberSession.UserID == fromUserID && subscriberSession.PublisherUserID == toUserID {
+			return subscriberSession.Plugin
+		}
+	}
+
+	return nil
+}
+
+// bufferCandidate menyimpan ICE candidate sampai handle tujuannya tersedia
+func (sh *SignalingHandler) bufferCandidate(roomID, fromUserID, toUserID string, candidate ICECandidate) {
+	key := candidateKey(roomID, fromUserID, toUserID)
+
+	pending, exists := sh.pendingCandidates[key]
+	if !exists || time.Since(pending.CreatedAt) > pendingCandidateTTL {
+		pending = &PendingCandidates{
+			RoomID:    roomID,
+			UserID:    fromUserID,
+			CreatedAt: time.Now(),
+		}
+		sh.pendingCandidates[key] = pending
+	}
+
+	if len(pending.Candidates) >= maxPendingCandidates {
+		sh.logger.WithField("key", key).Warn("Pending ICE candidate buffer full, dropping candidate")
+		return
+	}
+
+	pending.Candidates = append(pending.Candidates, candidate)
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":      roomID,
+		"from_user_id": fromUserID,
+		"to_user_id":   toUserID,
+		"pending":      len(pending.Candidates),
+	}).Debug("Buffered ICE candidate until handle is ready")
+}
+
+// flushCandidates mengirim ICE candidate yang di-buffer ke handle yang baru dibuat
+func (sh *SignalingHandler) flushCandidates(roomID, fromUserID, toUserID string, handle *PluginHandle) {
+	key := candidateKey(roomID, fromUserID, toUserID)
+
+	pending, exists := sh.pendingCandidates[key]
+	if !exists {
+		return
+	}
+	delete(sh.pendingCandidates, key)
+
+	if time.Since(pending.CreatedAt) > pendingCandidateTTL {
+		return
+	}
+
+	candidates := make([]ICECandidate, 0, len(pending.Candidates))
+	completed := false
+	for _, candidate := range pending.Candidates {
+		if candidate.Completed {
+			completed = true
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if err := handle.TrickleCandidates(candidates); err != nil {
+		sh.logger.Errorf("Failed to flush pending ICE candidates: %v", err)
+		return
+	}
+
+	// Penanda completed harus dikirim setelah semua candidate
+	if completed {
+		if err := handle.TrickleCompleted(); err != nil {
+			sh.logger.Errorf("Failed to flush ICE completed marker: %v", err)
+		}
+	}
+}
+
+// candidateKey membuat key buffer ICE candidate untuk handle tujuan
+func candidateKey(roomID, fromUserID, toUserID string) string {
+	if toUserID == fromUserID {
+		toUserID = ""
+	}
+	return fmt.Sprintf("%s|%s|%s", roomID, fromUserID, toUserID)
+}
+
+// watchHandle membaca event asinkron dari plugin handle sampai handle dilepas.
+// feedID bernilai 0 untuk handle publisher dan berisi feed yang ditonton untuk handle subscriber.
+func (sh *SignalingHandler) watchHandle(roomID, userID string, feedID uint64, handle *PluginHandle) {
+	for event := range handle.Events {
+		sh.handlePluginEvent(roomID, userID, feedID, event)
+	}
+}
+
+// handlePluginEvent memproses event asinkron Janus dan meneruskannya ke user
+func (sh *SignalingHandler) handlePluginEvent(roomID, userID string, feedID uint64, event *JanusResponse) {
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"user_id":   userID,
+		"feed_id":   feedID,
+		"janus":     event.Janus,
+		"handle_id": event.Sender,
+	}).Debug("Received Janus event")
+
+	switch event.Janus {
+	case "event":
+		if event.Jsep != nil {
+			switch {
+			case feedID == 0 && event.Jsep.Type == "answer":
+				// JSEP answer yang datang terlambat (setelah request timeout)
+				sh.sendAnswer(roomID, userID, event.Jsep)
+			case feedID != 0 && event.Jsep.Type == "offer":
+				// Janus menegosiasi ulang subscriber (misalnya publisher menambah track)
+				sh.mu.RLock()
+				subscriberSession := sh.findSubscriber(roomID, userID, feedID)
+				sh.mu.RUnlock()
+				if subscriberSession != nil {
+					sh.sendOffer(roomID, subscriberSession, event.Jsep)
+				}
+			}
+		}
+
+		if event.PluginData != nil && event.PluginData.Plugin == AudioBridgePlugin {
+			sh.handleAudioBridgeEvent(roomID, userID, event)
+			return
+		}
+
+		var data VideoRoomEvent
+		if err := event.DecodePluginData(&data); err != nil {
+			sh.sendHandleEvent(roomID, userID, feedID, "error", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		switch {
+		case data.VideoRoom == "talking":
+			// Pembicara aktif untuk RTP forward mode active-speaker
+			sh.switchActiveSpeaker(roomID, data.ID)
+		case len(data.Publishers) > 0:
+			sh.mu.RLock()
+			roomSession := sh.RoomSessions[roomID]
+			var publishers []map[string]interface{}
+			if roomSession != nil {
+				publishers = sh.describePublishers(roomSession, data.Publishers)
+			}
+			sh.mu.RUnlock()
+
+			sh.sendMediaEvent(roomID, userID, "publishers", map[string]interface{}{
+				"publishers": publishers,
+			})
+
+			// Setiap publisher baru ditonton oleh user ini melalui handle subscriber tersendiri
+			sh.subscribeToPublishers(roomID, userID, data.Publishers)
+		case len(data.Kicked) > 0:
+			kickedFeedID := rawFeedID(data.Kicked)
+			sh.sendMediaEvent(roomID, userID, "leaving", map[string]interface{}{
+				"feedId": kickedFeedID,
+				"reason": "kicked",
+			})
+			sh.unsubscribeFromFeed(roomID, userID, kickedFeedID)
+			sh.forwardLost(roomID, kickedFeedID)
+		case len(data.Leaving) > 0 && data.Reason == "kicked":
+			// Handle milik user yang di-kick; notifikasi kicked sudah dikirim oleh KickParticipant
+		case data.Substream != nil || data.Temporal != nil || data.SpatialLayer != nil || data.TemporalLayer != nil:
+			// Janus berpindah layer untuk subscriber ini
+			sh.sendHandleEvent(roomID, userID, feedID, "layer", map[string]interface{}{
+				"substream":     data.Substream,
+				"temporal":      data.Temporal,
+				"spatialLayer":  data.SpatialLayer,
+				"temporalLayer": data.TemporalLayer,
+			})
+		case data.Moderation != "":
+			sh.sendMediaEvent(roomID, userID, "moderation", map[string]interface{}{
+				"feedId": data.ID,
+				"mid":    data.Mid,
+				"muted":  data.Moderation == "muted",
+			})
+		case len(data.Leaving) > 0:
+			leavingFeedID := rawFeedID(data.Leaving)
+			sh.sendMediaEvent(roomID, userID, "leaving", map[string]interface{}{
+				"feedId": leavingFeedID,
+			})
+			sh.unsubscribeFromFeed(roomID, userID, leavingFeedID)
+			sh.forwardLost(roomID, leavingFeedID)
+		case len(data.Unpublished) > 0:
+			unpublishedFeedID := rawFeedID(data.Unpublished)
+			sh.sendMediaEvent(roomID, userID, "unpublished", map[string]interface{}{
+				"feedId": unpublishedFeedID,
+			})
+			sh.unsubscribeFromFeed(roomID, userID, unpublishedFeedID)
+			sh.forwardLost(roomID, unpublishedFeedID)
+		}
+
+	case "webrtcup":
+		sh.sendHandleEvent(roomID, userID, feedID, "webrtcup", nil)
+
+	case "media":
+		sh.sendHandleEvent(roomID, userID, feedID, "media", map[string]interface{}{
+			"type":      event.Type,
+			"receiving": event.Receiving,
+		})
+
+	case "slowlink":
+		sh.sendHandleEvent(roomID, userID, feedID, "slowlink", map[string]interface{}{
+			"uplink": event.Uplink,
+		})
+
+	case "hangup":
+		sh.sendHandleEvent(roomID, userID, feedID, "hangup", map[string]interface{}{
+			"reason": event.Reason,
+		})
+
+	case "detached":
+		sh.sendHandleEvent(roomID, userID, feedID, "detached", nil)
+
+	case "trickle":
+		// Candidate dari Janus (full-trickle) diteruskan ke browser
+		if event.Candidate != nil {
+			sh.sendIceCandidate(roomID, userID, feedID, event.Candidate)
+		}
+	}
+}
+
+// subscribeToPublishers membuat subscriber untuk setiap publisher yang diberikan
+func (sh *SignalingHandler) subscribeToPublishers(roomID, userID string, publishers []VideoRoomPublisher) {
+	for _, publisher := range publishers {
+		if err := sh.subscribeToFeed(roomID, userID, publisher); err != nil {
+			sh.logger.WithFields(logrus.Fields{
+				"room_id": roomID,
+				"user_id": userID,
+				"feed_id": publisher.ID,
+			}).Errorf("Failed to subscribe to feed: %v", err)
+		}
+	}
+}
+
+// subscribeToFeed meng-attach handle subscriber untuk user, join sebagai subscriber
+// ke feed publisher, lalu meneruskan offer dari Janus ke browser user
+func (sh *SignalingHandler) subscribeToFeed(roomID, userID string, publisher VideoRoomPublisher) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return fmt.Errorf("room session not found: %s", roomID)
+	}
+
+	publisherSession, exists := roomSession.Publishers[userID]
+	if !exists {
+		return fmt.Errorf("publisher session not found: %s", userID)
+	}
+
+	// User tidak perlu menonton feed miliknya sendiri
+	if publisher.ID == publisherSession.JanusID {
+		return nil
+	}
+
+	key := subscriberKey(userID, publisher.ID)
+	if _, exists := roomSession.Subscribers[key]; exists {
+		return nil
+	}
+
+	// Attach plugin untuk subscriber
+	attachOptions, err := sh.attachOptions(roomSession.Client, userID)
+	if err != nil {
+		return err
+	}
+
+	subscriberPlugin, err := roomSession.Client.AttachPluginWithOptions("janus.plugin.videoroom", attachOptions)
+	if err != nil {
+		return fmt.Errorf("failed to attach subscriber plugin: %w", err)
+	}
+
+	subscriberSession := &SubscriberSession{
+		UserID:          userID,
+		FeedID:          publisher.ID,
+		PublisherUserID: sh.publisherUserID(roomSession, publisher.ID),
+		Plugin:          subscriberPlugin,
+		VideoCodec:      publisher.VideoCodec,
+		CreatedAt:       time.Now(),
+	}
+
+	// Teruskan event asinkron dari handle subscriber ke user
+	go sh.watchHandle(roomID, userID, publisher.ID, subscriberPlugin)
+
+	// Join sebagai subscriber, private ID menghubungkan subscriber dengan publisher milik user
+	offer, err := subscriberPlugin.SubscribeToVideoRoom(roomSession.JanusRoom, publisher.ID, publisherSession.PrivateID)
+	if err != nil {
+		if detachErr := subscriberPlugin.DetachPlugin(); detachErr != nil {
+			sh.logger.Errorf("Failed to detach subscriber plugin: %v", detachErr)
+		}
+		return err
+	}
+
+	roomSession.Subscribers[key] = subscriberSession
+	if userSession, exists := sh.UserSessions[userID]; exists {
+		userSession.SubscriberIDs[subscriberPlugin.ID()] = true
+	}
+
+	// Kirim ICE candidate yang datang sebelum handle subscriber tersedia
+	sh.flushCandidates(roomID, userID, subscriberSession.PublisherUserID, subscriberPlugin)
+
+	// Browser menjawab offer ini dengan pesan answer ke publisher user
+	sh.sendOffer(roomID, subscriberSession, offer)
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id":           roomID,
+		"user_id":           userID,
+		"feed_id":           publisher.ID,
+		"publisher_user_id": subscriberSession.PublisherUserID,
+	}).Info("User subscribed to feed")
+
+	return nil
+}
+
+// unsubscribeFromFeed melepas handle subscriber user untuk feed tertentu
+func (sh *SignalingHandler) unsubscribeFromFeed(roomID, userID string, feedID uint64) {
+	if feedID == 0 {
+		return
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return
+	}
+
+	key := subscriberKey(userID, feedID)
+	subscriberSession, exists := roomSession.Subscribers[key]
+	if !exists {
+		return
+	}
+	delete(roomSession.Subscribers, key)
+
+	if subscriberSession.Plugin != nil {
+		if userSession, exists := sh.UserSessions[userID]; exists {
+			delete(userSession.SubscriberIDs, subscriberSession.Plugin.ID())
+		}
+
+		if err := subscriberSession.Plugin.DetachPlugin(); err != nil {
+			sh.logger.Errorf("Failed to detach subscriber plugin: %v", err)
+		}
+	}
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": userID,
+		"feed_id": feedID,
+	}).Info("User unsubscribed from feed")
+}
+
+// findSubscriber mencari subscriber session user untuk feed tertentu
+func (sh *SignalingHandler) findSubscriber(roomID, userID string, feedID uint64) *SubscriberSession {
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return nil
+	}
+	return roomSession.Subscribers[subscriberKey(userID, feedID)]
+}
+
+// attachOptions membuat opsi attach untuk handle milik user. Jika token auth
+// Janus aktif, user mendapat token berumur pendek yang hanya berlaku untuk plugin room.
+func (sh *SignalingHandler) attachOptions(client *JanusClient, userID string) (AttachOptions, error) {
+	options := AttachOptions{
+		OpaqueID: userID,
+	}
+
+	if sh.TokenManager != nil {
+		token, err := sh.TokenManager.Issue(client, userID)
+		if err != nil {
+			return options, fmt.Errorf("failed to issue janus token: %w", err)
+		}
+		options.Token = token
+	}
+
+	return options, nil
+}
+
+// subscriberKey membuat key map subscriber dari user yang menonton dan feed yang ditonton
+func subscriberKey(userID string, feedID uint64) string {
+	return fmt.Sprintf("%s:%d", userID, feedID)
+}
+
+// publisherUserID mencari user ID pemilik feed Janus.
+// Jika tidak ditemukan, feed ID digunakan sebagai pengenal.
+func (sh *SignalingHandler) publisherUserID(roomSession *RoomSession, feedID uint64) string {
+	for publisherUserID, publisherSession := range roomSession.Publishers {
+		if publisherSession.JanusID == feedID {
+			return publisherUserID
+		}
+	}
+	return strconv.FormatUint(feedID, 10)
+}
+
+// describePublishers mengubah daftar publisher Janus menjadi data untuk client
+func (sh *SignalingHandler) describePublishers(roomSession *RoomSession, publishers []VideoRoomPublisher) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(publishers))
+	for _, publisher := range publishers {
+		result = append(result, map[string]interface{}{
+			"feedId":  publisher.ID,
+			"display": publisher.Display,
+			"userId":  sh.publisherUserID(roomSession, publisher.ID),
+		})
+	}
+	return result
+}
+
+// sendAnswer mengirim JSEP answer dari Janus ke user
+func (sh *SignalingHandler) sendAnswer(roomID, userID string, jsep *JSEP) {
+	sh.sendToUser(userID, websocket.Message{
+		Type:   websocket.MessageTypeAnswer,
+		RoomID: roomID,
+		UserID: userID,
+		Data: websocket.AnswerData{
+			RoomID:     roomID,
+			FromUserID: userID,
+			ToUserID:   userID,
+			SDP:        jsep.SDP,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendOffer mengirim JSEP offer subscriber dari Janus ke user yang menonton
+func (sh *SignalingHandler) sendOffer(roomID string, subscriberSession *SubscriberSession, jsep *JSEP) {
+	sh.sendToUser(subscriberSession.UserID, websocket.Message{
+		Type:   websocket.MessageTypeOffer,
+		RoomID: roomID,
+		UserID: subscriberSession.PublisherUserID,
+		Data: websocket.OfferData{
+			RoomID:     roomID,
+			FromUserID: subscriberSession.PublisherUserID,
+			ToUserID:   subscriberSession.UserID,
+			SDP:        jsep.SDP,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendIceCandidate mengirim ICE candidate dari Janus ke user.
+// Untuk handle subscriber, candidate dikirim atas nama publisher yang ditonton.
+func (sh *SignalingHandler) sendIceCandidate(roomID, userID string, feedID uint64, candidate *ICECandidate) {
+	fromUserID := userID
+	if feedID != 0 {
+		sh.mu.RLock()
+		subscriberSession := sh.findSubscriber(roomID, userID, feedID)
+		sh.mu.RUnlock()
+		if subscriberSession == nil {
+			return
+		}
+		fromUserID = subscriberSession.PublisherUserID
+	}
+
+	sh.sendToUser(userID, websocket.Message{
+		Type:   websocket.MessageTypeIceCandidate,
+		RoomID: roomID,
+		UserID: fromUserID,
+		Data: websocket.IceCandidateData{
+			RoomID:        roomID,
+			FromUserID:    fromUserID,
+			ToUserID:      userID,
+			Candidate:     candidate.Candidate,
+			SDPMID:        candidate.SDPMid,
+			SDPMLineIndex: candidate.SDPMLineIndex,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendHandleEvent mengirim event dari handle publisher atau subscriber ke user.
+// Event dari handle subscriber diberi feedId agar client tahu stream mana yang dimaksud.
+func (sh *SignalingHandler) sendHandleEvent(roomID, userID string, feedID uint64, event string, data map[string]interface{}) {
+	if feedID != 0 {
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		data["feedId"] = feedID
+	}
+
+	if data == nil {
+		sh.sendMediaEvent(roomID, userID, event, nil)
+		return
+	}
+	sh.sendMediaEvent(roomID, userID, event, data)
+}
+
+// sendMediaEvent mengirim event media server ke user
+func (sh *SignalingHandler) sendMediaEvent(roomID, userID, event string, data interface{}) {
+	sh.sendToUser(userID, websocket.Message{
+		Type:   websocket.MessageTypeMediaEvent,
+		RoomID: roomID,
+		UserID: userID,
+		Data: websocket.MediaEventData{
+			RoomID: roomID,
+			UserID: userID,
+			Event:  event,
+			Data:   data,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendToUser mengirim pesan ke user melalui hub.
+// Handler ini juga dipanggil dari dalam loop hub, jadi pengiriman tidak boleh memblokir;
+// pesan diantre di outbox agar urutan signaling untuk setiap peer tetap terjaga.
+func (sh *SignalingHandler) sendToUser(userID string, message websocket.Message) {
+	if sh.outbox == nil {
+		return
+	}
+
+	if err := sh.outbox.Send(userID, message); err != nil {
+		sh.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"type":    message.Type,
+		}).Errorf("Dropped message to user: %v", err)
+	}
+}
+
+// rawFeedID mem-parse feed ID dari field event videoroom yang bisa berupa angka atau string
+func rawFeedID(raw json.RawMessage) uint64 {
+	var feedID uint64
+	if err := json.Unmarshal(raw, &feedID); err != nil {
+		return 0
+	}
+	return feedID
+}
+
+// RebuildRoomSessions membangun ulang state Janus untuk room session milik
+// client setelah session Janus-nya dibuat ulang (misalnya karena Janus restart).
+// Jika client nil, semua room session dibangun ulang. Plugin handle sudah
+// di-attach ulang oleh JanusClient, jadi yang perlu dilakukan adalah membuat
+// ulang room dan join ulang semua publisher.
+func (sh *SignalingHandler) RebuildRoomSessions(client *JanusClient) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.logger.WithField("room_count", len(sh.RoomSessions)).Warn("Rebuilding room sessions after Janus session reset")
+
+	for roomID, roomSession := range sh.RoomSessions {
+		if client != nil && roomSession.Client != client {
+			continue
+		}
+		sh.restoreRoomSession(roomID, roomSession)
+	}
+
+	sh.rebuildUserHandleIndex()
+
+	sh.logger.Info("Room sessions rebuilt")
+}
+
+// restoreRoomSession membuat ulang room di Janus, membuang subscriber lama,
+// join ulang semua publisher dan meminta client melakukan negosiasi ulang media
+func (sh *SignalingHandler) restoreRoomSession(roomID string, roomSession *RoomSession) {
+	// Buat ulang room di Janus
+	if roomSession.Plugin != nil {
+		if err := sh.createJanusRoom(roomSession.Plugin, roomSession.JanusRoom, roomID, roomSession.Options); err != nil {
+			sh.logger.Warnf("Failed to recreate room (might already exist): %v", err)
+		}
+	}
+
+	// Subscriber lama tidak berlaku lagi, akan dibuat ulang saat publisher publish ulang
+	for key, subscriberSession := range roomSession.Subscribers {
+		if subscriberSession.Plugin != nil {
+			if err := subscriberSession.Plugin.DetachPlugin(); err != nil {
+				sh.logger.Errorf("Failed to detach subscriber plugin: %v", err)
+			}
+		}
+		delete(roomSession.Subscribers, key)
+	}
+
+	// Forwarder ikut hilang; forward dilanjutkan saat publisher publish ulang
+	for _, rf := range sh.forwards[roomID] {
+		rf.FeedID = 0
+		rf.StreamIDs = nil
+	}
+
+	// Text room dan data channel dinegosiasi ulang
+	sh.restoreTextRoom(roomSession)
+
+	// Join ulang semua publisher
+	for userID, publisherSession := range roomSession.Publishers {
+		publisherSession.IsPublishing = false
+
+		if publisherSession.Plugin == nil {
+			continue
+		}
+
+		if roomSession.Options.AudioBridge {
+			sh.rejoinAudioBridge(roomSession, publisherSession)
+			continue
+		}
+
+		joined, err := publisherSession.Plugin.JoinVideoRoom(roomSession.JanusRoom, publisherSession.JanusID, publisherSession.Display)
+		if err != nil {
+			sh.logger.WithFields(logrus.Fields{
+				"room_id": roomID,
+				"user_id": userID,
+			}).Errorf("Failed to rejoin video room: %v", err)
+			continue
+		}
+		publisherSession.PrivateID = joined.PrivateID
+
+		sh.sendMediaEvent(roomID, userID, "session-reset", map[string]interface{}{
+			"janusId":    publisherSession.JanusID,
+			"publishers": sh.describePublishers(roomSession, joined.Publishers),
+		})
+	}
+}
+
+// rebuildUserHandleIndex memperbarui index handle pada user session
+// setelah handle ID berubah karena attach ulang
+func (sh *SignalingHandler) rebuildUserHandleIndex() {
+	for _, userSession := range sh.UserSessions {
+		userSession.PublisherIDs = make(map[uint64]bool)
+		userSession.SubscriberIDs = make(map[uint64]bool)
+	}
+	for _, roomSession := range sh.RoomSessions {
+		for userID, publisherSession := range roomSession.Publishers {
+			if userSession, exists := sh.UserSessions[userID]; exists && publisherSession.Plugin != nil {
+				userSession.PublisherIDs[publisherSession.Plugin.ID()] = true
+			}
+		}
+		for _, subscriberSession := range roomSession.Subscribers {
+			if userSession, exists := sh.UserSessions[subscriberSession.UserID]; exists && subscriberSession.Plugin != nil {
+				userSession.SubscriberIDs[subscriberSession.Plugin.ID()] = true
+			}
+		}
+	}
+}
+
+// clientForRoom mengembalikan client Janus untuk room, dari pool jika dipakai.
+// Pemanggil harus sudah memegang sh.mu.
+func (sh *SignalingHandler) clientForRoom(roomID string) (*JanusClient, error) {
+	if sh.Pool != nil {
+		return sh.Pool.ClientForRoom(roomID, sh.roomLoads())
+	}
+
+	if sh.JanusClient == nil {
+		return nil, fmt.Errorf("no janus client configured")
+	}
+
+	return sh.JanusClient, nil
+}
+
+// roomLoads mengembalikan jumlah publisher setiap room untuk penempatan room di pool.
+// Pemanggil harus sudah memegang sh.mu.
+func (sh *SignalingHandler) roomLoads() map[string]int {
+	loads := make(map[string]int, len(sh.RoomSessions))
+	for roomID := range sh.RoomSessions {
+		if count, ok := sh.roomStats(roomID)["publisher_count"].(int); ok {
+			loads[roomID] = count
+		}
+	}
+	return loads
+}
+
+// CreateRoom membuat video room di Janus sebelum ada user yang join
+func (sh *SignalingHandler) CreateRoom(roomID string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, err := sh.getOrCreateRoomSession(roomID); err != nil {
+		return fmt.Errorf("failed to get room session: %w", err)
+	}
+
+	return nil
+}
+
+// DestroyRoom melepas semua handle user di room, lalu menghapus video room di Janus
+func (sh *SignalingHandler) DestroyRoom(roomID string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return nil
+	}
+
+	users := make(map[string]bool)
+
+	for key, subscriberSession := range roomSession.Subscribers {
+		users[subscriberSession.UserID] = true
+		if subscriberSession.Plugin != nil {
+			if userSession, exists := sh.UserSessions[subscriberSession.UserID]; exists {
+				delete(userSession.SubscriberIDs, subscriberSession.Plugin.ID())
+			}
+			if err := subscriberSession.Plugin.DetachPlugin(); err != nil {
+				sh.logger.Errorf("Failed to detach subscriber plugin: %v", err)
+			}
+		}
+		delete(roomSession.Subscribers, key)
+	}
+
+	for userID, publisherSession := range roomSession.Publishers {
+		users[userID] = true
+		if publisherSession.DataPlugin != nil {
+			if err := publisherSession.DataPlugin.DetachPlugin(); err != nil {
+				sh.logger.Errorf("Failed to detach text room plugin: %v", err)
+			}
+		}
+		if publisherSession.Plugin != nil {
+			if userSession, exists := sh.UserSessions[userID]; exists {
+				delete(userSession.PublisherIDs, publisherSession.Plugin.ID())
+			}
+			if err := publisherSession.Plugin.DetachPlugin(); err != nil {
+				sh.logger.Errorf("Failed to detach publisher plugin: %v", err)
+			}
+		}
+		delete(roomSession.Publishers, userID)
+	}
+
+	for key, pending := range sh.pendingCandidates {
+		if pending.RoomID == roomID {
+			delete(sh.pendingCandidates, key)
+		}
+	}
+
+	for userID := range users {
+		if userSession, exists := sh.UserSessions[userID]; exists {
+			delete(userSession.RoomIDs, roomID)
+			if len(userSession.RoomIDs) == 0 {
+				delete(sh.UserSessions, userID)
+			}
+		}
+		sh.sendMediaEvent(roomID, userID, "destroyed", nil)
+	}
+
+	if roomSession.Plugin != nil {
+		destroy := roomSession.Plugin.DestroyVideoRoom
+		if roomSession.Options.AudioBridge {
+			destroy = roomSession.Plugin.DestroyAudioBridge
+		}
+		if err := destroy(roomSession.JanusRoom); err != nil {
+			sh.logger.Errorf("Failed to destroy video room: %v", err)
+		}
+		if err := roomSession.Plugin.DetachPlugin(); err != nil {
+			sh.logger.Errorf("Failed to detach room plugin: %v", err)
+		}
+	}
+
+	if roomSession.TextRoom != nil {
+		if err := roomSession.TextRoom.DestroyTextRoom(roomSession.JanusRoom); err != nil {
+			sh.logger.Errorf("Failed to destroy text room: %v", err)
+		}
+		if err := roomSession.TextRoom.DetachPlugin(); err != nil {
+			sh.logger.Errorf("Failed to detach text room plugin: %v", err)
+		}
+	}
+
+	// Forwarder ikut terhapus bersama video room
+	delete(sh.forwards, roomID)
+
+	delete(sh.RoomSessions, roomID)
+	if sh.Pool != nil {
+		sh.Pool.ReleaseRoom(roomID)
+	}
+
+	sh.logger.WithField("room_id", roomID).Info("Destroyed room session")
+
+	return nil
+}
+
 // getOrCreateRoomSession membuat atau mendapatkan room session
 func (sh *SignalingHandler) getOrCreateRoomSession(roomID string) (*RoomSession, error) {
 	if roomSession, exists := sh.RoomSessions[roomID]; exists {
@@ -318,14 +1401,23 @@ func (sh *SignalingHandler) getOrCreateRoomSession(roomID string) (*RoomSession,
 		janusRoomID = sh.hashString(roomID)
 	}
 
+	// Pilih instance Janus untuk room
+	client, err := sh.clientForRoom(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get janus client: %w", err)
+	}
+
+	// Kebijakan media room menentukan plugin (videoroom atau audiobridge) dan opsinya
+	options := sh.roomOptions(roomID)
+
 	// Buat plugin untuk room management
-	roomPlugin, err := sh.JanusClient.AttachPlugin("janus.plugin.videoroom")
+	roomPlugin, err := client.AttachPlugin(options.pluginName())
 	if err != nil {
 		return nil, fmt.Errorf("failed to attach room plugin: %w", err)
 	}
 
-	// Buat room di Janus
-	if err := roomPlugin.CreateVideoRoom(janusRoomID, fmt.Sprintf("Room %s", roomID)); err != nil {
+	// Buat room di Janus dengan kebijakan media room tersebut
+	if err := sh.createJanusRoom(roomPlugin, janusRoomID, roomID, options); err != nil {
 		// Room mungkin sudah ada, lanjutkan saja
 		sh.logger.Warnf("Failed to create room (might already exist): %v", err)
 	}
@@ -334,17 +1426,25 @@ func (sh *SignalingHandler) getOrCreateRoomSession(roomID string) (*RoomSession,
 	roomSession := &RoomSession{
 		RoomID:      roomID,
 		JanusRoom:   janusRoomID,
+		Client:      client,
 		Plugin:      roomPlugin,
+		Options:     options,
 		Publishers:  make(map[string]*PublisherSession),
 		Subscribers: make(map[string]*SubscriberSession),
 		CreatedAt:   time.Now(),
 	}
 
+	// Text room dengan ID Janus yang sama untuk data channel peserta
+	if options.DataChannels {
+		sh.createTextRoom(roomSession)
+	}
+
 	sh.RoomSessions[roomID] = roomSession
 
 	sh.logger.WithFields(logrus.Fields{
 		"room_id":       roomID,
 		"janus_room_id": janusRoomID,
+		"plugin":        options.pluginName(),
 	}).Info("Created room session")
 
 	return roomSession, nil
@@ -406,6 +1506,19 @@ func (sh *SignalingHandler) GetRoomStats(roomID string) map[string]interface{} {
 	sh.mu.RLock()
 	defer sh.mu.RUnlock()
 
+	stats := sh.roomStats(roomID)
+
+	if roomSession, exists := sh.RoomSessions[roomID]; exists && sh.Pool != nil {
+		if instance := sh.Pool.InstanceForClient(roomSession.Client); instance != nil {
+			stats["janus_instance"] = instance.Name
+		}
+	}
+
+	return stats
+}
+
+// roomStats menghitung statistik room tanpa mengambil lock
+func (sh *SignalingHandler) roomStats(roomID string) map[string]interface{} {
 	stats := map[string]interface{}{
 		"room_id": roomID,
 		"exists":  false,
@@ -414,6 +1527,7 @@ func (sh *SignalingHandler) GetRoomStats(roomID string) map[string]interface{} {
 	if roomSession, exists := sh.RoomSessions[roomID]; exists {
 		stats["exists"] = true
 		stats["janus_room_id"] = roomSession.JanusRoom
+		stats["plugin"] = roomSession.Options.pluginName()
 		stats["publisher_count"] = len(roomSession.Publishers)
 		stats["subscriber_count"] = len(roomSession.Subscribers)
 		stats["created_at"] = roomSession.CreatedAt
@@ -425,15 +1539,31 @@ func (sh *SignalingHandler) GetRoomStats(roomID string) map[string]interface{} {
 		stats["publishers"] = publishers
 
 		subscribers := make([]map[string]interface{}, 0, len(roomSession.Subscribers))
-		for feedID, subscriber := range roomSession.Subscribers {
+		for _, subscriber := range roomSession.Subscribers {
 			subscribers = append(subscribers, map[string]interface{}{
-				"user_id": subscriber.UserID,
-				"feed_id": feedID,
+				"user_id":           subscriber.UserID,
+				"feed_id":           subscriber.FeedID,
+				"publisher_user_id": subscriber.PublisherUserID,
+				"is_subscribed":     subscriber.IsSubscribed,
 			})
 		}
 		stats["subscribers"] = subscribers
 	}
 
+	if roomForwards := sh.forwards[roomID]; len(roomForwards) > 0 {
+		forwards := make(map[string]interface{})
+		for forwardID, rf := range roomForwards {
+			forwards[forwardID] = map[string]interface{}{
+				"mode":        rf.Mode,
+				"user_id":     rf.UserID,
+				"forwarding":  rf.FeedID != 0,
+				"audio_codec": rf.AudioCodec,
+				"video_codec": rf.VideoCodec,
+			}
+		}
+		stats["forwards"] = forwards
+	}
+
 	return stats
 }
 
@@ -508,8 +1638,23 @@ func (sh *SignalingHandler) Cleanup(maxAge time.Duration) {
 					sh.logger.Errorf("Failed to detach room plugin: %v", err)
 				}
 			}
+			if roomSession.TextRoom != nil {
+				if err := roomSession.TextRoom.DetachPlugin(); err != nil {
+					sh.logger.Errorf("Failed to detach text room plugin: %v", err)
+				}
+			}
 
 			delete(sh.RoomSessions, roomID)
+			if sh.Pool != nil {
+				sh.Pool.ReleaseRoom(roomID)
+			}
+		}
+	}
+
+	// Cleanup ICE candidate yang tidak pernah mendapatkan handle
+	for key, pending := range sh.pendingCandidates {
+		if now.Sub(pending.CreatedAt) > pendingCandidateTTL {
+			delete(sh.pendingCandidates, key)
 		}
 	}
 
@@ -521,3 +1666,28 @@ func (sh *SignalingHandler) Cleanup(maxAge time.Duration) {
 		}
 	}
 }
+
+// Close menghapus token Janus yang masih berlaku dan menutup koneksi ke Janus
+func (sh *SignalingHandler) Close() error {
+	if sh.outbox != nil {
+		sh.outbox.Close()
+	}
+
+	if sh.SIP != nil {
+		sh.SIP.Close()
+	}
+
+	if sh.TokenManager != nil {
+		sh.TokenManager.Stop()
+	}
+
+	if sh.Pool != nil {
+		return sh.Pool.Close()
+	}
+
+	if sh.JanusClient != nil {
+		return sh.JanusClient.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/webrtc/janustest"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// newHTTPTestClient membuat JanusClient dengan transport HTTP ke fake server
+func newHTTPTestClient(t *testing.T, server *janustest.Server) *JanusClient {
+	t.Helper()
+
+	client := NewJanusClient(server.URL, "", "", "")
+	t.Cleanup(func() { client.Close() })
+
+	if _, err := client.CreateSession(); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	return client
+}
+
+// newTestSignalingHandler membuat SignalingHandler dengan hub yang tidak dijalankan,
+// sehingga pesan ke user dapat dibaca langsung dari hub.UserMessage
+func newTestSignalingHandler(client *JanusClient) (*SignalingHandler, *websocket.Hub) {
+	hub := websocket.NewHub()
+	return NewSignalingHandler(client, hub), hub
+}
+
+// waitUserMessage menunggu pesan untuk user yang memenuhi match. Pesan lain dibuang.
+func waitUserMessage(t *testing.T, hub *websocket.Hub, userID string, match func(websocket.Message) bool) websocket.Message {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case userMessage := <-hub.UserMessage:
+			if userMessage.UserID == userID && match(userMessage.Message) {
+				return userMessage.Message
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for message to %s", userID)
+			return websocket.Message{}
+		}
+	}
+}
+
+// waitMediaEvent menunggu media-event tertentu untuk user dan mengembalikan datanya
+func waitMediaEvent(t *testing.T, hub *websocket.Hub, userID, event string) map[string]interface{} {
+	t.Helper()
+
+	message := waitUserMessage(t, hub, userID, func(message websocket.Message) bool {
+		data, ok := message.Data.(websocket.MediaEventData)
+		return ok && message.Type == websocket.MessageTypeMediaEvent && data.Event == event
+	})
+
+	data, _ := message.Data.(websocket.MediaEventData).Data.(map[string]interface{})
+	return data
+}
+
+// waitMessageType menunggu pesan dengan tipe tertentu untuk user
+func waitMessageType(t *testing.T, hub *websocket.Hub, userID string, messageType websocket.MessageType) websocket.Message {
+	t.Helper()
+
+	return waitUserMessage(t, hub, userID, func(message websocket.Message) bool {
+		return message.Type == messageType
+	})
+}
+
+const (
+	testAliceCandidate = "candidate:1 1 udp 2122260223 192.0.2.10 50000 typ host"
+	testBobCandidate   = "candidate:1 1 udp 2122260223 192.0.2.20 50000 typ host"
+)
+
+func TestSignalingHandlerPublishAndSubscribe(t *testing.T) {
+	server := janustest.NewServer()
+	defer server.Close()
+
+	sh, hub := newTestSignalingHandler(newHTTPTestClient(t, server))
+
+	// Candidate yang datang sebelum join di-buffer sampai handle publisher dibuat
+	if err := sh.HandleIceCandidate("1001", "alice", "", testAliceCandidate, "0", 0); err != nil {
+		t.Fatalf("ice candidate before join failed: %v", err)
+	}
+
+	if err := sh.HandleJoinRoom("1001", "alice", "Alice"); err != nil {
+		t.Fatalf("alice join failed: %v", err)
+	}
+	waitMediaEvent(t, hub, "alice", "joined")
+
+	if err := sh.HandleOffer("1001", "alice", "", testOfferSDP); err != nil {
+		t.Fatalf("alice offer failed: %v", err)
+	}
+	answer := waitMessageType(t, hub, "alice", websocket.MessageTypeAnswer)
+	if data, ok := answer.Data.(websocket.AnswerData); !ok || data.SDP == "" {
+		t.Fatalf("unexpected answer message: %+v", answer.Data)
+	}
+
+	sh.mu.RLock()
+	roomSession := sh.RoomSessions["1001"]
+	alice := roomSession.Publishers["alice"]
+	alicePlugin := alice.Plugin
+	sh.mu.RUnlock()
+
+	publisherHandle, exists := server.Handle(alicePlugin.ID())
+	if !exists || !publisherHandle.Publishing {
+		t.Fatalf("alice is not publishing on the server: %+v", publisherHandle)
+	}
+	if len(publisherHandle.Candidates) != 1 {
+		t.Fatalf("buffered candidate was not trickled to the publisher handle: %d candidates", len(publisherHandle.Candidates))
+	}
+
+	// Candidate bob untuk feed alice tiba sebelum handle subscriber ada
+	if err := sh.HandleIceCandidate("1001", "bob", "alice", testBobCandidate, "0", 0); err != nil {
+		t.Fatalf("ice candidate before subscribe failed: %v", err)
+	}
+
+	if err := sh.HandleJoinRoom("1001", "bob", "Bob"); err != nil {
+		t.Fatalf("bob join failed: %v", err)
+	}
+	joined := waitMediaEvent(t, hub, "bob", "joined")
+	if publishers, ok := joined["publishers"].([]map[string]interface{}); !ok || len(publishers) != 1 {
+		t.Fatalf("bob did not see alice as publisher: %+v", joined["publishers"])
+	}
+
+	// Bob otomatis subscribe ke feed alice dan menerima offer dari Janus
+	offer := waitMessageType(t, hub, "bob", websocket.MessageTypeOffer)
+	if data, ok := offer.Data.(websocket.OfferData); !ok || data.FromUserID != "alice" || data.SDP == "" {
+		t.Fatalf("unexpected offer message: %+v", offer.Data)
+	}
+
+	if err := sh.HandleAnswer("1001", "bob", "alice", testOfferSDP); err != nil {
+		t.Fatalf("bob answer failed: %v", err)
+	}
+	if err := sh.HandleIceCandidate("1001", "bob", "alice", "", "", 0); err != nil {
+		t.Fatalf("end of candidates failed: %v", err)
+	}
+
+	sh.mu.RLock()
+	subscriber := roomSession.Subscribers[subscriberKey("bob", alice.JanusID)]
+	sh.mu.RUnlock()
+	if subscriber == nil || !subscriber.IsSubscribed {
+		t.Fatalf("bob is not subscribed to alice: %+v", subscriber)
+	}
+
+	subscriberHandle, exists := server.Handle(subscriber.Plugin.ID())
+	if !exists || subscriberHandle.PType != "subscriber" || subscriberHandle.Feed != alice.JanusID || !subscriberHandle.Started {
+		t.Fatalf("unexpected subscriber handle on server: %+v", subscriberHandle)
+	}
+	if len(subscriberHandle.Candidates) != 1 || !subscriberHandle.TrickleCompleted {
+		t.Fatalf("subscriber candidates not trickled: %d candidates, completed %v", len(subscriberHandle.Candidates), subscriberHandle.TrickleCompleted)
+	}
+}
+
+func TestSignalingHandlerRebuildsRoomAfterSessionRecreated(t *testing.T) {
+	server := janustest.NewServer()
+	defer server.Close()
+
+	client := newHTTPTestClient(t, server)
+	sh, hub := newTestSignalingHandler(client)
+
+	if err := sh.HandleJoinRoom("1001", "alice", "Alice"); err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	waitMediaEvent(t, hub, "alice", "joined")
+
+	if err := sh.HandleOffer("1001", "alice", "", testOfferSDP); err != nil {
+		t.Fatalf("offer failed: %v", err)
+	}
+	waitMessageType(t, hub, "alice", websocket.MessageTypeAnswer)
+
+	// Session timeout di Janus menghapus room beserta semua handle
+	oldSessionID := client.currentSession()
+	server.ExpireSession(oldSessionID)
+
+	reset := waitMediaEvent(t, hub, "alice", "session-reset")
+
+	sh.mu.RLock()
+	roomSession := sh.RoomSessions["1001"]
+	alice := roomSession.Publishers["alice"]
+	sh.mu.RUnlock()
+
+	if reset["janusId"] != alice.JanusID {
+		t.Fatalf("session-reset carries janus ID %v, expected %d", reset["janusId"], alice.JanusID)
+	}
+	if client.currentSession() == oldSessionID || alice.Plugin.SessionID() != client.currentSession() {
+		t.Fatal("publisher handle was not reattached to the new session")
+	}
+
+	room, exists := server.Room(roomSession.JanusRoom)
+	if !exists || len(room.Participants) != 1 || room.Participants[0] != alice.JanusID {
+		t.Fatalf("room was not rebuilt on the server: %+v", room)
+	}
+
+	// Publisher perlu negosiasi ulang sebelum publish kembali
+	if len(room.Publishers) != 0 {
+		t.Fatalf("publisher should not be publishing before renegotiation: %+v", room.Publishers)
+	}
+	if err := sh.HandleOffer("1001", "alice", "", testOfferSDP); err != nil {
+		t.Fatalf("offer after session reset failed: %v", err)
+	}
+	if room, _ := server.Room(roomSession.JanusRoom); len(room.Publishers) != 1 {
+		t.Fatalf("publisher did not publish again: %+v", room.Publishers)
+	}
+}
+
+func TestSignalingHandlerDetachesPublisherWhenJoinFails(t *testing.T) {
+	server := janustest.NewServer()
+	defer server.Close()
+
+	sh, _ := newTestSignalingHandler(newHTTPTestClient(t, server))
+
+	server.InjectFault(janustest.Fault{
+		Janus:   "message",
+		Request: "join",
+		Code:    janustest.VideoRoomErrorUnauthorized,
+		Reason:  "Unauthorized",
+	})
+
+	if err := sh.HandleJoinRoom("1001", "alice", "Alice"); err == nil {
+		t.Fatal("expected join to fail")
+	}
+
+	sh.mu.RLock()
+	_, roomExists := sh.RoomSessions["1001"]
+	_, userExists := sh.UserSessions["alice"]
+	sh.mu.RUnlock()
+	if roomExists || userExists {
+		t.Fatalf("failed join left state behind: room %v, user %v", roomExists, userExists)
+	}
+
+	for _, handle := range server.Handles() {
+		if handle.OpaqueID == "alice" {
+			t.Fatalf("publisher handle %d was not detached", handle.ID)
+		}
+	}
+}
+
+func TestSignalingHandlerKeepsMessageOrderWhenHubIsBusy(t *testing.T) {
+	sh, hub := newTestSignalingHandler(nil)
+	defer sh.Close()
+
+	// Lebih banyak pesan daripada buffer UserMessage hub
+	const count = 1000
+	for i := 0; i < count; i++ {
+		sh.sendMediaEvent("1001", "alice", "event", i)
+	}
+
+	for i := 0; i < count; i++ {
+		message := waitUserMessage(t, hub, "alice", func(websocket.Message) bool { return true })
+		if got := message.Data.(websocket.MediaEventData).Data; got != i {
+			t.Fatalf("message %d delivered at position %d", got, i)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+const (
+	// Layer simulcast/SVC dari yang terendah sampai tertinggi
+	layerLow    = 0
+	layerMedium = 1
+	layerHigh   = 2
+
+	// Jumlah publisher aktif mulai dari mana layer otomatis diturunkan,
+	// agar meeting besar tidak mengirim resolusi penuh ke semua peserta
+	mediumLayerPublishers = 5
+	lowLayerPublishers    = 10
+)
+
+// String mengembalikan representasi layer untuk log
+func (l VideoLayer) String() string {
+	parts := make([]string, 0, 4)
+	if l.Substream != nil {
+		parts = append(parts, fmt.Sprintf("substream=%d", *l.Substream))
+	}
+	if l.Temporal != nil {
+		parts = append(parts, fmt.Sprintf("temporal=%d", *l.Temporal))
+	}
+	if l.SpatialLayer != nil {
+		parts = append(parts, fmt.Sprintf("spatial_layer=%d", *l.SpatialLayer))
+	}
+	if l.TemporalLayer != nil {
+		parts = append(parts, fmt.Sprintf("temporal_layer=%d", *l.TemporalLayer))
+	}
+	return strings.Join(parts, ",")
+}
+
+// IsEmpty mengembalikan true jika tidak ada layer yang dipilih
+func (l VideoLayer) IsEmpty() bool {
+	return l.Substream == nil && l.Temporal == nil && l.SpatialLayer == nil && l.TemporalLayer == nil
+}
+
+// HandleSelectLayer memilih layer video yang diterima user (from) dari publisher (to).
+// Jika Auto true, pilihan manual dihapus dan layer kembali ditentukan server.
+func (sh *SignalingHandler) HandleSelectLayer(roomID, fromUserID, toUserID string, data websocket.SelectLayerData) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		return fmt.Errorf("room session not found: %s", roomID)
+	}
+
+	var subscriberSession *SubscriberSession
+	for _, session := range roomSession.Subscribers {
+		if session.UserID != fromUserID {
+			continue
+		}
+		if (data.FeedID != 0 && session.FeedID == data.FeedID) || (data.FeedID == 0 && session.PublisherUserID == toUserID) {
+			subscriberSession = session
+			break
+		}
+	}
+	if subscriberSession == nil {
+		return fmt.Errorf("subscriber session not found: %s -> %s", fromUserID, toUserID)
+	}
+
+	var layer VideoLayer
+	if data.Auto {
+		subscriberSession.ManualLayer = false
+		layer = sh.autoLayer(roomSession, subscriberSession)
+	} else {
+		layer = VideoLayer{
+			Substream:     data.Substream,
+			Temporal:      data.Temporal,
+			SpatialLayer:  data.SpatialLayer,
+			TemporalLayer: data.TemporalLayer,
+		}
+		if layer.IsEmpty() {
+			return fmt.Errorf("no layer selected")
+		}
+		subscriberSession.ManualLayer = true
+	}
+
+	if err := subscriberSession.Plugin.ConfigureSubscriber(layer); err != nil {
+		return err
+	}
+	subscriberSession.Layer = layer
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomID,
+		"user_id": fromUserID,
+		"feed_id": subscriberSession.FeedID,
+		"layer":   layer.String(),
+		"auto":    data.Auto,
+	}).Info("Subscriber layer selected")
+
+	return nil
+}
+
+// applyAutoLayer memilih layer otomatis untuk subscriber yang belum memilih layer
+// secara manual. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) applyAutoLayer(roomSession *RoomSession, subscriberSession *SubscriberSession) {
+	if subscriberSession.ManualLayer {
+		return
+	}
+
+	layer := sh.autoLayer(roomSession, subscriberSession)
+	if sameLayer(layer, subscriberSession.Layer) {
+		return
+	}
+
+	if err := subscriberSession.Plugin.ConfigureSubscriber(layer); err != nil {
+		sh.logger.WithFields(logrus.Fields{
+			"room_id": roomSession.RoomID,
+			"user_id": subscriberSession.UserID,
+			"feed_id": subscriberSession.FeedID,
+		}).Errorf("Failed to apply automatic layer: %v", err)
+		return
+	}
+	subscriberSession.Layer = layer
+}
+
+// adaptRoomLayers menyesuaikan ulang layer otomatis semua subscriber di room,
+// misalnya setelah jumlah publisher berubah
+func (sh *SignalingHandler) adaptRoomLayers(roomID string) {
+	type layerChange struct {
+		subscriber *SubscriberSession
+		layer      VideoLayer
+	}
+
+	sh.mu.RLock()
+	roomSession, exists := sh.RoomSessions[roomID]
+	if !exists {
+		sh.mu.RUnlock()
+		return
+	}
+
+	var changes []layerChange
+	for _, subscriberSession := range roomSession.Subscribers {
+		if subscriberSession.ManualLayer || !subscriberSession.IsSubscribed {
+			continue
+		}
+		layer := sh.autoLayer(roomSession, subscriberSession)
+		if !sameLayer(layer, subscriberSession.Layer) {
+			changes = append(changes, layerChange{subscriber: subscriberSession, layer: layer})
+		}
+	}
+	sh.mu.RUnlock()
+
+	// Configure menunggu event dari Janus, jadi dikirim tanpa memegang lock
+	for _, change := range changes {
+		if err := change.subscriber.Plugin.ConfigureSubscriber(change.layer); err != nil {
+			sh.logger.WithFields(logrus.Fields{
+				"room_id": roomID,
+				"user_id": change.subscriber.UserID,
+				"feed_id": change.subscriber.FeedID,
+			}).Errorf("Failed to adapt subscriber layer: %v", err)
+			continue
+		}
+
+		sh.mu.Lock()
+		change.subscriber.Layer = change.layer
+		sh.mu.Unlock()
+	}
+
+	if len(changes) > 0 {
+		sh.logger.WithFields(logrus.Fields{
+			"room_id":     roomID,
+			"subscribers": len(changes),
+		}).Info("Adapted subscriber layers")
+	}
+}
+
+// autoLayer menentukan layer dari VideoQuality user penerima, dibatasi oleh
+// jumlah publisher aktif di room. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) autoLayer(roomSession *RoomSession, subscriberSession *SubscriberSession) VideoLayer {
+	quality := ""
+	if userSession, exists := sh.UserSessions[subscriberSession.UserID]; exists {
+		quality = userSession.VideoQuality
+	}
+
+	level := qualityLayer(quality)
+	if sizeLevel := roomSizeLayer(activePublishers(roomSession)); sizeLevel < level {
+		level = sizeLevel
+	}
+
+	if strings.EqualFold(subscriberSession.VideoCodec, "vp9") {
+		return VideoLayer{SpatialLayer: &level}
+	}
+	return VideoLayer{Substream: &level}
+}
+
+// qualityLayer memetakan UserSetting.VideoQuality ke layer tertinggi yang diterima user
+func qualityLayer(quality string) int {
+	switch strings.ToLower(quality) {
+	case "low", "ld", "180p", "240p":
+		return layerLow
+	case "medium", "sd", "360p", "480p":
+		return layerMedium
+	default:
+		// "hd", "fhd", "high", "auto" atau kosong
+		return layerHigh
+	}
+}
+
+// roomSizeLayer mengembalikan layer tertinggi yang dikirim untuk jumlah publisher tertentu
+func roomSizeLayer(publishers int) int {
+	switch {
+	case publishers >= lowLayerPublishers:
+		return layerLow
+	case publishers >= mediumLayerPublishers:
+		return layerMedium
+	default:
+		return layerHigh
+	}
+}
+
+// activePublishers menghitung publisher yang sedang mengirim media di room
+func activePublishers(roomSession *RoomSession) int {
+	count := 0
+	for _, publisherSession := range roomSession.Publishers {
+		if publisherSession.IsPublishing {
+			count++
+		}
+	}
+	return count
+}
+
+// lookupVideoQuality membaca preferensi kualitas video user, kosong jika tidak tersedia
+func (sh *SignalingHandler) lookupVideoQuality(userID string) string {
+	if sh.VideoQualityLookup == nil {
+		return ""
+	}
+	return sh.VideoQualityLookup(userID)
+}
+
+// sameLayer membandingkan dua pilihan layer berdasarkan nilainya
+func sameLayer(a, b VideoLayer) bool {
+	return sameInt(a.Substream, b.Substream) &&
+		sameInt(a.Temporal, b.Temporal) &&
+		sameInt(a.SpatialLayer, b.SpatialLayer) &&
+		sameInt(a.TemporalLayer, b.TemporalLayer)
+}
+
+// sameInt membandingkan dua pointer int berdasarkan nilainya
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,195 @@
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SIPPlugin adalah nama plugin Janus yang menjadi gateway antara WebRTC dan SIP
+const SIPPlugin = "janus.plugin.sip"
+
+// SIPRegisterRequest adalah request register akun SIP. Type "helper" membuat handle
+// tambahan yang memakai registrasi handle master sehingga bisa melayani panggilan paralel.
+type SIPRegisterRequest struct {
+	Request     string `json:"request"`
+	Type        string `json:"type,omitempty"`
+	MasterID    uint64 `json:"master_id,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+	Proxy       string `json:"proxy,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Header SIP dengan prefix ini diteruskan Janus di event incomingcall
+	IncomingHeaderPrefixes []string `json:"incoming_header_prefixes,omitempty"`
+}
+
+// SIPCallRequest adalah request panggilan keluar ke URI SIP
+type SIPCallRequest struct {
+	Request string            `json:"request"`
+	URI     string            `json:"uri"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// SIPDeclineRequest adalah request menolak panggilan masuk dengan kode SIP
+type SIPDeclineRequest struct {
+	Request string `json:"request"`
+	Code    int    `json:"code,omitempty"`
+}
+
+// SIPSimpleRequest adalah request plugin SIP tanpa parameter (accept, hangup)
+type SIPSimpleRequest struct {
+	Request string `json:"request"`
+}
+
+// SIPEvent adalah data event dari plugin SIP
+type SIPEvent struct {
+	SIP    string         `json:"sip"`
+	CallID string         `json:"call_id,omitempty"`
+	Result SIPEventResult `json:"result"`
+}
+
+// SIPEventResult adalah isi event plugin SIP, misalnya registered, incomingcall,
+// accepted, hangup atau info (DTMF melalui SIP INFO)
+type SIPEventResult struct {
+	Event       string            `json:"event"`
+	Username    string            `json:"username,omitempty"`
+	DisplayName string            `json:"displayname,omitempty"`
+	Callee      string            `json:"callee,omitempty"`
+	CallID      string            `json:"call_id,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Code        int               `json:"code,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+
+	// Isi SIP INFO, misalnya "Signal=5\r\nDuration=160" untuk application/dtmf-relay
+	Type    string `json:"type,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// RegisterSIP mendaftarkan akun SIP di proxy. Hasil registrasi dikirim Janus
+// secara asinkron sebagai event registered atau registration_failed.
+func (ph *PluginHandle) RegisterSIP(username, secret, proxy, displayName string) error {
+	body := SIPRegisterRequest{
+		Request:                "register",
+		Username:               username,
+		Secret:                 secret,
+		Proxy:                  proxy,
+		DisplayName:            displayName,
+		IncomingHeaderPrefixes: []string{"X-"},
+	}
+
+	if _, err := ph.sendMessage(body, nil, true); err != nil {
+		return fmt.Errorf("failed to register sip account: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"username":  username,
+		"proxy":     proxy,
+		"handle_id": ph.ID(),
+	}).Info("Registering SIP account")
+
+	return nil
+}
+
+// RegisterSIPHelper menjadikan handle ini helper dari handle master yang sudah
+// terdaftar, sehingga satu akun SIP dapat melayani beberapa panggilan sekaligus
+func (ph *PluginHandle) RegisterSIPHelper(masterID uint64, username string) error {
+	body := SIPRegisterRequest{
+		Request:  "register",
+		Type:     "helper",
+		MasterID: masterID,
+		Username: username,
+	}
+
+	if _, err := ph.sendMessage(body, nil, true); err != nil {
+		return fmt.Errorf("failed to register sip helper: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"master_id": masterID,
+		"handle_id": ph.ID(),
+	}).Debug("Registered SIP helper")
+
+	return nil
+}
+
+// CallSIP memulai panggilan keluar dengan JSEP offer. Status panggilan dikirim
+// Janus secara asinkron (ringing, accepted berisi JSEP answer, hangup).
+func (ph *PluginHandle) CallSIP(uri string, headers map[string]string, jsep *JSEP) (string, error) {
+	body := SIPCallRequest{
+		Request: "call",
+		URI:     uri,
+		Headers: headers,
+	}
+
+	resp, err := ph.sendMessage(body, jsep, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to call %s: %w", uri, err)
+	}
+
+	var calling SIPEvent
+	if err := resp.DecodePluginData(&calling); err != nil {
+		return "", fmt.Errorf("failed to parse call response: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"uri":       uri,
+		"call_id":   calling.callID(),
+		"handle_id": ph.ID(),
+	}).Info("Calling SIP peer")
+
+	return calling.callID(), nil
+}
+
+// AcceptSIPCall menerima panggilan masuk dengan JSEP answer
+func (ph *PluginHandle) AcceptSIPCall(jsep *JSEP) error {
+	if _, err := ph.sendMessage(SIPSimpleRequest{Request: "accept"}, jsep, true); err != nil {
+		return fmt.Errorf("failed to accept sip call: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+	}).Info("Accepted SIP call")
+
+	return nil
+}
+
+// DeclineSIPCall menolak panggilan masuk dengan kode SIP (misalnya 486 Busy Here)
+func (ph *PluginHandle) DeclineSIPCall(code int) error {
+	body := SIPDeclineRequest{
+		Request: "decline",
+		Code:    code,
+	}
+
+	if _, err := ph.sendMessage(body, nil, true); err != nil {
+		return fmt.Errorf("failed to decline sip call: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"code":      code,
+		"handle_id": ph.ID(),
+	}).Info("Declined SIP call")
+
+	return nil
+}
+
+// HangupSIPCall mengakhiri panggilan yang sedang berjalan di handle ini
+func (ph *PluginHandle) HangupSIPCall() error {
+	if _, err := ph.sendMessage(SIPSimpleRequest{Request: "hangup"}, nil, true); err != nil {
+		return fmt.Errorf("failed to hang up sip call: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+	}).Info("Hung up SIP call")
+
+	return nil
+}
+
+// callID mengembalikan Call-ID SIP dari event. Janus menaruhnya di luar atau di dalam result.
+func (e *SIPEvent) callID() string {
+	if e.CallID != "" {
+		return e.CallID
+	}
+	return e.Result.CallID
+}
@@ -0,0 +1,1012 @@
+package webrtc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	pionwebrtc "github.com/pion/webrtc/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+const (
+	// DefaultSIPMaxCalls adalah jumlah panggilan paralel default (handle master + helper)
+	DefaultSIPMaxCalls = 4
+
+	// DefaultSIPPINTimeout adalah batas waktu penelepon memasukkan PIN dial-in
+	DefaultSIPPINTimeout = 30 * time.Second
+
+	// Kesempatan memasukkan PIN sebelum panggilan diakhiri
+	maxPINAttempts = 3
+
+	// Panjang maksimum PIN yang dikumpulkan dari DTMF
+	maxPINLength = 16
+
+	// Codec default panggilan keluar, didukung hampir semua telepon dan PBX
+	defaultSIPCodec = "pcmu"
+
+	// Payload type telephone-event (RFC 4733) yang ditawarkan gateway
+	telephoneEventPayloadType = 101
+
+	// Header SIP yang dapat membawa PIN dial-in, misalnya diisi PBX dari IVR-nya
+	roomPINHeader = "X-Room-PIN"
+)
+
+// dialNumberPattern adalah format nomor yang boleh ditelepon host
+var dialNumberPattern = regexp.MustCompile(`^\+?[0-9*#]{3,20}$`)
+
+// sipCodecs adalah codec audio yang dapat dijembatani gateway tanpa transcoding.
+// Codec yang sama dipakai di sisi telepon dan di sisi room.
+var sipCodecs = map[string]pionwebrtc.RTPCodecCapability{
+	"opus": {MimeType: pionwebrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1"},
+	"pcmu": {MimeType: pionwebrtc.MimeTypePCMU, ClockRate: 8000},
+	"pcma": {MimeType: pionwebrtc.MimeTypePCMA, ClockRate: 8000},
+	"g722": {MimeType: pionwebrtc.MimeTypeG722, ClockRate: 8000},
+}
+
+// SIPConfig adalah konfigurasi akun SIP yang dipakai gateway
+type SIPConfig struct {
+	// Proxy SIP tempat akun didaftarkan, misalnya sip:pbx.example.com:5060
+	Proxy string
+
+	// Akun SIP (sip:user@domain) beserta password-nya
+	Username    string
+	Secret      string
+	DisplayName string
+
+	// Domain untuk nomor dial-out (kosong = domain akun)
+	DialDomain string
+
+	// Jumlah panggilan paralel, satu handle Janus per panggilan
+	MaxCalls int
+
+	// Batas waktu penelepon memasukkan PIN melalui DTMF
+	PINTimeout time.Duration
+}
+
+// SIPGateway menjembatani panggilan telepon ke room melalui plugin SIP Janus.
+//
+// Setiap panggilan memakai dua PeerConnection pion: satu ke handle SIP (sisi telepon)
+// dan satu ke handle room sebagai publisher dengan user ID "sip:<id>" (sisi room).
+// Paket RTP diteruskan apa adanya di antara keduanya sehingga kedua sisi memakai
+// codec yang sama. Di room audio bridge penelepon mendengar mix semua peserta;
+// di video room penelepon hanya didengar karena audio peserta lain (Opus) tidak
+// dapat diteruskan ke telepon tanpa transcoding.
+type SIPGateway struct {
+	// Signaling handler pemilik room
+	Signaling *SignalingHandler
+
+	// Janus client tempat handle SIP di-attach
+	Client *JanusClient
+
+	Config SIPConfig
+
+	// Pencari room dari PIN dial-in (nil = panggilan masuk ditolak)
+	RoomLookup func(pin string) (roomID string, ok bool)
+
+	lines []*sipLine
+	calls map[string]*sipCall
+	mu    sync.Mutex
+
+	logger *logrus.Logger
+}
+
+// sipLine adalah satu handle SIP Janus yang melayani satu panggilan
+type sipLine struct {
+	Plugin *PluginHandle
+	call   *sipCall
+}
+
+// sipCall adalah state satu panggilan telepon
+type sipCall struct {
+	info  mediaplane.SIPCall
+	line  *sipLine
+	codec string
+
+	api    *pionwebrtc.API
+	sipPC  *pionwebrtc.PeerConnection
+	roomPC *pionwebrtc.PeerConnection
+
+	// Track lokal menuju telepon dan menuju room
+	toPhone *pionwebrtc.TrackLocalStaticRTP
+	toRoom  *pionwebrtc.TrackLocalStaticRTP
+
+	// Room sudah (mencoba) dimasuki sehingga perlu leave saat panggilan berakhir
+	joined bool
+
+	digits  chan byte
+	ended   chan struct{}
+	endOnce sync.Once
+}
+
+// NewSIPGateway membuat gateway SIP untuk signaling handler
+func NewSIPGateway(sh *SignalingHandler, client *JanusClient, config SIPConfig) *SIPGateway {
+	if config.MaxCalls <= 0 {
+		config.MaxCalls = DefaultSIPMaxCalls
+	}
+	if config.PINTimeout <= 0 {
+		config.PINTimeout = DefaultSIPPINTimeout
+	}
+
+	return &SIPGateway{
+		Signaling: sh,
+		Client:    client,
+		Config:    config,
+		calls:     make(map[string]*sipCall),
+		logger:    logrus.New(),
+	}
+}
+
+// Start mendaftarkan akun SIP pada handle master lalu menyiapkan handle helper
+// untuk panggilan paralel
+func (g *SIPGateway) Start() error {
+	master, err := g.Client.AttachPlugin(SIPPlugin)
+	if err != nil {
+		return fmt.Errorf("failed to attach sip plugin: %w", err)
+	}
+
+	if err := master.RegisterSIP(g.Config.Username, g.Config.Secret, g.Config.Proxy, g.Config.DisplayName); err != nil {
+		if detachErr := master.DetachPlugin(); detachErr != nil {
+			g.logger.Errorf("Failed to detach sip plugin: %v", detachErr)
+		}
+		return err
+	}
+	g.addLine(master)
+
+	for i := 1; i < g.Config.MaxCalls; i++ {
+		helper, err := g.Client.AttachPlugin(SIPPlugin)
+		if err != nil {
+			g.logger.Warnf("Failed to attach sip helper, limiting parallel calls to %d: %v", i, err)
+			break
+		}
+
+		if err := helper.RegisterSIPHelper(master.ID(), g.Config.Username); err != nil {
+			if detachErr := helper.DetachPlugin(); detachErr != nil {
+				g.logger.Errorf("Failed to detach sip plugin: %v", detachErr)
+			}
+			g.logger.Warnf("Failed to register sip helper, limiting parallel calls to %d: %v", i, err)
+			break
+		}
+		g.addLine(helper)
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"username":  g.Config.Username,
+		"proxy":     g.Config.Proxy,
+		"max_calls": len(g.lines),
+	}).Info("SIP gateway started")
+
+	return nil
+}
+
+// addLine mencatat handle SIP dan mulai membaca event-nya
+func (g *SIPGateway) addLine(plugin *PluginHandle) {
+	line := &sipLine{Plugin: plugin}
+
+	g.mu.Lock()
+	g.lines = append(g.lines, line)
+	g.mu.Unlock()
+
+	go g.watchLine(line)
+}
+
+// watchLine membaca event handle SIP sampai handle dilepas
+func (g *SIPGateway) watchLine(line *sipLine) {
+	for event := range line.Plugin.Events {
+		g.handleLineEvent(line, event)
+	}
+
+	g.mu.Lock()
+	call := line.call
+	g.mu.Unlock()
+
+	if call != nil {
+		g.endCall(call, "sip handle closed", false)
+	}
+}
+
+// handleLineEvent memproses event asinkron plugin SIP
+func (g *SIPGateway) handleLineEvent(line *sipLine, event *JanusResponse) {
+	g.mu.Lock()
+	call := line.call
+	g.mu.Unlock()
+
+	if event.Janus == "hangup" {
+		// PeerConnection gateway ke handle SIP ditutup Janus
+		if call != nil {
+			g.endCall(call, "media disconnected", true)
+		}
+		return
+	}
+
+	if event.Janus != "event" {
+		return
+	}
+
+	var data SIPEvent
+	if err := event.DecodePluginData(&data); err != nil {
+		g.logger.WithField("handle_id", line.Plugin.ID()).Warnf("SIP request failed: %v", err)
+		return
+	}
+
+	switch data.Result.Event {
+	case "registered":
+		g.logger.WithField("username", data.Result.Username).Info("SIP account registered")
+	case "registration_failed":
+		g.logger.WithFields(logrus.Fields{
+			"code":   data.Result.Code,
+			"reason": data.Result.Reason,
+		}).Error("SIP registration failed")
+	case "incomingcall":
+		go g.incomingCall(line, data, event.Jsep)
+	case "ringing", "proceeding", "progress":
+		if call != nil {
+			g.mu.Lock()
+			call.info.State = mediaplane.SIPCallRinging
+			g.mu.Unlock()
+		}
+	case "accepted":
+		if call != nil && call.info.Direction == mediaplane.SIPCallOutbound && event.Jsep != nil {
+			go g.outboundAnswered(call, event.Jsep)
+		}
+	case "info":
+		if call != nil && strings.EqualFold(data.Result.Type, "application/dtmf-relay") {
+			if digit, ok := dtmfRelayDigit(data.Result.Content); ok {
+				call.digit(digit)
+			}
+		}
+	case "hangup":
+		if call != nil {
+			g.endCall(call, fmt.Sprintf("%d %s", data.Result.Code, data.Result.Reason), false)
+		}
+	}
+}
+
+// incomingCall menerima panggilan masuk, menentukan room dari PIN lalu memasukkan
+// penelepon ke room. PIN dibaca dari header X-Room-PIN atau nomor tujuan; jika
+// tidak cocok, penelepon diminta mengetik PIN diakhiri # melalui DTMF.
+func (g *SIPGateway) incomingCall(line *sipLine, event SIPEvent, offer *JSEP) {
+	number := sipUser(event.Result.Username)
+	logger := g.logger.WithFields(logrus.Fields{
+		"from":    number,
+		"callee":  event.Result.Callee,
+		"call_id": event.callID(),
+	})
+
+	codec := ""
+	if offer != nil {
+		codec = sdpCodecs(offer.SDP)["audio"]
+	}
+
+	if g.RoomLookup == nil || offer == nil {
+		logger.Warn("Declining incoming call: dial-in is not available")
+		if err := line.Plugin.DeclineSIPCall(603); err != nil {
+			logger.Errorf("Failed to decline call: %v", err)
+		}
+		return
+	}
+	if _, ok := sipCodecs[codec]; !ok {
+		logger.Warnf("Declining incoming call: unsupported codec %q", codec)
+		if err := line.Plugin.DeclineSIPCall(488); err != nil {
+			logger.Errorf("Failed to decline call: %v", err)
+		}
+		return
+	}
+
+	display := event.Result.DisplayName
+	if display == "" {
+		display = number
+	}
+
+	g.mu.Lock()
+	call := g.newCall(line, mediaplane.SIPCallInbound, number, display, codec)
+	g.mu.Unlock()
+
+	answer, err := g.answerPhone(call, offer)
+	if err == nil {
+		err = line.Plugin.AcceptSIPCall(answer)
+	}
+	if err != nil {
+		logger.Errorf("Failed to answer incoming call: %v", err)
+		g.endCall(call, "answer failed", true)
+		return
+	}
+
+	now := time.Now()
+	g.mu.Lock()
+	call.info.AnsweredAt = &now
+	g.mu.Unlock()
+
+	roomID, ok := g.lookupPIN(event.Result.Headers[roomPINHeader], sipUser(event.Result.Callee))
+	if !ok {
+		g.mu.Lock()
+		call.info.State = mediaplane.SIPCallPIN
+		g.mu.Unlock()
+
+		roomID, ok = g.collectPIN(call)
+	}
+	if !ok {
+		logger.Warn("No valid dial-in PIN entered, hanging up")
+		g.endCall(call, "invalid pin", true)
+		return
+	}
+
+	if err := g.bridge(call, roomID); err != nil {
+		logger.WithField("room_id", roomID).Errorf("Failed to bridge call into room: %v", err)
+		g.endCall(call, "bridge failed", true)
+	}
+}
+
+// DialOut menelepon nomor lalu memasukkan penerima telepon ke room setelah panggilan dijawab
+func (g *SIPGateway) DialOut(roomID string, dial mediaplane.SIPDial) (*mediaplane.SIPCall, error) {
+	number := strings.ReplaceAll(dial.Number, " ", "")
+	if !dialNumberPattern.MatchString(number) {
+		return nil, fmt.Errorf("invalid phone number: %s", dial.Number)
+	}
+
+	display := dial.Display
+	if display == "" {
+		display = number
+	}
+
+	g.mu.Lock()
+	var line *sipLine
+	for _, candidate := range g.lines {
+		if candidate.call == nil {
+			line = candidate
+			break
+		}
+	}
+	if line == nil {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("all %d phone lines are busy", len(g.lines))
+	}
+
+	call := g.newCall(line, mediaplane.SIPCallOutbound, number, display, defaultSIPCodec)
+	call.info.RoomID = roomID
+	call.info.StartedBy = dial.StartedBy
+	info := call.info
+	g.mu.Unlock()
+
+	offer, err := g.offerPhone(call)
+	if err == nil {
+		_, err = line.Plugin.CallSIP(g.dialURI(number), nil, offer)
+	}
+	if err != nil {
+		g.endCall(call, "dial failed", false)
+		return nil, err
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"room_id":    roomID,
+		"call":       info.ID,
+		"number":     number,
+		"started_by": dial.StartedBy,
+	}).Info("Dialing out to phone")
+
+	return &info, nil
+}
+
+// outboundAnswered menyelesaikan negosiasi sisi telepon lalu memasukkan penerima ke room
+func (g *SIPGateway) outboundAnswered(call *sipCall, answer *JSEP) {
+	err := call.sipPC.SetRemoteDescription(pionwebrtc.SessionDescription{
+		Type: pionwebrtc.SDPTypeAnswer,
+		SDP:  answer.SDP,
+	})
+
+	g.mu.Lock()
+	now := time.Now()
+	call.info.AnsweredAt = &now
+	roomID := call.info.RoomID
+	g.mu.Unlock()
+
+	if err == nil {
+		err = g.bridge(call, roomID)
+	}
+	if err != nil {
+		g.logger.WithFields(logrus.Fields{
+			"room_id": roomID,
+			"call":    call.info.ID,
+		}).Errorf("Failed to bridge answered call into room: %v", err)
+		g.endCall(call, "bridge failed", true)
+	}
+}
+
+// HangupCall mengakhiri panggilan telepon di room
+func (g *SIPGateway) HangupCall(roomID, callID string) error {
+	g.mu.Lock()
+	call, exists := g.calls[callID]
+	inRoom := exists && call.info.RoomID == roomID
+	g.mu.Unlock()
+
+	if !inRoom {
+		return fmt.Errorf("%w: %s", websocket.ErrSIPCallNotFound, callID)
+	}
+
+	g.endCall(call, "hangup by host", true)
+	return nil
+}
+
+// ListCalls mengembalikan panggilan di room, urut waktu mulai
+func (g *SIPGateway) ListCalls(roomID string) []mediaplane.SIPCall {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	calls := make([]mediaplane.SIPCall, 0)
+	for _, call := range g.calls {
+		if call.info.RoomID == roomID {
+			calls = append(calls, call.info)
+		}
+	}
+
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].CreatedAt.Before(calls[j].CreatedAt)
+	})
+
+	return calls
+}
+
+// Close mengakhiri semua panggilan dan melepas handle SIP
+func (g *SIPGateway) Close() {
+	g.mu.Lock()
+	calls := make([]*sipCall, 0, len(g.calls))
+	for _, call := range g.calls {
+		calls = append(calls, call)
+	}
+	lines := g.lines
+	g.lines = nil
+	g.mu.Unlock()
+
+	for _, call := range calls {
+		g.endCall(call, "gateway closed", true)
+	}
+
+	for _, line := range lines {
+		if err := line.Plugin.DetachPlugin(); err != nil {
+			g.logger.Errorf("Failed to detach sip plugin: %v", err)
+		}
+	}
+}
+
+// newCall mencatat panggilan baru pada line. Dipanggil saat g.mu dipegang.
+func (g *SIPGateway) newCall(line *sipLine, direction, number, display, codec string) *sipCall {
+	id := uuid.New().String()
+	call := &sipCall{
+		info: mediaplane.SIPCall{
+			ID:        id,
+			UserID:    "sip:" + id,
+			Direction: direction,
+			Number:    number,
+			Display:   display,
+			State:     mediaplane.SIPCallRinging,
+			CreatedAt: time.Now(),
+		},
+		line:   line,
+		codec:  codec,
+		digits: make(chan byte, maxPINLength),
+		ended:  make(chan struct{}),
+	}
+
+	line.call = call
+	g.calls[id] = call
+
+	return call
+}
+
+// endCall mengakhiri panggilan sekali saja: menutup kedua PeerConnection,
+// mengeluarkan penelepon dari room dan (jika hangup) memutus panggilan SIP
+func (g *SIPGateway) endCall(call *sipCall, reason string, hangup bool) {
+	call.endOnce.Do(func() {
+		close(call.ended)
+
+		g.mu.Lock()
+		delete(g.calls, call.info.ID)
+		if call.line.call == call {
+			call.line.call = nil
+		}
+		info := call.info
+		joined := call.joined
+		g.mu.Unlock()
+
+		if hangup {
+			if err := call.line.Plugin.HangupSIPCall(); err != nil {
+				g.logger.WithField("call", info.ID).Debugf("Failed to hang up call: %v", err)
+			}
+		}
+
+		for _, pc := range []*pionwebrtc.PeerConnection{call.roomPC, call.sipPC} {
+			if pc != nil {
+				if err := pc.Close(); err != nil {
+					g.logger.WithField("call", info.ID).Debugf("Failed to close peer connection: %v", err)
+				}
+			}
+		}
+
+		if joined {
+			if err := g.Signaling.HandleLeaveRoom(info.RoomID, info.UserID); err != nil {
+				g.logger.WithField("call", info.ID).Debugf("Failed to remove caller from room: %v", err)
+			}
+		}
+
+		g.logger.WithFields(logrus.Fields{
+			"room_id":   info.RoomID,
+			"call":      info.ID,
+			"number":    info.Number,
+			"direction": info.Direction,
+			"reason":    reason,
+		}).Info("Phone call ended")
+	})
+}
+
+// lookupPIN mencari room dari kandidat PIN pertama yang valid
+func (g *SIPGateway) lookupPIN(candidates ...string) (string, bool) {
+	for _, pin := range candidates {
+		if pin == "" {
+			continue
+		}
+		if roomID, ok := g.RoomLookup(pin); ok {
+			return roomID, true
+		}
+	}
+	return "", false
+}
+
+// collectPIN membaca PIN dari DTMF penelepon, maksimal maxPINAttempts kali
+func (g *SIPGateway) collectPIN(call *sipCall) (string, bool) {
+	for attempt := 0; attempt < maxPINAttempts; attempt++ {
+		pin, ok := g.readPIN(call)
+		if !ok {
+			return "", false
+		}
+		if roomID, ok := g.lookupPIN(pin); ok {
+			return roomID, true
+		}
+
+		g.logger.WithFields(logrus.Fields{
+			"call":    call.info.ID,
+			"attempt": attempt + 1,
+		}).Warn("Invalid dial-in PIN entered")
+	}
+	return "", false
+}
+
+// readPIN mengumpulkan digit DTMF sampai # ditekan. * menghapus digit yang sudah diketik.
+func (g *SIPGateway) readPIN(call *sipCall) (string, bool) {
+	timer := time.NewTimer(g.Config.PINTimeout)
+	defer timer.Stop()
+
+	var pin strings.Builder
+	for {
+		select {
+		case digit := <-call.digits:
+			switch digit {
+			case '#':
+				if pin.Len() > 0 {
+					return pin.String(), true
+				}
+			case '*':
+				pin.Reset()
+			default:
+				if pin.Len() < maxPINLength {
+					pin.WriteByte(digit)
+				}
+			}
+		case <-timer.C:
+			return "", false
+		case <-call.ended:
+			return "", false
+		}
+	}
+}
+
+// bridge memasukkan penelepon ke room sebagai publisher audio dan meneruskan
+// audio room (mix audio bridge) ke telepon
+func (g *SIPGateway) bridge(call *sipCall, roomID string) error {
+	roomPC, err := call.api.NewPeerConnection(pionwebrtc.Configuration{})
+	if err != nil {
+		return fmt.Errorf("failed to create room connection: %w", err)
+	}
+
+	g.mu.Lock()
+	call.roomPC = roomPC
+	call.info.RoomID = roomID
+	call.joined = true
+	info := call.info
+	g.mu.Unlock()
+
+	sender, err := roomPC.AddTrack(call.toRoom)
+	if err != nil {
+		return fmt.Errorf("failed to add room track: %w", err)
+	}
+	go drainRTCP(sender)
+
+	// Audio dari room (mix audio bridge) diteruskan ke telepon
+	roomPC.OnTrack(func(remote *pionwebrtc.TrackRemote, receiver *pionwebrtc.RTPReceiver) {
+		forwardRTP(remote, call.toPhone, 0, nil)
+	})
+
+	description, err := roomPC.CreateOffer(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create room offer: %w", err)
+	}
+	offer, err := gatherLocalDescription(roomPC, description)
+	if err != nil {
+		return err
+	}
+
+	roomHandle, answer, err := g.Signaling.joinPhone(roomID, info.UserID, info.Display, call.codec, offer)
+	if err != nil {
+		return err
+	}
+	go g.watchRoomLeg(call, roomHandle)
+
+	if err := roomPC.SetRemoteDescription(pionwebrtc.SessionDescription{
+		Type: pionwebrtc.SDPTypeAnswer,
+		SDP:  answer.SDP,
+	}); err != nil {
+		return fmt.Errorf("failed to set room answer: %w", err)
+	}
+
+	g.mu.Lock()
+	call.info.State = mediaplane.SIPCallConnected
+	g.mu.Unlock()
+
+	g.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"call":      info.ID,
+		"user_id":   info.UserID,
+		"number":    info.Number,
+		"direction": info.Direction,
+		"codec":     call.codec,
+	}).Info("Phone call bridged into room")
+
+	return nil
+}
+
+// watchRoomLeg membaca event handle room milik penelepon. Jika Janus menutup media
+// penelepon (misalnya di-kick host), panggilan telepon ikut diakhiri.
+func (g *SIPGateway) watchRoomLeg(call *sipCall, handle *PluginHandle) {
+	for event := range handle.Events {
+		if event.Janus == "hangup" {
+			g.endCall(call, "removed from room", true)
+		}
+	}
+}
+
+// answerPhone menjawab offer dari handle SIP (panggilan masuk)
+func (g *SIPGateway) answerPhone(call *sipCall, offer *JSEP) (*JSEP, error) {
+	if err := g.connectPhone(call); err != nil {
+		return nil, err
+	}
+
+	if err := call.sipPC.SetRemoteDescription(pionwebrtc.SessionDescription{
+		Type: pionwebrtc.SDPTypeOffer,
+		SDP:  offer.SDP,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set sip offer: %w", err)
+	}
+
+	answer, err := call.sipPC.CreateAnswer(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sip answer: %w", err)
+	}
+	return gatherLocalDescription(call.sipPC, answer)
+}
+
+// offerPhone membuat offer untuk handle SIP (panggilan keluar)
+func (g *SIPGateway) offerPhone(call *sipCall) (*JSEP, error) {
+	if err := g.connectPhone(call); err != nil {
+		return nil, err
+	}
+	offer, err := call.sipPC.CreateOffer(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sip offer: %w", err)
+	}
+	return gatherLocalDescription(call.sipPC, offer)
+}
+
+// connectPhone menyiapkan PeerConnection sisi telepon beserta track menuju telepon
+// dan menuju room. Audio telepon diteruskan ke room, DTMF RFC 4733 dibaca sebagai PIN.
+func (g *SIPGateway) connectPhone(call *sipCall) error {
+	api, err := newSIPMediaAPI(call.codec)
+	if err != nil {
+		return err
+	}
+	call.api = api
+
+	capability := sipCodecs[call.codec]
+	call.toPhone, err = pionwebrtc.NewTrackLocalStaticRTP(capability, "audio", "room")
+	if err != nil {
+		return fmt.Errorf("failed to create phone track: %w", err)
+	}
+	call.toRoom, err = pionwebrtc.NewTrackLocalStaticRTP(capability, "audio", call.info.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to create room track: %w", err)
+	}
+
+	sipPC, err := api.NewPeerConnection(pionwebrtc.Configuration{})
+	if err != nil {
+		return fmt.Errorf("failed to create sip connection: %w", err)
+	}
+
+	g.mu.Lock()
+	call.sipPC = sipPC
+	g.mu.Unlock()
+
+	sender, err := sipPC.AddTrack(call.toPhone)
+	if err != nil {
+		return fmt.Errorf("failed to add phone track: %w", err)
+	}
+	go drainRTCP(sender)
+
+	sipPC.OnTrack(func(remote *pionwebrtc.TrackRemote, receiver *pionwebrtc.RTPReceiver) {
+		var dtmfPayloadType uint8
+		for _, codec := range receiver.GetParameters().Codecs {
+			if strings.EqualFold(codec.MimeType, "audio/telephone-event") {
+				dtmfPayloadType = uint8(codec.PayloadType)
+			}
+		}
+
+		forwardRTP(remote, call.toRoom, dtmfPayloadType, call.digit)
+	})
+
+	return nil
+}
+
+// digit meneruskan digit DTMF ke pengumpul PIN tanpa memblokir
+func (c *sipCall) digit(digit byte) {
+	select {
+	case c.digits <- digit:
+	default:
+	}
+}
+
+// dialURI membangun URI SIP untuk nomor telepon
+func (g *SIPGateway) dialURI(number string) string {
+	domain := g.Config.DialDomain
+	if domain == "" {
+		if index := strings.LastIndex(g.Config.Username, "@"); index >= 0 {
+			domain = g.Config.Username[index+1:]
+		}
+	}
+	return "sip:" + number + "@" + domain
+}
+
+// joinPhone memasukkan penelepon ke room sebagai publisher audio dengan offer dari
+// gateway dan mengembalikan handle beserta answer Janus. Event handle dibaca gateway,
+// bukan diteruskan ke hub, karena penelepon tidak punya koneksi WebSocket.
+func (sh *SignalingHandler) joinPhone(roomID, userID, display, codec string, offer *JSEP) (*PluginHandle, *JSEP, error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	roomSession, err := sh.getOrCreateRoomSession(roomID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get room session: %w", err)
+	}
+
+	userSession := sh.getOrCreateUserSession(userID)
+	userSession.RoomIDs[roomID] = true
+
+	publisherSession := &PublisherSession{
+		UserID:     userID,
+		Display:    display,
+		JanusID:    sh.generateJanusUserID(userID),
+		AudioCodec: codec,
+		CreatedAt:  time.Now(),
+	}
+
+	attachOptions, err := sh.attachOptions(roomSession.Client, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plugin, err := roomSession.Client.AttachPluginWithOptions(roomSession.Options.pluginName(), attachOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to attach publisher plugin: %w", err)
+	}
+
+	publisherSession.Plugin = plugin
+	roomSession.Publishers[userID] = publisherSession
+	userSession.PublisherIDs[plugin.ID()] = true
+
+	var answer *JSEP
+	if roomSession.Options.AudioBridge {
+		if _, err := plugin.JoinAudioBridge(roomSession.JanusRoom, publisherSession.JanusID, display, codec, false); err != nil {
+			return plugin, nil, err
+		}
+		answer, err = plugin.ConfigureAudioBridge(AudioBridgeConfigureRequest{}, offer)
+	} else {
+		joined, joinErr := plugin.JoinVideoRoom(roomSession.JanusRoom, publisherSession.JanusID, display)
+		if joinErr != nil {
+			return plugin, nil, joinErr
+		}
+		publisherSession.PrivateID = joined.PrivateID
+		answer, err = plugin.PublishToVideoRoom(offer)
+	}
+	if err != nil {
+		return plugin, nil, err
+	}
+	if answer == nil {
+		return plugin, nil, fmt.Errorf("janus did not answer phone publisher %s", userID)
+	}
+
+	publisherSession.IsPublishing = true
+	publisherSession.Mids = sdpMids(offer.SDP)
+	if !roomSession.Options.AudioBridge {
+		sh.resumeForward(roomSession, publisherSession)
+	}
+
+	return plugin, answer, nil
+}
+
+// DialOut menelepon nomor dan memasukkannya ke room melalui gateway SIP
+func (sh *SignalingHandler) DialOut(roomID string, dial mediaplane.SIPDial) (*mediaplane.SIPCall, error) {
+	if sh.SIP == nil {
+		return nil, websocket.ErrSIPUnavailable
+	}
+	return sh.SIP.DialOut(roomID, dial)
+}
+
+// HangupCall mengakhiri panggilan telepon di room
+func (sh *SignalingHandler) HangupCall(roomID, callID string) error {
+	if sh.SIP == nil {
+		return websocket.ErrSIPUnavailable
+	}
+	return sh.SIP.HangupCall(roomID, callID)
+}
+
+// ListCalls mengembalikan panggilan telepon yang sedang berjalan di room
+func (sh *SignalingHandler) ListCalls(roomID string) ([]mediaplane.SIPCall, error) {
+	if sh.SIP == nil {
+		return nil, websocket.ErrSIPUnavailable
+	}
+	return sh.SIP.ListCalls(roomID), nil
+}
+
+// newSIPMediaAPI membuat API pion yang hanya mengenal satu codec audio dan
+// telephone-event, sehingga negosiasi dengan Janus tidak memilih codec lain
+func newSIPMediaAPI(codec string) (*pionwebrtc.API, error) {
+	capability, ok := sipCodecs[codec]
+	if !ok {
+		return nil, fmt.Errorf("unsupported phone codec: %s", codec)
+	}
+
+	mediaEngine := &pionwebrtc.MediaEngine{}
+	if err := mediaEngine.RegisterCodec(pionwebrtc.RTPCodecParameters{
+		RTPCodecCapability: capability,
+		PayloadType:        pionwebrtc.PayloadType(websocket.RTPPayloads[codec].Type),
+	}, pionwebrtc.RTPCodecTypeAudio); err != nil {
+		return nil, fmt.Errorf("failed to register codec: %w", err)
+	}
+
+	if err := mediaEngine.RegisterCodec(pionwebrtc.RTPCodecParameters{
+		RTPCodecCapability: pionwebrtc.RTPCodecCapability{MimeType: "audio/telephone-event", ClockRate: 8000, SDPFmtpLine: "0-16"},
+		PayloadType:        telephoneEventPayloadType,
+	}, pionwebrtc.RTPCodecTypeAudio); err != nil {
+		return nil, fmt.Errorf("failed to register telephone-event: %w", err)
+	}
+
+	registry := &interceptor.Registry{}
+	if err := pionwebrtc.RegisterDefaultInterceptors(mediaEngine, registry); err != nil {
+		return nil, fmt.Errorf("failed to register interceptors: %w", err)
+	}
+
+	return pionwebrtc.NewAPI(
+		pionwebrtc.WithMediaEngine(mediaEngine),
+		pionwebrtc.WithInterceptorRegistry(registry),
+	), nil
+}
+
+// gatherLocalDescription memasang offer/answer sebagai local description dan menunggu
+// ICE gathering selesai sehingga SDP yang dikirim ke Janus sudah berisi semua candidate
+func gatherLocalDescription(pc *pionwebrtc.PeerConnection, description pionwebrtc.SessionDescription) (*JSEP, error) {
+	gathered := pionwebrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(description); err != nil {
+		return nil, fmt.Errorf("failed to set local description: %w", err)
+	}
+	<-gathered
+
+	local := pc.LocalDescription()
+	return &JSEP{
+		Type: local.Type.String(),
+		SDP:  local.SDP,
+	}, nil
+}
+
+// forwardRTP meneruskan paket RTP dari track remote ke track lokal sampai track
+// berakhir. Paket telephone-event (dtmfPayloadType, 0 = tidak ada) tidak diteruskan
+// tetapi diubah menjadi digit dan diberikan ke onDigit.
+func forwardRTP(remote *pionwebrtc.TrackRemote, local *pionwebrtc.TrackLocalStaticRTP, dtmfPayloadType uint8, onDigit func(byte)) {
+	var lastDTMFTimestamp uint32
+	for {
+		packet, _, err := remote.ReadRTP()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logrus.Debugf("Phone audio forwarding stopped: %v", err)
+			}
+			return
+		}
+
+		if dtmfPayloadType != 0 && packet.PayloadType == dtmfPayloadType {
+			// Event DTMF dikirim berulang; digit diambil sekali dari paket end pertama
+			if digit, ok := telephoneEventDigit(packet); ok && packet.Timestamp != lastDTMFTimestamp {
+				lastDTMFTimestamp = packet.Timestamp
+				if onDigit != nil {
+					onDigit(digit)
+				}
+			}
+			continue
+		}
+
+		if err := local.WriteRTP(packet); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			return
+		}
+	}
+}
+
+// drainRTCP membaca RTCP dari sender agar interceptor pion tetap berjalan
+func drainRTCP(sender *pionwebrtc.RTPSender) {
+	buffer := make([]byte, 1500)
+	for {
+		if _, _, err := sender.Read(buffer); err != nil {
+			return
+		}
+	}
+}
+
+// telephoneEventDigit mengubah paket telephone-event RFC 4733 yang menandai akhir
+// tombol menjadi digit DTMF
+func telephoneEventDigit(packet *rtp.Packet) (byte, bool) {
+	if len(packet.Payload) < 4 || packet.Payload[1]&0x80 == 0 {
+		return 0, false
+	}
+	return dtmfDigit(packet.Payload[0])
+}
+
+// dtmfRelayDigit membaca digit dari isi SIP INFO application/dtmf-relay ("Signal=5")
+func dtmfRelayDigit(content string) (byte, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(key), "signal") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) == 1 && strings.ContainsAny(value, "0123456789*#") {
+			return value[0], true
+		}
+	}
+	return 0, false
+}
+
+// dtmfDigit mengubah kode event RFC 4733 (0-9, 10 = *, 11 = #) menjadi karakter
+func dtmfDigit(event byte) (byte, bool) {
+	switch {
+	case event <= 9:
+		return '0' + event, true
+	case event == 10:
+		return '*', true
+	case event == 11:
+		return '#', true
+	}
+	return 0, false
+}
+
+// sipUser mengambil bagian user dari URI SIP, misalnya "sip:+62811@pbx" menjadi "+62811"
+func sipUser(uri string) string {
+	uri = strings.TrimPrefix(strings.TrimPrefix(uri, "sips:"), "sip:")
+	if index := strings.IndexAny(uri, "@;"); index >= 0 {
+		uri = uri[:index]
+	}
+	return uri
+}
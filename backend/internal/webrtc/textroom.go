@@ -0,0 +1,303 @@
+package webrtc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+	"github.com/webrtc-meeting/backend/internal/websocket"
+)
+
+// Batas panjang pesan chat data channel yang disimpan ke RoomMessage
+const maxDataMessageLength = 4000
+
+// Jenis pesan data channel. Hanya chat yang disimpan, jenis lain hanya di-relay
+// Janus ke peserta lain.
+const (
+	DataMessageChat       = "chat"
+	DataMessageReaction   = "reaction"
+	DataMessageCursor     = "cursor"
+	DataMessageWhiteboard = "whiteboard"
+)
+
+// DataMessage adalah isi field text pesan TextRoom yang dikirim client
+type DataMessage struct {
+	Kind    string          `json:"kind"`
+	Message string          `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// createTextRoom membuat text room untuk room dengan ID Janus yang sama dengan
+// video room atau audio bridge-nya. Hanya peserta yang tokennya sudah ditambahkan
+// yang dapat join. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) createTextRoom(roomSession *RoomSession) {
+	textRoom := roomSession.TextRoom
+	if textRoom == nil {
+		plugin, err := roomSession.Client.AttachPlugin(TextRoomPlugin)
+		if err != nil {
+			sh.logger.WithField("room_id", roomSession.RoomID).Errorf("Failed to attach text room plugin, data channels disabled: %v", err)
+			return
+		}
+		textRoom = plugin
+		roomSession.TextRoom = plugin
+	}
+
+	if err := textRoom.CreateTextRoom(roomSession.JanusRoom, fmt.Sprintf("Room %s", roomSession.RoomID), sh.textRoomPostURL(roomSession.RoomID)); err != nil {
+		// Text room mungkin sudah ada, lanjutkan saja
+		sh.logger.Warnf("Failed to create text room (might already exist): %v", err)
+	}
+
+	if err := textRoom.AllowTextRoom(roomSession.JanusRoom, "enable", nil); err != nil {
+		sh.logger.WithField("room_id", roomSession.RoomID).Errorf("Failed to restrict text room access: %v", err)
+	}
+}
+
+// joinTextRoom membuat handle textroom untuk user dan memulai negosiasi data
+// channel-nya. Kegagalan tidak membatalkan join room, user hanya tidak mendapat
+// data channel. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) joinTextRoom(roomSession *RoomSession, publisherSession *PublisherSession) {
+	attachOptions, err := sh.attachOptions(roomSession.Client, publisherSession.UserID)
+	if err != nil {
+		sh.logger.WithField("user_id", publisherSession.UserID).Errorf("Failed to prepare text room plugin: %v", err)
+		return
+	}
+
+	plugin, err := roomSession.Client.AttachPluginWithOptions(TextRoomPlugin, attachOptions)
+	if err != nil {
+		sh.logger.WithField("user_id", publisherSession.UserID).Errorf("Failed to attach text room plugin: %v", err)
+		return
+	}
+	publisherSession.DataPlugin = plugin
+
+	go sh.watchTextRoom(roomSession.RoomID, publisherSession.UserID, plugin)
+
+	sh.setupDataChannel(roomSession, publisherSession)
+}
+
+// setupDataChannel mengizinkan token baru user di text room, meminta offer data
+// channel dari Janus lalu mengirimkannya ke user bersama parameter join text room.
+// Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) setupDataChannel(roomSession *RoomSession, publisherSession *PublisherSession) {
+	logger := sh.logger.WithFields(logrus.Fields{
+		"room_id": roomSession.RoomID,
+		"user_id": publisherSession.UserID,
+	})
+
+	token, err := newTextRoomToken()
+	if err != nil {
+		logger.Errorf("Failed to generate text room token: %v", err)
+		return
+	}
+
+	if err := roomSession.TextRoom.AllowTextRoom(roomSession.JanusRoom, "add", []string{token}); err != nil {
+		logger.Errorf("Failed to allow text room token: %v", err)
+		return
+	}
+	publisherSession.DataToken = token
+
+	offer, err := publisherSession.DataPlugin.SetupTextRoom()
+	if err != nil {
+		logger.Errorf("Failed to set up data channel: %v", err)
+		return
+	}
+
+	// ICE candidate data channel yang datang sebelum handle siap
+	sh.flushCandidates(roomSession.RoomID, publisherSession.UserID, websocket.DataChannelPeer, publisherSession.DataPlugin)
+
+	sh.sendDataChannelOffer(roomSession, publisherSession, offer)
+
+	logger.Info("Data channel offer sent")
+}
+
+// sendDataChannelOffer mengirim parameter join text room dan offer data channel ke user
+func (sh *SignalingHandler) sendDataChannelOffer(roomSession *RoomSession, publisherSession *PublisherSession, offer *JSEP) {
+	// Setelah data channel terbuka, client join text room dengan parameter ini
+	sh.sendMediaEvent(roomSession.RoomID, publisherSession.UserID, "datachannel", map[string]interface{}{
+		"room":     roomSession.JanusRoom,
+		"username": publisherSession.UserID,
+		"display":  publisherSession.Display,
+		"token":    publisherSession.DataToken,
+	})
+
+	sh.sendToUser(publisherSession.UserID, websocket.Message{
+		Type:   websocket.MessageTypeOffer,
+		RoomID: roomSession.RoomID,
+		UserID: websocket.DataChannelPeer,
+		Data: websocket.OfferData{
+			RoomID:     roomSession.RoomID,
+			FromUserID: websocket.DataChannelPeer,
+			ToUserID:   publisherSession.UserID,
+			SDP:        offer.SDP,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// answerTextRoom meneruskan answer data channel dari browser ke Janus.
+// Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) answerTextRoom(roomSession *RoomSession, userID, sdp string) error {
+	publisherSession, exists := roomSession.Publishers[userID]
+	if !exists || publisherSession.DataPlugin == nil {
+		return fmt.Errorf("data channel not found: %s", userID)
+	}
+
+	if err := publisherSession.DataPlugin.AckTextRoom(&JSEP{Type: "answer", SDP: sdp}); err != nil {
+		return err
+	}
+
+	sh.logger.WithFields(logrus.Fields{
+		"room_id": roomSession.RoomID,
+		"user_id": userID,
+	}).Info("Data channel answer handled")
+
+	return nil
+}
+
+// leaveTextRoom mencabut token text room user lalu melepas handle textroom-nya,
+// sehingga Janus mengeluarkan user dari text room. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) leaveTextRoom(roomSession *RoomSession, publisherSession *PublisherSession) {
+	if publisherSession.DataPlugin == nil {
+		return
+	}
+
+	if roomSession.TextRoom != nil && publisherSession.DataToken != "" {
+		if err := roomSession.TextRoom.AllowTextRoom(roomSession.JanusRoom, "remove", []string{publisherSession.DataToken}); err != nil {
+			sh.logger.Errorf("Failed to revoke text room token: %v", err)
+		}
+	}
+
+	if err := publisherSession.DataPlugin.DetachPlugin(); err != nil {
+		sh.logger.Errorf("Failed to detach text room plugin: %v", err)
+	}
+	publisherSession.DataPlugin = nil
+	publisherSession.DataToken = ""
+}
+
+// restoreTextRoom membuat ulang text room dan negosiasi ulang data channel setiap
+// user setelah session Janus dibuat ulang. Dipanggil saat sh.mu dipegang.
+func (sh *SignalingHandler) restoreTextRoom(roomSession *RoomSession) {
+	if roomSession.TextRoom == nil {
+		return
+	}
+
+	sh.createTextRoom(roomSession)
+
+	for _, publisherSession := range roomSession.Publishers {
+		if publisherSession.DataPlugin != nil {
+			sh.setupDataChannel(roomSession, publisherSession)
+		}
+	}
+}
+
+// watchTextRoom meneruskan ICE candidate dan status koneksi data channel ke user.
+// Pesan text room sendiri mengalir langsung antara browser dan Janus.
+func (sh *SignalingHandler) watchTextRoom(roomID, userID string, handle *PluginHandle) {
+	for event := range handle.Events {
+		switch event.Janus {
+		case "trickle":
+			if event.Candidate == nil {
+				continue
+			}
+			sh.sendToUser(userID, websocket.Message{
+				Type:   websocket.MessageTypeIceCandidate,
+				RoomID: roomID,
+				UserID: websocket.DataChannelPeer,
+				Data: websocket.IceCandidateData{
+					RoomID:        roomID,
+					FromUserID:    websocket.DataChannelPeer,
+					ToUserID:      userID,
+					Candidate:     event.Candidate.Candidate,
+					SDPMID:        event.Candidate.SDPMid,
+					SDPMLineIndex: event.Candidate.SDPMLineIndex,
+				},
+				Timestamp: time.Now(),
+			})
+		case "webrtcup":
+			sh.sendMediaEvent(roomID, userID, "datachannel-up", nil)
+		case "hangup":
+			sh.sendMediaEvent(roomID, userID, "datachannel-down", map[string]interface{}{
+				"reason": event.Reason,
+			})
+		}
+	}
+}
+
+// ReceiveDataMessage menerima pesan data channel yang di-POST Janus TextRoom dan
+// menyimpan pesan chat ke RoomMessage. Whisper, pesan selain chat dan pesan yang
+// tidak dapat di-parse tidak disimpan.
+func (sh *SignalingHandler) ReceiveDataMessage(roomID, signature string, message websocket.DataChannelMessage) error {
+	// Endpoint ini publik, sehingga tanpa secret tidak ada pesan yang dipercaya
+	if sh.TextRoomSecret == "" || !hmac.Equal([]byte(signature), []byte(sh.textRoomSignature(roomID))) {
+		return websocket.ErrInvalidSignature
+	}
+
+	if message.Whisper || sh.MessageStore == nil {
+		return nil
+	}
+
+	var data DataMessage
+	if err := json.Unmarshal([]byte(message.Text), &data); err != nil || data.Kind != DataMessageChat {
+		return nil
+	}
+
+	text := strings.TrimSpace(data.Message)
+	if text == "" || len(text) > maxDataMessageLength {
+		return nil
+	}
+
+	// Hanya peserta yang sedang terhubung ke room yang dapat mengirim chat
+	sh.mu.RLock()
+	joined := false
+	if roomSession, exists := sh.RoomSessions[roomID]; exists {
+		if publisherSession, exists := roomSession.Publishers[message.From]; exists {
+			joined = publisherSession.DataPlugin != nil
+		}
+	}
+	sh.mu.RUnlock()
+
+	if !joined {
+		return mediaplane.ErrParticipantNotFound
+	}
+
+	if err := sh.MessageStore(roomID, message.From, text); err != nil {
+		return fmt.Errorf("failed to store chat message: %w", err)
+	}
+
+	return nil
+}
+
+// textRoomPostURL membuat URL post text room yang ditandatangani untuk room
+// (kosong jika TextRoomPostURL atau TextRoomSecret tidak diatur, karena pesan
+// tanpa tanda tangan akan ditolak)
+func (sh *SignalingHandler) textRoomPostURL(roomID string) string {
+	if sh.TextRoomPostURL == "" || sh.TextRoomSecret == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s/messages?sig=%s", strings.TrimSuffix(sh.TextRoomPostURL, "/"), url.PathEscape(roomID), sh.textRoomSignature(roomID))
+}
+
+// textRoomSignature menghitung HMAC-SHA256 room ID dengan TextRoomSecret
+func (sh *SignalingHandler) textRoomSignature(roomID string) string {
+	mac := hmac.New(sha256.New, []byte(sh.TextRoomSecret))
+	mac.Write([]byte(roomID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newTextRoomToken membuat token acak untuk join text room
+func newTextRoomToken() (string, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
@@ -0,0 +1,162 @@
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TextRoomPlugin adalah nama plugin Janus yang me-relay pesan data channel antar peserta
+const TextRoomPlugin = "janus.plugin.textroom"
+
+// TextRoomCreateRequest adalah request untuk membuat text room. Post adalah URL
+// yang menerima HTTP POST dari Janus untuk setiap pesan yang dikirim peserta.
+type TextRoomCreateRequest struct {
+	Request     string `json:"request"`
+	Room        uint64 `json:"room,omitempty"`
+	Description string `json:"description,omitempty"`
+	IsPrivate   bool   `json:"is_private,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+	Post        string `json:"post,omitempty"`
+	History     int    `json:"history,omitempty"`
+}
+
+// TextRoomDestroyRequest adalah request untuk menghapus text room
+type TextRoomDestroyRequest struct {
+	Request string `json:"request"`
+	Room    uint64 `json:"room"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// TextRoomAllowedRequest adalah request untuk mengatur token yang boleh join text room
+// (action "enable", "disable", "add" atau "remove")
+type TextRoomAllowedRequest struct {
+	Request string   `json:"request"`
+	Room    uint64   `json:"room"`
+	Secret  string   `json:"secret,omitempty"`
+	Action  string   `json:"action"`
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+// TextRoomKickRequest adalah request untuk mengeluarkan peserta dari text room
+type TextRoomKickRequest struct {
+	Request  string `json:"request"`
+	Room     uint64 `json:"room"`
+	Secret   string `json:"secret,omitempty"`
+	Username string `json:"username"`
+}
+
+// TextRoomSimpleRequest adalah request plugin textroom tanpa parameter (setup, ack)
+type TextRoomSimpleRequest struct {
+	Request string `json:"request"`
+}
+
+// CreateTextRoom membuat text room baru. Pesan peserta di-POST Janus ke URL post
+// (kosong = tidak diteruskan ke server).
+func (ph *PluginHandle) CreateTextRoom(roomID uint64, description, post string) error {
+	body := TextRoomCreateRequest{
+		Request:     "create",
+		Room:        roomID,
+		Description: description,
+		Post:        post,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to create text room: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"handle_id": ph.ID(),
+	}).Info("Created text room")
+
+	return nil
+}
+
+// DestroyTextRoom menghapus text room. Semua peserta akan menerima event destroyed.
+func (ph *PluginHandle) DestroyTextRoom(roomID uint64) error {
+	body := TextRoomDestroyRequest{
+		Request: "destroy",
+		Room:    roomID,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to destroy text room: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"handle_id": ph.ID(),
+	}).Info("Destroyed text room")
+
+	return nil
+}
+
+// AllowTextRoom mengatur daftar token yang boleh join text room
+func (ph *PluginHandle) AllowTextRoom(roomID uint64, action string, tokens []string) error {
+	body := TextRoomAllowedRequest{
+		Request: "allowed",
+		Room:    roomID,
+		Action:  action,
+		Allowed: tokens,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to %s text room tokens: %w", action, err)
+	}
+
+	return nil
+}
+
+// KickFromTextRoom mengeluarkan peserta dari text room berdasarkan username-nya
+func (ph *PluginHandle) KickFromTextRoom(roomID uint64, username string) error {
+	body := TextRoomKickRequest{
+		Request:  "kick",
+		Room:     roomID,
+		Username: username,
+	}
+
+	if _, err := ph.sendMessage(body, nil, false); err != nil {
+		return fmt.Errorf("failed to kick participant from text room: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"room_id":   roomID,
+		"username":  username,
+		"handle_id": ph.ID(),
+	}).Info("Kicked participant from text room")
+
+	return nil
+}
+
+// SetupTextRoom meminta Janus membuat PeerConnection data channel untuk handle ini
+// dan mengembalikan JSEP offer yang harus dijawab oleh browser
+func (ph *PluginHandle) SetupTextRoom() (*JSEP, error) {
+	resp, err := ph.sendMessage(TextRoomSimpleRequest{Request: "setup"}, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up text room: %w", err)
+	}
+
+	if resp.Jsep == nil || resp.Jsep.Type != "offer" {
+		return nil, fmt.Errorf("janus did not send a data channel offer")
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+	}).Debug("Set up text room data channel")
+
+	return resp.Jsep, nil
+}
+
+// AckTextRoom mengirim JSEP answer dari browser ke Janus sehingga data channel terbuka
+func (ph *PluginHandle) AckTextRoom(jsep *JSEP) error {
+	if _, err := ph.sendMessage(TextRoomSimpleRequest{Request: "ack"}, jsep, true); err != nil {
+		return fmt.Errorf("failed to acknowledge text room answer: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+	}).Debug("Acknowledged text room answer")
+
+	return nil
+}
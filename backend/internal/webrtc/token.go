@@ -0,0 +1,184 @@
+package webrtc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTokenTTL adalah masa berlaku default token Janus untuk user yang join
+const DefaultTokenTTL = 2 * time.Minute
+
+// TokenManager menerbitkan stored token Janus berumur pendek untuk setiap user
+// yang join, dan menghapusnya dari Janus setelah kedaluwarsa
+type TokenManager struct {
+	// Masa berlaku token
+	TTL time.Duration
+
+	// Plugin yang boleh diakses dengan token
+	Plugins []string
+
+	// Token yang sudah diterbitkan
+	tokens map[string]*issuedToken
+
+	// Channel untuk menghentikan expiry loop
+	stop chan struct{}
+
+	// Mutex untuk thread safety
+	mu sync.Mutex
+
+	// Logger
+	logger *logrus.Logger
+}
+
+// issuedToken adalah token yang diterbitkan untuk user
+type issuedToken struct {
+	UserID    string
+	Admin     *JanusAdminClient
+	ExpiresAt time.Time
+}
+
+// NewTokenManager membuat instance TokenManager baru dengan token yang
+// hanya boleh mengakses plugin room (videoroom, audiobridge dan textroom)
+func NewTokenManager(ttl time.Duration) *TokenManager {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	return &TokenManager{
+		TTL:     ttl,
+		Plugins: []string{"janus.plugin.videoroom", AudioBridgePlugin, TextRoomPlugin},
+		tokens:  make(map[string]*issuedToken),
+		logger:  logrus.New(),
+	}
+}
+
+// Issue menerbitkan token baru untuk user dan mendaftarkannya di instance
+// Janus milik client melalui Admin API
+func (tm *TokenManager) Issue(client *JanusClient, userID string) (string, error) {
+	admin := client.Admin()
+
+	tokenBytes := make([]byte, 24)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	if err := admin.AddToken(token, tm.Plugins); err != nil {
+		return "", err
+	}
+
+	tm.mu.Lock()
+	tm.tokens[token] = &issuedToken{
+		UserID:    userID,
+		Admin:     admin,
+		ExpiresAt: time.Now().Add(tm.TTL),
+	}
+	tm.mu.Unlock()
+
+	tm.logger.WithField("user_id", userID).Debug("Issued Janus token")
+
+	return token, nil
+}
+
+// Revoke menghapus token dari Janus sebelum kedaluwarsa
+func (tm *TokenManager) Revoke(token string) error {
+	tm.mu.Lock()
+	issued, exists := tm.tokens[token]
+	delete(tm.tokens, token)
+	tm.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+
+	return issued.Admin.RemoveToken(token)
+}
+
+// RevokeUser menghapus semua token milik user
+func (tm *TokenManager) RevokeUser(userID string) {
+	tm.mu.Lock()
+	var tokens []string
+	for token, issued := range tm.tokens {
+		if issued.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	tm.mu.Unlock()
+
+	for _, token := range tokens {
+		if err := tm.Revoke(token); err != nil {
+			tm.logger.WithError(err).WithField("user_id", userID).Warn("Failed to revoke Janus token")
+		}
+	}
+}
+
+// Start memulai loop yang menghapus token kedaluwarsa
+func (tm *TokenManager) Start(interval time.Duration) {
+	tm.mu.Lock()
+	if tm.stop != nil {
+		tm.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	tm.stop = stop
+	tm.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				tm.removeExpired()
+			}
+		}
+	}()
+}
+
+// Stop menghentikan expiry loop dan menghapus semua token yang masih tersisa
+func (tm *TokenManager) Stop() {
+	tm.mu.Lock()
+	if tm.stop != nil {
+		close(tm.stop)
+		tm.stop = nil
+	}
+	tokens := make([]string, 0, len(tm.tokens))
+	for token := range tm.tokens {
+		tokens = append(tokens, token)
+	}
+	tm.mu.Unlock()
+
+	for _, token := range tokens {
+		if err := tm.Revoke(token); err != nil {
+			tm.logger.WithError(err).Warn("Failed to revoke Janus token")
+		}
+	}
+}
+
+// removeExpired menghapus token yang sudah kedaluwarsa dari Janus
+func (tm *TokenManager) removeExpired() {
+	now := time.Now()
+
+	tm.mu.Lock()
+	var expired []string
+	for token, issued := range tm.tokens {
+		if now.After(issued.ExpiresAt) {
+			expired = append(expired, token)
+		}
+	}
+	tm.mu.Unlock()
+
+	for _, token := range expired {
+		if err := tm.Revoke(token); err != nil {
+			tm.logger.WithError(err).Warn("Failed to remove expired Janus token")
+		}
+	}
+}
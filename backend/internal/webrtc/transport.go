@@ -0,0 +1,454 @@
+package webrtc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Transport adalah jalur komunikasi antara JanusClient dan Janus server.
+// Semua pesan yang diterima dari Janus (balasan sinkron maupun event
+// asinkron) diteruskan ke handler yang diberikan pada Start.
+type Transport interface {
+	// Start memulai transport dan mendaftarkan handler untuk pesan masuk.
+	// onDisconnect dipanggil ketika koneksi ke Janus terputus atau transport
+	// ditutup, sehingga request yang belum dibalas tidak akan mendapat balasan.
+	Start(handler func(*JanusResponse), onDisconnect func(error)) error
+
+	// Send mengirim request ke Janus
+	Send(request JanusRequest) error
+
+	// Close menutup transport
+	Close() error
+}
+
+const (
+	// Subprotocol WebSocket yang digunakan Janus
+	janusWebSocketProtocol = "janus-protocol"
+
+	// Jumlah maksimum event per long-poll HTTP
+	httpLongPollMaxEvents = 10
+
+	// Timeout untuk long-poll HTTP (Janus menahan request maksimal 30 detik)
+	httpLongPollTimeout = 45 * time.Second
+)
+
+// HTTPTransport mengirim request melalui Janus REST API dan menerima
+// event asinkron dengan long-poll GET /janus/<session>
+type HTTPTransport struct {
+	// URL Janus server
+	BaseURL string
+
+	// API secret yang disertakan pada long-poll
+	APISecret string
+
+	// HTTP client untuk request biasa
+	HTTPClient *http.Client
+
+	// HTTP client untuk long-poll
+	pollClient *http.Client
+
+	// Handler untuk pesan masuk
+	handler func(*JanusResponse)
+
+	// Long-poll yang aktif per session
+	polls map[uint64]chan struct{}
+
+	// Mutex untuk thread safety
+	mu sync.Mutex
+
+	// Logger
+	logger *logrus.Logger
+}
+
+// NewHTTPTransport membuat instance HTTPTransport baru
+func NewHTTPTransport(baseURL string) *HTTPTransport {
+	return &HTTPTransport{
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		pollClient: &http.Client{
+			Timeout: httpLongPollTimeout,
+		},
+		polls:  make(map[uint64]chan struct{}),
+		logger: logrus.New(),
+	}
+}
+
+// Start mendaftarkan handler untuk pesan masuk. Setiap request HTTP membawa
+// balasannya sendiri, jadi onDisconnect tidak dipakai.
+func (t *HTTPTransport) Start(handler func(*JanusResponse), onDisconnect func(error)) error {
+	t.mu.Lock()
+	t.handler = handler
+	t.mu.Unlock()
+	return nil
+}
+
+// Send mengirim request ke Janus dan meneruskan balasannya ke handler
+func (t *HTTPTransport) Send(request JanusRequest) error {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := t.HTTPClient.Post(t.requestURL(request), "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var janusResp JanusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&janusResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Mulai atau hentikan long-poll sesuai siklus hidup session
+	switch {
+	case request.Janus == "create" && janusResp.Janus == "success":
+		var data struct {
+			ID uint64 `json:"id"`
+		}
+		if err := json.Unmarshal(janusResp.Data, &data); err == nil && data.ID != 0 {
+			t.startPoll(data.ID)
+		}
+	case request.Janus == "destroy":
+		t.stopPoll(request.SessionID)
+	}
+
+	t.dispatch(&janusResp)
+
+	return nil
+}
+
+// Close menghentikan semua long-poll yang aktif
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for sessionID, stop := range t.polls {
+		close(stop)
+		delete(t.polls, sessionID)
+	}
+
+	return nil
+}
+
+// requestURL menentukan endpoint REST berdasarkan session dan handle
+func (t *HTTPTransport) requestURL(request JanusRequest) string {
+	switch {
+	case request.HandleID != 0:
+		return fmt.Sprintf("%s/%d/%d", t.BaseURL, request.SessionID, request.HandleID)
+	case request.SessionID != 0:
+		return fmt.Sprintf("%s/%d", t.BaseURL, request.SessionID)
+	default:
+		return t.BaseURL
+	}
+}
+
+// dispatch meneruskan pesan ke handler
+func (t *HTTPTransport) dispatch(message *JanusResponse) {
+	t.mu.Lock()
+	handler := t.handler
+	t.mu.Unlock()
+
+	if handler != nil {
+		handler(message)
+	}
+}
+
+// startPoll memulai long-poll untuk session tertentu
+func (t *HTTPTransport) startPoll(sessionID uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.polls[sessionID]; exists {
+		return
+	}
+
+	stop := make(chan struct{})
+	t.polls[sessionID] = stop
+
+	go t.pollLoop(sessionID, stop)
+}
+
+// stopPoll menghentikan long-poll untuk session tertentu
+func (t *HTTPTransport) stopPoll(sessionID uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if stop, exists := t.polls[sessionID]; exists {
+		close(stop)
+		delete(t.polls, sessionID)
+	}
+}
+
+// pollLoop melakukan long-poll berulang sampai session dihentikan
+func (t *HTTPTransport) pollLoop(sessionID uint64, stop chan struct{}) {
+	query := url.Values{}
+	query.Set("maxev", fmt.Sprintf("%d", httpLongPollMaxEvents))
+	if t.APISecret != "" {
+		query.Set("apisecret", t.APISecret)
+	}
+	pollURL := fmt.Sprintf("%s/%d?%s", t.BaseURL, sessionID, query.Encode())
+
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		messages, err := t.poll(pollURL)
+		if err != nil {
+			t.logger.WithError(err).WithField("session_id", sessionID).Warn("Janus long-poll failed")
+
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			// Keepalive dari long-poll hanya menandakan tidak ada event
+			if message.Janus == "keepalive" {
+				continue
+			}
+
+			t.dispatch(message)
+
+			// Session sudah tidak ada di Janus, hentikan long-poll
+			if message.Janus == "error" && message.Error != nil && message.Error.Code == JanusErrorSessionNotFound {
+				t.stopPoll(sessionID)
+				return
+			}
+		}
+	}
+}
+
+// poll melakukan satu kali long-poll dan mengembalikan event yang diterima
+func (t *HTTPTransport) poll(pollURL string) ([]*JanusResponse, error) {
+	resp, err := t.pollClient.Get(pollURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode long-poll response: %w", err)
+	}
+
+	// Dengan maxev > 1 Janus mengembalikan array, error dikembalikan sebagai object
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var messages []*JanusResponse
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return nil, fmt.Errorf("failed to parse long-poll events: %w", err)
+		}
+		return messages, nil
+	}
+
+	var message JanusResponse
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return nil, fmt.Errorf("failed to parse long-poll event: %w", err)
+	}
+
+	return []*JanusResponse{&message}, nil
+}
+
+// WebSocketTransport berkomunikasi dengan Janus melalui WebSocket
+// menggunakan subprotocol janus-protocol
+type WebSocketTransport struct {
+	// URL WebSocket Janus server
+	URL string
+
+	// Dialer WebSocket
+	Dialer *websocket.Dialer
+
+	// Koneksi aktif
+	conn *websocket.Conn
+
+	// Handler untuk pesan masuk
+	handler func(*JanusResponse)
+
+	// Handler ketika koneksi terputus
+	onDisconnect func(error)
+
+	// Penanda transport sudah ditutup
+	closed bool
+
+	// Mutex untuk koneksi dan state
+	mu sync.Mutex
+
+	// Mutex untuk penulisan ke koneksi
+	writeMu sync.Mutex
+
+	// Logger
+	logger *logrus.Logger
+}
+
+// NewWebSocketTransport membuat instance WebSocketTransport baru
+func NewWebSocketTransport(url string) *WebSocketTransport {
+	return &WebSocketTransport{
+		URL: url,
+		Dialer: &websocket.Dialer{
+			HandshakeTimeout: 10 * time.Second,
+			Subprotocols:     []string{janusWebSocketProtocol},
+		},
+		logger: logrus.New(),
+	}
+}
+
+// Start membuka koneksi WebSocket ke Janus dan memulai read loop
+func (t *WebSocketTransport) Start(handler func(*JanusResponse), onDisconnect func(error)) error {
+	t.bind(handler, onDisconnect)
+
+	_, err := t.connection()
+	return err
+}
+
+// bind memasang handler tanpa membuka koneksi. Koneksi dibuka pada Send pertama.
+func (t *WebSocketTransport) bind(handler func(*JanusResponse), onDisconnect func(error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.handler = handler
+	t.onDisconnect = onDisconnect
+	t.closed = false
+}
+
+// Send mengirim request melalui koneksi WebSocket.
+// Jika koneksi terputus, transport akan mencoba menyambung ulang.
+func (t *WebSocketTransport) Send(request JanusRequest) error {
+	conn, err := t.connection()
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.WriteMessage(websocket.TextMessage, jsonData); err != nil {
+		t.dropConnection(conn, err)
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+
+	return nil
+}
+
+// Close menutup koneksi WebSocket
+func (t *WebSocketTransport) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	conn := t.conn
+	t.conn = nil
+	onDisconnect := t.onDisconnect
+	t.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	err := conn.Close()
+	if onDisconnect != nil {
+		onDisconnect(fmt.Errorf("transport is closed"))
+	}
+	return err
+}
+
+// connection mengembalikan koneksi aktif atau membuat koneksi baru
+func (t *WebSocketTransport) connection() (*websocket.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, fmt.Errorf("transport is closed")
+	}
+
+	if t.conn != nil {
+		return t.conn, nil
+	}
+
+	conn, _, err := t.Dialer.Dial(t.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to janus websocket: %w", err)
+	}
+
+	if conn.Subprotocol() != janusWebSocketProtocol {
+		conn.Close()
+		return nil, fmt.Errorf("janus did not accept subprotocol %s", janusWebSocketProtocol)
+	}
+
+	t.conn = conn
+	go t.readLoop(conn)
+
+	t.logger.WithField("url", t.URL).Info("Connected to Janus WebSocket")
+
+	return conn, nil
+}
+
+// dropConnection melepas koneksi yang rusak agar dibuat ulang saat Send berikutnya,
+// lalu memberi tahu JanusClient bahwa balasan yang ditunggu lewat koneksi ini hilang
+func (t *WebSocketTransport) dropConnection(conn *websocket.Conn, cause error) {
+	t.mu.Lock()
+	if t.conn != conn {
+		t.mu.Unlock()
+		return
+	}
+	t.conn.Close()
+	t.conn = nil
+	onDisconnect := t.onDisconnect
+	t.mu.Unlock()
+
+	if onDisconnect != nil {
+		onDisconnect(cause)
+	}
+}
+
+// readLoop membaca pesan dari Janus dan meneruskannya ke handler
+func (t *WebSocketTransport) readLoop(conn *websocket.Conn) {
+	for {
+		_, messageBytes, err := conn.ReadMessage()
+		if err != nil {
+			t.mu.Lock()
+			closed := t.closed
+			t.mu.Unlock()
+
+			if !closed {
+				t.logger.WithError(err).Warn("Janus WebSocket connection closed")
+			}
+			t.dropConnection(conn, err)
+			return
+		}
+
+		var message JanusResponse
+		if err := json.Unmarshal(messageBytes, &message); err != nil {
+			t.logger.WithError(err).Error("Failed to parse Janus message")
+			continue
+		}
+
+		t.mu.Lock()
+		handler := t.handler
+		t.mu.Unlock()
+
+		if handler != nil {
+			handler(&message)
+		}
+	}
+}
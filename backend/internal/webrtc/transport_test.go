@@ -0,0 +1,104 @@
+package webrtc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/webrtc/janustest"
+)
+
+// newWebSocketTestClient membuat JanusClient dengan transport WebSocket ke fake server
+func newWebSocketTestClient(t *testing.T, server *janustest.Server) *JanusClient {
+	t.Helper()
+
+	client, err := NewJanusClientWithTransport(NewWebSocketTransport(server.WSURL), "", "", "")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if _, err := client.CreateSession(); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	return client
+}
+
+// waitFailed menunggu hasil request yang sedang berjalan dan memastikan request
+// gagal karena koneksi terputus jauh sebelum janusRequestTimeout
+func waitFailed(t *testing.T, result chan error) {
+	t.Helper()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrJanusDisconnected) {
+			t.Fatalf("expected ErrJanusDisconnected, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending request was not failed after the connection dropped")
+	}
+}
+
+func TestWebSocketTransportFailsPendingRequestsOnServerRestart(t *testing.T) {
+	server := janustest.NewServer()
+	defer server.Close()
+
+	client := newWebSocketTestClient(t, server)
+
+	// Keepalive tidak pernah dibalas sehingga request menunggu sampai koneksi putus
+	server.InjectFault(janustest.Fault{Janus: "keepalive", Drop: true})
+
+	result := make(chan error, 1)
+	go func() { result <- client.Keepalive() }()
+
+	// Pastikan keepalive sudah diterima server sebelum koneksi diputus
+	deadline := time.Now().Add(2 * time.Second)
+	for countRequests(server, "keepalive") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("keepalive was not received by the server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	server.Restart()
+	waitFailed(t, result)
+
+	// Request berikutnya menyambung ulang koneksi
+	if _, err := client.CreateSession(); err != nil {
+		t.Fatalf("failed to create session after reconnect: %v", err)
+	}
+}
+
+func TestWebSocketTransportFailsPendingRequestsOnClose(t *testing.T) {
+	server := janustest.NewServer()
+	defer server.Close()
+
+	client := newWebSocketTestClient(t, server)
+	server.InjectFault(janustest.Fault{Janus: "keepalive", Drop: true})
+
+	result := make(chan error, 1)
+	go func() { result <- client.Keepalive() }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for countRequests(server, "keepalive") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("keepalive was not received by the server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client.Close()
+	waitFailed(t, result)
+}
+
+// countRequests menghitung request core dengan tipe tertentu yang diterima server
+func countRequests(server *janustest.Server, janus string) int {
+	count := 0
+	for _, request := range server.Requests() {
+		if request.Janus == janus {
+			count++
+		}
+	}
+	return count
+}
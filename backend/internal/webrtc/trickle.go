@@ -0,0 +1,77 @@
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ICECandidate adalah ICE candidate yang dikirim atau diterima melalui trickle
+type ICECandidate struct {
+	Candidate     string `json:"candidate,omitempty"`
+	SDPMid        string `json:"sdpMid,omitempty"`
+	SDPMLineIndex int    `json:"sdpMLineIndex"`
+
+	// Completed bernilai true jika ini adalah penanda akhir gathering
+	Completed bool `json:"completed,omitempty"`
+}
+
+// iceCompletedMarker adalah penanda bahwa tidak ada candidate lagi
+type iceCompletedMarker struct {
+	Completed bool `json:"completed"`
+}
+
+// Trickle mengirim satu ICE candidate ke Janus untuk handle ini
+func (ph *PluginHandle) Trickle(candidate ICECandidate) error {
+	if candidate.Completed {
+		return ph.TrickleCompleted()
+	}
+
+	request := JanusRequest{
+		Janus:     "trickle",
+		Candidate: candidate,
+	}
+
+	if _, err := ph.Client.sessionRequest(ph, request, false); err != nil {
+		return fmt.Errorf("failed to trickle candidate: %w", err)
+	}
+
+	return nil
+}
+
+// TrickleCandidates mengirim beberapa ICE candidate sekaligus dalam satu request
+func (ph *PluginHandle) TrickleCandidates(candidates []ICECandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	request := JanusRequest{
+		Janus:      "trickle",
+		Candidates: candidates,
+	}
+
+	if _, err := ph.Client.sessionRequest(ph, request, false); err != nil {
+		return fmt.Errorf("failed to trickle candidates: %w", err)
+	}
+
+	ph.Client.logger.WithFields(logrus.Fields{
+		"handle_id": ph.ID(),
+		"count":     len(candidates),
+	}).Debug("Trickled ICE candidates")
+
+	return nil
+}
+
+// TrickleCompleted memberi tahu Janus bahwa gathering candidate sudah selesai
+func (ph *PluginHandle) TrickleCompleted() error {
+	request := JanusRequest{
+		Janus:     "trickle",
+		Candidate: iceCompletedMarker{Completed: true},
+	}
+
+	if _, err := ph.Client.sessionRequest(ph, request, false); err != nil {
+		return fmt.Errorf("failed to trickle completed: %w", err)
+	}
+
+	return nil
+}
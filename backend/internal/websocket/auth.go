@@ -0,0 +1,188 @@
+package websocket
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// AccessTokenProtocol adalah nama subprotocol penanda access token pada header
+// Sec-WebSocket-Protocol. Browser tidak dapat mengirim header Authorization saat
+// membuka WebSocket, sehingga token dikirim sebagai subprotocol berikutnya:
+// new WebSocket(url, ["access_token", token]).
+const AccessTokenProtocol = "access_token"
+
+// AccessTokenCookie adalah nama cookie yang dapat membawa access token
+const AccessTokenCookie = "access_token"
+
+// TokenValidator memvalidasi access token dengan aturan yang sama dengan API server:
+// signature JWT, user aktif dan UserSession yang masih berlaku. expiresAt adalah
+// waktu token berhenti berlaku.
+type TokenValidator interface {
+	ValidateSession(token string) (user *models.User, expiresAt time.Time, err error)
+}
+
+// authenticate menentukan user koneksi WebSocket dari access token. Jika
+// allowUserID diizinkan dan tidak ada token, user ID diambil dari query userId
+// atau header X-User-ID tanpa validasi. fromCookie bernilai true jika token berasal
+// dari cookie. Response error sudah ditulis ketika ok false.
+func (h *Handler) authenticate(c *gin.Context, allowUserID bool) (userID string, expiresAt time.Time, responseHeader http.Header, fromCookie, ok bool) {
+	token, source := accessToken(c)
+
+	if token == "" {
+		if allowUserID {
+			userID = c.Query("userId")
+			if userID == "" {
+				userID = c.GetHeader("X-User-ID")
+			}
+			if userID != "" {
+				return userID, time.Time{}, nil, false, true
+			}
+		}
+
+		logrus.Error("Authorization token is required")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+		return "", time.Time{}, nil, false, false
+	}
+
+	if h.Auth == nil {
+		logrus.Error("Token validation is not configured")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token authentication is not available"})
+		return "", time.Time{}, nil, false, false
+	}
+
+	user, expiresAt, err := h.Auth.ValidateSession(token)
+	if err != nil {
+		logrus.WithField("remoteAddr", c.Request.RemoteAddr).Warnf("Rejected WebSocket connection: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return "", time.Time{}, nil, false, false
+	}
+
+	// Browser menolak koneksi jika server tidak memilih salah satu subprotocol
+	if source == tokenFromProtocol {
+		responseHeader = http.Header{"Sec-Websocket-Protocol": {AccessTokenProtocol}}
+	}
+
+	return user.ID.String(), expiresAt, responseHeader, source == tokenFromCookie, true
+}
+
+// tokenSource adalah asal access token pada request WebSocket
+type tokenSource int
+
+const (
+	tokenFromQuery tokenSource = iota
+	tokenFromHeader
+	tokenFromProtocol
+	tokenFromCookie
+)
+
+// accessToken mengambil access token dari query token, header Authorization,
+// header Sec-WebSocket-Protocol atau cookie, sesuai urutan tersebut
+func accessToken(c *gin.Context) (token string, source tokenSource) {
+	if token := c.Query("token"); token != "" {
+		return token, tokenFromQuery
+	}
+
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer "), tokenFromHeader
+	}
+
+	protocols := websocket.Subprotocols(c.Request)
+	for i, protocol := range protocols {
+		if protocol == AccessTokenProtocol && i+1 < len(protocols) {
+			return protocols[i+1], tokenFromProtocol
+		}
+	}
+
+	if cookie, err := c.Cookie(AccessTokenCookie); err == nil && cookie != "" {
+		return cookie, tokenFromCookie
+	}
+
+	return "", tokenFromQuery
+}
+
+// upgrader membuat Upgrader yang memeriksa origin request dengan checkOrigin
+func (h *Handler) upgrader(fromCookie bool) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return h.checkOrigin(r, fromCookie)
+		},
+	}
+}
+
+// checkOrigin menentukan apakah origin request boleh membuka WebSocket. Request
+// tanpa header Origin (bukan dari browser) dan origin di AllowedOrigins selalu
+// diterima, begitu juga origin yang sama dengan host server. Origin lain hanya
+// diterima jika AllowedOrigins kosong dan token tidak berasal dari cookie: browser
+// mengirim cookie ke WebSocket lintas situs, sehingga tanpa pemeriksaan ini halaman
+// lain dapat membuka koneksi atas nama user (cross-site WebSocket hijacking).
+func (h *Handler) checkOrigin(r *http.Request, fromCookie bool) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range h.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	return len(h.AllowedOrigins) == 0 && !fromCookie
+}
+
+// handleAuthenticate menangani pesan authenticate, yaitu pembaruan access token
+// tanpa membuka koneksi baru. Token baru harus milik user yang sama, dan koneksi
+// ditutup pada waktu kedaluwarsa token baru.
+func (c *Client) handleAuthenticate(message Message) {
+	var data AuthenticateData
+	if err := mapToStruct(message.Data, &data); err != nil || data.Token == "" {
+		c.SendErrorMessage("Invalid authenticate data")
+		return
+	}
+
+	if c.validator == nil {
+		c.sendError(http.StatusUnauthorized, "Token authentication is not available")
+		return
+	}
+
+	user, expiresAt, err := c.validator.ValidateSession(data.Token)
+	if err != nil {
+		c.sendError(http.StatusUnauthorized, "Invalid or expired token")
+		return
+	}
+
+	if user.ID.String() != c.UserID {
+		c.sendError(http.StatusUnauthorized, "Token belongs to another user")
+		return
+	}
+
+	c.reauthenticate(expiresAt)
+
+	logrus.WithFields(logrus.Fields{
+		"userId":    c.UserID,
+		"expiresAt": expiresAt,
+	}).Info("WebSocket connection re-authenticated")
+
+	authenticatedMsg := Message{
+		Type:      MessageTypeAuthenticated,
+		UserID:    c.UserID,
+		Data:      AuthenticatedData{ExpiresAt: expiresAt},
+		Timestamp: time.Now(),
+	}
+
+	c.queue(authenticatedMsg)
+}
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// newAuthServer menjalankan server WebSocket dengan satu token sah milik user
+func newAuthServer(t *testing.T, configure func(*Handler)) (server string, userID uuid.UUID, dialer func(path string, header http.Header) (*testConn, int)) {
+	t.Helper()
+
+	userID = uuid.New()
+	validator := &fakeValidator{users: map[string]uuid.UUID{"valid-token": userID}}
+	testServer := newTestServer(t, NewHub(), func(h *Handler) {
+		h.Auth = validator
+		if configure != nil {
+			configure(h)
+		}
+	})
+
+	return testServer.URL, userID, func(path string, header http.Header) (*testConn, int) {
+		conn, response, err := dial(testServer, path, header)
+		status := 0
+		if response != nil {
+			status = response.StatusCode
+		}
+		if err != nil {
+			return nil, status
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn, status
+	}
+}
+
+func TestWebSocketRejectsInvalidToken(t *testing.T) {
+	_, _, dialer := newAuthServer(t, nil)
+
+	tests := map[string]struct {
+		path   string
+		header http.Header
+	}{
+		"missing token":       {path: "/ws"},
+		"user id without dev": {path: "/ws?userId=" + uuid.NewString()},
+		"expired query token": {path: "/ws?token=expired-token"},
+		"invalid bearer":      {path: "/ws", header: http.Header{"Authorization": {"Bearer invalid-token"}}},
+		"invalid subprotocol": {path: "/ws", header: http.Header{"Sec-Websocket-Protocol": {AccessTokenProtocol + ", invalid-token"}}},
+		"invalid cookie":      {path: "/ws", header: http.Header{"Cookie": {AccessTokenCookie + "=invalid-token"}}},
+		"auth route user id":  {path: "/ws/auth?userId=" + uuid.NewString()},
+	}
+	for name, test := range tests {
+		if conn, status := dialer(test.path, test.header); conn != nil || status != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got status %d", name, status)
+		}
+	}
+}
+
+func TestWebSocketAcceptsValidToken(t *testing.T) {
+	_, userID, dialer := newAuthServer(t, nil)
+
+	tests := map[string]struct {
+		path   string
+		header http.Header
+	}{
+		"query":       {path: "/ws?token=valid-token"},
+		"bearer":      {path: "/ws/auth", header: http.Header{"Authorization": {"Bearer valid-token"}}},
+		"subprotocol": {path: "/ws", header: http.Header{"Sec-Websocket-Protocol": {AccessTokenProtocol + ", valid-token"}}},
+	}
+	for name, test := range tests {
+		conn, status := dialer(test.path, test.header)
+		if conn == nil {
+			t.Errorf("%s: connection rejected with status %d", name, status)
+			continue
+		}
+
+		if name == "subprotocol" && conn.Subprotocol() != AccessTokenProtocol {
+			t.Errorf("%s: server selected subprotocol %q", name, conn.Subprotocol())
+		}
+		if session := conn.expect(t, MessageTypeSession); session.UserID != userID.String() {
+			t.Errorf("%s: session for user %s, expected %s", name, session.UserID, userID)
+		}
+	}
+}
+
+func TestWebSocketCookieTokenRequiresAllowedOrigin(t *testing.T) {
+	cookie := AccessTokenCookie + "=valid-token"
+
+	// Tanpa allowlist, token cookie hanya diterima dari origin yang sama
+	url, _, dialer := newAuthServer(t, nil)
+	if conn, status := dialer("/ws", http.Header{"Cookie": {cookie}, "Origin": {"https://evil.example"}}); conn != nil || status != http.StatusForbidden {
+		t.Fatalf("cross-site cookie connection: expected 403, got %d", status)
+	}
+	if conn, status := dialer("/ws", http.Header{"Cookie": {cookie}, "Origin": {url}}); conn == nil {
+		t.Fatalf("same origin cookie connection rejected with status %d", status)
+	}
+	if conn, status := dialer("/ws", http.Header{"Cookie": {cookie}}); conn == nil {
+		t.Fatalf("cookie connection without origin rejected with status %d", status)
+	}
+
+	// Token yang dikirim eksplisit tidak dapat dipakai halaman lain tanpa mengetahuinya
+	if conn, status := dialer("/ws?token=valid-token", http.Header{"Origin": {"https://evil.example"}}); conn == nil {
+		t.Fatalf("explicit token from another origin rejected with status %d", status)
+	}
+}
+
+func TestWebSocketEnforcesAllowedOrigins(t *testing.T) {
+	_, _, dialer := newAuthServer(t, func(h *Handler) {
+		h.AllowedOrigins = []string{"https://meet.example.com/"}
+	})
+
+	if conn, status := dialer("/ws", http.Header{"Cookie": {AccessTokenCookie + "=valid-token"}, "Origin": {"https://meet.example.com"}}); conn == nil {
+		t.Fatalf("allowed origin rejected with status %d", status)
+	}
+	if conn, status := dialer("/ws?token=valid-token", http.Header{"Origin": {"https://MEET.example.com"}}); conn == nil {
+		t.Fatalf("allowed origin with different case rejected with status %d", status)
+	}
+
+	for _, origin := range []string{"https://evil.example", "https://meet.example.com.evil.example", "http://meet.example.com"} {
+		if conn, status := dialer("/ws?token=valid-token", http.Header{"Origin": {origin}}); conn != nil || status != http.StatusForbidden {
+			t.Errorf("origin %s: expected 403, got %d", origin, status)
+		}
+	}
+}
+
+func TestAuthenticateMessageRejectsInvalidToken(t *testing.T) {
+	otherUser := uuid.New()
+	userID := uuid.New()
+	validator := &fakeValidator{users: map[string]uuid.UUID{"valid-token": userID, "other-token": otherUser}}
+	server := newTestServer(t, NewHub(), func(h *Handler) { h.Auth = validator })
+
+	conn := mustDial(t, server, "/ws?token=valid-token", nil)
+	conn.expect(t, MessageTypeSession)
+
+	for token, expected := range map[string]string{
+		"expired-token": "Invalid or expired token",
+		"other-token":   "Token belongs to another user",
+	} {
+		conn.send(t, Message{Type: MessageTypeAuthenticate, Data: AuthenticateData{Token: token}})
+
+		var data ErrorData
+		decode(t, conn.expect(t, MessageTypeError).Data, &data)
+		if data.Code != http.StatusUnauthorized || data.Message != expected {
+			t.Errorf("%s: unexpected error %+v", token, data)
+		}
+	}
+
+	// Koneksi tetap terbuka dan token yang sah diterima
+	conn.send(t, Message{Type: MessageTypeAuthenticate, Data: AuthenticateData{Token: "valid-token"}})
+	conn.expect(t, MessageTypeAuthenticated)
+}
@@ -0,0 +1,217 @@
+package websocket
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EnvelopeKind menentukan cara node penerima mengirimkan pesan dalam Envelope
+type EnvelopeKind string
+
+const (
+	// EnvelopeRoom dikirim ke semua client dalam room kecuali ExcludeUserID
+	EnvelopeRoom EnvelopeKind = "room"
+	// EnvelopeUser dikirim ke koneksi milik UserID (atau perangkat DeviceID saja)
+	EnvelopeUser EnvelopeKind = "user"
+	// EnvelopeBroadcast dikirim ke semua client yang terhubung
+	EnvelopeBroadcast EnvelopeKind = "broadcast"
+	// EnvelopeCallTransfer meminta node yang memegang panggilan UserID di RoomID
+	// melepasnya karena panggilan dipindahkan ke perangkat DeviceID
+	EnvelopeCallTransfer EnvelopeKind = "call-transfer"
+)
+
+// Envelope adalah pesan hub yang disebarkan ke node websocket server lain
+type Envelope struct {
+	NodeID        string       `json:"nodeId"`
+	Kind          EnvelopeKind `json:"kind"`
+	RoomID        string       `json:"roomId,omitempty"`
+	UserID        string       `json:"userId,omitempty"`
+	DeviceID      string       `json:"deviceId,omitempty"`
+	ExcludeUserID string       `json:"excludeUserId,omitempty"`
+	Message       Message      `json:"message"`
+}
+
+// Backplane menyebarkan pesan hub antar node websocket server dan menyimpan
+// keanggotaan room seluruh cluster. Keanggotaan dicatat per node sehingga entri
+// milik node yang mati dapat diabaikan setelah heartbeat-nya berhenti.
+type Backplane interface {
+	// Publish mengirim envelope ke semua node, termasuk node pengirim
+	Publish(envelope Envelope) error
+
+	// Subscribe mengembalikan channel envelope yang dipublish semua node
+	Subscribe() (<-chan Envelope, error)
+
+	// AddRoomMember mencatat user berada di room melalui node tertentu
+	AddRoomMember(nodeID, roomID, userID string) error
+
+	// RemoveRoomMember menghapus catatan user di room untuk node tertentu
+	RemoveRoomMember(nodeID, roomID, userID string) error
+
+	// RoomMembers mengembalikan user ID dalam room di seluruh node yang masih hidup
+	RoomMembers(roomID string) ([]string, error)
+
+	// UserRooms mengembalikan room ID yang dijoin user di seluruh node yang masih hidup
+	UserRooms(userID string) ([]string, error)
+
+	// Heartbeat menandai node masih hidup selama ttl
+	Heartbeat(nodeID string, ttl time.Duration) error
+
+	// RemoveNode menghapus semua keanggotaan room milik node, dipanggil saat shutdown
+	RemoveNode(nodeID string) error
+
+	// Close menutup koneksi backplane dan semua channel Subscribe
+	Close() error
+}
+
+// Ukuran buffer channel Subscribe
+const backplaneBufferSize = 256
+
+// MemoryBackplane adalah Backplane di dalam satu proses. Cocok untuk satu node atau
+// beberapa Hub dalam proses yang sama.
+type MemoryBackplane struct {
+	mu          sync.RWMutex
+	subscribers []chan Envelope
+
+	// members memetakan room ID ke user ID ke node ID
+	members map[string]map[string]map[string]bool
+}
+
+// NewMemoryBackplane membuat instance MemoryBackplane baru
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{
+		members: make(map[string]map[string]map[string]bool),
+	}
+}
+
+// Publish mengirim envelope ke semua subscriber. Subscriber yang buffer-nya penuh
+// dilewati agar hub pengirim tidak tertahan.
+func (b *MemoryBackplane) Publish(envelope Envelope) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscriber := range b.subscribers {
+		select {
+		case subscriber <- envelope:
+		default:
+			logrus.WithField("kind", envelope.Kind).Warn("Backplane subscriber is full, dropping envelope")
+		}
+	}
+
+	return nil
+}
+
+// Subscribe mendaftarkan subscriber baru
+func (b *MemoryBackplane) Subscribe() (<-chan Envelope, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscriber := make(chan Envelope, backplaneBufferSize)
+	b.subscribers = append(b.subscribers, subscriber)
+	return subscriber, nil
+}
+
+// AddRoomMember mencatat user berada di room melalui node tertentu
+func (b *MemoryBackplane) AddRoomMember(nodeID, roomID, userID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.members[roomID]; !exists {
+		b.members[roomID] = make(map[string]map[string]bool)
+	}
+	if _, exists := b.members[roomID][userID]; !exists {
+		b.members[roomID][userID] = make(map[string]bool)
+	}
+	b.members[roomID][userID][nodeID] = true
+
+	return nil
+}
+
+// RemoveRoomMember menghapus catatan user di room untuk node tertentu
+func (b *MemoryBackplane) RemoveRoomMember(nodeID, roomID, userID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeMember(nodeID, roomID, userID)
+	return nil
+}
+
+// RoomMembers mengembalikan user ID dalam room
+func (b *MemoryBackplane) RoomMembers(roomID string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	users := make([]string, 0, len(b.members[roomID]))
+	for userID := range b.members[roomID] {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+
+	return users, nil
+}
+
+// UserRooms mengembalikan room ID yang dijoin user
+func (b *MemoryBackplane) UserRooms(userID string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var rooms []string
+	for roomID, users := range b.members {
+		if _, exists := users[userID]; exists {
+			rooms = append(rooms, roomID)
+		}
+	}
+	sort.Strings(rooms)
+
+	return rooms, nil
+}
+
+// Heartbeat tidak diperlukan di dalam satu proses
+func (b *MemoryBackplane) Heartbeat(nodeID string, ttl time.Duration) error {
+	return nil
+}
+
+// RemoveNode menghapus semua keanggotaan room milik node
+func (b *MemoryBackplane) RemoveNode(nodeID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for roomID, users := range b.members {
+		for userID := range users {
+			b.removeMember(nodeID, roomID, userID)
+		}
+	}
+
+	return nil
+}
+
+// Close menutup semua channel subscriber
+func (b *MemoryBackplane) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscriber := range b.subscribers {
+		close(subscriber)
+	}
+	b.subscribers = nil
+
+	return nil
+}
+
+// removeMember menghapus satu catatan keanggotaan. Dipanggil saat b.mu dipegang.
+func (b *MemoryBackplane) removeMember(nodeID, roomID, userID string) {
+	nodes, exists := b.members[roomID][userID]
+	if !exists {
+		return
+	}
+
+	delete(nodes, nodeID)
+	if len(nodes) == 0 {
+		delete(b.members[roomID], userID)
+	}
+	if len(b.members[roomID]) == 0 {
+		delete(b.members, roomID)
+	}
+}
@@ -0,0 +1,288 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// Batas waktu setiap operasi Redis backplane. Hub memanggil backplane dari loop
+// utamanya, jadi Redis yang tidak responsif tidak boleh menahan hub terlalu lama.
+const redisBackplaneTimeout = 2 * time.Second
+
+// Pemisah bagian field registry; ID node, room dan user tidak mengandung NUL
+const redisFieldSeparator = "\x00"
+
+// RedisBackplane adalah Backplane berbasis Redis pub/sub. Keanggotaan room disimpan
+// di hash per room dan per user dengan field "<node>\x00<id>", sehingga entri node
+// yang heartbeat-nya sudah kedaluwarsa dapat disaring dan dibersihkan saat dibaca.
+type RedisBackplane struct {
+	client  *redis.Client
+	prefix  string
+	channel string
+
+	mu      sync.Mutex
+	pubsubs []*redis.PubSub
+}
+
+// NewRedisBackplane membuat instance RedisBackplane baru. prefix dipakai untuk
+// semua key dan channel Redis (kosong = "ws").
+func NewRedisBackplane(client *redis.Client, prefix string) *RedisBackplane {
+	if prefix == "" {
+		prefix = "ws"
+	}
+
+	return &RedisBackplane{
+		client:  client,
+		prefix:  prefix,
+		channel: prefix + ":backplane",
+	}
+}
+
+// Publish mengirim envelope ke channel backplane
+func (b *RedisBackplane) Publish(envelope Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisBackplaneTimeout)
+	defer cancel()
+
+	if err := b.client.Publish(ctx, b.channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish envelope: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe berlangganan channel backplane. Koneksi pub/sub disambung ulang
+// otomatis oleh client Redis.
+func (b *RedisBackplane) Subscribe() (<-chan Envelope, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisBackplaneTimeout)
+	defer cancel()
+
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to backplane: %w", err)
+	}
+
+	b.mu.Lock()
+	b.pubsubs = append(b.pubsubs, pubsub)
+	b.mu.Unlock()
+
+	envelopes := make(chan Envelope, backplaneBufferSize)
+	go func() {
+		defer close(envelopes)
+
+		for message := range pubsub.Channel() {
+			var envelope Envelope
+			if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+				logrus.Errorf("Failed to parse backplane envelope: %v", err)
+				continue
+			}
+			envelopes <- envelope
+		}
+	}()
+
+	return envelopes, nil
+}
+
+// AddRoomMember mencatat user berada di room melalui node tertentu
+func (b *RedisBackplane) AddRoomMember(nodeID, roomID, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisBackplaneTimeout)
+	defer cancel()
+
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, b.roomKey(roomID), joinField(nodeID, userID), 1)
+		pipe.HSet(ctx, b.userKey(userID), joinField(nodeID, roomID), 1)
+		pipe.SAdd(ctx, b.nodeMembersKey(nodeID), joinField(roomID, userID))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add room member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveRoomMember menghapus catatan user di room untuk node tertentu
+func (b *RedisBackplane) RemoveRoomMember(nodeID, roomID, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisBackplaneTimeout)
+	defer cancel()
+
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, b.roomKey(roomID), joinField(nodeID, userID))
+		pipe.HDel(ctx, b.userKey(userID), joinField(nodeID, roomID))
+		pipe.SRem(ctx, b.nodeMembersKey(nodeID), joinField(roomID, userID))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove room member: %w", err)
+	}
+
+	return nil
+}
+
+// RoomMembers mengembalikan user ID dalam room di seluruh node yang masih hidup
+func (b *RedisBackplane) RoomMembers(roomID string) ([]string, error) {
+	users, err := b.liveMembers(b.roomKey(roomID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room members: %w", err)
+	}
+	return users, nil
+}
+
+// UserRooms mengembalikan room ID yang dijoin user di seluruh node yang masih hidup
+func (b *RedisBackplane) UserRooms(userID string) ([]string, error) {
+	rooms, err := b.liveMembers(b.userKey(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user rooms: %w", err)
+	}
+	return rooms, nil
+}
+
+// Heartbeat menandai node masih hidup selama ttl
+func (b *RedisBackplane) Heartbeat(nodeID string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisBackplaneTimeout)
+	defer cancel()
+
+	if err := b.client.Set(ctx, b.nodeKey(nodeID), time.Now().Unix(), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to send node heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveNode menghapus semua keanggotaan room milik node beserta heartbeat-nya
+func (b *RedisBackplane) RemoveNode(nodeID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisBackplaneTimeout)
+	defer cancel()
+
+	members, err := b.client.SMembers(ctx, b.nodeMembersKey(nodeID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get node members: %w", err)
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, member := range members {
+			roomID, userID, ok := splitField(member)
+			if !ok {
+				continue
+			}
+			pipe.HDel(ctx, b.roomKey(roomID), joinField(nodeID, userID))
+			pipe.HDel(ctx, b.userKey(userID), joinField(nodeID, roomID))
+		}
+		pipe.Del(ctx, b.nodeMembersKey(nodeID), b.nodeKey(nodeID))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove node: %w", err)
+	}
+
+	return nil
+}
+
+// Close menutup semua langganan dan koneksi Redis
+func (b *RedisBackplane) Close() error {
+	b.mu.Lock()
+	for _, pubsub := range b.pubsubs {
+		pubsub.Close()
+	}
+	b.pubsubs = nil
+	b.mu.Unlock()
+
+	return b.client.Close()
+}
+
+// liveMembers membaca hash registry dan mengembalikan ID unik milik node yang masih
+// hidup. Field milik node yang sudah mati dihapus.
+func (b *RedisBackplane) liveMembers(key string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisBackplaneTimeout)
+	defer cancel()
+
+	fields, err := b.client.HKeys(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// Periksa heartbeat setiap node yang muncul di registry
+	alive := make(map[string]*redis.IntCmd)
+	pipe := b.client.Pipeline()
+	for _, field := range fields {
+		nodeID, _, ok := splitField(field)
+		if !ok {
+			continue
+		}
+		if _, exists := alive[nodeID]; !exists {
+			alive[nodeID] = pipe.Exists(ctx, b.nodeKey(nodeID))
+		}
+	}
+	if len(alive) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	var stale []string
+	for _, field := range fields {
+		nodeID, id, ok := splitField(field)
+		if !ok || alive[nodeID].Val() == 0 {
+			stale = append(stale, field)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(stale) > 0 {
+		if err := b.client.HDel(ctx, key, stale...).Err(); err != nil {
+			logrus.Warnf("Failed to remove stale backplane members: %v", err)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// roomKey adalah key hash anggota room
+func (b *RedisBackplane) roomKey(roomID string) string {
+	return b.prefix + ":room:" + roomID + ":members"
+}
+
+// userKey adalah key hash room milik user
+func (b *RedisBackplane) userKey(userID string) string {
+	return b.prefix + ":user:" + userID + ":rooms"
+}
+
+// nodeKey adalah key heartbeat node
+func (b *RedisBackplane) nodeKey(nodeID string) string {
+	return b.prefix + ":node:" + nodeID
+}
+
+// nodeMembersKey adalah key set keanggotaan yang dicatat node
+func (b *RedisBackplane) nodeMembersKey(nodeID string) string {
+	return b.prefix + ":node:" + nodeID + ":members"
+}
+
+// joinField menggabungkan dua ID menjadi satu field registry
+func joinField(first, second string) string {
+	return first + redisFieldSeparator + second
+}
+
+// splitField memecah field registry menjadi dua ID
+func splitField(field string) (first, second string, ok bool) {
+	return strings.Cut(field, redisFieldSeparator)
+}
@@ -0,0 +1,112 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newClusterNode menjalankan hub dan server WebSocket sebagai satu node cluster
+// yang memakai backplane bersama
+func newClusterNode(t *testing.T, backplane Backplane, nodeID string, maxUsers int) *httptest.Server {
+	t.Helper()
+
+	hub := NewHub()
+	hub.AuthorizeJoin = allowJoin(maxUsers)
+	if err := hub.SetBackplane(backplane, nodeID); err != nil {
+		t.Fatalf("failed to join cluster: %v", err)
+	}
+
+	return newTestServer(t, hub, func(h *Handler) { h.AllowUserID = true })
+}
+
+// postJSON mengirim request admin ke server dan mengembalikan status HTTP-nya
+func postJSON(t *testing.T, server *httptest.Server, path, body string) int {
+	t.Helper()
+
+	response, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request to %s failed: %v", path, err)
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+func TestMemoryBackplaneDeliversAcrossHubs(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	nodeA := newClusterNode(t, backplane, "node-a", 2)
+	nodeB := newClusterNode(t, backplane, "node-b", 2)
+
+	alice := connectUser(t, nodeA, "alice", "phone")
+	if joined := alice.joinRoom(t, "room-1"); joined.Type != MessageTypeRoomJoined {
+		t.Fatalf("alice rejected: %+v", joined)
+	}
+
+	// Daftar user room mencakup user yang terhubung ke node lain
+	bob := connectUser(t, nodeB, "bob", "laptop")
+	joined := bob.joinRoom(t, "room-1")
+	if joined.Type != MessageTypeRoomJoined {
+		t.Fatalf("bob rejected: %+v", joined)
+	}
+	var data RoomJoinedData
+	decode(t, joined.Data, &data)
+	if strings.Join(data.Users, ",") != "alice,bob" {
+		t.Fatalf("room users across nodes: %v", data.Users)
+	}
+
+	if message := alice.expect(t, MessageTypeUserJoined); message.UserID != "bob" {
+		t.Fatalf("alice was told about %s instead of bob", message.UserID)
+	}
+
+	// Batas jumlah user room berlaku untuk seluruh cluster
+	expectJoinError(t, connectUser(t, nodeB, "carol", "phone").joinRoom(t, "room-1"), http.StatusForbidden, "Room is full")
+
+	// Pesan ke user yang terhubung ke node lain diteruskan lewat backplane
+	if status := postJSON(t, nodeA, "/api/v1/websocket/admin/users/bob/message", `{"type":"notice","data":{"text":"hello bob"}}`); status != http.StatusOK {
+		t.Fatalf("direct message to remote user returned %d", status)
+	}
+	if message := bob.expect(t, "notice"); message.UserID != "bob" {
+		t.Fatalf("unexpected direct message: %+v", message)
+	}
+
+	// Broadcast admin sampai ke client di semua node
+	if status := postJSON(t, nodeB, "/api/v1/websocket/admin/broadcast", `{"type":"maintenance","data":{}}`); status != http.StatusOK {
+		t.Fatalf("broadcast returned %d", status)
+	}
+	alice.expect(t, "maintenance")
+	bob.expect(t, "maintenance")
+
+	// Keluar dari room diumumkan ke node lain dan dihapus dari registry cluster
+	bob.send(t, Message{Type: MessageTypeLeaveRoom, Data: LeaveRoomData{RoomID: "room-1"}})
+	bob.expect(t, MessageTypeRoomLeft)
+	if message := alice.expect(t, MessageTypeUserLeft); message.UserID != "bob" {
+		t.Fatalf("alice was told %s left instead of bob", message.UserID)
+	}
+
+	if members, _ := backplane.RoomMembers("room-1"); strings.Join(members, ",") != "alice" {
+		t.Fatalf("cluster room members after leave: %v", members)
+	}
+}
+
+func TestMemoryBackplaneRemoveNode(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	backplane.AddRoomMember("node-a", "room-1", "alice")
+	backplane.AddRoomMember("node-b", "room-1", "alice")
+	backplane.AddRoomMember("node-b", "room-2", "bob")
+
+	// User yang masih terhubung lewat node lain tetap tercatat
+	if err := backplane.RemoveNode("node-b"); err != nil {
+		t.Fatalf("failed to remove node: %v", err)
+	}
+
+	if members, _ := backplane.RoomMembers("room-1"); strings.Join(members, ",") != "alice" {
+		t.Fatalf("room-1 members: %v", members)
+	}
+	if members, _ := backplane.RoomMembers("room-2"); len(members) != 0 {
+		t.Fatalf("room-2 members of removed node: %v", members)
+	}
+	if rooms, _ := backplane.UserRooms("bob"); len(rooms) != 0 {
+		t.Fatalf("bob rooms after node removal: %v", rooms)
+	}
+}
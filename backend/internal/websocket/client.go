@@ -2,10 +2,14 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
 )
 
 const (
@@ -18,8 +22,8 @@ const (
 	// Send pings to peer with this period. Must be less than pongWait.
 	pingPeriod = (pongWait * 9) / 10
 
-	// Maximum message size allowed from peer.
-	maxMessageSize = 512
+	// Maximum message size allowed from peer (cukup untuk SDP offer dan access token).
+	maxMessageSize = 64 * 1024
 )
 
 var (
@@ -27,22 +31,31 @@ var (
 	space   = []byte{' '}
 )
 
-// ReadPump membaca pesan dari koneksi WebSocket
+// ReadPump membaca pesan dari koneksi WebSocket yang sedang dipakai session.
+// Ketika koneksi putus, session dilepas dari koneksi dan hub diberi tahu.
 func (c *Client) ReadPump() {
+	cur := c.connection()
+	if cur == nil {
+		return
+	}
+
 	defer func() {
-		c.Hub.Unregister <- c
-		c.Conn.Close()
+		// Koneksi yang sudah digantikan resume tidak mempengaruhi session
+		if c.detach(cur) {
+			c.Hub.Unregister <- c
+		}
+		cur.conn.Close()
 	}()
 
-	c.Conn.SetReadLimit(maxMessageSize)
-	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	cur.conn.SetReadLimit(maxMessageSize)
+	cur.conn.SetReadDeadline(time.Now().Add(pongWait))
+	cur.conn.SetPongHandler(func(string) error {
+		cur.conn.SetReadDeadline(time.Now().Add(pongWait))
 		return nil
 	})
 
 	for {
-		_, messageBytes, err := c.Conn.ReadMessage()
+		_, messageBytes, err := cur.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				logrus.Errorf("error: %v", err)
@@ -66,41 +79,85 @@ func (c *Client) ReadPump() {
 	}
 }
 
-// WritePump mengirim pesan ke koneksi WebSocket
+// WritePump menulis pesan outbox session ke koneksi WebSocket. Koneksi ditutup
+// dengan close code policy violation ketika access token kedaluwarsa.
 func (c *Client) WritePump() {
+	cur := c.connection()
+	if cur == nil {
+		return
+	}
+
 	ticker := time.NewTicker(pingPeriod)
+
+	// Channel nil tidak pernah menerima, sehingga koneksi tanpa ExpiresAt tidak kedaluwarsa
+	var expiry *time.Timer
+	var expired <-chan time.Time
+	if !c.ExpiresAt.IsZero() {
+		expiry = time.NewTimer(time.Until(c.ExpiresAt))
+		expired = expiry.C
+	}
+
 	defer func() {
 		ticker.Stop()
-		c.Conn.Close()
+		if expiry != nil {
+			expiry.Stop()
+		}
+		cur.conn.Close()
 	}()
 
 	for {
 		select {
-		case message, ok := <-c.Send:
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// Hub menutup channel
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+		case <-cur.wake:
+			messages, closed, stale := c.nextBatch(cur)
+			if stale {
 				return
 			}
 
-			// Serialize pesan ke JSON
-			messageBytes, err := json.Marshal(message)
-			if err != nil {
-				logrus.Errorf("error marshaling message: %v", err)
-				continue
+			for _, message := range messages {
+				// Serialize pesan ke JSON
+				messageBytes, err := json.Marshal(message)
+				if err != nil {
+					logrus.Errorf("error marshaling message: %v", err)
+					continue
+				}
+
+				cur.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if err := cur.conn.WriteMessage(websocket.TextMessage, messageBytes); err != nil {
+					logrus.Errorf("error writing message: %v", err)
+					return
+				}
 			}
 
-			if err := c.Conn.WriteMessage(websocket.TextMessage, messageBytes); err != nil {
-				logrus.Errorf("error writing message: %v", err)
+			if closed {
+				// Session diakhiri hub
+				cur.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				cur.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
+		case <-cur.done:
+			// Koneksi dilepas dari session atau digantikan resume
+			return
+
 		case <-ticker.C:
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			cur.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := cur.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+
+		case expiresAt := <-cur.reauth:
+			// Token diperbarui melalui pesan authenticate
+			if expiry != nil {
+				expiry.Stop()
+			}
+			expiry = time.NewTimer(time.Until(expiresAt))
+			expired = expiry.C
+
+		case <-expired:
+			logrus.WithField("userId", c.UserID).Info("Closing WebSocket connection with expired token")
+			cur.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			cur.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token expired"))
+			return
 		}
 	}
 }
@@ -124,6 +181,14 @@ func (c *Client) handleMessage(message Message) {
 		c.handleAnswer(message)
 	case MessageTypeIceCandidate:
 		c.handleIceCandidate(message)
+	case MessageTypeSelectLayer:
+		c.handleSelectLayer(message)
+	case MessageTypeAuthenticate:
+		c.handleAuthenticate(message)
+	case MessageTypeAck:
+		c.handleAck(message)
+	case MessageTypeTransferCall:
+		c.handleTransferCall(message)
 	default:
 		logrus.Warnf("Unknown message type: %s", message.Type)
 		c.SendErrorMessage("Unknown message type")
@@ -136,7 +201,8 @@ func (c *Client) SetSignalingHandler(handler interface{}) {
 	// Ini akan diimplementasikan setelah signaling handler terintegrasi dengan hub
 }
 
-// handleJoinRoom menangani pesan join-room
+// handleJoinRoom menangani pesan join-room. User ID pada payload diabaikan, user
+// yang join selalu identitas koneksi yang sudah terautentikasi.
 func (c *Client) handleJoinRoom(message Message) {
 	var data JoinRoomData
 	if err := mapToStruct(message.Data, &data); err != nil {
@@ -145,14 +211,36 @@ func (c *Client) handleJoinRoom(message Message) {
 	}
 
 	// Validasi data
-	if data.RoomID == "" || data.UserID == "" {
-		c.SendErrorMessage("Room ID and User ID are required")
+	if data.RoomID == "" {
+		c.SendErrorMessage("Room ID is required")
+		return
+	}
+	data.UserID = c.UserID
+
+	// Tanpa otorisasi keanggotaan room, join ditolak agar user tidak dapat masuk
+	// ke room mana pun hanya dengan mengetahui ID-nya
+	if c.Hub.AuthorizeJoin == nil {
+		logrus.WithFields(logrus.Fields{
+			"roomId": data.RoomID,
+			"userId": c.UserID,
+		}).Error("Rejected join room: room authorization is not configured")
+		c.sendError(http.StatusServiceUnavailable, "Room authorization is not available")
 		return
 	}
 
-	// Pastikan user ID sesuai dengan client
-	if data.UserID != c.UserID {
-		c.SendErrorMessage("User ID mismatch")
+	// Otorisasi dilakukan di goroutine client agar query database tidak menahan hub
+	maxUsers, err := c.Hub.AuthorizeJoin(data.RoomID, c.UserID)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"roomId": data.RoomID,
+			"userId": c.UserID,
+		}).Warnf("Rejected join room: %v", err)
+
+		if errors.Is(err, mediaplane.ErrRoomAccessDenied) {
+			c.sendError(http.StatusForbidden, err.Error())
+		} else {
+			c.sendError(http.StatusInternalServerError, "Failed to authorize room access")
+		}
 		return
 	}
 
@@ -166,12 +254,15 @@ func (c *Client) handleJoinRoom(message Message) {
 			Data:      data,
 			Timestamp: time.Now(),
 		},
+		MaxUsers: maxUsers,
+		Sender:   c,
 	}
 
 	c.Hub.RoomMessage <- joinMsg
 }
 
-// handleLeaveRoom menangani pesan leave-room
+// handleLeaveRoom menangani pesan leave-room. Seperti join-room, user ID pada
+// payload diabaikan.
 func (c *Client) handleLeaveRoom(message Message) {
 	var data LeaveRoomData
 	if err := mapToStruct(message.Data, &data); err != nil {
@@ -180,16 +271,11 @@ func (c *Client) handleLeaveRoom(message Message) {
 	}
 
 	// Validasi data
-	if data.RoomID == "" || data.UserID == "" {
-		c.SendErrorMessage("Room ID and User ID are required")
-		return
-	}
-
-	// Pastikan user ID sesuai dengan client
-	if data.UserID != c.UserID {
-		c.SendErrorMessage("User ID mismatch")
+	if data.RoomID == "" {
+		c.SendErrorMessage("Room ID is required")
 		return
 	}
+	data.UserID = c.UserID
 
 	// Leave room melalui hub
 	leaveMsg := RoomMessage{
@@ -201,6 +287,7 @@ func (c *Client) handleLeaveRoom(message Message) {
 			Data:      data,
 			Timestamp: time.Now(),
 		},
+		Sender: c,
 	}
 
 	c.Hub.RoomMessage <- leaveMsg
@@ -236,6 +323,7 @@ func (c *Client) handleOffer(message Message) {
 			Data:      data,
 			Timestamp: time.Now(),
 		},
+		Sender: c,
 	}
 
 	c.Hub.RoomMessage <- offerMsg
@@ -271,6 +359,7 @@ func (c *Client) handleAnswer(message Message) {
 			Data:      data,
 			Timestamp: time.Now(),
 		},
+		Sender: c,
 	}
 
 	c.Hub.RoomMessage <- answerMsg
@@ -306,24 +395,62 @@ func (c *Client) handleIceCandidate(message Message) {
 			Data:      data,
 			Timestamp: time.Now(),
 		},
+		Sender: c,
 	}
 
 	c.Hub.RoomMessage <- iceMsg
 }
 
+// handleSelectLayer menangani pesan select-layer
+func (c *Client) handleSelectLayer(message Message) {
+	var data SelectLayerData
+	if err := mapToStruct(message.Data, &data); err != nil {
+		c.SendErrorMessage("Invalid select layer data")
+		return
+	}
+
+	// Validasi data
+	if data.RoomID == "" || data.FromUserID == "" || (data.ToUserID == "" && data.FeedID == 0) {
+		c.SendErrorMessage("Invalid select layer data: missing required fields")
+		return
+	}
+
+	// Pastikan from user ID sesuai dengan client
+	if data.FromUserID != c.UserID {
+		c.SendErrorMessage("From user ID mismatch")
+		return
+	}
+
+	// Kirim pemilihan layer ke room tertentu
+	selectLayerMsg := RoomMessage{
+		RoomID: data.RoomID,
+		Message: Message{
+			Type:      MessageTypeSelectLayer,
+			RoomID:    data.RoomID,
+			UserID:    c.UserID,
+			Data:      data,
+			Timestamp: time.Now(),
+		},
+		Sender: c,
+	}
+
+	c.Hub.RoomMessage <- selectLayerMsg
+}
+
 // SendErrorMessage mengirim pesan error ke client
 func (c *Client) SendErrorMessage(message string) {
+	c.sendError(http.StatusBadRequest, message)
+}
+
+// sendError mengirim pesan error dengan kode tertentu ke client
+func (c *Client) sendError(code int, message string) {
 	errorMsg := Message{
 		Type:      MessageTypeError,
-		Data:      ErrorData{Code: 400, Message: message},
+		Data:      ErrorData{Code: code, Message: message},
 		Timestamp: time.Now(),
 	}
 
-	select {
-	case c.Send <- errorMsg:
-	default:
-		close(c.Send)
-	}
+	c.queue(errorMsg)
 }
 
 // SendSuccessMessage mengirim pesan success ke client
@@ -334,11 +461,7 @@ func (c *Client) SendSuccessMessage(message string) {
 		Timestamp: time.Now(),
 	}
 
-	select {
-	case c.Send <- successMsg:
-	default:
-		close(c.Send)
-	}
+	c.queue(successMsg)
 }
 
 // mapToStruct mengkonversi interface{} ke struct menggunakan JSON marshaling/unmarshaling
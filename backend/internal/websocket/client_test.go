@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+)
+
+// connectUser membuka koneksi development (query userId) untuk perangkat user
+func connectUser(t *testing.T, server *httptest.Server, userID, deviceID string) *testConn {
+	t.Helper()
+
+	conn := mustDial(t, server, "/ws?userId="+userID+"&deviceId="+deviceID, nil)
+	conn.expect(t, MessageTypeSession)
+	return conn
+}
+
+// joinRoom mengirim join-room lalu mengembalikan balasan pertama yang relevan:
+// room-joined atau error
+func (c *testConn) joinRoom(t *testing.T, roomID string) Message {
+	t.Helper()
+
+	c.send(t, Message{Type: MessageTypeJoinRoom, Data: JoinRoomData{RoomID: roomID}})
+	for {
+		message := c.read(t)
+		if message.Type == MessageTypeRoomJoined || message.Type == MessageTypeError {
+			return message
+		}
+	}
+}
+
+// expectJoinError memastikan join-room ditolak dengan kode dan pesan tertentu
+func expectJoinError(t *testing.T, message Message, code int, text string) {
+	t.Helper()
+
+	if message.Type != MessageTypeError {
+		t.Fatalf("join room accepted: %+v", message)
+	}
+	var data ErrorData
+	decode(t, message.Data, &data)
+	if data.Code != code || data.Message != text {
+		t.Fatalf("unexpected join error %+v, expected %d %q", data, code, text)
+	}
+}
+
+// allowJoin adalah AuthorizeJoin yang mengizinkan semua join dengan batas maxUsers
+func allowJoin(maxUsers int) func(roomID, userID string) (int, error) {
+	return func(roomID, userID string) (int, error) { return maxUsers, nil }
+}
+
+func TestJoinRoomRejectedWithoutAuthorizer(t *testing.T) {
+	hub := NewHub()
+	server := newTestServer(t, hub, func(h *Handler) { h.AllowUserID = true })
+
+	conn := connectUser(t, server, "user-1", "phone")
+	expectJoinError(t, conn.joinRoom(t, "room-1"), http.StatusServiceUnavailable, "Room authorization is not available")
+}
+
+func TestJoinRoomDeniedByAuthorizer(t *testing.T) {
+	// Alasan penolakan mengikuti room.SignalingAccess
+	denied := map[string]string{
+		"kicked":   "removed from room by host",
+		"banned":   "banned from room",
+		"outsider": "not a participant of this room",
+	}
+
+	hub := NewHub()
+	hub.AuthorizeJoin = func(roomID, userID string) (int, error) {
+		if reason, ok := denied[userID]; ok {
+			return 0, fmt.Errorf("%w: %s", mediaplane.ErrRoomAccessDenied, reason)
+		}
+		if userID == "unavailable" {
+			return 0, errors.New("connection refused")
+		}
+		return 0, nil
+	}
+	server := newTestServer(t, hub, func(h *Handler) { h.AllowUserID = true })
+
+	for userID, reason := range denied {
+		conn := connectUser(t, server, userID, "phone")
+		expectJoinError(t, conn.joinRoom(t, "room-1"), http.StatusForbidden, mediaplane.ErrRoomAccessDenied.Error()+": "+reason)
+	}
+
+	// Kegagalan database tidak dibocorkan ke client
+	conn := connectUser(t, server, "unavailable", "phone")
+	expectJoinError(t, conn.joinRoom(t, "room-1"), http.StatusInternalServerError, "Failed to authorize room access")
+
+	// Hanya member yang tercatat di room
+	joined := connectUser(t, server, "member", "phone").joinRoom(t, "room-1")
+	if joined.Type != MessageTypeRoomJoined {
+		t.Fatalf("member rejected: %+v", joined)
+	}
+	var data RoomJoinedData
+	decode(t, joined.Data, &data)
+	if len(data.Users) != 1 || data.Users[0] != "member" {
+		t.Fatalf("denied users joined: %v", data.Users)
+	}
+}
+
+func TestJoinRoomRejectsFullRoom(t *testing.T) {
+	hub := NewHub()
+	hub.AuthorizeJoin = allowJoin(2)
+	server := newTestServer(t, hub, func(h *Handler) { h.AllowUserID = true })
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		if joined := connectUser(t, server, userID, "phone").joinRoom(t, "room-1"); joined.Type != MessageTypeRoomJoined {
+			t.Fatalf("%s rejected: %+v", userID, joined)
+		}
+	}
+
+	expectJoinError(t, connectUser(t, server, "user-3", "phone").joinRoom(t, "room-1"), http.StatusForbidden, "Room is full")
+
+	// Perangkat lain user yang sudah ada di room tidak dihitung sebagai user baru
+	joined := connectUser(t, server, "user-1", "laptop").joinRoom(t, "room-1")
+	if joined.Type != MessageTypeRoomJoined {
+		t.Fatalf("second device of a member rejected: %+v", joined)
+	}
+	var data RoomJoinedData
+	decode(t, joined.Data, &data)
+	if len(data.Users) != 2 {
+		t.Fatalf("expected 2 users in room, got %v", data.Users)
+	}
+}
@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Lama node dianggap hidup tanpa heartbeat baru
+const nodeTTL = 30 * time.Second
+
+// SetBackplane menghubungkan hub ke cluster melalui backplane. Harus dipanggil
+// sebelum Run.
+func (h *Hub) SetBackplane(backplane Backplane, nodeID string) error {
+	if nodeID == "" {
+		return fmt.Errorf("node ID is required")
+	}
+
+	// Node harus terlihat hidup sebelum mencatat keanggotaan room
+	if err := backplane.Heartbeat(nodeID, nodeTTL); err != nil {
+		return err
+	}
+
+	envelopes, err := backplane.Subscribe()
+	if err != nil {
+		return err
+	}
+
+	h.NodeID = nodeID
+	h.backplane = backplane
+
+	go h.receiveEnvelopes(envelopes)
+	go h.heartbeat()
+
+	logrus.WithField("nodeId", nodeID).Info("WebSocket hub joined cluster")
+	return nil
+}
+
+// LeaveCluster menghapus keanggotaan room node ini dari registry cluster dan
+// menutup backplane. Dipanggil saat server shutdown.
+func (h *Hub) LeaveCluster() {
+	if h.backplane == nil {
+		return
+	}
+
+	if err := h.backplane.RemoveNode(h.NodeID); err != nil {
+		logrus.Errorf("Failed to remove node from cluster: %v", err)
+	}
+	if err := h.backplane.Close(); err != nil {
+		logrus.Errorf("Failed to close backplane: %v", err)
+	}
+}
+
+// HasBackplane memeriksa apakah hub terhubung ke cluster
+func (h *Hub) HasBackplane() bool {
+	return h.backplane != nil
+}
+
+// receiveEnvelopes meneruskan envelope dari node lain ke loop hub
+func (h *Hub) receiveEnvelopes(envelopes <-chan Envelope) {
+	for envelope := range envelopes {
+		// Envelope milik node ini sudah dikirim ke client lokal saat dipublish
+		if envelope.NodeID == h.NodeID {
+			continue
+		}
+		h.remote <- envelope
+	}
+}
+
+// heartbeat memperbarui tanda hidup node secara berkala
+func (h *Hub) heartbeat() {
+	ticker := time.NewTicker(nodeTTL / 3)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := h.backplane.Heartbeat(h.NodeID, nodeTTL); err != nil {
+			logrus.Errorf("Failed to send cluster heartbeat: %v", err)
+		}
+	}
+}
+
+// handleEnvelope mengirim envelope dari node lain ke client lokal
+func (h *Hub) handleEnvelope(envelope Envelope) {
+	switch envelope.Kind {
+	case EnvelopeRoom:
+		h.deliverToRoom(envelope.RoomID, envelope.Message, func(client *Client) bool {
+			return envelope.ExcludeUserID != "" && client.UserID == envelope.ExcludeUserID
+		})
+	case EnvelopeUser:
+		h.deliverToUser(envelope.UserID, envelope.DeviceID, envelope.Message)
+	case EnvelopeBroadcast:
+		h.deliverToAll(envelope.Message)
+	case EnvelopeCallTransfer:
+		h.handleRemoteCallTransfer(envelope)
+	default:
+		logrus.Warnf("Unknown backplane envelope kind: %s", envelope.Kind)
+	}
+}
+
+// publish menyebarkan envelope ke node lain (tidak melakukan apa pun tanpa backplane)
+func (h *Hub) publish(envelope Envelope) {
+	if h.backplane == nil {
+		return
+	}
+
+	envelope.NodeID = h.NodeID
+	if err := h.backplane.Publish(envelope); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"kind":   envelope.Kind,
+			"roomId": envelope.RoomID,
+			"userId": envelope.UserID,
+		}).Errorf("Failed to publish envelope: %v", err)
+	}
+}
+
+// addRoomMember mencatat user di registry room cluster
+func (h *Hub) addRoomMember(roomID, userID string) {
+	if h.backplane == nil {
+		return
+	}
+
+	if err := h.backplane.AddRoomMember(h.NodeID, roomID, userID); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"roomId": roomID,
+			"userId": userID,
+		}).Errorf("Failed to register room member: %v", err)
+	}
+}
+
+// removeRoomMember menghapus user dari registry room cluster jika tidak ada lagi
+// koneksi user tersebut di room pada node ini
+func (h *Hub) removeRoomMember(roomID, userID string) {
+	if h.backplane == nil {
+		return
+	}
+
+	if len(h.userClientsInRoom(roomID, userID)) > 0 {
+		return
+	}
+
+	if err := h.backplane.RemoveRoomMember(h.NodeID, roomID, userID); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"roomId": roomID,
+			"userId": userID,
+		}).Errorf("Failed to unregister room member: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+)
+
+// InternalSecretHeader adalah header yang membawa shared secret endpoint internal
+const InternalSecretHeader = "X-Internal-Secret"
+
+// ControlClient adalah client HTTP yang dipakai API server untuk memanggil
+// endpoint internal websocket server, misalnya untuk menegakkan moderasi host
+// di media plane yang dikelola websocket server
+type ControlClient struct {
+	// BaseURL websocket server, misalnya http://websocket:8081
+	BaseURL string
+
+	// Secret dikirim di header X-Internal-Secret
+	Secret string
+
+	httpClient *http.Client
+}
+
+// NewControlClient membuat instance ControlClient baru
+func NewControlClient(baseURL, secret string) *ControlClient {
+	return &ControlClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Secret:  secret,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// KickParticipant memutus media user dan mengeluarkannya dari room
+func (cc *ControlClient) KickParticipant(roomID, userID string) error {
+	return cc.post(cc.participantPath(roomID, userID, "kick"), nil)
+}
+
+// MuteParticipant mute atau unmute audio/video user di media server
+func (cc *ControlClient) MuteParticipant(roomID, userID, kind string, mute bool) error {
+	body := map[string]interface{}{
+		"kind": kind,
+		"mute": mute,
+	}
+	return cc.post(cc.participantPath(roomID, userID, "mute"), body)
+}
+
+// UnpublishParticipant menghentikan publish user tanpa mengeluarkannya dari room
+func (cc *ControlClient) UnpublishParticipant(roomID, userID string) error {
+	return cc.post(cc.participantPath(roomID, userID, "unpublish"), nil)
+}
+
+// SetParticipantVolume mengubah volume user di mix audio room (persen, 100 = tidak diubah)
+func (cc *ControlClient) SetParticipantVolume(roomID, userID string, volume int) error {
+	body := map[string]interface{}{
+		"volume": volume,
+	}
+	return cc.post(cc.participantPath(roomID, userID, "volume"), body)
+}
+
+// ApplyMediaPolicy menerapkan kebijakan media ke room yang sedang berjalan di media server
+func (cc *ControlClient) ApplyMediaPolicy(roomID string, policy mediaplane.MediaPolicy) error {
+	return cc.post(fmt.Sprintf("/api/v1/websocket/internal/rooms/%s/media-policy", url.PathEscape(roomID)), policy)
+}
+
+// StartRecording mulai merekam room ke directory (relatif terhadap direktori recording
+// media server) dan memberitahu semua peserta room
+func (cc *ControlClient) StartRecording(roomID, recordingID, directory, startedBy string) error {
+	body := map[string]interface{}{
+		"recording_id": recordingID,
+		"directory":    directory,
+		"started_by":   startedBy,
+	}
+	return cc.post(cc.recordingPath(roomID, "start"), body)
+}
+
+// StopRecording menghentikan recording room dan memberitahu semua peserta room
+func (cc *ControlClient) StopRecording(roomID, recordingID string) error {
+	body := map[string]interface{}{
+		"recording_id": recordingID,
+	}
+	return cc.post(cc.recordingPath(roomID, "stop"), body)
+}
+
+// StartRTPForward mulai meneruskan media publisher room sebagai RTP dan mengembalikan
+// publisher yang diteruskan beserta codec-nya
+func (cc *ControlClient) StartRTPForward(roomID string, forward RTPForward) (*RTPForwardStatus, error) {
+	var response struct {
+		Data RTPForwardStatus `json:"data"`
+	}
+	if err := cc.do(cc.forwardPath(roomID, "start"), forward, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// StopRTPForward menghentikan satu RTP forwarding room
+func (cc *ControlClient) StopRTPForward(roomID, forwardID string) error {
+	body := map[string]interface{}{
+		"id": forwardID,
+	}
+	return cc.post(cc.forwardPath(roomID, "stop"), body)
+}
+
+// NotifyLiveStream mengirim status restream RTMP ke semua koneksi user
+func (cc *ControlClient) NotifyLiveStream(userID string, data LiveStreamData) error {
+	return cc.post(fmt.Sprintf("/api/v1/websocket/internal/users/%s/live-stream", url.PathEscape(userID)), data)
+}
+
+// DialOut menelepon nomor melalui gateway SIP dan memasukkannya ke room
+func (cc *ControlClient) DialOut(roomID string, dial mediaplane.SIPDial) (*mediaplane.SIPCall, error) {
+	var response struct {
+		Data mediaplane.SIPCall `json:"data"`
+	}
+	if err := cc.do(cc.sipPath(roomID, "dial"), dial, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// HangupCall mengakhiri panggilan telepon di room
+func (cc *ControlClient) HangupCall(roomID, callID string) error {
+	body := map[string]interface{}{
+		"id": callID,
+	}
+	return cc.post(cc.sipPath(roomID, "hangup"), body)
+}
+
+// ListCalls mengembalikan panggilan telepon yang sedang berjalan di room
+func (cc *ControlClient) ListCalls(roomID string) ([]mediaplane.SIPCall, error) {
+	var response struct {
+		Data []mediaplane.SIPCall `json:"data"`
+	}
+	if err := cc.do(cc.sipPath(roomID, "calls"), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// sipPath membangun path endpoint internal panggilan telepon room
+func (cc *ControlClient) sipPath(roomID, action string) string {
+	return fmt.Sprintf("/api/v1/websocket/internal/rooms/%s/sip/%s", url.PathEscape(roomID), action)
+}
+
+// forwardPath membangun path endpoint internal RTP forwarding room
+func (cc *ControlClient) forwardPath(roomID, action string) string {
+	return fmt.Sprintf("/api/v1/websocket/internal/rooms/%s/forward/%s", url.PathEscape(roomID), action)
+}
+
+// recordingPath membangun path endpoint internal recording room
+func (cc *ControlClient) recordingPath(roomID, action string) string {
+	return fmt.Sprintf("/api/v1/websocket/internal/rooms/%s/recording/%s", url.PathEscape(roomID), action)
+}
+
+// participantPath membangun path endpoint internal untuk user di room
+func (cc *ControlClient) participantPath(roomID, userID, action string) string {
+	return fmt.Sprintf("/api/v1/websocket/internal/rooms/%s/users/%s/%s",
+		url.PathEscape(roomID), url.PathEscape(userID), action)
+}
+
+// post mengirim request POST ke websocket server tanpa membaca body response
+func (cc *ControlClient) post(path string, body interface{}) error {
+	return cc.do(path, body, nil)
+}
+
+// do mengirim request POST ke websocket server dan mengubah status error menjadi error Go.
+// Body response 200 di-decode ke out jika out tidak nil. Status 404 dikembalikan sebagai
+// mediaplane.ErrParticipantNotFound.
+func (cc *ControlClient) do(path string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cc.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cc.Secret != "" {
+		req.Header.Set(InternalSecretHeader, cc.Secret)
+	}
+
+	resp, err := cc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach websocket server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+		return nil
+	}
+
+	var errorBody struct {
+		Error string `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&errorBody)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", mediaplane.ErrParticipantNotFound, errorBody.Error)
+	}
+	return fmt.Errorf("websocket server returned %d: %s", resp.StatusCode, errorBody.Error)
+}
@@ -0,0 +1,238 @@
+package websocket
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Panjang maksimum device ID dari query deviceId
+const maxDeviceIDLength = 128
+
+// addUserClient mendaftarkan client ke index user. Device ID yang kosong diganti
+// session ID. Session lama dengan device ID yang sama dan sedang terputus berarti
+// perangkat itu membuka session baru tanpa resume, sehingga session lama diakhiri.
+func (h *Hub) addUserClient(client *Client) {
+	if client.DeviceID == "" {
+		client.DeviceID = client.SessionID
+	}
+
+	for other := range h.Users[client.UserID] {
+		if other.DeviceID != client.DeviceID {
+			continue
+		}
+
+		if other.connection() == nil {
+			h.endSession(other)
+			continue
+		}
+
+		// Beberapa tab dapat berbagi device ID dari penyimpanan browser yang sama
+		client.DeviceID = client.DeviceID + "-" + client.SessionID[:8]
+		break
+	}
+
+	if _, exists := h.Users[client.UserID]; !exists {
+		h.Users[client.UserID] = make(map[*Client]bool)
+	}
+	h.Users[client.UserID][client] = true
+}
+
+// removeUserClient menghapus client dari index user
+func (h *Hub) removeUserClient(client *Client) {
+	if userClients, exists := h.Users[client.UserID]; exists {
+		delete(userClients, client)
+		if len(userClients) == 0 {
+			delete(h.Users, client.UserID)
+		}
+	}
+}
+
+// userClients mengembalikan client lokal milik user, hanya perangkat deviceID jika diisi
+func (h *Hub) userClients(userID, deviceID string) []*Client {
+	var clients []*Client
+	for client := range h.Users[userID] {
+		if deviceID == "" || client.DeviceID == deviceID {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// userClientsInRoom mengembalikan client lokal milik user yang berada di room
+func (h *Hub) userClientsInRoom(roomID, userID string) []*Client {
+	var clients []*Client
+	for client := range h.Users[userID] {
+		if client.RoomIDs[roomID] {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// callDevice mengembalikan client yang memegang panggilan user di room (nil jika tidak ada)
+func (h *Hub) callDevice(roomID, userID string) *Client {
+	return h.calls[roomID][userID]
+}
+
+// setCallDevice menjadikan client pemegang panggilan user di room
+func (h *Hub) setCallDevice(roomID string, client *Client) {
+	if _, exists := h.calls[roomID]; !exists {
+		h.calls[roomID] = make(map[string]*Client)
+	}
+	h.calls[roomID][client.UserID] = client
+}
+
+// releaseCall menghapus client sebagai pemegang panggilan user di room dan
+// mengembalikan true jika client memang memegangnya
+func (h *Hub) releaseCall(roomID string, client *Client) bool {
+	if h.callDevice(roomID, client.UserID) != client {
+		return false
+	}
+
+	delete(h.calls[roomID], client.UserID)
+	if len(h.calls[roomID]) == 0 {
+		delete(h.calls, roomID)
+	}
+	return true
+}
+
+// checkCallDevice memastikan pesan signaling room berasal dari perangkat yang
+// memegang panggilan user, karena media server hanya mengenal satu koneksi per user.
+// Pesan dari server (sender nil) selalu diizinkan.
+func (h *Hub) checkCallDevice(roomID string, sender *Client) bool {
+	if sender == nil {
+		return true
+	}
+
+	if h.callDevice(roomID, sender.UserID) == sender {
+		return true
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"roomId":   roomID,
+		"userId":   sender.UserID,
+		"deviceId": sender.DeviceID,
+	}).Warn("Rejected signaling from device without the call")
+	sender.sendError(http.StatusConflict, "Call is not active on this device")
+	return false
+}
+
+// signalingTarget menentukan client tujuan signaling langsung antar peserta:
+// perangkat toDeviceID jika diisi, selain itu perangkat yang memegang panggilan user
+func (h *Hub) signalingTarget(roomID, userID, toDeviceID string) *Client {
+	if toDeviceID == "" {
+		return h.callDevice(roomID, userID)
+	}
+
+	for _, client := range h.userClients(userID, toDeviceID) {
+		if client.RoomIDs[roomID] {
+			return client
+		}
+	}
+	return nil
+}
+
+// handleTransferCall memindahkan panggilan user di room ke perangkat pengirim.
+// Handle media perangkat lama dilepas, lalu perangkat baru join ke media server
+// sehingga offer dan event media berikutnya dikirim ke perangkat baru.
+func (h *Hub) handleTransferCall(roomID string, sender *Client) {
+	if sender == nil || !h.Clients[sender] {
+		logrus.Error("Sender client not found")
+		return
+	}
+
+	if !sender.RoomIDs[roomID] {
+		sender.SendErrorMessage("Join the room on this device before moving the call")
+		return
+	}
+
+	previous := h.callDevice(roomID, sender.UserID)
+	if previous == sender {
+		h.notifyCallDevice(roomID, sender.UserID, sender.DeviceID, "")
+		return
+	}
+
+	previousDeviceID := ""
+	if previous != nil {
+		previousDeviceID = previous.DeviceID
+		h.signalingLeave(roomID, sender.UserID)
+	} else {
+		// Panggilan mungkin dipegang perangkat yang terhubung ke node lain
+		h.publish(Envelope{
+			Kind:     EnvelopeCallTransfer,
+			RoomID:   roomID,
+			UserID:   sender.UserID,
+			DeviceID: sender.DeviceID,
+		})
+	}
+
+	h.setCallDevice(roomID, sender)
+	h.signalingJoin(roomID, sender.UserID)
+
+	logrus.WithFields(logrus.Fields{
+		"roomId":         roomID,
+		"userId":         sender.UserID,
+		"deviceId":       sender.DeviceID,
+		"previousDevice": previousDeviceID,
+	}).Info("Call transferred to device")
+
+	h.notifyCallDevice(roomID, sender.UserID, sender.DeviceID, previousDeviceID)
+}
+
+// handleRemoteCallTransfer melepas panggilan yang dipegang perangkat di node ini
+// karena user memindahkannya ke perangkat di node lain
+func (h *Hub) handleRemoteCallTransfer(envelope Envelope) {
+	holder := h.callDevice(envelope.RoomID, envelope.UserID)
+	if holder == nil {
+		return
+	}
+
+	h.releaseCall(envelope.RoomID, holder)
+	h.signalingLeave(envelope.RoomID, envelope.UserID)
+	h.notifyCallDevice(envelope.RoomID, envelope.UserID, envelope.DeviceID, holder.DeviceID)
+}
+
+// notifyCallDevice memberi tahu semua perangkat user di room tentang perangkat
+// yang sekarang memegang panggilan
+func (h *Hub) notifyCallDevice(roomID, userID, deviceID, previousDeviceID string) {
+	message := Message{
+		Type:   MessageTypeCallDevice,
+		RoomID: roomID,
+		UserID: userID,
+		Data: CallDeviceData{
+			RoomID:           roomID,
+			UserID:           userID,
+			DeviceID:         deviceID,
+			PreviousDeviceID: previousDeviceID,
+		},
+		Timestamp: time.Now(),
+	}
+
+	for _, client := range h.userClientsInRoom(roomID, userID) {
+		client.queue(message)
+	}
+}
+
+// handleTransferCall menangani pesan transfer-call dari perangkat yang ingin
+// mengambil alih panggilan user. Perangkat harus sudah join room.
+func (c *Client) handleTransferCall(message Message) {
+	var data TransferCallData
+	if err := mapToStruct(message.Data, &data); err != nil || data.RoomID == "" {
+		c.SendErrorMessage("Invalid transfer call data")
+		return
+	}
+
+	c.Hub.RoomMessage <- RoomMessage{
+		RoomID: data.RoomID,
+		Message: Message{
+			Type:      MessageTypeTransferCall,
+			RoomID:    data.RoomID,
+			UserID:    c.UserID,
+			Data:      data,
+			Timestamp: time.Now(),
+		},
+		Sender: c,
+	}
+}
@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSignaling mencatat join dan leave media yang diminta hub
+type fakeSignaling struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (s *fakeSignaling) HandleJoinRoom(roomID, userID, displayName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, "join:"+userID)
+	return nil
+}
+
+func (s *fakeSignaling) HandleLeaveRoom(roomID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, "leave:"+userID)
+	return nil
+}
+
+func (s *fakeSignaling) history() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.events, " ")
+}
+
+func TestUserMessageReachesEveryDevice(t *testing.T) {
+	server := newTestServer(t, NewHub(), func(h *Handler) { h.AllowUserID = true })
+
+	phone := connectUser(t, server, "alice", "phone")
+	laptop := connectUser(t, server, "alice", "laptop")
+
+	if status := postJSON(t, server, "/api/v1/websocket/admin/users/alice/message", `{"type":"notice","data":{"text":"all"}}`); status != http.StatusOK {
+		t.Fatalf("message to user returned %d", status)
+	}
+	phone.expect(t, "notice")
+	laptop.expect(t, "notice")
+
+	// Dengan deviceId, hanya perangkat itu yang menerima pesan
+	if status := postJSON(t, server, "/api/v1/websocket/admin/users/alice/message", `{"type":"notice","data":{"text":"laptop"},"deviceId":"laptop"}`); status != http.StatusOK {
+		t.Fatalf("message to device returned %d", status)
+	}
+	if status := postJSON(t, server, "/api/v1/websocket/admin/users/alice/message", `{"type":"marker","data":{},"deviceId":"phone"}`); status != http.StatusOK {
+		t.Fatalf("message to device returned %d", status)
+	}
+	laptop.expect(t, "notice")
+	if message := phone.read(t); message.Type != "marker" {
+		t.Fatalf("phone received message for laptop: %+v", message)
+	}
+
+	if status := postJSON(t, server, "/api/v1/websocket/admin/users/alice/message", `{"type":"notice","data":{},"deviceId":"tablet"}`); status != http.StatusNotFound {
+		t.Fatalf("message to unknown device returned %d", status)
+	}
+}
+
+func TestTransferCallMovesCallToDevice(t *testing.T) {
+	signaling := &fakeSignaling{}
+	hub := NewHub()
+	hub.AuthorizeJoin = allowJoin(0)
+	hub.SignalingHandler = signaling
+	server := newTestServer(t, hub, func(h *Handler) { h.AllowUserID = true })
+
+	bob := connectUser(t, server, "bob", "desktop")
+	bob.joinRoom(t, "room-1")
+
+	// Perangkat pertama yang join memegang panggilan
+	phone := connectUser(t, server, "alice", "phone")
+	var data RoomJoinedData
+	decode(t, phone.joinRoom(t, "room-1").Data, &data)
+	if data.CallDeviceID != "phone" {
+		t.Fatalf("call held by %q after first join, expected phone", data.CallDeviceID)
+	}
+	bob.expect(t, MessageTypeUserJoined)
+
+	laptop := connectUser(t, server, "alice", "laptop")
+	decode(t, laptop.joinRoom(t, "room-1").Data, &data)
+	if data.CallDeviceID != "phone" {
+		t.Fatalf("second device took the call on join: %q", data.CallDeviceID)
+	}
+
+	laptop.send(t, Message{Type: MessageTypeTransferCall, Data: TransferCallData{RoomID: "room-1"}})
+
+	// Semua perangkat alice di room diberi tahu perangkat pemegang panggilan yang baru
+	for name, conn := range map[string]*testConn{"phone": phone, "laptop": laptop} {
+		var callDevice CallDeviceData
+		decode(t, conn.expect(t, MessageTypeCallDevice).Data, &callDevice)
+		if callDevice.DeviceID != "laptop" || callDevice.PreviousDeviceID != "phone" {
+			t.Fatalf("%s: unexpected call-device %+v", name, callDevice)
+		}
+	}
+
+	// Handle media perangkat lama dilepas sebelum perangkat baru join
+	if history := signaling.history(); history != "join:bob join:alice leave:alice join:alice" {
+		t.Fatalf("unexpected signaling history: %s", history)
+	}
+
+	// Signaling dari perangkat lama ditolak
+	offer := OfferData{RoomID: "room-1", FromUserID: "alice", ToUserID: "bob", SDP: "v=0"}
+	phone.send(t, Message{Type: MessageTypeOffer, Data: offer})
+	var rejected ErrorData
+	decode(t, phone.expect(t, MessageTypeError).Data, &rejected)
+	if rejected.Code != http.StatusConflict {
+		t.Fatalf("offer from previous device: unexpected error %+v", rejected)
+	}
+
+	// Signaling untuk alice diteruskan ke perangkat yang sekarang memegang panggilan
+	bob.send(t, Message{Type: MessageTypeOffer, Data: OfferData{RoomID: "room-1", FromUserID: "bob", ToUserID: "alice", SDP: "v=0"}})
+	var received OfferData
+	decode(t, laptop.expect(t, MessageTypeOffer).Data, &received)
+	if received.FromUserID != "bob" || received.FromDeviceID != "desktop" {
+		t.Fatalf("unexpected offer on laptop: %+v", received)
+	}
+}
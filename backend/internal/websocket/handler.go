@@ -1,29 +1,35 @@
 package websocket
 
 import (
+	"crypto/hmac"
+	"errors"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
-)
 
-// Upgrader digunakan untuk mengupgrade HTTP connection ke WebSocket
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// TODO: Implement origin checking untuk production
-		// Untuk development, allow all origins
-		return true
-	},
-}
+	"github.com/webrtc-meeting/backend/internal/mediaplane"
+)
 
 // Handler adalah struct untuk WebSocket HTTP handler
 type Handler struct {
 	Hub *Hub
+
+	// InternalSecret adalah shared secret untuk endpoint internal (kosong = endpoint
+	// internal tidak didaftarkan)
+	InternalSecret string
+
+	// Auth memvalidasi access token koneksi WebSocket (nil = koneksi dengan token ditolak)
+	Auth TokenValidator
+
+	// AllowUserID mengizinkan /ws tanpa token dengan query userId, hanya untuk development
+	AllowUserID bool
+
+	// AllowedOrigins adalah origin browser yang boleh membuka WebSocket, misalnya
+	// https://meet.example.com ("*" = semua origin). Lihat checkOrigin.
+	AllowedOrigins []string
 }
 
 // NewHandler membuat instance Handler baru
@@ -33,84 +39,75 @@ func NewHandler(hub *Hub) *Handler {
 	}
 }
 
-// HandleWebSocket menangani koneksi WebSocket masuk
+// HandleWebSocket menangani koneksi WebSocket masuk. User ditentukan dari access
+// token; query userId hanya diterima jika AllowUserID aktif.
 func (h *Handler) HandleWebSocket(c *gin.Context) {
-	// Ambil user ID dari query parameter atau header
-	userID := c.Query("userId")
-	if userID == "" {
-		userID = c.GetHeader("X-User-ID")
-	}
+	h.serveWebSocket(c, h.AllowUserID)
+}
 
-	// Validasi user ID
-	if userID == "" {
-		logrus.Error("User ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID is required"})
+// HandleWebSocketWithAuth menangani koneksi WebSocket yang selalu membutuhkan access token
+func (h *Handler) HandleWebSocketWithAuth(c *gin.Context) {
+	h.serveWebSocket(c, false)
+}
+
+// serveWebSocket mengautentikasi request lalu mengupgrade-nya menjadi koneksi
+// WebSocket. Dengan query resume=<sessionId>&lastSeq=<seq>, koneksi dipasang ke
+// session lama milik user; jika gagal, client mendapat session baru. Query deviceId
+// adalah ID perangkat yang disimpan client (kosong = dibuat server).
+func (h *Handler) serveWebSocket(c *gin.Context, allowUserID bool) {
+	userID, expiresAt, responseHeader, fromCookie, ok := h.authenticate(c, allowUserID)
+	if !ok {
 		return
 	}
 
 	// Upgrade HTTP connection ke WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader(fromCookie).Upgrade(c.Writer, c.Request, responseHeader)
 	if err != nil {
-		logrus.Errorf("Error upgrading connection: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade connection"})
+		logrus.WithFields(logrus.Fields{
+			"origin":     c.GetHeader("Origin"),
+			"remoteAddr": c.Request.RemoteAddr,
+		}).Errorf("Error upgrading connection: %v", err)
+		// Upgrader sudah menulis response error (misalnya 403 untuk origin yang ditolak)
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade connection"})
+		}
 		return
 	}
 
-	// Log koneksi baru
-	logrus.WithFields(logrus.Fields{
-		"userId":     userID,
-		"remoteAddr": c.Request.RemoteAddr,
-	}).Info("New WebSocket connection")
+	if sessionID := c.Query("resume"); sessionID != "" {
+		lastSeq, _ := strconv.ParseUint(c.Query("lastSeq"), 10, 64)
 
-	// Buat client baru
-	client := NewClient(h.Hub, conn, userID)
-
-	// Register client ke hub
-	h.Hub.Register <- client
-
-	// Start goroutines untuk membaca dan menulis pesan
-	go client.WritePump()
-	go client.ReadPump()
-}
-
-// HandleWebSocketWithAuth menangani koneksi WebSocket dengan autentikasi JWT
-func (h *Handler) HandleWebSocketWithAuth(c *gin.Context) {
-	// Ambil token dari query parameter atau header
-	token := c.Query("token")
-	if token == "" {
-		authHeader := c.GetHeader("Authorization")
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			token = strings.TrimPrefix(authHeader, "Bearer ")
+		client, err := h.Hub.Resume(sessionID, userID, lastSeq, conn, expiresAt)
+		if err == nil {
+			go client.WritePump()
+			go client.ReadPump()
+			return
 		}
-	}
 
-	// Validasi token
-	if token == "" {
-		logrus.Error("Authorization token is required")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
-		return
+		logrus.WithFields(logrus.Fields{
+			"userId":    userID,
+			"sessionId": sessionID,
+		}).Warnf("Failed to resume session, starting new session: %v", err)
 	}
 
-	// TODO: Validasi JWT token dan dapatkan user ID
-	// Untuk saat ini, kita anggap token adalah user ID
-	userID := token
-
-	// Upgrade HTTP connection ke WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		logrus.Errorf("Error upgrading connection: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade connection"})
-		return
+	deviceID := c.Query("deviceId")
+	if len(deviceID) > maxDeviceIDLength {
+		deviceID = ""
 	}
 
 	// Log koneksi baru
 	logrus.WithFields(logrus.Fields{
 		"userId":     userID,
+		"deviceId":   deviceID,
 		"remoteAddr": c.Request.RemoteAddr,
-	}).Info("New authenticated WebSocket connection")
+		"expiresAt":  expiresAt,
+	}).Info("New WebSocket connection")
 
 	// Buat client baru
 	client := NewClient(h.Hub, conn, userID)
+	client.DeviceID = deviceID
+	client.ExpiresAt = expiresAt
+	client.validator = h.Auth
 
 	// Register client ke hub
 	h.Hub.Register <- client
@@ -126,6 +123,7 @@ func (h *Handler) GetStats(c *gin.Context) {
 		"connected_clients": h.Hub.GetClientCount(),
 		"active_rooms":      h.Hub.GetRoomCount(),
 		"server_status":     "running",
+		"node_id":           h.Hub.NodeID,
 	}
 
 	c.JSON(http.StatusOK, stats)
@@ -234,7 +232,8 @@ func (h *Handler) BroadcastToAll(c *gin.Context) {
 	})
 }
 
-// SendDirectMessage mengirim pesan langsung ke user tertentu (admin endpoint)
+// SendDirectMessage mengirim pesan langsung ke semua perangkat user tertentu, atau
+// hanya perangkat deviceId jika diisi (admin endpoint)
 func (h *Handler) SendDirectMessage(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
@@ -243,8 +242,9 @@ func (h *Handler) SendDirectMessage(c *gin.Context) {
 	}
 
 	var request struct {
-		Type string      `json:"type" binding:"required"`
-		Data interface{} `json:"data" binding:"required"`
+		Type     string      `json:"type" binding:"required"`
+		Data     interface{} `json:"data" binding:"required"`
+		DeviceID string      `json:"deviceId"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -252,20 +252,6 @@ func (h *Handler) SendDirectMessage(c *gin.Context) {
 		return
 	}
 
-	// Cari client target
-	var targetClient *Client
-	for client := range h.Hub.Clients {
-		if client.UserID == userID {
-			targetClient = client
-			break
-		}
-	}
-
-	if targetClient == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or not connected"})
-		return
-	}
-
 	// Buat pesan langsung
 	message := Message{
 		Type:      MessageType(request.Type),
@@ -274,13 +260,17 @@ func (h *Handler) SendDirectMessage(c *gin.Context) {
 		Timestamp: time.Now(),
 	}
 
-	// Kirim ke client target
-	directMessage := DirectMessage{
-		Client:  targetClient,
-		Message: message,
+	// Dengan backplane, user mungkin terhubung ke node lain
+	if !h.Hub.HasBackplane() && len(h.Hub.userClients(userID, request.DeviceID)) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or not connected"})
+		return
 	}
 
-	h.Hub.DirectMessage <- directMessage
+	h.Hub.UserMessage <- UserMessage{
+		UserID:   userID,
+		DeviceID: request.DeviceID,
+		Message:  message,
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Message sent to user",
@@ -289,7 +279,8 @@ func (h *Handler) SendDirectMessage(c *gin.Context) {
 	})
 }
 
-// DisconnectUser memutuskan koneksi user tertentu (admin endpoint)
+// DisconnectUser memutuskan semua koneksi user tertentu, atau hanya perangkat pada
+// query deviceId (admin endpoint)
 func (h *Handler) DisconnectUser(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
@@ -297,26 +288,21 @@ func (h *Handler) DisconnectUser(c *gin.Context) {
 		return
 	}
 
-	// Cari client target
-	var targetClient *Client
-	for client := range h.Hub.Clients {
-		if client.UserID == userID {
-			targetClient = client
-			break
-		}
-	}
-
-	if targetClient == nil {
+	targetClients := h.Hub.userClients(userID, c.Query("deviceId"))
+	if len(targetClients) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or not connected"})
 		return
 	}
 
-	// Tutup koneksi client
-	targetClient.Conn.Close()
+	// Akhiri session client, koneksi ditutup dan tidak dapat di-resume
+	for _, targetClient := range targetClients {
+		targetClient.Close()
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User disconnected",
-		"userId":  userID,
+		"message":     "User disconnected",
+		"userId":      userID,
+		"connections": len(targetClients),
 	})
 }
 
@@ -341,5 +327,568 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 			admin.POST("/users/:userId/message", h.SendDirectMessage)
 			admin.DELETE("/users/:userId/disconnect", h.DisconnectUser)
 		}
+
+		// Pesan data channel dari Janus TextRoom. Janus tidak dapat mengirim header
+		// internal, sehingga URL-nya ditandatangani per room oleh media backend.
+		api.POST("/textroom/:roomId/messages", h.ReceiveDataMessage)
+
+		// Endpoint internal yang dipanggil API server untuk moderasi host dan kebijakan media.
+		// Tanpa shared secret endpoint ini tidak didaftarkan sama sekali.
+		if h.InternalSecret == "" {
+			logrus.Error("INTERNAL_API_SECRET is not set, internal endpoints are disabled")
+			return
+		}
+
+		internal := api.Group("/internal")
+		internal.Use(h.InternalAuthMiddleware())
+		{
+			internal.POST("/rooms/:roomId/users/:userId/kick", h.KickParticipant)
+			internal.POST("/rooms/:roomId/users/:userId/mute", h.MuteParticipant)
+			internal.POST("/rooms/:roomId/users/:userId/unpublish", h.UnpublishParticipant)
+			internal.POST("/rooms/:roomId/users/:userId/volume", h.SetParticipantVolume)
+			internal.POST("/rooms/:roomId/media-policy", h.ApplyMediaPolicy)
+			internal.POST("/rooms/:roomId/recording/start", h.StartRecording)
+			internal.POST("/rooms/:roomId/recording/stop", h.StopRecording)
+			internal.POST("/rooms/:roomId/forward/start", h.StartRTPForward)
+			internal.POST("/rooms/:roomId/forward/stop", h.StopRTPForward)
+			internal.POST("/rooms/:roomId/sip/dial", h.DialOut)
+			internal.POST("/rooms/:roomId/sip/hangup", h.HangupCall)
+			internal.POST("/rooms/:roomId/sip/calls", h.ListCalls)
+			internal.POST("/users/:userId/live-stream", h.NotifyLiveStream)
+		}
+	}
+}
+
+// InternalAuthMiddleware memastikan request internal membawa header X-Internal-Secret yang benar
+func (h *Handler) InternalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := c.GetHeader(InternalSecretHeader)
+		if h.InternalSecret == "" || !hmac.Equal([]byte(secret), []byte(h.InternalSecret)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid internal secret"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// KickParticipant memutus media user di media server lalu mengeluarkannya dari room hub (internal endpoint)
+func (h *Handler) KickParticipant(c *gin.Context) {
+	roomID := c.Param("roomId")
+	userID := c.Param("userId")
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		KickParticipant(roomID, userID string) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support moderation"})
+		return
+	}
+
+	if err := backend.KickParticipant(roomID, userID); err != nil {
+		h.moderationError(c, err)
+		return
+	}
+
+	// Keluarkan user dari room hub; signaling handler ikut melepas handle media user
+	h.Hub.RoomMessage <- RoomMessage{
+		RoomID: roomID,
+		Message: Message{
+			Type:   MessageTypeLeaveRoom,
+			RoomID: roomID,
+			UserID: userID,
+			Data: LeaveRoomData{
+				RoomID: roomID,
+				UserID: userID,
+			},
+			Timestamp: time.Now(),
+		},
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Participant kicked",
+		"roomId":  roomID,
+		"userId":  userID,
+	})
+}
+
+// MuteParticipant mute atau unmute audio/video user di media server (internal endpoint)
+func (h *Handler) MuteParticipant(c *gin.Context) {
+	roomID := c.Param("roomId")
+	userID := c.Param("userId")
+
+	var request struct {
+		Kind string `json:"kind" binding:"required,oneof=audio video"`
+		Mute bool   `json:"mute"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		MuteParticipant(roomID, userID, kind string, mute bool) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support moderation"})
+		return
+	}
+
+	if err := backend.MuteParticipant(roomID, userID, request.Kind, request.Mute); err != nil {
+		h.moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Participant moderated",
+		"roomId":  roomID,
+		"userId":  userID,
+		"kind":    request.Kind,
+		"mute":    request.Mute,
+	})
+}
+
+// UnpublishParticipant menghentikan publish user tanpa mengeluarkannya dari room (internal endpoint)
+func (h *Handler) UnpublishParticipant(c *gin.Context) {
+	roomID := c.Param("roomId")
+	userID := c.Param("userId")
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		UnpublishParticipant(roomID, userID string) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support moderation"})
+		return
+	}
+
+	if err := backend.UnpublishParticipant(roomID, userID); err != nil {
+		h.moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Participant unpublished",
+		"roomId":  roomID,
+		"userId":  userID,
+	})
+}
+
+// SetParticipantVolume mengubah volume user di mix audio room (internal endpoint)
+func (h *Handler) SetParticipantVolume(c *gin.Context) {
+	roomID := c.Param("roomId")
+	userID := c.Param("userId")
+
+	var request struct {
+		Volume *int `json:"volume" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		SetParticipantVolume(roomID, userID string, volume int) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support audio mixing"})
+		return
+	}
+
+	if err := backend.SetParticipantVolume(roomID, userID, *request.Volume); err != nil {
+		h.moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Participant volume changed",
+		"roomId":  roomID,
+		"userId":  userID,
+		"volume":  *request.Volume,
+	})
+}
+
+// ApplyMediaPolicy menerapkan kebijakan media room ke media backend (internal endpoint).
+// Room yang belum aktif di media server tidak diubah; kebijakan dibaca saat room dibuat.
+func (h *Handler) ApplyMediaPolicy(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var policy mediaplane.MediaPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		ApplyMediaPolicy(roomID string, policy mediaplane.MediaPolicy) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support media policy"})
+		return
+	}
+
+	if err := backend.ApplyMediaPolicy(roomID, policy); err != nil {
+		logrus.WithField("roomId", roomID).Errorf("Failed to apply media policy: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Media policy applied",
+		"roomId":  roomID,
+	})
+}
+
+// StartRecording mulai merekam room di media server lalu memberitahu semua peserta (internal endpoint)
+func (h *Handler) StartRecording(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var request struct {
+		RecordingID string `json:"recording_id" binding:"required"`
+		Directory   string `json:"directory" binding:"required"`
+		StartedBy   string `json:"started_by"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		StartRecording(roomID, directory string) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support recording"})
+		return
+	}
+
+	if err := backend.StartRecording(roomID, request.Directory); err != nil {
+		logrus.WithField("roomId", roomID).Errorf("Failed to start recording: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.broadcastRecording(RecordingData{
+		RoomID:      roomID,
+		RecordingID: request.RecordingID,
+		Active:      true,
+		StartedBy:   request.StartedBy,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Recording started",
+		"roomId":      roomID,
+		"recordingId": request.RecordingID,
+	})
+}
+
+// StopRecording menghentikan recording room di media server lalu memberitahu semua peserta (internal endpoint)
+func (h *Handler) StopRecording(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var request struct {
+		RecordingID string `json:"recording_id"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		StopRecording(roomID string) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support recording"})
+		return
+	}
+
+	if err := backend.StopRecording(roomID); err != nil {
+		logrus.WithField("roomId", roomID).Errorf("Failed to stop recording: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.broadcastRecording(RecordingData{
+		RoomID:      roomID,
+		RecordingID: request.RecordingID,
+		Active:      false,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Recording stopped",
+		"roomId":      roomID,
+		"recordingId": request.RecordingID,
+	})
+}
+
+// StartRTPForward mulai meneruskan media publisher room sebagai RTP (internal endpoint)
+func (h *Handler) StartRTPForward(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var forward RTPForward
+	if err := c.ShouldBindJSON(&forward); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		StartRTPForward(roomID string, forward RTPForward) (*RTPForwardStatus, error)
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support RTP forwarding"})
+		return
+	}
+
+	status, err := backend.StartRTPForward(roomID, forward)
+	if err != nil {
+		logrus.WithField("roomId", roomID).Errorf("Failed to start RTP forward: %v", err)
+		if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrForwardHostNotAllowed) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "RTP forward started",
+		"roomId":  roomID,
+		"data":    status,
+	})
+}
+
+// StopRTPForward menghentikan satu RTP forwarding room (internal endpoint)
+func (h *Handler) StopRTPForward(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		StopRTPForward(roomID, forwardID string) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support RTP forwarding"})
+		return
+	}
+
+	if err := backend.StopRTPForward(roomID, request.ID); err != nil {
+		logrus.WithField("roomId", roomID).Errorf("Failed to stop RTP forward: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "RTP forward stopped",
+		"roomId":  roomID,
+		"id":      request.ID,
+	})
+}
+
+// NotifyLiveStream mengirim status restream RTMP ke semua koneksi user (internal endpoint)
+func (h *Handler) NotifyLiveStream(c *gin.Context) {
+	userID := c.Param("userId")
+
+	var data LiveStreamData
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.Hub.UserMessage <- UserMessage{
+		UserID: userID,
+		Message: Message{
+			Type:      MessageTypeLiveStream,
+			RoomID:    data.RoomID,
+			UserID:    userID,
+			Data:      data,
+			Timestamp: time.Now(),
+		},
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Live stream status sent",
+		"userId":  userID,
+	})
+}
+
+// sipBackend adalah kemampuan opsional media backend untuk panggilan telepon SIP
+type sipBackend interface {
+	DialOut(roomID string, dial mediaplane.SIPDial) (*mediaplane.SIPCall, error)
+	HangupCall(roomID, callID string) error
+	ListCalls(roomID string) ([]mediaplane.SIPCall, error)
+}
+
+// DialOut menelepon nomor dan memasukkan penerima telepon ke room (internal endpoint)
+func (h *Handler) DialOut(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var dial mediaplane.SIPDial
+	if err := c.ShouldBindJSON(&dial); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(sipBackend)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support phone calls"})
+		return
+	}
+
+	call, err := backend.DialOut(roomID, dial)
+	if err != nil {
+		h.sipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Call started",
+		"roomId":  roomID,
+		"data":    call,
+	})
+}
+
+// HangupCall mengakhiri panggilan telepon di room (internal endpoint)
+func (h *Handler) HangupCall(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(sipBackend)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support phone calls"})
+		return
+	}
+
+	if err := backend.HangupCall(roomID, request.ID); err != nil {
+		h.sipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Call ended",
+		"roomId":  roomID,
+		"id":      request.ID,
+	})
+}
+
+// ListCalls mengembalikan panggilan telepon yang sedang berjalan di room (internal endpoint)
+func (h *Handler) ListCalls(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	backend, ok := h.Hub.SignalingHandler.(sipBackend)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support phone calls"})
+		return
+	}
+
+	calls, err := backend.ListCalls(roomID)
+	if err != nil {
+		h.sipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roomId": roomID,
+		"data":   calls,
+	})
+}
+
+// ReceiveDataMessage menerima pesan data channel yang di-POST Janus TextRoom
+// lalu meneruskannya ke media backend untuk disimpan
+func (h *Handler) ReceiveDataMessage(c *gin.Context) {
+	roomID := c.Param("roomId")
+
+	var message DataChannelMessage
+	if err := c.ShouldBindJSON(&message); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	backend, ok := h.Hub.SignalingHandler.(interface {
+		ReceiveDataMessage(roomID, signature string, message DataChannelMessage) error
+	})
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media backend does not support data channels"})
+		return
+	}
+
+	if err := backend.ReceiveDataMessage(roomID, c.Query("sig"), message); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"roomId": roomID,
+			"from":   message.From,
+		}).Warnf("Data channel message rejected: %v", err)
+
+		switch {
+		case errors.Is(err, ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, mediaplane.ErrParticipantNotFound):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Message received",
+		"roomId":  roomID,
+	})
+}
+
+// sipError mengubah error gateway SIP menjadi response HTTP
+func (h *Handler) sipError(c *gin.Context, err error) {
+	logrus.WithField("roomId", c.Param("roomId")).Errorf("Phone call request failed: %v", err)
+
+	switch {
+	case errors.Is(err, ErrSIPUnavailable):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrSIPCallNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+// broadcastRecording memberitahu semua peserta room tentang status recording
+func (h *Handler) broadcastRecording(data RecordingData) {
+	h.Hub.RoomMessage <- RoomMessage{
+		RoomID: data.RoomID,
+		Message: Message{
+			Type:      MessageTypeRecording,
+			RoomID:    data.RoomID,
+			Data:      data,
+			Timestamp: time.Now(),
+		},
+	}
+}
+
+// moderationError mengubah error media backend menjadi response HTTP
+func (h *Handler) moderationError(c *gin.Context, err error) {
+	logrus.WithFields(logrus.Fields{
+		"roomId": c.Param("roomId"),
+		"userId": c.Param("userId"),
+	}).Errorf("Moderation failed: %v", err)
+
+	if errors.Is(err, mediaplane.ErrParticipantNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 }
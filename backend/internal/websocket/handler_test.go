@@ -0,0 +1,126 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/webrtc-meeting/backend/models"
+)
+
+// fakeValidator adalah TokenValidator dengan token yang sudah ditentukan test
+type fakeValidator struct {
+	users map[string]uuid.UUID
+}
+
+func (v *fakeValidator) ValidateSession(token string) (*models.User, time.Time, error) {
+	userID, ok := v.users[token]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("token is expired")
+	}
+	return &models.User{ID: userID}, time.Now().Add(time.Hour), nil
+}
+
+// newTestServer menjalankan hub dan handler WebSocket di server HTTP lokal
+func newTestServer(t *testing.T, hub *Hub, configure func(*Handler)) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	go hub.Run()
+
+	handler := NewHandler(hub)
+	if configure != nil {
+		configure(handler)
+	}
+
+	router := gin.New()
+	handler.SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testConn adalah koneksi client WebSocket di test
+type testConn struct {
+	*websocket.Conn
+}
+
+// dial membuka koneksi WebSocket ke path server test
+func dial(server *httptest.Server, path string, header http.Header) (*testConn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + path
+	conn, response, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		return nil, response, err
+	}
+	return &testConn{Conn: conn}, response, nil
+}
+
+// mustDial membuka koneksi WebSocket dan menutupnya di akhir test
+func mustDial(t *testing.T, server *httptest.Server, path string, header http.Header) *testConn {
+	t.Helper()
+
+	conn, response, err := dial(server, path, header)
+	if err != nil {
+		status := 0
+		if response != nil {
+			status = response.StatusCode
+		}
+		t.Fatalf("failed to connect to %s (status %d): %v", path, status, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// send menulis pesan ke server
+func (c *testConn) send(t *testing.T, message Message) {
+	t.Helper()
+
+	if err := c.WriteJSON(message); err != nil {
+		t.Fatalf("failed to send %s: %v", message.Type, err)
+	}
+}
+
+// read membaca pesan berikutnya dari server
+func (c *testConn) read(t *testing.T) Message {
+	t.Helper()
+
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message Message
+	if err := c.ReadJSON(&message); err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	return message
+}
+
+// expect membaca pesan sampai menemukan pesan bertipe messageType
+func (c *testConn) expect(t *testing.T, messageType MessageType) Message {
+	t.Helper()
+
+	for {
+		if message := c.read(t); message.Type == messageType {
+			return message
+		}
+	}
+}
+
+// decode mengubah Message.Data hasil JSON menjadi struct data pesan
+func decode(t *testing.T, data interface{}, v interface{}) {
+	t.Helper()
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("failed to encode message data: %v", err)
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		t.Fatalf("failed to decode message data %s: %v", encoded, err)
+	}
+}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -26,50 +27,76 @@ func (h *Hub) Run() {
 
 		case directMessage := <-h.DirectMessage:
 			h.sendDirectMessage(directMessage)
+
+		case userMessage := <-h.UserMessage:
+			h.sendUserMessage(userMessage)
+
+		case envelope := <-h.remote:
+			h.handleEnvelope(envelope)
+
+		case request := <-h.resume:
+			h.handleResume(request)
+
+		case expiry := <-h.expire:
+			h.handleSessionExpiry(expiry)
 		}
 	}
 }
 
 // registerClient mendaftarkan client baru ke hub
 func (h *Hub) registerClient(client *Client) {
-	logrus.WithField("userId", client.UserID).Info("Registering new client")
-
+	h.addUserClient(client)
 	h.Clients[client] = true
+	h.sessions[client.SessionID] = client
+
+	logrus.WithFields(logrus.Fields{
+		"userId":   client.UserID,
+		"deviceId": client.DeviceID,
+	}).Info("Registering new client")
 
-	// Kirim pesan success ke client
+	// Kirim pesan success dan session ke client
 	client.SendSuccessMessage("Connected to WebSocket server")
+	h.sendSession(client, false)
 }
 
-// unregisterClient menghapus client dari hub dan semua room
+// unregisterClient menangani koneksi client yang terputus. Session disimpan selama
+// ResumeWindow agar client dapat resume tanpa kehilangan room dan handle media;
+// setelah itu client dikeluarkan dari semua room.
 func (h *Hub) unregisterClient(client *Client) {
-	logrus.WithField("userId", client.UserID).Info("Unregistering client")
-
-	if _, ok := h.Clients[client]; ok {
-		delete(h.Clients, client)
-
-		// Hapus client dari semua room
-		for roomID := range client.RoomIDs {
-			h.leaveRoom(client, roomID)
-		}
+	if _, ok := h.Clients[client]; !ok {
+		return
+	}
 
-		close(client.Send)
+	if h.ResumeWindow <= 0 || client.isClosed() {
+		h.endSession(client)
+		return
 	}
+
+	logrus.WithFields(logrus.Fields{
+		"userId":    client.UserID,
+		"sessionId": client.SessionID,
+	}).Info("Client disconnected, keeping session for resume")
+
+	expiry := sessionExpiry{client: client, attachments: client.attachmentCount()}
+	time.AfterFunc(h.ResumeWindow, func() {
+		h.expire <- expiry
+	})
 }
 
-// broadcastMessage mengirim pesan ke semua client yang terhubung
+// broadcastMessage mengirim pesan ke semua client yang terhubung di seluruh cluster
 func (h *Hub) broadcastMessage(message Message) {
 	logrus.WithFields(logrus.Fields{
 		"type": message.Type,
 	}).Info("Broadcasting message")
 
+	h.deliverToAll(message)
+	h.publish(Envelope{Kind: EnvelopeBroadcast, Message: message})
+}
+
+// deliverToAll mengirim pesan ke semua client lokal
+func (h *Hub) deliverToAll(message Message) {
 	for client := range h.Clients {
-		select {
-		case client.Send <- message:
-		default:
-			// Client tidak bisa menerima pesan, unregister
-			close(client.Send)
-			delete(h.Clients, client)
-		}
+		client.queue(message)
 	}
 }
 
@@ -86,51 +113,49 @@ func (h *Hub) handleRoomMessage(roomMessage RoomMessage) {
 
 	switch message.Type {
 	case MessageTypeJoinRoom:
-		h.handleJoinRoom(message, roomID)
+		h.handleJoinRoom(message, roomID, roomMessage.MaxUsers, roomMessage.Sender)
 	case MessageTypeLeaveRoom:
-		h.handleLeaveRoom(message, roomID)
+		h.handleLeaveRoom(message, roomID, roomMessage.Sender)
 	case MessageTypeOffer:
-		h.handleOfferMessage(message, roomID)
+		h.handleOfferMessage(message, roomID, roomMessage.Sender)
 	case MessageTypeAnswer:
-		h.handleAnswerMessage(message, roomID)
+		h.handleAnswerMessage(message, roomID, roomMessage.Sender)
 	case MessageTypeIceCandidate:
-		h.handleIceCandidateMessage(message, roomID)
+		h.handleIceCandidateMessage(message, roomID, roomMessage.Sender)
+	case MessageTypeSelectLayer:
+		h.handleSelectLayerMessage(message, roomID, roomMessage.Sender)
+	case MessageTypeTransferCall:
+		h.handleTransferCall(roomID, roomMessage.Sender)
 	default:
-		logrus.Warnf("Unknown room message type: %s", message.Type)
+		// Pesan lain (recording, broadcast admin) diteruskan ke semua peserta room
+		h.broadcastToRoom(roomID, message, nil)
 	}
 }
 
-// handleJoinRoom menangani client yang bergabung ke room
-func (h *Hub) handleJoinRoom(message Message, roomID string) {
-	var data JoinRoomData
-	if err := mapToStruct(message.Data, &data); err != nil {
-		logrus.Errorf("Error parsing join room data: %v", err)
+// handleJoinRoom menangani client yang bergabung ke room. Akses room sudah
+// diotorisasi client; hub hanya menegakkan batas jumlah user (0 = tanpa batas).
+// Perangkat pertama user di room memegang panggilan dan terhubung ke media server;
+// perangkat berikutnya hanya menerima pesan room sampai mengirim transfer-call.
+func (h *Hub) handleJoinRoom(message Message, roomID string, maxUsers int, sender *Client) {
+	// Session pengirim mungkin sudah berakhir sebelum pesan diproses
+	if sender == nil || !h.Clients[sender] {
+		logrus.Error("Sender client not found")
 		return
 	}
 
-	// Gunakan signaling handler jika tersedia
-	if h.SignalingHandler != nil {
-		if signalingHandler, ok := h.SignalingHandler.(interface {
-			HandleJoinRoom(roomID, userID, displayName string) error
-		}); ok {
-			if err := signalingHandler.HandleJoinRoom(roomID, data.UserID, data.UserID); err != nil {
-				logrus.Errorf("Error handling join room with signaling handler: %v", err)
-			}
-		}
+	if maxUsers > 0 && !sender.RoomIDs[roomID] && h.countOtherRoomUsers(roomID, sender.UserID) >= maxUsers {
+		logrus.WithFields(logrus.Fields{
+			"roomId":   roomID,
+			"userId":   sender.UserID,
+			"maxUsers": maxUsers,
+		}).Warn("Rejected join room: room is full")
+		sender.sendError(http.StatusForbidden, "Room is full")
+		return
 	}
 
-	// Cari client yang mengirim pesan
-	var sender *Client
-	for client := range h.Clients {
-		if client.UserID == data.UserID {
-			sender = client
-			break
-		}
-	}
-
-	if sender == nil {
-		logrus.Error("Sender client not found")
-		return
+	if holder := h.callDevice(roomID, sender.UserID); holder == nil || holder == sender {
+		h.setCallDevice(roomID, sender)
+		h.signalingJoin(roomID, sender.UserID)
 	}
 
 	// Tambahkan client ke room
@@ -140,37 +165,24 @@ func (h *Hub) handleJoinRoom(message Message, roomID string) {
 
 	// Jika client sudah ada di room, tidak perlu ditambahkan lagi
 	if !sender.RoomIDs[roomID] {
+		// User yang sudah ada di room melalui perangkat lain tidak diumumkan ulang
+		alreadyInRoom := len(h.userClientsInRoom(roomID, sender.UserID)) > 0
+
 		h.Rooms[roomID][sender] = true
 		sender.RoomIDs[roomID] = true
+		h.addRoomMember(roomID, sender.UserID)
 
 		logrus.WithFields(logrus.Fields{
-			"roomId": roomID,
-			"userId": sender.UserID,
+			"roomId":   roomID,
+			"userId":   sender.UserID,
+			"deviceId": sender.DeviceID,
 		}).Info("User joined room")
 
-		// Kumpulkan daftar user di room
-		var users []string
-		for client := range h.Rooms[roomID] {
-			users = append(users, client.UserID)
-		}
-
 		// Kirim konfirmasi ke sender
-		roomJoinedMsg := Message{
-			Type:   MessageTypeRoomJoined,
-			RoomID: roomID,
-			UserID: sender.UserID,
-			Data: RoomJoinedData{
-				RoomID: roomID,
-				UserID: sender.UserID,
-				Users:  users,
-			},
-			Timestamp: time.Now(),
-		}
+		sender.queue(h.roomJoinedMessage(roomID, sender))
 
-		select {
-		case sender.Send <- roomJoinedMsg:
-		default:
-			close(sender.Send)
+		if alreadyInRoom {
+			return
 		}
 
 		// Beritahu user lain di room bahwa ada user baru bergabung
@@ -188,98 +200,166 @@ func (h *Hub) handleJoinRoom(message Message, roomID string) {
 		h.broadcastToRoom(roomID, userJoinedMsg, sender)
 	} else {
 		// Client sudah ada di room, kirim daftar user saat ini
-		var users []string
-		for client := range h.Rooms[roomID] {
-			users = append(users, client.UserID)
-		}
+		sender.queue(h.roomJoinedMessage(roomID, sender))
+	}
+}
 
-		roomJoinedMsg := Message{
-			Type:   MessageTypeRoomJoined,
-			RoomID: roomID,
-			UserID: sender.UserID,
-			Data: RoomJoinedData{
-				RoomID: roomID,
-				UserID: sender.UserID,
-				Users:  users,
-			},
-			Timestamp: time.Now(),
-		}
+// roomJoinedMessage membuat pesan room-joined untuk client beserta daftar user
+// room dan perangkat yang memegang panggilan user
+func (h *Hub) roomJoinedMessage(roomID string, client *Client) Message {
+	callDeviceID := ""
+	if holder := h.callDevice(roomID, client.UserID); holder != nil {
+		callDeviceID = holder.DeviceID
+	}
 
-		select {
-		case sender.Send <- roomJoinedMsg:
-		default:
-			close(sender.Send)
-		}
+	return Message{
+		Type:   MessageTypeRoomJoined,
+		RoomID: roomID,
+		UserID: client.UserID,
+		Data: RoomJoinedData{
+			RoomID:       roomID,
+			UserID:       client.UserID,
+			DeviceID:     client.DeviceID,
+			CallDeviceID: callDeviceID,
+			Users:        h.roomUsers(roomID),
+		},
+		Timestamp: time.Now(),
 	}
 }
 
-// handleLeaveRoom menangani client yang keluar dari room
-func (h *Hub) handleLeaveRoom(message Message, roomID string) {
-	var data LeaveRoomData
-	if err := mapToStruct(message.Data, &data); err != nil {
-		logrus.Errorf("Error parsing leave room data: %v", err)
-		return
+// countOtherRoomUsers menghitung user berbeda di room selain excludeUserID di
+// seluruh cluster
+func (h *Hub) countOtherRoomUsers(roomID, excludeUserID string) int {
+	count := 0
+	for _, userID := range h.roomUsers(roomID) {
+		if userID != excludeUserID {
+			count++
+		}
 	}
+	return count
+}
 
-	// Gunakan signaling handler jika tersedia
-	if h.SignalingHandler != nil {
-		if signalingHandler, ok := h.SignalingHandler.(interface {
-			HandleLeaveRoom(roomID, userID string) error
-		}); ok {
-			if err := signalingHandler.HandleLeaveRoom(roomID, data.UserID); err != nil {
-				logrus.Errorf("Error handling leave room with signaling handler: %v", err)
-			}
+// roomUsers mengembalikan user ID unik dalam room di seluruh cluster. Tanpa
+// backplane, atau jika registry gagal dibaca, hanya client lokal yang dihitung.
+func (h *Hub) roomUsers(roomID string) []string {
+	seen := make(map[string]bool)
+	var users []string
+
+	if h.backplane != nil {
+		members, err := h.backplane.RoomMembers(roomID)
+		if err != nil {
+			logrus.WithField("roomId", roomID).Errorf("Failed to read cluster room members: %v", err)
+		}
+		for _, userID := range members {
+			seen[userID] = true
+			users = append(users, userID)
 		}
 	}
 
-	// Cari client yang mengirim pesan
-	var sender *Client
-	for client := range h.Clients {
-		if client.UserID == data.UserID {
-			sender = client
-			break
+	for client := range h.Rooms[roomID] {
+		if !seen[client.UserID] {
+			seen[client.UserID] = true
+			users = append(users, client.UserID)
 		}
 	}
 
+	return users
+}
+
+// handleLeaveRoom menangani client yang keluar dari room. Pesan dari server
+// (misalnya kick admin) tidak memiliki sender, sehingga semua perangkat user di room
+// dikeluarkan; jika user tidak lagi terhubung, handle media user tetap dilepas.
+func (h *Hub) handleLeaveRoom(message Message, roomID string, sender *Client) {
+	clients := []*Client{sender}
 	if sender == nil {
+		clients = h.userClientsInRoom(roomID, message.UserID)
+	}
+
+	if len(clients) == 0 {
+		h.signalingLeave(roomID, message.UserID)
 		logrus.Error("Sender client not found")
 		return
 	}
 
-	// Hapus client dari room
-	h.leaveRoom(sender, roomID)
+	for _, client := range clients {
+		h.departRoom(client, roomID)
 
-	// Kirim konfirmasi ke sender
-	roomLeftMsg := Message{
-		Type:   MessageTypeRoomLeft,
-		RoomID: roomID,
-		UserID: sender.UserID,
-		Data: RoomLeftData{
+		// Kirim konfirmasi ke client
+		client.queue(Message{
+			Type:   MessageTypeRoomLeft,
 			RoomID: roomID,
-			UserID: sender.UserID,
-		},
-		Timestamp: time.Now(),
+			UserID: client.UserID,
+			Data: RoomLeftData{
+				RoomID: roomID,
+				UserID: client.UserID,
+			},
+			Timestamp: time.Now(),
+		})
 	}
+}
 
-	select {
-	case sender.Send <- roomLeftMsg:
-	default:
-		close(sender.Send)
+// departRoom mengeluarkan client dari room. Handle media user dilepas jika client
+// memegang panggilan; peserta lain baru diberitahu ketika tidak ada lagi perangkat
+// user tersebut di room.
+func (h *Hub) departRoom(client *Client, roomID string) {
+	h.leaveRoom(client, roomID)
+
+	released := h.releaseCall(roomID, client)
+	if released {
+		h.signalingLeave(roomID, client.UserID)
+	}
+
+	if len(h.userClientsInRoom(roomID, client.UserID)) > 0 {
+		// Perangkat lain tetap di room tanpa panggilan sampai salah satunya transfer-call
+		if released {
+			h.notifyCallDevice(roomID, client.UserID, "", client.DeviceID)
+		}
+		return
 	}
 
 	// Beritahu user lain di room bahwa user telah keluar
 	userLeftMsg := Message{
 		Type:   MessageTypeUserLeft,
 		RoomID: roomID,
-		UserID: sender.UserID,
+		UserID: client.UserID,
 		Data: UserLeftData{
 			RoomID: roomID,
-			UserID: sender.UserID,
+			UserID: client.UserID,
 		},
 		Timestamp: time.Now(),
 	}
 
-	h.broadcastToRoom(roomID, userLeftMsg, sender)
+	h.broadcastToRoom(roomID, userLeftMsg, client)
+}
+
+// signalingJoin menghubungkan user ke room media melalui signaling handler
+func (h *Hub) signalingJoin(roomID, userID string) {
+	if h.SignalingHandler == nil {
+		return
+	}
+
+	if signalingHandler, ok := h.SignalingHandler.(interface {
+		HandleJoinRoom(roomID, userID, displayName string) error
+	}); ok {
+		if err := signalingHandler.HandleJoinRoom(roomID, userID, userID); err != nil {
+			logrus.Errorf("Error handling join room with signaling handler: %v", err)
+		}
+	}
+}
+
+// signalingLeave melepas handle media user di room melalui signaling handler
+func (h *Hub) signalingLeave(roomID, userID string) {
+	if h.SignalingHandler == nil {
+		return
+	}
+
+	if signalingHandler, ok := h.SignalingHandler.(interface {
+		HandleLeaveRoom(roomID, userID string) error
+	}); ok {
+		if err := signalingHandler.HandleLeaveRoom(roomID, userID); err != nil {
+			logrus.Errorf("Error handling leave room with signaling handler: %v", err)
+		}
+	}
 }
 
 // leaveRoom menghapus client dari room
@@ -293,6 +373,7 @@ func (h *Hub) leaveRoom(client *Client, roomID string) {
 			if len(roomClients) == 0 {
 				delete(h.Rooms, roomID)
 			}
+			h.removeRoomMember(roomID, client.UserID)
 
 			logrus.WithFields(logrus.Fields{
 				"roomId": roomID,
@@ -302,14 +383,18 @@ func (h *Hub) leaveRoom(client *Client, roomID string) {
 	}
 }
 
-// handleOfferMessage menangani pesan offer
-func (h *Hub) handleOfferMessage(message Message, roomID string) {
+// handleOfferMessage menangani pesan offer dari perangkat yang memegang panggilan
+func (h *Hub) handleOfferMessage(message Message, roomID string, sender *Client) {
 	var data OfferData
 	if err := mapToStruct(message.Data, &data); err != nil {
 		logrus.Errorf("Error parsing offer data: %v", err)
 		return
 	}
 
+	if !h.checkCallDevice(roomID, sender) {
+		return
+	}
+
 	// Gunakan signaling handler jika tersedia
 	if h.SignalingHandler != nil {
 		if signalingHandler, ok := h.SignalingHandler.(interface {
@@ -322,44 +407,35 @@ func (h *Hub) handleOfferMessage(message Message, roomID string) {
 		}
 	}
 
-	// Fallback ke routing langsung jika tidak ada signaling handler
-	// Cari client target
-	var targetClient *Client
-	for client := range h.Clients {
-		if client.UserID == data.ToUserID {
-			targetClient = client
-			break
-		}
-	}
-
+	// Fallback ke routing langsung jika tidak ada signaling handler. Target adalah
+	// perangkat user tujuan di room yang sama.
+	targetClient := h.signalingTarget(roomID, data.ToUserID, data.ToDeviceID)
 	if targetClient == nil {
-		logrus.Warnf("Target client not found: %s", data.ToUserID)
+		logrus.Warnf("Target client not found in room %s: %s", roomID, data.ToUserID)
 		return
 	}
 
-	// Pastikan target client ada di room yang sama
-	if !targetClient.RoomIDs[roomID] {
-		logrus.Warnf("Target client not in room: %s", roomID)
-		return
+	if sender != nil {
+		data.FromDeviceID = sender.DeviceID
 	}
+	message.Data = data
 
 	// Kirim offer ke target client
-	select {
-	case targetClient.Send <- message:
-	default:
-		close(targetClient.Send)
-		delete(h.Clients, targetClient)
-	}
+	targetClient.queue(message)
 }
 
-// handleAnswerMessage menangani pesan answer
-func (h *Hub) handleAnswerMessage(message Message, roomID string) {
+// handleAnswerMessage menangani pesan answer dari perangkat yang memegang panggilan
+func (h *Hub) handleAnswerMessage(message Message, roomID string, sender *Client) {
 	var data AnswerData
 	if err := mapToStruct(message.Data, &data); err != nil {
 		logrus.Errorf("Error parsing answer data: %v", err)
 		return
 	}
 
+	if !h.checkCallDevice(roomID, sender) {
+		return
+	}
+
 	// Gunakan signaling handler jika tersedia
 	if h.SignalingHandler != nil {
 		if signalingHandler, ok := h.SignalingHandler.(interface {
@@ -372,44 +448,35 @@ func (h *Hub) handleAnswerMessage(message Message, roomID string) {
 		}
 	}
 
-	// Fallback ke routing langsung jika tidak ada signaling handler
-	// Cari client target
-	var targetClient *Client
-	for client := range h.Clients {
-		if client.UserID == data.ToUserID {
-			targetClient = client
-			break
-		}
-	}
-
+	// Fallback ke routing langsung jika tidak ada signaling handler. Target adalah
+	// perangkat user tujuan di room yang sama.
+	targetClient := h.signalingTarget(roomID, data.ToUserID, data.ToDeviceID)
 	if targetClient == nil {
-		logrus.Warnf("Target client not found: %s", data.ToUserID)
+		logrus.Warnf("Target client not found in room %s: %s", roomID, data.ToUserID)
 		return
 	}
 
-	// Pastikan target client ada di room yang sama
-	if !targetClient.RoomIDs[roomID] {
-		logrus.Warnf("Target client not in room: %s", roomID)
-		return
+	if sender != nil {
+		data.FromDeviceID = sender.DeviceID
 	}
+	message.Data = data
 
 	// Kirim answer ke target client
-	select {
-	case targetClient.Send <- message:
-	default:
-		close(targetClient.Send)
-		delete(h.Clients, targetClient)
-	}
+	targetClient.queue(message)
 }
 
-// handleIceCandidateMessage menangani pesan ice-candidate
-func (h *Hub) handleIceCandidateMessage(message Message, roomID string) {
+// handleIceCandidateMessage menangani pesan ice-candidate dari perangkat yang memegang panggilan
+func (h *Hub) handleIceCandidateMessage(message Message, roomID string, sender *Client) {
 	var data IceCandidateData
 	if err := mapToStruct(message.Data, &data); err != nil {
 		logrus.Errorf("Error parsing ice candidate data: %v", err)
 		return
 	}
 
+	if !h.checkCallDevice(roomID, sender) {
+		return
+	}
+
 	// Gunakan signaling handler jika tersedia
 	if h.SignalingHandler != nil {
 		if signalingHandler, ok := h.SignalingHandler.(interface {
@@ -422,56 +489,75 @@ func (h *Hub) handleIceCandidateMessage(message Message, roomID string) {
 		}
 	}
 
-	// Fallback ke routing langsung jika tidak ada signaling handler
-	// Cari client target
-	var targetClient *Client
-	for client := range h.Clients {
-		if client.UserID == data.ToUserID {
-			targetClient = client
-			break
-		}
+	// Fallback ke routing langsung jika tidak ada signaling handler. Target adalah
+	// perangkat user tujuan di room yang sama.
+	targetClient := h.signalingTarget(roomID, data.ToUserID, data.ToDeviceID)
+	if targetClient == nil {
+		logrus.Warnf("Target client not found in room %s: %s", roomID, data.ToUserID)
+		return
 	}
 
-	if targetClient == nil {
-		logrus.Warnf("Target client not found: %s", data.ToUserID)
+	if sender != nil {
+		data.FromDeviceID = sender.DeviceID
+	}
+	message.Data = data
+
+	// Kirim ice candidate ke target client
+	targetClient.queue(message)
+}
+
+// handleSelectLayerMessage menangani pesan select-layer. Pemilihan layer hanya
+// berarti bagi media server, jadi tidak ada fallback routing langsung.
+func (h *Hub) handleSelectLayerMessage(message Message, roomID string, sender *Client) {
+	var data SelectLayerData
+	if err := mapToStruct(message.Data, &data); err != nil {
+		logrus.Errorf("Error parsing select layer data: %v", err)
 		return
 	}
 
-	// Pastikan target client ada di room yang sama
-	if !targetClient.RoomIDs[roomID] {
-		logrus.Warnf("Target client not in room: %s", roomID)
+	if !h.checkCallDevice(roomID, sender) {
 		return
 	}
 
-	// Kirim ice candidate ke target client
-	select {
-	case targetClient.Send <- message:
-	default:
-		close(targetClient.Send)
-		delete(h.Clients, targetClient)
+	if h.SignalingHandler == nil {
+		return
+	}
+
+	signalingHandler, ok := h.SignalingHandler.(interface {
+		HandleSelectLayer(roomID, fromUserID, toUserID string, data SelectLayerData) error
+	})
+	if !ok {
+		logrus.Warn("Signaling handler does not support layer selection")
+		return
+	}
+
+	if err := signalingHandler.HandleSelectLayer(roomID, data.FromUserID, data.ToUserID, data); err != nil {
+		logrus.Errorf("Error handling select layer with signaling handler: %v", err)
 	}
 }
 
-// broadcastToRoom mengirim pesan ke semua client dalam room kecuali sender
+// broadcastToRoom mengirim pesan ke semua client dalam room di seluruh cluster
+// kecuali sender. Di node lain, semua koneksi milik user sender dilewati.
 func (h *Hub) broadcastToRoom(roomID string, message Message, sender *Client) {
+	h.deliverToRoom(roomID, message, func(client *Client) bool {
+		return client == sender
+	})
+
+	envelope := Envelope{Kind: EnvelopeRoom, RoomID: roomID, Message: message}
+	if sender != nil {
+		envelope.ExcludeUserID = sender.UserID
+	}
+	h.publish(envelope)
+}
+
+// deliverToRoom mengirim pesan ke client lokal dalam room kecuali yang dikecualikan
+func (h *Hub) deliverToRoom(roomID string, message Message, exclude func(*Client) bool) {
 	if roomClients, exists := h.Rooms[roomID]; exists {
 		for client := range roomClients {
-			if client != sender {
-				select {
-				case client.Send <- message:
-				default:
-					// Client tidak bisa menerima pesan, unregister
-					close(client.Send)
-					delete(h.Clients, client)
-					delete(roomClients, client)
-				}
+			if !exclude(client) {
+				client.queue(message)
 			}
 		}
-
-		// Jika room kosong, hapus room
-		if len(roomClients) == 0 {
-			delete(h.Rooms, roomID)
-		}
 	}
 }
 
@@ -485,49 +571,85 @@ func (h *Hub) sendDirectMessage(directMessage DirectMessage) {
 		"userId": client.UserID,
 	}).Info("Sending direct message")
 
-	select {
-	case client.Send <- message:
-	default:
-		// Client tidak bisa menerima pesan, unregister
-		close(client.Send)
-		delete(h.Clients, client)
-	}
+	client.queue(message)
 }
 
-// GetRoomUsers mengembalikan daftar user ID dalam room tertentu
-func (h *Hub) GetRoomUsers(roomID string) []string {
-	var users []string
+// sendUserMessage mengirim pesan ke client milik user tertentu. Pesan hanya
+// disebarkan ke node lain jika tidak ada client tujuan di node ini, karena pesan
+// signaling media selalu ditujukan ke koneksi yang ditangani node yang sama.
+func (h *Hub) sendUserMessage(userMessage UserMessage) {
+	message := userMessage.Message
 
-	if roomClients, exists := h.Rooms[roomID]; exists {
-		for client := range roomClients {
-			users = append(users, client.UserID)
+	logrus.WithFields(logrus.Fields{
+		"type":     message.Type,
+		"userId":   userMessage.UserID,
+		"deviceId": userMessage.DeviceID,
+	}).Debug("Sending user message")
+
+	if h.deliverToUser(userMessage.UserID, userMessage.DeviceID, message) == 0 {
+		h.publish(Envelope{
+			Kind:     EnvelopeUser,
+			UserID:   userMessage.UserID,
+			DeviceID: userMessage.DeviceID,
+			Message:  message,
+		})
+	}
+}
+
+// deliverToUser mengirim pesan ke client lokal milik user dan mengembalikan jumlah
+// client yang dituju. Dengan deviceID hanya perangkat tersebut yang dituju; pesan
+// room dari media server hanya dikirim ke perangkat yang memegang panggilan user.
+func (h *Hub) deliverToUser(userID, deviceID string, message Message) int {
+	if deviceID == "" && message.RoomID != "" {
+		if holder := h.callDevice(message.RoomID, userID); holder != nil {
+			holder.queue(message)
+			return 1
 		}
 	}
 
-	return users
+	clients := h.userClients(userID, deviceID)
+	for _, client := range clients {
+		client.queue(message)
+	}
+
+	return len(clients)
+}
+
+// GetRoomUsers mengembalikan daftar user ID dalam room tertentu di seluruh cluster
+func (h *Hub) GetRoomUsers(roomID string) []string {
+	return h.roomUsers(roomID)
 }
 
-// GetClientRooms mengembalikan daftar room ID untuk client tertentu
+// GetClientRooms mengembalikan daftar room ID untuk user tertentu di seluruh cluster
 func (h *Hub) GetClientRooms(userID string) []string {
+	if h.backplane != nil {
+		rooms, err := h.backplane.UserRooms(userID)
+		if err == nil {
+			return rooms
+		}
+		logrus.WithField("userId", userID).Errorf("Failed to read cluster user rooms: %v", err)
+	}
+
+	seen := make(map[string]bool)
 	var rooms []string
 
-	for client := range h.Clients {
-		if client.UserID == userID {
-			for roomID := range client.RoomIDs {
+	for client := range h.Users[userID] {
+		for roomID := range client.RoomIDs {
+			if !seen[roomID] {
+				seen[roomID] = true
 				rooms = append(rooms, roomID)
 			}
-			break
 		}
 	}
 
 	return rooms
 }
 
-// IsClientInRoom memeriksa apakah client ada di room tertentu
+// IsClientInRoom memeriksa apakah user ada di room tertentu di seluruh cluster
 func (h *Hub) IsClientInRoom(userID, roomID string) bool {
-	for client := range h.Clients {
-		if client.UserID == userID {
-			return client.RoomIDs[roomID]
+	for _, room := range h.GetClientRooms(userID) {
+		if room == roomID {
+			return true
 		}
 	}
 	return false
@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"errors"
+	"sync"
+)
+
+// Jumlah maksimum pesan yang menunggu dikirim ke hub per outbox
+const maxOutboxMessages = 4096
+
+// ErrOutboxFull dikembalikan ketika antrean outbox penuh dan pesan dibuang
+var ErrOutboxFull = errors.New("outbox is full")
+
+// Outbox mengantre pesan untuk user dan meneruskannya ke hub satu per satu
+// sesuai urutan pengiriman. Offer, answer dan ICE candidate untuk peer yang sama
+// harus tiba berurutan, sehingga pengirim yang tidak boleh memblokir (misalnya
+// media backend yang dipanggil dari loop hub) memakai outbox alih-alih goroutine
+// per pesan ketika buffer UserMessage penuh.
+type Outbox struct {
+	hub *Hub
+
+	mu     sync.Mutex
+	queue  []UserMessage
+	closed bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+// NewOutbox membuat outbox untuk hub dan menjalankan pengirimnya
+func NewOutbox(hub *Hub) *Outbox {
+	o := &Outbox{
+		hub:  hub,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	go o.run()
+	return o
+}
+
+// Send mengantre pesan untuk user tanpa memblokir. ErrOutboxFull dikembalikan
+// jika antrean penuh karena hub tidak lagi membaca pesan.
+func (o *Outbox) Send(userID string, message Message) error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	if len(o.queue) >= maxOutboxMessages {
+		o.mu.Unlock()
+		return ErrOutboxFull
+	}
+	o.queue = append(o.queue, UserMessage{UserID: userID, Message: message})
+	o.mu.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close menghentikan pengirim. Pesan yang masih mengantre dibuang.
+func (o *Outbox) Close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return
+	}
+	o.closed = true
+	o.queue = nil
+	close(o.done)
+}
+
+// run meneruskan pesan ke hub sesuai urutan antrean
+func (o *Outbox) run() {
+	for {
+		select {
+		case <-o.wake:
+		case <-o.done:
+			return
+		}
+
+		for {
+			o.mu.Lock()
+			if len(o.queue) == 0 {
+				o.mu.Unlock()
+				break
+			}
+			userMessage := o.queue[0]
+			o.queue[0] = UserMessage{}
+			o.queue = o.queue[1:]
+			o.mu.Unlock()
+
+			select {
+			case o.hub.UserMessage <- userMessage:
+			case <-o.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package websocket
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Lama default session disimpan setelah koneksi client terputus
+	defaultResumeWindow = 30 * time.Second
+
+	// Jumlah pesan yang sudah ditulis tetapi belum di-ack yang disimpan untuk replay
+	replayBufferSize = 512
+
+	// Jumlah pesan yang belum ditulis sebelum session dianggap tidak dapat mengejar
+	// dan diakhiri
+	maxPendingMessages = 1024
+)
+
+// connection adalah satu koneksi WebSocket yang dipasang ke session beserta channel
+// milik pump-nya. Setiap resume membuat connection baru sehingga pump koneksi lama
+// tidak dapat mengambil pesan milik koneksi baru.
+type connection struct {
+	conn *websocket.Conn
+
+	// wake membangunkan WritePump ketika ada pesan baru di outbox
+	wake chan struct{}
+
+	// reauth membawa waktu kedaluwarsa token baru dari ReadPump ke WritePump
+	reauth chan time.Time
+
+	// done ditutup ketika koneksi dilepas dari session
+	done chan struct{}
+}
+
+// resumeRequest adalah permintaan memasang koneksi baru ke session yang tersimpan
+type resumeRequest struct {
+	sessionID string
+	userID    string
+	lastSeq   uint64
+	conn      *websocket.Conn
+	expiresAt time.Time
+	result    chan resumeResult
+}
+
+// resumeResult adalah hasil resumeRequest
+type resumeResult struct {
+	client *Client
+	err    error
+}
+
+// sessionExpiry menandai batas waktu resume session. attachments adalah jumlah
+// koneksi session saat terputus, sehingga session yang sudah di-resume tidak diakhiri.
+type sessionExpiry struct {
+	client      *Client
+	attachments uint64
+}
+
+// attach memasang koneksi ke session. Dipanggil saat c.mu dipegang atau sebelum
+// client dipakai bersama.
+func (c *Client) attach(conn *websocket.Conn) *connection {
+	c.current = &connection{
+		conn:   conn,
+		wake:   make(chan struct{}, 1),
+		reauth: make(chan time.Time, 1),
+		done:   make(chan struct{}),
+	}
+	c.Conn = conn
+	c.attachments++
+
+	// Pesan yang tertunda selama terputus langsung dikirim
+	c.current.wake <- struct{}{}
+
+	return c.current
+}
+
+// connection mengembalikan koneksi yang sedang dipakai session (nil saat terputus)
+func (c *Client) connection() *connection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+// detach melepas koneksi dari session. Mengembalikan false jika koneksi sudah
+// digantikan koneksi lain melalui resume.
+func (c *Client) detach(cur *connection) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.current != cur {
+		return false
+	}
+
+	close(cur.done)
+	c.current = nil
+	c.Conn = nil
+	return true
+}
+
+// detachedSince memeriksa apakah session masih terputus sejak koneksi ke-attachments
+func (c *Client) detachedSince(attachments uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current == nil && c.attachments == attachments
+}
+
+// attachmentCount mengembalikan jumlah koneksi yang pernah dipasang ke session
+func (c *Client) attachmentCount() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.attachments
+}
+
+// isClosed memeriksa apakah session sudah berakhir
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// queue memberi nomor urut pada pesan lalu menyimpannya di outbox untuk ditulis
+// WritePump. Pesan tetap disimpan ketika client terputus, tetapi session diakhiri
+// jika terlalu banyak pesan yang belum tertulis.
+func (c *Client) queue(message Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	c.seq++
+	message.Seq = c.seq
+	c.outbox = append(c.outbox, message)
+
+	pending := int(c.seq - c.written)
+	if pending > maxPendingMessages {
+		logrus.WithFields(logrus.Fields{
+			"userId":    c.UserID,
+			"sessionId": c.SessionID,
+		}).Warn("Client cannot keep up with messages, closing session")
+		c.closeLocked()
+		return
+	}
+
+	// Pesan yang sudah ditulis hanya disimpan sebanyak replayBufferSize
+	if excess := len(c.outbox) - pending - replayBufferSize; excess > 0 {
+		c.outbox = c.outbox[excess:]
+	}
+
+	if c.current != nil {
+		select {
+		case c.current.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// nextBatch mengambil pesan yang belum ditulis ke koneksi cur. stale bernilai true
+// jika cur bukan lagi koneksi session.
+func (c *Client) nextBatch(cur *connection) (messages []Message, closed, stale bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.current != cur {
+		return nil, false, true
+	}
+
+	for _, message := range c.outbox {
+		if message.Seq > c.written {
+			messages = append(messages, message)
+		}
+	}
+	c.written = c.seq
+
+	return messages, c.closed, false
+}
+
+// ack menghapus pesan yang sudah diterima client dari replay buffer
+func (c *Client) ack(seq uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	acked := 0
+	for acked < len(c.outbox) && c.outbox[acked].Seq <= seq && c.outbox[acked].Seq <= c.written {
+		acked++
+	}
+	c.outbox = c.outbox[acked:]
+}
+
+// resume memasang koneksi baru ke session dan menjadwalkan pengiriman ulang semua
+// pesan setelah lastSeq. Gagal jika session sudah berakhir atau pesan yang terlewat
+// sudah tidak ada di replay buffer.
+func (c *Client) resume(conn *websocket.Conn, lastSeq uint64, expiresAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.New("session has ended")
+	}
+
+	if lastSeq > c.seq {
+		return errors.New("invalid sequence number")
+	}
+
+	oldest := c.seq + 1
+	if len(c.outbox) > 0 {
+		oldest = c.outbox[0].Seq
+	}
+	if lastSeq+1 < oldest {
+		return errors.New("missed messages are no longer available")
+	}
+
+	received := 0
+	for received < len(c.outbox) && c.outbox[received].Seq <= lastSeq {
+		received++
+	}
+	c.outbox = c.outbox[received:]
+	c.written = lastSeq
+
+	// Client dapat reconnect sebelum server menyadari koneksi lamanya putus
+	if previous := c.current; previous != nil {
+		close(previous.done)
+		previous.conn.Close()
+	}
+
+	c.ExpiresAt = expiresAt
+	c.attach(conn)
+
+	return nil
+}
+
+// reauthenticate meneruskan waktu kedaluwarsa token baru ke WritePump koneksi aktif
+func (c *Client) reauthenticate(expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.current == nil {
+		return
+	}
+
+	// Hanya ReadPump koneksi ini yang mengirim ke reauth, sehingga setelah
+	// dikosongkan pengiriman berikut tidak akan blocking
+	select {
+	case <-c.current.reauth:
+	default:
+	}
+	c.current.reauth <- expiresAt
+}
+
+// Close mengakhiri session client. Koneksi ditutup setelah pesan yang tersisa
+// ditulis dan session tidak dapat di-resume.
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+// closeLocked mengakhiri session. Dipanggil saat c.mu dipegang.
+func (c *Client) closeLocked() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+
+	if c.current != nil {
+		select {
+		case c.current.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// handleAck menangani pesan ack dari client
+func (c *Client) handleAck(message Message) {
+	var data AckData
+	if err := mapToStruct(message.Data, &data); err != nil {
+		c.SendErrorMessage("Invalid ack data")
+		return
+	}
+
+	c.ack(data.Seq)
+}
+
+// Resume memasang koneksi baru ke session yang tersimpan milik user. Pesan yang
+// terlewat dikirim ulang, diikuti pesan session dengan Resumed true.
+func (h *Hub) Resume(sessionID, userID string, lastSeq uint64, conn *websocket.Conn, expiresAt time.Time) (*Client, error) {
+	result := make(chan resumeResult, 1)
+	h.resume <- resumeRequest{
+		sessionID: sessionID,
+		userID:    userID,
+		lastSeq:   lastSeq,
+		conn:      conn,
+		expiresAt: expiresAt,
+		result:    result,
+	}
+
+	resumed := <-result
+	return resumed.client, resumed.err
+}
+
+// handleResume memproses resumeRequest di loop hub
+func (h *Hub) handleResume(request resumeRequest) {
+	client, exists := h.sessions[request.sessionID]
+	if !exists || client.UserID != request.userID {
+		request.result <- resumeResult{err: errors.New("session not found")}
+		return
+	}
+
+	if err := client.resume(request.conn, request.lastSeq, request.expiresAt); err != nil {
+		request.result <- resumeResult{err: err}
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"userId":    client.UserID,
+		"sessionId": client.SessionID,
+		"lastSeq":   request.lastSeq,
+	}).Info("Client session resumed")
+
+	h.sendSession(client, true)
+	request.result <- resumeResult{client: client}
+}
+
+// handleSessionExpiry mengakhiri session yang tidak di-resume dalam ResumeWindow
+func (h *Hub) handleSessionExpiry(expiry sessionExpiry) {
+	if _, ok := h.Clients[expiry.client]; !ok {
+		return
+	}
+
+	if expiry.client.detachedSince(expiry.attachments) {
+		h.endSession(expiry.client)
+	}
+}
+
+// endSession menghapus client dari hub, mengeluarkannya dari semua room lalu
+// menutup session-nya
+func (h *Hub) endSession(client *Client) {
+	logrus.WithFields(logrus.Fields{
+		"userId":    client.UserID,
+		"sessionId": client.SessionID,
+		"deviceId":  client.DeviceID,
+	}).Info("Ending client session")
+
+	delete(h.Clients, client)
+	delete(h.sessions, client.SessionID)
+	h.removeUserClient(client)
+
+	for roomID := range client.RoomIDs {
+		h.departRoom(client, roomID)
+	}
+
+	client.Close()
+}
+
+// sendSession mengirim status session ke client
+func (h *Hub) sendSession(client *Client, resumed bool) {
+	client.queue(Message{
+		Type:   MessageTypeSession,
+		UserID: client.UserID,
+		Data: SessionData{
+			SessionID:    client.SessionID,
+			DeviceID:     client.DeviceID,
+			Resumed:      resumed,
+			ResumeWindow: int(h.ResumeWindow / time.Second),
+		},
+		Timestamp: time.Now(),
+	})
+}
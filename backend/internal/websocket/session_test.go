@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// connectSession membuka koneksi ke path lalu mengembalikan data session-nya
+func connectSession(t *testing.T, server *httptest.Server, path string) (*testConn, SessionData) {
+	t.Helper()
+
+	conn := mustDial(t, server, path, nil)
+	var session SessionData
+	decode(t, conn.expect(t, MessageTypeSession).Data, &session)
+	return conn, session
+}
+
+// resumePath membuat path koneksi yang me-resume session user
+func resumePath(userID, sessionID string, lastSeq uint64) string {
+	return fmt.Sprintf("/ws?userId=%s&resume=%s&lastSeq=%d", userID, sessionID, lastSeq)
+}
+
+func TestResumeReplaysUnackedMessages(t *testing.T) {
+	hub := NewHub()
+	hub.AuthorizeJoin = allowJoin(0)
+	server := newTestServer(t, hub, func(h *Handler) { h.AllowUserID = true })
+
+	alice, session := connectSession(t, server, "/ws?userId=alice&deviceId=phone")
+	joined := alice.joinRoom(t, "room-1")
+	if joined.Type != MessageTypeRoomJoined {
+		t.Fatalf("alice rejected: %+v", joined)
+	}
+
+	bob := connectUser(t, server, "bob", "laptop")
+	bob.joinRoom(t, "room-1")
+	userJoined := alice.expect(t, MessageTypeUserJoined)
+
+	// Koneksi putus setelah user-joined diterima tetapi sebelum diproses client
+	alice.Close()
+
+	// Pesan selama terputus disimpan di session
+	if status := postJSON(t, server, "/api/v1/websocket/admin/users/alice/message", `{"type":"notice","data":{}}`); status != http.StatusOK {
+		t.Fatalf("message to disconnected user returned %d", status)
+	}
+
+	// Pesan setelah lastSeq dikirim ulang dengan nomor urut asli, diikuti session
+	replay := mustDial(t, server, resumePath("alice", session.SessionID, joined.Seq), nil)
+	previous := joined.Seq
+	for _, messageType := range []MessageType{MessageTypeUserJoined, "notice", MessageTypeSession} {
+		message := replay.read(t)
+		if message.Type != messageType || message.Seq != previous+1 {
+			t.Fatalf("expected %s with seq %d, got %s with seq %d", messageType, previous+1, message.Type, message.Seq)
+		}
+		if message.Type == MessageTypeUserJoined && message.Seq != userJoined.Seq {
+			t.Fatalf("user-joined replayed with seq %d, originally %d", message.Seq, userJoined.Seq)
+		}
+		if message.Type == MessageTypeSession {
+			var resumed SessionData
+			decode(t, message.Data, &resumed)
+			if !resumed.Resumed || resumed.SessionID != session.SessionID {
+				t.Fatalf("session was not resumed: %+v", resumed)
+			}
+		}
+		previous = message.Seq
+	}
+
+	// Session tetap berada di room selama terputus
+	bob.send(t, Message{Type: MessageTypeLeaveRoom, Data: LeaveRoomData{RoomID: "room-1"}})
+	if message := replay.expect(t, MessageTypeUserLeft); message.UserID != "bob" {
+		t.Fatalf("resumed session did not receive room messages: %+v", message)
+	}
+}
+
+func TestResumeRejectsOtherUserAndAckedMessages(t *testing.T) {
+	hub := NewHub()
+	hub.AuthorizeJoin = allowJoin(0)
+	server := newTestServer(t, hub, func(h *Handler) { h.AllowUserID = true })
+
+	alice, session := connectSession(t, server, "/ws?userId=alice&deviceId=phone")
+	joined := alice.joinRoom(t, "room-1")
+
+	// Ack diproses berurutan di ReadPump; room-joined berikutnya memastikan ack sudah diterapkan
+	alice.send(t, Message{Type: MessageTypeAck, Data: AckData{Seq: joined.Seq}})
+	alice.joinRoom(t, "room-2")
+	alice.Close()
+
+	// User lain tidak dapat mengambil alih session walaupun mengetahui ID-nya
+	if _, mallory := connectSession(t, server, resumePath("mallory", session.SessionID, 0)); mallory.Resumed || mallory.SessionID == session.SessionID {
+		t.Fatalf("session resumed by another user: %+v", mallory)
+	}
+
+	// Pesan yang sudah di-ack tidak dapat diminta ulang, client mendapat session baru
+	_, fresh := connectSession(t, server, resumePath("alice", session.SessionID, 1))
+	if fresh.Resumed || fresh.SessionID == session.SessionID {
+		t.Fatalf("resume from acked sequence accepted: %+v", fresh)
+	}
+
+	// Nomor urut di depan server juga ditolak
+	_, ahead := connectSession(t, server, resumePath("alice", session.SessionID, joined.Seq+1000))
+	if ahead.Resumed {
+		t.Fatalf("resume from future sequence accepted: %+v", ahead)
+	}
+
+	// Session asli tetap dapat di-resume dari pesan yang sudah di-ack
+	if _, resumed := connectSession(t, server, resumePath("alice", session.SessionID, joined.Seq)); !resumed.Resumed {
+		t.Fatalf("valid resume rejected: %+v", resumed)
+	}
+}
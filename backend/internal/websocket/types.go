@@ -1,8 +1,12 @@
 package websocket
 
 import (
+	"errors"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -14,18 +18,37 @@ const (
 	MessageTypeOffer        MessageType = "offer"
 	MessageTypeAnswer       MessageType = "answer"
 	MessageTypeIceCandidate MessageType = "ice-candidate"
+	MessageTypeSelectLayer  MessageType = "select-layer"
 	MessageTypeJoinRoom     MessageType = "join-room"
 	MessageTypeLeaveRoom    MessageType = "leave-room"
 	MessageTypeRoomJoined   MessageType = "room-joined"
 	MessageTypeRoomLeft     MessageType = "room-left"
 	MessageTypeUserJoined   MessageType = "user-joined"
 	MessageTypeUserLeft     MessageType = "user-left"
+	MessageTypeMediaEvent   MessageType = "media-event"
+	MessageTypeRecording    MessageType = "recording"
+	MessageTypeLiveStream   MessageType = "live-stream"
 	MessageTypeError        MessageType = "error"
 	MessageTypeSuccess      MessageType = "success"
+
+	// Pembaruan access token pada koneksi yang sudah terbuka
+	MessageTypeAuthenticate  MessageType = "authenticate"
+	MessageTypeAuthenticated MessageType = "authenticated"
+
+	// Pengiriman andal: status session dan konfirmasi nomor urut dari client
+	MessageTypeSession MessageType = "session"
+	MessageTypeAck     MessageType = "ack"
+
+	// Pemindahan panggilan user ke perangkat lain dan pemberitahuan perangkat
+	// yang memegang panggilan
+	MessageTypeTransferCall MessageType = "transfer-call"
+	MessageTypeCallDevice   MessageType = "call-device"
 )
 
-// Message adalah struktur dasar untuk semua pesan WebSocket
+// Message adalah struktur dasar untuk semua pesan WebSocket. Seq adalah nomor urut
+// pesan server dalam session client, dimulai dari 1 dan berlanjut setelah resume.
 type Message struct {
+	Seq       uint64      `json:"seq,omitempty"`
 	Type      MessageType `json:"type"`
 	RoomID    string      `json:"roomId,omitempty"`
 	UserID    string      `json:"userId,omitempty"`
@@ -45,11 +68,15 @@ type LeaveRoomData struct {
 	UserID string `json:"userId"`
 }
 
-// RoomJoinedData adalah data untuk pesan room-joined
+// RoomJoinedData adalah data untuk pesan room-joined. CallDeviceID adalah perangkat
+// user yang memegang panggilan di room; jika bukan DeviceID, perangkat ini hanya
+// menerima pesan room dan dapat memindahkan panggilan dengan transfer-call.
 type RoomJoinedData struct {
-	RoomID string   `json:"roomId"`
-	UserID string   `json:"userId"`
-	Users  []string `json:"users"`
+	RoomID       string   `json:"roomId"`
+	UserID       string   `json:"userId"`
+	DeviceID     string   `json:"deviceId"`
+	CallDeviceID string   `json:"callDeviceId,omitempty"`
+	Users        []string `json:"users"`
 }
 
 // RoomLeftData adalah data untuk pesan room-left
@@ -70,32 +97,216 @@ type UserLeftData struct {
 	UserID string `json:"userId"`
 }
 
-// OfferData adalah data untuk pesan offer
+// OfferData adalah data untuk pesan offer. ToDeviceID mengarahkan pesan ke perangkat
+// tertentu milik ToUserID; FromDeviceID diisi server.
 type OfferData struct {
-	RoomID     string `json:"roomId"`
-	FromUserID string `json:"fromUserId"`
-	ToUserID   string `json:"toUserId"`
-	SDP        string `json:"sdp"`
+	RoomID       string `json:"roomId"`
+	FromUserID   string `json:"fromUserId"`
+	ToUserID     string `json:"toUserId"`
+	FromDeviceID string `json:"fromDeviceId,omitempty"`
+	ToDeviceID   string `json:"toDeviceId,omitempty"`
+	SDP          string `json:"sdp"`
 }
 
-// AnswerData adalah data untuk pesan answer
+// AnswerData adalah data untuk pesan answer. ToDeviceID mengarahkan pesan ke perangkat
+// tertentu milik ToUserID; FromDeviceID diisi server.
 type AnswerData struct {
-	RoomID     string `json:"roomId"`
-	FromUserID string `json:"fromUserId"`
-	ToUserID   string `json:"toUserId"`
-	SDP        string `json:"sdp"`
+	RoomID       string `json:"roomId"`
+	FromUserID   string `json:"fromUserId"`
+	ToUserID     string `json:"toUserId"`
+	FromDeviceID string `json:"fromDeviceId,omitempty"`
+	ToDeviceID   string `json:"toDeviceId,omitempty"`
+	SDP          string `json:"sdp"`
 }
 
-// IceCandidateData adalah data untuk pesan ice-candidate
+// IceCandidateData adalah data untuk pesan ice-candidate. Seperti offer,
+// ToDeviceID bersifat opsional dan FromDeviceID diisi server.
 type IceCandidateData struct {
 	RoomID        string `json:"roomId"`
 	FromUserID    string `json:"fromUserId"`
 	ToUserID      string `json:"toUserId"`
+	FromDeviceID  string `json:"fromDeviceId,omitempty"`
+	ToDeviceID    string `json:"toDeviceId,omitempty"`
 	Candidate     string `json:"candidate"`
 	SDPMID        string `json:"sdpMid"`
 	SDPMLineIndex int    `json:"sdpMLineIndex"`
 }
 
+// SelectLayerData adalah data untuk pesan select-layer. Subscriber (from) memilih
+// layer simulcast (substream/temporal) atau SVC (spatialLayer/temporalLayer) dari
+// feed publisher (to, atau feedId jika diisi). Auto mengembalikan pemilihan ke server.
+type SelectLayerData struct {
+	RoomID        string `json:"roomId"`
+	FromUserID    string `json:"fromUserId"`
+	ToUserID      string `json:"toUserId"`
+	FeedID        uint64 `json:"feedId,omitempty"`
+	Substream     *int   `json:"substream,omitempty"`
+	Temporal      *int   `json:"temporal,omitempty"`
+	SpatialLayer  *int   `json:"spatialLayer,omitempty"`
+	TemporalLayer *int   `json:"temporalLayer,omitempty"`
+	Auto          bool   `json:"auto,omitempty"`
+}
+
+// MediaEventData adalah data untuk pesan media-event yang berasal dari media server
+type MediaEventData struct {
+	RoomID string      `json:"roomId"`
+	UserID string      `json:"userId"`
+	Event  string      `json:"event"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// ModerationData adalah data media-event moderasi yang dikirim ke user yang terkena
+type ModerationData struct {
+	Kind  string `json:"kind,omitempty"`
+	Muted bool   `json:"muted"`
+}
+
+// RecordingData adalah data pesan recording yang dikirim ke semua peserta room
+// ketika recording dimulai atau dihentikan
+type RecordingData struct {
+	RoomID      string `json:"roomId"`
+	RecordingID string `json:"recordingId"`
+	Active      bool   `json:"active"`
+	StartedBy   string `json:"startedBy,omitempty"`
+}
+
+// LiveStreamData adalah data pesan live-stream yang dikirim ke host ketika status
+// restream RTMP room berubah
+type LiveStreamData struct {
+	RoomID       string `json:"roomId"`
+	LiveStreamID string `json:"liveStreamId"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	Restarts     int    `json:"restarts"`
+}
+
+// Mode RTP forwarding
+const (
+	// RTPForwardPublisher meneruskan media satu publisher (RTPForward.UserID)
+	RTPForwardPublisher = "publisher"
+
+	// RTPForwardActiveSpeaker meneruskan media publisher yang sedang berbicara
+	RTPForwardActiveSpeaker = "active-speaker"
+)
+
+// RTPForward adalah permintaan API server untuk meneruskan media publisher room
+// sebagai RTP ke port UDP, misalnya ke ffmpeg yang membuat HLS
+type RTPForward struct {
+	// ID membedakan beberapa forward dalam satu room (misalnya HLS dan restream RTMP);
+	// forward dengan ID yang sama diganti
+	ID string `json:"id"`
+
+	Mode   string `json:"mode"`
+	UserID string `json:"user_id,omitempty"`
+
+	// Host penerima (dilihat dari media server) dan port RTP-nya (RTCP di port+1)
+	Host      string `json:"host"`
+	AudioPort int    `json:"audio_port"`
+	VideoPort int    `json:"video_port"`
+
+	// SSRC tetap agar penerima melihat satu stream ketika publisher berganti
+	AudioSSRC uint32 `json:"audio_ssrc,omitempty"`
+	VideoSSRC uint32 `json:"video_ssrc,omitempty"`
+}
+
+// ErrForwardHostNotAllowed dikembalikan media backend ketika host tujuan RTP forward
+// tidak ada di daftar host yang diizinkan
+var ErrForwardHostNotAllowed = errors.New("rtp forward host is not allowed")
+
+// RTPForwardStatus adalah publisher yang sedang diteruskan beserta codec-nya.
+// Payload type setiap codec mengikuti RTPPayloads.
+type RTPForwardStatus struct {
+	UserID     string `json:"user_id"`
+	AudioCodec string `json:"audio_codec,omitempty"`
+	VideoCodec string `json:"video_codec,omitempty"`
+}
+
+// RTPPayload adalah payload type RTP dan atribut SDP sebuah codec
+type RTPPayload struct {
+	Type   int
+	RTPMap string
+	FMTP   string
+}
+
+// RTPPayloads adalah payload type yang dipakai media server saat meneruskan RTP,
+// sehingga penerima dapat membangun SDP dari nama codec saja
+var RTPPayloads = map[string]RTPPayload{
+	"opus": {Type: 111, RTPMap: "opus/48000/2"},
+	"pcmu": {Type: 0, RTPMap: "PCMU/8000"},
+	"pcma": {Type: 8, RTPMap: "PCMA/8000"},
+	"g722": {Type: 9, RTPMap: "G722/8000"},
+	"vp8":  {Type: 96, RTPMap: "VP8/90000"},
+	"vp9":  {Type: 98, RTPMap: "VP9/90000"},
+	"h264": {Type: 102, RTPMap: "H264/90000", FMTP: "packetization-mode=1"},
+	"av1":  {Type: 45, RTPMap: "AV1/90000"},
+}
+
+// ErrSIPUnavailable dikembalikan media backend ketika gateway SIP tidak dikonfigurasi
+var ErrSIPUnavailable = errors.New("sip gateway is not configured")
+
+// ErrSIPCallNotFound dikembalikan media backend ketika panggilan tidak ditemukan di room
+var ErrSIPCallNotFound = errors.New("sip call not found")
+
+// DataChannelPeer adalah user ID semu pada offer, answer dan ice-candidate untuk
+// koneksi data channel room (Janus TextRoom), bukan koneksi media ke peserta lain
+const DataChannelPeer = "datachannel"
+
+// DataChannelMessage adalah pesan data channel peserta yang di-POST Janus TextRoom
+// ke websocket server. From adalah username TextRoom, yaitu user ID peserta.
+type DataChannelMessage struct {
+	Room    uint64 `json:"room"`
+	From    string `json:"from"`
+	Date    string `json:"date,omitempty"`
+	Text    string `json:"text"`
+	Whisper bool   `json:"whisper,omitempty"`
+}
+
+// ErrInvalidSignature dikembalikan media backend ketika URL post data channel tidak
+// ditandatangani dengan benar
+var ErrInvalidSignature = errors.New("invalid data channel signature")
+
+// AuthenticateData adalah data untuk pesan authenticate
+type AuthenticateData struct {
+	Token string `json:"token"`
+}
+
+// AuthenticatedData adalah data untuk pesan authenticated
+type AuthenticatedData struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SessionData adalah data untuk pesan session yang dikirim setiap kali koneksi
+// terbuka. Jika Resumed false, client memulai session baru dan harus join ulang
+// room-nya. ResumeWindow adalah batas waktu resume dalam detik. DeviceID dapat
+// berbeda dari query deviceId jika perangkat lain milik user sudah memakainya.
+type SessionData struct {
+	SessionID    string `json:"sessionId"`
+	DeviceID     string `json:"deviceId"`
+	Resumed      bool   `json:"resumed"`
+	ResumeWindow int    `json:"resumeWindow"`
+}
+
+// AckData adalah data untuk pesan ack, yaitu nomor urut terakhir yang sudah
+// diterima client
+type AckData struct {
+	Seq uint64 `json:"seq"`
+}
+
+// TransferCallData adalah data untuk pesan transfer-call, yaitu permintaan
+// perangkat untuk mengambil alih panggilan user di room
+type TransferCallData struct {
+	RoomID string `json:"roomId"`
+}
+
+// CallDeviceData adalah data pesan call-device yang dikirim ke semua perangkat user
+// di room ketika perangkat pemegang panggilan berubah (DeviceID kosong = tidak ada)
+type CallDeviceData struct {
+	RoomID           string `json:"roomId"`
+	UserID           string `json:"userId"`
+	DeviceID         string `json:"deviceId,omitempty"`
+	PreviousDeviceID string `json:"previousDeviceId,omitempty"`
+}
+
 // ErrorData adalah data untuk pesan error
 type ErrorData struct {
 	Code    int    `json:"code"`
@@ -107,22 +318,54 @@ type SuccessData struct {
 	Message string `json:"message"`
 }
 
-// Client merepresentasikan sebuah koneksi WebSocket client
+// Client merepresentasikan session WebSocket client. Session bertahan melewati
+// reconnect selama ResumeWindow hub, sehingga satu Client dapat dipakai beberapa
+// koneksi secara bergantian.
 type Client struct {
 	// Hub adalah pointer ke hub yang mengelola client ini
 	Hub *Hub
 
-	// Conn adalah koneksi WebSocket
+	// Conn adalah koneksi WebSocket yang sedang dipakai (nil saat terputus)
 	Conn *websocket.Conn
 
-	// Send adalah channel untuk mengirim pesan ke client
-	Send chan Message
+	// SessionID adalah ID session untuk resume setelah reconnect
+	SessionID string
 
 	// UserID adalah ID user yang terhubung
 	UserID string
 
+	// DeviceID adalah ID perangkat session, unik di antara session milik user yang
+	// sama. User dapat terhubung dari beberapa perangkat atau tab sekaligus.
+	DeviceID string
+
 	// RoomIDs adalah daftar room ID yang dijoin oleh client
 	RoomIDs map[string]bool
+
+	// ExpiresAt adalah waktu access token koneksi berhenti berlaku. Koneksi ditutup
+	// pada waktu ini kecuali client mengirim pesan authenticate (zero = tanpa batas).
+	ExpiresAt time.Time
+
+	// validator memvalidasi token pada pesan authenticate
+	validator TokenValidator
+
+	// mu melindungi outbox dan status koneksi di bawah
+	mu sync.Mutex
+
+	// current adalah koneksi yang sedang dipakai session
+	current *connection
+
+	// attachments bertambah setiap kali koneksi baru dipasang ke session
+	attachments uint64
+
+	// outbox berisi pesan yang belum di-ack client, urut berdasarkan Seq
+	outbox []Message
+
+	// seq adalah nomor urut terakhir yang diberikan, written yang terakhir ditulis
+	seq     uint64
+	written uint64
+
+	// closed bernilai true setelah session berakhir
+	closed bool
 }
 
 // Hub mengelola semua client yang terhubung dan routing pesan
@@ -133,6 +376,13 @@ type Hub struct {
 	// Rooms memetakan room ID ke daftar client
 	Rooms map[string]map[*Client]bool
 
+	// Users memetakan user ID ke semua client milik user tersebut
+	Users map[string]map[*Client]bool
+
+	// calls memetakan room ID ke user ID ke client yang memegang panggilan user,
+	// yaitu satu-satunya perangkat yang terhubung ke media server
+	calls map[string]map[string]*Client
+
 	// Register adalah channel untuk registrasi client baru
 	Register chan *Client
 
@@ -148,14 +398,48 @@ type Hub struct {
 	// DirectMessage adalah channel untuk mengirim pesan langsung ke client tertentu
 	DirectMessage chan DirectMessage
 
+	// UserMessage adalah channel untuk mengirim pesan ke semua koneksi milik user tertentu
+	UserMessage chan UserMessage
+
 	// SignalingHandler untuk WebRTC signaling
 	SignalingHandler interface{}
+
+	// AuthorizeJoin memeriksa apakah user boleh join room dan mengembalikan batas
+	// jumlah user room (0 = tanpa batas). Penolakan dibungkus mediaplane.ErrRoomAccessDenied.
+	// nil = semua join ditolak.
+	AuthorizeJoin func(roomID, userID string) (maxUsers int, err error)
+
+	// NodeID adalah ID node ini di cluster websocket server
+	NodeID string
+
+	// backplane menyebarkan pesan ke node lain (nil = satu node tanpa cluster)
+	backplane Backplane
+
+	// remote adalah channel envelope dari node lain
+	remote chan Envelope
+
+	// ResumeWindow adalah lama session client disimpan setelah koneksinya terputus
+	// (0 = session langsung berakhir)
+	ResumeWindow time.Duration
+
+	// sessions memetakan session ID ke client
+	sessions map[string]*Client
+
+	// resume dan expire adalah channel permintaan resume dan kedaluwarsa session
+	resume chan resumeRequest
+	expire chan sessionExpiry
 }
 
 // RoomMessage adalah pesan yang akan dikirim ke semua client dalam sebuah room
 type RoomMessage struct {
 	RoomID  string
 	Message Message
+
+	// MaxUsers adalah batas jumlah user room untuk join-room (0 = tanpa batas)
+	MaxUsers int
+
+	// Sender adalah client pengirim pesan (nil untuk pesan dari server)
+	Sender *Client
 }
 
 // DirectMessage adalah pesan yang akan dikirim langsung ke client tertentu
@@ -164,26 +448,46 @@ type DirectMessage struct {
 	Message Message
 }
 
+// UserMessage adalah pesan yang akan dikirim ke koneksi milik user tertentu. Tanpa
+// DeviceID, pesan untuk room dikirim ke perangkat yang memegang panggilan user di
+// room tersebut dan pesan lain ke semua perangkat user.
+type UserMessage struct {
+	UserID   string
+	DeviceID string
+	Message  Message
+}
+
 // NewHub membuat instance Hub baru
 func NewHub() *Hub {
 	return &Hub{
 		Clients:       make(map[*Client]bool),
 		Rooms:         make(map[string]map[*Client]bool),
+		Users:         make(map[string]map[*Client]bool),
+		calls:         make(map[string]map[string]*Client),
 		Register:      make(chan *Client),
 		Unregister:    make(chan *Client),
 		Broadcast:     make(chan Message),
 		RoomMessage:   make(chan RoomMessage),
 		DirectMessage: make(chan DirectMessage),
+		UserMessage:   make(chan UserMessage, 256),
+		remote:        make(chan Envelope, backplaneBufferSize),
+		ResumeWindow:  defaultResumeWindow,
+		sessions:      make(map[string]*Client),
+		resume:        make(chan resumeRequest),
+		expire:        make(chan sessionExpiry),
 	}
 }
 
-// NewClient membuat instance Client baru
+// NewClient membuat instance Client baru dengan session baru
 func NewClient(hub *Hub, conn *websocket.Conn, userID string) *Client {
-	return &Client{
-		Hub:     hub,
-		Conn:    conn,
-		Send:    make(chan Message, 256),
-		UserID:  userID,
-		RoomIDs: make(map[string]bool),
+	client := &Client{
+		Hub:       hub,
+		SessionID: uuid.NewString(),
+		UserID:    userID,
+		RoomIDs:   make(map[string]bool),
+	}
+	if conn != nil {
+		client.attach(conn)
 	}
+	return client
 }
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StoredFile model untuk tabel stored_files: objek di storage beserta pemilik dan
+// room-nya, dipakai untuk membuat signed URL dan menghitung quota storage room
+type StoredFile struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Key         string     `json:"-" gorm:"uniqueIndex;not null"`
+	Kind        FileKind   `json:"kind" gorm:"not null;index"`
+	RoomID      *uuid.UUID `json:"room_id" gorm:"type:uuid;index"` // nil untuk file yang tidak dihitung ke quota room
+	OwnerID     uuid.UUID  `json:"owner_id" gorm:"type:uuid;not null"`
+	Name        string     `json:"name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// FileKind enum untuk jenis file di storage
+type FileKind string
+
+const (
+	FileKindRecording  FileKind = "recording"
+	FileKindAttachment FileKind = "attachment"
+	FileKindAvatar     FileKind = "avatar"
+)
+
+// TableName untuk StoredFile model
+func (StoredFile) TableName() string {
+	return "stored_files"
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LiveStream model untuk tabel live_streams: restream RTMP room ke platform eksternal
+// (YouTube, Twitch, server RTMP sendiri)
+type LiveStream struct {
+	ID        uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RoomID    uuid.UUID        `json:"room_id" gorm:"type:uuid;not null;index"`
+	CreatedBy uuid.UUID        `json:"created_by" gorm:"type:uuid;not null"`
+	Name      string           `json:"name"`
+	TargetURL string           `json:"target_url" gorm:"not null"` // misalnya rtmp://a.rtmp.youtube.com/live2
+	StreamKey string           `json:"-"`                          // terenkripsi, tidak pernah dikirim ke client
+	Mode      string           `json:"mode" gorm:"default:'active-speaker'"`
+	UserID    string           `json:"user_id,omitempty"` // publisher yang diteruskan pada mode publisher
+	Status    LiveStreamStatus `json:"status" gorm:"default:'idle'"`
+	Error     string           `json:"error,omitempty"`
+	Restarts  int              `json:"restarts" gorm:"default:0"` // restart ffmpeg sejak restream dimulai
+	StartedBy *uuid.UUID       `json:"started_by" gorm:"type:uuid"`
+	StartedAt *time.Time       `json:"started_at"`
+	StoppedAt *time.Time       `json:"stopped_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Room    *Room `json:"room,omitempty" gorm:"foreignKey:RoomID"`
+	Creator *User `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+}
+
+// LiveStreamStatus enum untuk status restream
+type LiveStreamStatus string
+
+const (
+	LiveStreamStatusIdle         LiveStreamStatus = "idle" // belum pernah dimulai
+	LiveStreamStatusStarting     LiveStreamStatus = "starting"
+	LiveStreamStatusLive         LiveStreamStatus = "live"
+	LiveStreamStatusReconnecting LiveStreamStatus = "reconnecting" // ffmpeg dimulai ulang setelah gagal
+	LiveStreamStatusStopped      LiveStreamStatus = "stopped"
+	LiveStreamStatusFailed       LiveStreamStatus = "failed"
+)
+
+// TableName untuk LiveStream model
+func (LiveStream) TableName() string {
+	return "live_streams"
+}
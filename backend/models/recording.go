@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Recording model untuk tabel recordings
+type Recording struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RoomID      uuid.UUID       `json:"room_id" gorm:"type:uuid;not null;index"`
+	StartedBy   uuid.UUID       `json:"started_by" gorm:"type:uuid;not null"`
+	StoppedBy   *uuid.UUID      `json:"stopped_by" gorm:"type:uuid"`
+	Status      RecordingStatus `json:"status" gorm:"default:'recording'"`
+	AutoStarted bool            `json:"auto_started" gorm:"default:false"`
+	Directory   string          `json:"directory" gorm:"not null"` // relatif terhadap direktori recording
+	Files       pq.StringArray  `json:"files" gorm:"type:text[]"`  // file .mjr per track
+	Size        int64           `json:"size"`
+	StartedAt   time.Time       `json:"started_at" gorm:"not null"`
+	StoppedAt   *time.Time      `json:"stopped_at"`
+
+	// Hasil post-processing file .mjr menjadi file yang dapat diputar
+	OutputFiles pq.StringArray `json:"output_files" gorm:"type:text[]"` // key file di storage
+	OutputSize  int64          `json:"output_size"`
+	Progress    int            `json:"progress" gorm:"default:0"` // persen 0-100
+	Error       string         `json:"error,omitempty"`
+	Attempts    int            `json:"attempts" gorm:"default:0"`
+	ProcessedAt *time.Time     `json:"processed_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Room    *Room `json:"room,omitempty" gorm:"foreignKey:RoomID"`
+	Starter *User `json:"starter,omitempty" gorm:"foreignKey:StartedBy"`
+}
+
+// RecordingStatus enum untuk status recording
+type RecordingStatus string
+
+const (
+	RecordingStatusRecording  RecordingStatus = "recording"
+	RecordingStatusStopped    RecordingStatus = "stopped" // menunggu post-processing
+	RecordingStatusProcessing RecordingStatus = "processing"
+	RecordingStatusCompleted  RecordingStatus = "completed"
+	RecordingStatusFailed     RecordingStatus = "failed"
+)
+
+// TableName untuk Recording model
+func (Recording) TableName() string {
+	return "recordings"
+}
@@ -20,6 +20,7 @@ type Room struct {
 	Type        RoomType       `json:"type" gorm:"default:'meeting'"`
 	IsPublic    bool           `json:"is_public" gorm:"default:true"`
 	IsRecording bool           `json:"is_recording" gorm:"default:false"`
+	DialInPIN   *string        `json:"-" gorm:"uniqueIndex"` // PIN dial-in telepon (nil = dial-in nonaktif)
 	StartTime   *time.Time     `json:"start_time"`
 	EndTime     *time.Time     `json:"end_time"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -52,6 +53,9 @@ const (
 	RoomTypeWebinar    RoomType = "webinar"
 	RoomTypeConference RoomType = "conference"
 	RoomTypeClassroom  RoomType = "classroom"
+
+	// RoomTypeAudio adalah room khusus audio yang di-mix server (Janus AudioBridge)
+	RoomTypeAudio RoomType = "audio"
 )
 
 // RoomParticipant model untuk tabel room_participants
@@ -101,6 +105,7 @@ type RoomMessage struct {
 	SenderID  uuid.UUID   `json:"sender_id" gorm:"type:uuid;not null"`
 	Message   string      `json:"message" gorm:"not null"`
 	Type      MessageType `json:"type" gorm:"default:'text'"`
+	FileID    *uuid.UUID  `json:"file_id,omitempty" gorm:"type:uuid"` // lampiran di storage
 	FileURL   string      `json:"file_url"`
 	FileName  string      `json:"file_name"`
 	FileSize  int64       `json:"file_size"`
@@ -140,6 +145,7 @@ type RoomSetting struct {
 	EnablePolling       bool      `json:"enable_polling" gorm:"default:false"`
 	EnableWhiteboard    bool      `json:"enable_whiteboard" gorm:"default:false"`
 	EnableRecording     bool      `json:"enable_recording" gorm:"default:true"`
+	AudioOnly           bool      `json:"audio_only" gorm:"default:false"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 